@@ -29,6 +29,7 @@ import (
 
 	"github.com/ngrok/ngrok-api-go/v6"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -45,6 +46,23 @@ type DomainSpec struct {
 	// Region is the region in which to reserve the domain
 	// +kubebuilder:validation:Required
 	Region string `json:"region,omitempty"`
+
+	// CertificateRef references a Secret of type kubernetes.io/tls whose certificate
+	// is uploaded to ngrok and served for the domain instead of an ngrok-managed one
+	// +kubebuilder:validation:Optional
+	CertificateRef *DomainCertificateRef `json:"certificateRef,omitempty"`
+}
+
+// DomainCertificateRef references a Secret of type kubernetes.io/tls
+type DomainCertificateRef struct {
+	// Name is the name of the Secret
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Secret. Defaults to the namespace of the Domain.
+	// A Secret in another namespace is only used when a ReferenceGrant in its namespace allows it
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // DomainStatus defines the observed state of Domain
@@ -66,6 +84,10 @@ type DomainStatus struct {
 
 	// CNAMETarget is the CNAME target for the domain
 	CNAMETarget *string `json:"cnameTarget,omitempty"`
+
+	// CertificateID is the ID of the TLS certificate uploaded to ngrok from the
+	// Secret referenced by the certificateRef
+	CertificateID string `json:"certificateID,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -118,6 +140,19 @@ func (d *Domain) Equal(ngrokDomain *ngrok.ReservedDomain) bool {
 		d.Spec.Metadata == ngrokDomain.Metadata
 }
 
+// CertificateSecretKey returns the namespace and name of the Secret referenced by the
+// domain's certificateRef, defaulting the namespace to the namespace of the domain
+func (d *Domain) CertificateSecretKey() types.NamespacedName {
+	if d.Spec.CertificateRef == nil {
+		return types.NamespacedName{}
+	}
+	namespace := d.Spec.CertificateRef.Namespace
+	if namespace == "" {
+		namespace = d.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: d.Spec.CertificateRef.Name}
+}
+
 var domainNameForResourceNameReplacer = strings.NewReplacer(
 	".", "-", // replace dots with dashes
 	"*", "wildcard", // replace wildcard with the literal "wildcard"
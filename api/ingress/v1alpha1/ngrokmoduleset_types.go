@@ -25,6 +25,8 @@ SOFTWARE.
 package v1alpha1
 
 import (
+	"encoding/json"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -53,6 +55,31 @@ type NgrokModuleSetModules struct {
 	WebhookVerification *EndpointWebhookVerification `json:"webhookVerification,omitempty"`
 }
 
+const (
+	// NgrokModuleSetConditionPolicyGenerated is True when the modules were converted to the generated policy
+	NgrokModuleSetConditionPolicyGenerated = "PolicyGenerated"
+
+	NgrokModuleSetReasonPolicyGenerated   = "PolicyGenerated"
+	NgrokModuleSetReasonUnsupportedModule = "UnsupportedModule"
+	NgrokModuleSetReasonConversionFailed  = "ConversionFailed"
+)
+
+// NgrokModuleSetStatus defines the observed state of NgrokModuleSet
+type NgrokModuleSetStatus struct {
+	// GeneratedPolicy is the traffic policy equivalent to the modules, for use with the resources that only accept
+	// traffic policy. The values of the secrets the modules reference are redacted.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Type=object
+	GeneratedPolicy json.RawMessage `json:"generatedPolicy,omitempty"`
+
+	// Conditions describe whether the modules could be converted to traffic policy
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
@@ -62,6 +89,8 @@ type NgrokModuleSet struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	Modules NgrokModuleSetModules `json:"modules,omitempty"`
+
+	Status NgrokModuleSetStatus `json:"status,omitempty"`
 }
 
 func (ms *NgrokModuleSet) Merge(o *NgrokModuleSet) {
@@ -48,6 +48,10 @@ type TunnelSpec struct {
 
 	// The appProtocol for the backend. Currently only supports `http2`
 	AppProtocol string `json:"appProtocol,omitempty"`
+
+	// URL is the URL of the internal agent endpoint to start instead of a labeled tunnel, like
+	// `https://my-service.my-namespace.80.1a2b3c4d.internal`. The labels are ignored when it is set.
+	URL string `json:"url,omitempty"`
 }
 
 // BackendConfig defines the configuration for backend connections to services.
@@ -30,6 +30,7 @@ package v1alpha1
 
 import (
 	"encoding/json"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -53,7 +54,7 @@ func (in *Domain) DeepCopyInto(out *Domain) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -75,6 +76,21 @@ func (in *Domain) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DomainCertificateRef) DeepCopyInto(out *DomainCertificateRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DomainCertificateRef.
+func (in *DomainCertificateRef) DeepCopy() *DomainCertificateRef {
+	if in == nil {
+		return nil
+	}
+	out := new(DomainCertificateRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DomainList) DeepCopyInto(out *DomainList) {
 	*out = *in
@@ -111,6 +127,11 @@ func (in *DomainList) DeepCopyObject() runtime.Object {
 func (in *DomainSpec) DeepCopyInto(out *DomainSpec) {
 	*out = *in
 	out.ngrokAPICommon = in.ngrokAPICommon
+	if in.CertificateRef != nil {
+		in, out := &in.CertificateRef, &out.CertificateRef
+		*out = new(DomainCertificateRef)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DomainSpec.
@@ -1006,6 +1027,7 @@ func (in *NgrokModuleSet) DeepCopyInto(out *NgrokModuleSet) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Modules.DeepCopyInto(&out.Modules)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NgrokModuleSet.
@@ -1128,6 +1150,33 @@ func (in *NgrokModuleSetModules) DeepCopy() *NgrokModuleSetModules {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NgrokModuleSetStatus) DeepCopyInto(out *NgrokModuleSetStatus) {
+	*out = *in
+	if in.GeneratedPolicy != nil {
+		in, out := &in.GeneratedPolicy, &out.GeneratedPolicy
+		*out = make(json.RawMessage, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NgrokModuleSetStatus.
+func (in *NgrokModuleSetStatus) DeepCopy() *NgrokModuleSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NgrokModuleSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OAuthProviderCommon) DeepCopyInto(out *OAuthProviderCommon) {
 	*out = *in
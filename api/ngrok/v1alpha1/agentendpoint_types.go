@@ -0,0 +1,130 @@
+/*
+MIT License
+
+Copyright (c) 2024 ngrok, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AgentEndpointSpec defines the desired state of AgentEndpoint
+type AgentEndpointSpec struct {
+	// URL is the address the agent endpoint listens on. It is either a public URL, like `https://example.ngrok.app`,
+	// or an internal one that only cloud endpoints can forward to, like `https://my-service.internal`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// Upstream is the service the agent forwards the traffic of the endpoint to
+	// +kubebuilder:validation:Required
+	Upstream AgentEndpointUpstream `json:"upstream"`
+
+	// ClientCertificate is the certificate the agent presents to the upstream service when it connects to it over TLS
+	// +kubebuilder:validation:Optional
+	ClientCertificate *AgentEndpointClientCertificate `json:"clientCertificate,omitempty"`
+}
+
+// AgentEndpointUpstream is the service an agent endpoint forwards its traffic to
+type AgentEndpointUpstream struct {
+	// Service is the name of the service in the namespace of the AgentEndpoint
+	// +kubebuilder:validation:Required
+	Service string `json:"service"`
+
+	// Port is the port of the service
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// Protocol is how the agent connects to the service, HTTP or HTTPS
+	// +kubebuilder:validation:Enum=HTTP;HTTPS
+	// +kubebuilder:default:=HTTP
+	Protocol string `json:"protocol,omitempty"`
+
+	// AppProtocol is the application protocol of the service. Currently only supports `http2`
+	// +kubebuilder:validation:Optional
+	AppProtocol string `json:"appProtocol,omitempty"`
+}
+
+// AgentEndpointClientCertificate is a client certificate the agent presents to an upstream service
+type AgentEndpointClientCertificate struct {
+	// SecretName is the name of the kubernetes.io/tls secret in the namespace of the AgentEndpoint with the
+	// certificate and its private key
+	// +kubebuilder:validation:Required
+	SecretName string `json:"secretName"`
+}
+
+const (
+	// AgentEndpointConditionReady is True when the agent is listening on the URL of the endpoint
+	AgentEndpointConditionReady = "Ready"
+
+	AgentEndpointReasonStarted         = "EndpointStarted"
+	AgentEndpointReasonConfigError     = "ConfigError"
+	AgentEndpointReasonConnectionError = "ConnectionError"
+)
+
+// AgentEndpointStatus defines the observed state of AgentEndpoint
+type AgentEndpointStatus struct {
+	// AssignedURL is the URL the ngrok service assigned to the endpoint
+	AssignedURL string `json:"assignedURL,omitempty"`
+
+	// Conditions describe whether the agent is listening on the URL of the endpoint, and why not when it isn't
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".status.assignedURL"
+// +kubebuilder:printcolumn:name="Upstream",type="string",JSONPath=".spec.upstream.service"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// AgentEndpoint is the Schema for the agentendpoints API. Only the elected agent listens on the URL of an
+// AgentEndpoint, its traffic isn't load balanced between the agent replicas.
+//
+// AgentEndpoints don't reference a traffic policy yet. Forward to an internal AgentEndpoint from a CloudEndpoint to
+// apply one.
+type AgentEndpoint struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AgentEndpointSpec   `json:"spec,omitempty"`
+	Status AgentEndpointStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AgentEndpointList contains a list of AgentEndpoint
+type AgentEndpointList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AgentEndpoint `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AgentEndpoint{}, &AgentEndpointList{})
+}
@@ -45,6 +45,19 @@ type NgrokTrafficPolicySpec struct {
 	Policy json.RawMessage `json:"policy,omitempty"`
 }
 
+const (
+	// NgrokTrafficPolicyConditionValid is False when the policy has errors the ngrok API would reject it for
+	NgrokTrafficPolicyConditionValid = "Valid"
+	// NgrokTrafficPolicyConditionWarnings is True when the policy uses legacy options, or actions and fields the
+	// operator doesn't know about
+	NgrokTrafficPolicyConditionWarnings = "Warnings"
+
+	NgrokTrafficPolicyReasonValid      = "PolicyValid"
+	NgrokTrafficPolicyReasonInvalid    = "PolicyInvalid"
+	NgrokTrafficPolicyReasonWarnings   = "PolicyWarnings"
+	NgrokTrafficPolicyReasonNoWarnings = "NoWarnings"
+)
+
 // NgrokTrafficPolicyStatus defines the observed state of NgrokTrafficPolicy
 type NgrokTrafficPolicyStatus struct {
 
@@ -53,6 +66,12 @@ type NgrokTrafficPolicyStatus struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +kubebuilder:validation:Type=object
 	Policy json.RawMessage `json:"policy,omitempty"`
+
+	// Conditions describe the findings of the validation of the policy: its phases, actions and expressions
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
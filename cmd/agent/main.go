@@ -29,10 +29,14 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -76,6 +80,7 @@ type managerOpts struct {
 	serverAddr  string
 	description string
 	managerName string
+	electionID  string
 	zapOpts     *zap.Options
 
 	// feature flags
@@ -84,8 +89,9 @@ type managerOpts struct {
 	enableFeatureBindings bool
 
 	// agent(tunnel driver) flags
-	region  string
-	rootCAs string
+	region        string
+	rootCAs       string
+	clusterDomain string
 }
 
 func cmd() *cobra.Command {
@@ -102,10 +108,12 @@ func cmd() *cobra.Command {
 	c.Flags().StringVar(&opts.description, "description", "Created by the ngrok-operator", "Description for this installation")
 	// TODO(operator-rename): Same as above, but for the manager name.
 	c.Flags().StringVar(&opts.managerName, "manager-name", "agent-manager", "Manager name to identify unique ngrok operator agent instances")
+	c.Flags().StringVar(&opts.electionID, "election-id", "ngrok-operator-agent-leader", "The name of the lease used to elect the agent that runs the AgentEndpoints and the internal endpoints of the services, every agent runs them when empty")
 
 	// agent(tunnel driver) flags
 	c.Flags().StringVar(&opts.region, "region", "", "The region to use for ngrok tunnels")
 	c.Flags().StringVar(&opts.serverAddr, "server-addr", "", "The address of the ngrok server to use for tunnels")
+	c.Flags().StringVar(&opts.clusterDomain, "cluster-domain", "svc.cluster.local", "Cluster domain used in the cluster")
 	c.Flags().StringVar(&opts.rootCAs, "root-cas", "trusted", "trusted (default) or host: use the trusted ngrok agent CA or the host CA")
 
 	// feature flags
@@ -134,7 +142,18 @@ func runController(ctx context.Context, opts managerOpts) error {
 		},
 		WebhookServer:          webhook.NewServer(webhook.Options{Port: 9443}),
 		HealthProbeBindAddress: opts.probeAddr,
-		LeaderElection:         false,
+		// The agents all run the labeled tunnels, which ngrok balances between them. An endpoint URL can only be bound by
+		// a single agent though, so the elected agent is the only one running the AgentEndpoints and internal endpoints.
+		LeaderElection:   opts.electionID != "",
+		LeaderElectionID: opts.electionID,
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				// The AgentEndpoints only reference kubernetes.io/tls secrets, don't cache the others
+				&corev1.Secret{}: {
+					Field: fields.OneTermEqualSelector("type", string(corev1.SecretTypeTLS)),
+				},
+			},
+		},
 	}
 
 	// create default config and clientset for use outside the mgr.Start() blocking loop
@@ -184,6 +203,18 @@ func runController(ctx context.Context, opts managerOpts) error {
 			setupLog.Error(err, "unable to create controller", "controller", "Tunnel")
 			os.Exit(1)
 		}
+
+		if err = (&agentcontroller.AgentEndpointReconciler{
+			Client:        mgr.GetClient(),
+			Log:           ctrl.Log.WithName("controllers").WithName("agentendpoint"),
+			Scheme:        mgr.GetScheme(),
+			Recorder:      mgr.GetEventRecorderFor("agentendpoint-controller"),
+			TunnelDriver:  td,
+			ClusterDomain: opts.clusterDomain,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AgentEndpoint")
+			os.Exit(1)
+		}
 	}
 
 	// register healthchecks
@@ -33,6 +33,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -46,6 +47,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"github.com/ngrok/ngrok-api-go/v6"
 
@@ -61,7 +64,9 @@ import (
 	"github.com/ngrok/ngrok-operator/internal/store"
 	"github.com/ngrok/ngrok-operator/internal/util"
 	"github.com/ngrok/ngrok-operator/internal/version"
+	ngrokwebhook "github.com/ngrok/ngrok-operator/internal/webhook"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -70,9 +75,20 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+// ngrokAPIRetryBaseDelay is the delay of the first retry of a request to the ngrok API, doubling with each retry
+const ngrokAPIRetryBaseDelay = 250 * time.Millisecond
+
+// The modes of the orphaned ngrok resources collector
+const (
+	orphanGCModeReport = "report"
+	orphanGCModeDelete = "delete"
+)
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(gatewayv1.AddToScheme(scheme))
+	utilruntime.Must(gatewayv1alpha2.AddToScheme(scheme))
+	utilruntime.Must(gatewayv1beta1.AddToScheme(scheme))
 	utilruntime.Must(ingressv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(ngrokv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(bindingsv1alpha1.AddToScheme(scheme))
@@ -111,6 +127,37 @@ type managerOpts struct {
 	// and will log errors about missing required fields
 	oneClickDemoMode bool
 
+	// when true, the changes the driver would make to the ngrok resources are computed and logged, but nothing
+	// is written to the cluster and the controllers managing the ngrok API resources aren't started
+	dryRun bool
+
+	// when true, the syncs only recompute the ngrok resources of the hosts affected by the changes since the last one
+	incrementalSync bool
+	// the window during which the syncs requested are coalesced into a single one
+	syncDebounce time.Duration
+	// how the ingresses whose IngressClass doesn't choose a mapping strategy are translated to ngrok resources
+	ingressMappingStrategy string
+
+	// the middleware the requests to the ngrok API go through
+	ngrokAPI struct {
+		rateLimit               float64
+		rateBurst               int
+		maxRetries              int
+		maxRetryDelay           time.Duration
+		circuitBreakerThreshold int
+		circuitBreakerCooldown  time.Duration
+	}
+
+	// the collection of the ngrok resources created by the operator whose CRs are gone
+	orphanGC struct {
+		interval time.Duration
+		minAge   time.Duration
+		mode     string
+	}
+
+	// when true, the validating webhooks of the ngrok CRs and of the annotated Ingresses and Services are served
+	enableWebhooks bool
+
 	// feature flags
 	enableFeatureIngress  bool
 	enableFeatureGateway  bool
@@ -156,6 +203,20 @@ func cmd() *cobra.Command {
 	c.Flags().StringVar(&opts.managerName, "manager-name", "ngrok-ingress-controller-manager", "Manager name to identify unique ngrok ingress controller instances")
 	c.Flags().StringVar(&opts.clusterDomain, "cluster-domain", "svc.cluster.local", "Cluster domain used in the cluster")
 	c.Flags().BoolVar(&opts.oneClickDemoMode, "one-click-demo-mode", false, "Run the operator in one-click-demo mode (Ready, but not running)")
+	c.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Log the changes the operator would make to the ngrok resources without writing anything to the cluster or the ngrok API")
+	c.Flags().BoolVar(&opts.incrementalSync, "incremental-sync", false, "Only recompute the ngrok resources of the hosts affected by each change instead of all of them")
+	c.Flags().StringVar(&opts.ingressMappingStrategy, "ingress-mapping-strategy", string(store.IngressMappingStrategyEdges), "How the ingresses are translated to ngrok resources: HTTPSEdges forwarding to labeled tunnels (edges) or CloudEndpoints forwarding to internal agent endpoints (endpoints). The k8s.ngrok.com/mapping-strategy annotation of an IngressClass overrides it for its ingresses")
+	c.Flags().DurationVar(&opts.syncDebounce, "sync-debounce", 0, "How long a sync waits for more changes before reading them, coalescing the bursts of changes of rollouts into a single sync")
+	c.Flags().Float64Var(&opts.ngrokAPI.rateLimit, "ngrok-api-rate-limit", 10, "The maximum number of requests per second made to the ngrok API. 0 disables the limit")
+	c.Flags().IntVar(&opts.ngrokAPI.rateBurst, "ngrok-api-rate-burst", 20, "The maximum number of requests made to the ngrok API in a burst")
+	c.Flags().IntVar(&opts.ngrokAPI.maxRetries, "ngrok-api-max-retries", 3, "The maximum number of retries of the requests to the ngrok API that were rate limited or failed with a server error")
+	c.Flags().DurationVar(&opts.ngrokAPI.maxRetryDelay, "ngrok-api-max-retry-delay", 30*time.Second, "The maximum delay before retrying a request to the ngrok API. Requests asked to wait longer are requeued instead")
+	c.Flags().IntVar(&opts.ngrokAPI.circuitBreakerThreshold, "ngrok-api-circuit-breaker-threshold", 10, "The number of consecutive requests to the ngrok API failing with a server error after which the requests fail fast. 0 disables the circuit breaker")
+	c.Flags().DurationVar(&opts.ngrokAPI.circuitBreakerCooldown, "ngrok-api-circuit-breaker-cooldown", 30*time.Second, "How long the requests to the ngrok API fail fast once the circuit breaker opened")
+	c.Flags().DurationVar(&opts.orphanGC.interval, "orphan-gc-interval", 0, "How often to look for the ngrok resources created by the operator whose resources in the cluster are gone. 0 disables the collection")
+	c.Flags().DurationVar(&opts.orphanGC.minAge, "orphan-gc-min-age", 10*time.Minute, "The age below which the ngrok resources aren't collected, leaving the operator time to record them in the cluster")
+	c.Flags().StringVar(&opts.orphanGC.mode, "orphan-gc-mode", orphanGCModeReport, "Whether the orphaned ngrok resources are deleted (delete) or only logged (report)")
+	c.Flags().BoolVar(&opts.enableWebhooks, "enable-webhooks", false, "Serve the validating webhooks of the ngrok CRs and of the annotated Ingresses and Services on port 9443")
 
 	// feature flags
 	c.Flags().BoolVar(&opts.enableFeatureIngress, "enable-feature-ingress", true, "Enables the Ingress controller")
@@ -252,8 +313,12 @@ func runNormalMode(ctx context.Context, opts managerOpts, k8sClient client.Clien
 	}
 
 
+	if opts.dryRun {
+		setupLog.Info("running in dry-run mode, changes will be logged but not applied")
+	}
+
 	// TODO(hkatz) for now we are hiding the k8sop API regstration behind the bindings feature flag
-	if opts.enableFeatureBindings {
+	if opts.enableFeatureBindings && !opts.dryRun {
 		// register the k8sop in the ngrok API
 		if err := createKubernetesOperator(ctx, k8sClient, opts); err != nil {
 			return fmt.Errorf("unable to create KubernetesOperator: %w", err)
@@ -272,11 +337,13 @@ func runNormalMode(ctx context.Context, opts managerOpts, k8sClient client.Clien
 
 		// Run a migration for migrating from the old ingress controller to the operator
 		// TODO: Delete me after the initial releae of the ngrok-operator
-		setupLog.Info("Migrating Kubernetes Ingress Controller labels to ngrok operator")
-		if err := k8sResourceDriver.MigrateKubernetesIngressControllerLabelsToNgrokOperator(ctx, k8sClient); err != nil {
-			return fmt.Errorf("unable to migrate Kubernetes Ingress Controller labels to ngrok operator: %w", err)
+		if !opts.dryRun {
+			setupLog.Info("Migrating Kubernetes Ingress Controller labels to ngrok operator")
+			if err := k8sResourceDriver.MigrateKubernetesIngressControllerLabelsToNgrokOperator(ctx, k8sClient); err != nil {
+				return fmt.Errorf("unable to migrate Kubernetes Ingress Controller labels to ngrok operator: %w", err)
+			}
+			setupLog.Info("Kubernetes Ingress controller labels migrated to ngrok operator")
 		}
-		setupLog.Info("Kubernetes Ingress controller labels migrated to ngrok operator")
 	}
 
 	if opts.enableFeatureIngress {
@@ -297,7 +364,9 @@ func runNormalMode(ctx context.Context, opts managerOpts, k8sClient client.Clien
 		setupLog.Info("Gateway feature set disabled")
 	}
 
-	if opts.enableFeatureBindings {
+	if opts.enableFeatureBindings && opts.dryRun {
+		setupLog.Info("Endpoint Bindings feature set disabled in dry-run mode")
+	} else if opts.enableFeatureBindings {
 		setupLog.Info("Endpoint Bindings feature set enabled")
 		if err := enableBindingsFeatureSet(ctx, opts, mgr, k8sResourceDriver, ngrokClientset); err != nil {
 			return fmt.Errorf("unable to enable Bindings feature set: %w", err)
@@ -306,12 +375,27 @@ func runNormalMode(ctx context.Context, opts managerOpts, k8sClient client.Clien
 		setupLog.Info("Endpoint Bindings feature set disabled")
 	}
 
+	if opts.orphanGC.interval > 0 {
+		if err := enableOrphanCollector(opts, mgr, k8sResourceDriver, ngrokClientset); err != nil {
+			return fmt.Errorf("unable to enable the orphaned ngrok resources collector: %w", err)
+		}
+	}
+
+	if opts.enableWebhooks {
+		setupLog.Info("validating webhooks enabled")
+		if err := ngrokwebhook.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("unable to set up the validating webhooks: %w", err)
+		}
+	}
+
 	// new kubebuilder controllers will be generated here
 	// please attach these to a feature set
 	//+kubebuilder:scaffold:builder
 
 	// Always register the ngrok KubernetesOperator controller. It is independent of the feature set.
-	if err := (&ngrokcontroller.KubernetesOperatorReconciler{
+	if opts.dryRun {
+		setupLog.Info("KubernetesOperator controller disabled in dry-run mode")
+	} else if err := (&ngrokcontroller.KubernetesOperatorReconciler{
 		Client:         mgr.GetClient(),
 		Log:            ctrl.Log.WithName("controllers").WithName("KubernetesOperator"),
 		Scheme:         mgr.GetScheme(),
@@ -352,6 +436,13 @@ func loadManager(ctx context.Context, k8sConfig *rest.Config, opts managerOpts)
 		LeaderElectionID:       opts.electionID,
 	}
 
+	if opts.dryRun {
+		// Don't compete with the operator being previewed for the leader lock, and make sure none of the
+		// controllers writes to the cluster
+		options.LeaderElection = false
+		options.Client = client.Options{DryRun: ptr.To(true)}
+	}
+
 	if opts.ingressWatchNamespace != "" {
 		options.Cache = cache.Options{
 			DefaultNamespaces: map[string]cache.Config{
@@ -390,13 +481,22 @@ func loadNgrokClientset(ctx context.Context, opts managerOpts) (ngrokapi.Clients
 	}
 	setupLog.Info("configured API client", "base_url", ngrokClientConfig.BaseURL)
 
-	ngrokClientset := ngrokapi.NewClientSet(ngrokClientConfig)
+	ngrokClientset := ngrokapi.NewClientSet(ngrokClientConfig,
+		ngrokapi.WithLogger(ctrl.Log.WithName("ngrok-api")),
+		ngrokapi.WithRateLimit(opts.ngrokAPI.rateLimit, opts.ngrokAPI.rateBurst),
+		ngrokapi.WithRetries(opts.ngrokAPI.maxRetries, ngrokAPIRetryBaseDelay, opts.ngrokAPI.maxRetryDelay),
+		ngrokapi.WithCircuitBreaker(opts.ngrokAPI.circuitBreakerThreshold, opts.ngrokAPI.circuitBreakerCooldown),
+	)
 	return ngrokClientset, nil
 }
 
 // getK8sResourceDriver returns a new Driver instance that is seeded with the current state of the cluster.
 func getK8sResourceDriver(ctx context.Context, mgr manager.Manager, options managerOpts) (*store.Driver, error) {
 	logger := mgr.GetLogger().WithName("cache-store-driver")
+	mappingStrategy, err := store.ParseIngressMappingStrategy(options.ingressMappingStrategy)
+	if err != nil {
+		return nil, err
+	}
 	d := store.NewDriver(
 		logger,
 		mgr.GetScheme(),
@@ -407,6 +507,11 @@ func getK8sResourceDriver(ctx context.Context, mgr manager.Manager, options mana
 		},
 		store.WithGatewayEnabled(options.enableFeatureGateway),
 		store.WithClusterDomain(options.clusterDomain),
+		store.WithEventRecorder(mgr.GetEventRecorderFor("cache-store-driver")),
+		store.WithDryRun(options.dryRun),
+		store.WithIncrementalSync(options.incrementalSync),
+		store.WithSyncDebounce(options.syncDebounce),
+		store.WithIngressMappingStrategy(mappingStrategy),
 	)
 	if options.ngrokMetadata != "" {
 		customMetadata, err := util.ParseHelmDictionary(options.ngrokMetadata)
@@ -451,12 +556,41 @@ func enableIngressFeatureSet(_ context.Context, opts managerOpts, mgr ctrl.Manag
 		os.Exit(1)
 	}
 
+	if err := (&ingresscontroller.ModuleSetReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ngrok-module-set"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("ngrok-module-set-controller"),
+		Driver:   driver,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NgrokModuleSet")
+		os.Exit(1)
+	}
+
+	if err := (&ngrokcontroller.NgrokTrafficPolicyReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("traffic-policy"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("policy-controller"),
+		Driver:   driver,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TrafficPolicy")
+		os.Exit(1)
+	}
+
+	// The remaining controllers manage the ngrok API resources
+	if opts.dryRun {
+		setupLog.Info("ngrok API controllers disabled in dry-run mode")
+		return nil
+	}
+
 	if err := (&ingresscontroller.DomainReconciler{
-		Client:        mgr.GetClient(),
-		Log:           ctrl.Log.WithName("controllers").WithName("domain"),
-		Scheme:        mgr.GetScheme(),
-		Recorder:      mgr.GetEventRecorderFor("domain-controller"),
-		DomainsClient: ngrokClientset.Domains(),
+		Client:                mgr.GetClient(),
+		Log:                   ctrl.Log.WithName("controllers").WithName("domain"),
+		Scheme:                mgr.GetScheme(),
+		Recorder:              mgr.GetEventRecorderFor("domain-controller"),
+		DomainsClient:         ngrokClientset.Domains(),
+		TLSCertificatesClient: ngrokClientset.TLSCertificates(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Domain")
 		os.Exit(1)
@@ -507,28 +641,6 @@ func enableIngressFeatureSet(_ context.Context, opts managerOpts, mgr ctrl.Manag
 		os.Exit(1)
 	}
 
-	if err := (&ingresscontroller.ModuleSetReconciler{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("ngrok-module-set"),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("ngrok-module-set-controller"),
-		Driver:   driver,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "NgrokModuleSet")
-		os.Exit(1)
-	}
-
-	if err := (&ngrokcontroller.NgrokTrafficPolicyReconciler{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("traffic-policy"),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("policy-controller"),
-		Driver:   driver,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "TrafficPolicy")
-		os.Exit(1)
-	}
-
 	if err := (&ngrokcontroller.CloudEndpointReconciler{
 		Client:         mgr.GetClient(),
 		Log:            ctrl.Log.WithName("controllers").WithName("cloud-endpoint"),
@@ -543,6 +655,41 @@ func enableIngressFeatureSet(_ context.Context, opts managerOpts, mgr ctrl.Manag
 	return nil
 }
 
+// enableOrphanCollector starts collecting the ngrok resources created for the driver's resources whose CRs are gone
+func enableOrphanCollector(opts managerOpts, mgr ctrl.Manager, driver *store.Driver, ngrokClientset ngrokapi.Clientset) error {
+	if opts.orphanGC.mode != orphanGCModeReport && opts.orphanGC.mode != orphanGCModeDelete {
+		return fmt.Errorf("invalid orphan-gc-mode %q, must be %s or %s", opts.orphanGC.mode, orphanGCModeReport, orphanGCModeDelete)
+	}
+	if driver == nil {
+		setupLog.Info("orphaned ngrok resources collector disabled, it needs the Ingress or Gateway feature set")
+		return nil
+	}
+	// The default owner metadata is shared by every operator, so deleting the resources that carry only it would
+	// delete the resources of the other clusters using the same ngrok account
+	if opts.orphanGC.mode == orphanGCModeDelete {
+		customMetadata, err := util.ParseHelmDictionary(opts.ngrokMetadata)
+		if err != nil {
+			return fmt.Errorf("unable to parse ngrokMetadata: %w", err)
+		}
+		delete(customMetadata, "owned-by")
+		if len(customMetadata) == 0 {
+			return fmt.Errorf("orphan-gc-mode %s requires --ngrokMetadata to set a key unique to the cluster, such as cluster=<name>", orphanGCModeDelete)
+		}
+	}
+
+	reportOnly := opts.orphanGC.mode == orphanGCModeReport || opts.dryRun
+	setupLog.Info("orphaned ngrok resources collector enabled", "interval", opts.orphanGC.interval, "reportOnly", reportOnly)
+	return mgr.Add(&ingresscontroller.OrphanCollector{
+		Client:         mgr.GetClient(),
+		Log:            ctrl.Log.WithName("controllers").WithName("orphan-collector"),
+		NgrokClientset: ngrokClientset,
+		OwnerMetadata:  driver.OwnerMetadata(),
+		Interval:       opts.orphanGC.interval,
+		MinAge:         opts.orphanGC.minAge,
+		ReportOnly:     reportOnly,
+	})
+}
+
 // enableGatewayFeatureSet enables the Gateway feature set for the operator
 func enableGatewayFeatureSet(_ context.Context, _ managerOpts, mgr ctrl.Manager, driver *store.Driver, _ ngrokapi.Clientset) error {
 	if err := (&gatewaycontroller.GatewayClassReconciler{
@@ -577,9 +724,78 @@ func enableGatewayFeatureSet(_ context.Context, _ managerOpts, mgr ctrl.Manager,
 		os.Exit(1)
 	}
 
+	if err := (&gatewaycontroller.ReferenceGrantReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ReferenceGrant"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("gateway-controller"),
+		Driver:   driver,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ReferenceGrant")
+		os.Exit(1)
+	}
+
+	if err := (&gatewaycontroller.NamespaceReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("Namespace"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("gateway-controller"),
+		Driver:   driver,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Namespace")
+		os.Exit(1)
+	}
+
+	// TCPRoutes, TLSRoutes and GRPCRoutes are only available in the experimental channel of the Gateway API
+	if !gatewayV1Alpha2KindServed(mgr, "TCPRoute") {
+		setupLog.Info("TCPRoute CRD not found, TCPRoute support disabled")
+	} else if err := (&gatewaycontroller.TCPRouteReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("TCPRoute"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("gateway-controller"),
+		Driver:   driver,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TCPRoute")
+		os.Exit(1)
+	}
+
+	if !gatewayV1Alpha2KindServed(mgr, "TLSRoute") {
+		setupLog.Info("TLSRoute CRD not found, TLSRoute support disabled")
+	} else if err := (&gatewaycontroller.TLSRouteReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("TLSRoute"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("gateway-controller"),
+		Driver:   driver,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TLSRoute")
+		os.Exit(1)
+	}
+
+	if !gatewayV1Alpha2KindServed(mgr, "GRPCRoute") {
+		setupLog.Info("GRPCRoute CRD not found, GRPCRoute support disabled")
+	} else if err := (&gatewaycontroller.GRPCRouteReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("GRPCRoute"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("gateway-controller"),
+		Driver:   driver,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GRPCRoute")
+		os.Exit(1)
+	}
+
 	return nil
 }
 
+// gatewayV1Alpha2KindServed checks if the cluster serves a v1alpha2 Gateway API kind
+func gatewayV1Alpha2KindServed(mgr ctrl.Manager, kind string) bool {
+	gk := schema.GroupKind{Group: gatewayv1alpha2.GroupName, Kind: kind}
+	_, err := mgr.GetRESTMapper().RESTMapping(gk, gatewayv1alpha2.GroupVersion.Version)
+	return err == nil
+}
+
 // enableBindingsFeatureSet enables the Bindings feature set for the operator
 func enableBindingsFeatureSet(_ context.Context, opts managerOpts, mgr ctrl.Manager, _ *store.Driver, ngrokClientset ngrokapi.Clientset) error {
 	targetServiceAnnotations, err := util.ParseHelmDictionary(opts.bindings.serviceAnnotations)
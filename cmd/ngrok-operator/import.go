@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/ngrok/ngrok-operator/internal/controller"
+	"github.com/ngrok/ngrok-operator/internal/importer"
+	"github.com/ngrok/ngrok-operator/internal/ngrokapi"
+	"github.com/ngrok/ngrok-operator/internal/version"
+)
+
+type importOpts struct {
+	apiKey       string
+	apiURL       string
+	namespace    string
+	includeOwned bool
+	apply        bool
+}
+
+func importCmd() *cobra.Command {
+	var opts importOpts
+	c := &cobra.Command{
+		Use:   "import",
+		Short: "Print the Domain, IPPolicy and HTTPSEdge resources of the existing resources of an ngrok account",
+		Long: `Reads the reserved domains, IP policies and HTTPS edges of an ngrok account and prints the Domain, IPPolicy and
+HTTPSEdge resources that manage them, with their status already holding the IDs of the ngrok resources.
+
+Resources whose metadata has an owned-by marker are created by an operator and are skipped unless --include-owned is set.
+The parts of the resources that can't be imported, such as OAuth modules whose secrets the API doesn't return, are
+printed to stderr as warnings.
+
+Applying the printed resources drops their status, so IP policies, which the controllers can't find by their spec, would
+be created again. Use --apply to create the resources in the cluster of the current kubeconfig context along with
+their status instead. The resources are annotated with ` + controller.AnnotationImporting + ` until their status is
+set, and the controllers skip them until then.`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			return runImport(c.Context(), opts, c.OutOrStdout(), c.ErrOrStderr())
+		},
+	}
+
+	c.Flags().StringVar(&opts.apiKey, "api-key", os.Getenv("NGROK_API_KEY"), "The ngrok API key, defaults to the NGROK_API_KEY environment variable")
+	c.Flags().StringVar(&opts.apiURL, "api-url", "", "The base URL to use for the ngrok api")
+	c.Flags().StringVarP(&opts.namespace, "namespace", "n", "default", "Namespace of the imported resources")
+	c.Flags().BoolVar(&opts.includeOwned, "include-owned", false, "Imports the resources created by an operator too")
+	c.Flags().BoolVar(&opts.apply, "apply", false, "Creates the resources and their status in the cluster instead of printing them")
+
+	return c
+}
+
+func runImport(ctx context.Context, opts importOpts, stdout, stderr io.Writer) error {
+	if opts.apiKey == "" {
+		return errors.New("an ngrok API key is required, set --api-key or the NGROK_API_KEY environment variable")
+	}
+
+	ngrokClientConfig := ngrok.NewClientConfig(opts.apiKey, ngrok.WithUserAgent(version.GetUserAgent()))
+	if opts.apiURL != "" {
+		u, err := url.Parse(opts.apiURL)
+		if err != nil {
+			return fmt.Errorf("api-url must be a valid ngrok API URL: %w", err)
+		}
+		ngrokClientConfig.BaseURL = u
+	}
+
+	imp := &importer.Importer{
+		Clientset:    ngrokapi.NewClientSet(ngrokClientConfig),
+		Namespace:    opts.namespace,
+		IncludeOwned: opts.includeOwned,
+	}
+	result, err := imp.Import(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to import the ngrok resources: %w", err)
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintln(stderr, "warning:", warning)
+	}
+
+	if opts.apply {
+		return applyImported(ctx, result.Objects, stdout)
+	}
+
+	for i, obj := range result.Objects {
+		if i > 0 {
+			fmt.Fprintln(stdout, "---")
+		}
+		out, err := manifestYAML(obj, true)
+		if err != nil {
+			return err
+		}
+		if _, err := stdout.Write(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyImported creates the imported resources in the cluster and then sets their status, which is ignored on creation
+func applyImported(ctx context.Context, objs []client.Object, stdout io.Writer) error {
+	restConfig, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("unable to load the kubeconfig: %w", err)
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("unable to create the kubernetes client: %w", err)
+	}
+	return applyImportedWithClient(ctx, c, objs, stdout)
+}
+
+// applyImportedWithClient creates each resource with the importing annotation, so the controllers don't create the
+// ngrok resource again before its status holds the imported ID, then sets its status and removes the annotation.
+// Resources left with the annotation by a previous run are finished, the others that already exist are skipped.
+func applyImportedWithClient(ctx context.Context, c client.Client, objs []client.Object, stdout io.Writer) error {
+	for _, obj := range objs {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		withStatus := obj.DeepCopyObject().(client.Object)
+
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[controller.AnnotationImporting] = "true"
+		obj.SetAnnotations(annotations)
+
+		if err := c.Create(ctx, obj); err != nil {
+			if !apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("unable to create %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
+			}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+				return fmt.Errorf("unable to get %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
+			}
+			if !controller.IsImporting(obj) {
+				fmt.Fprintf(stdout, "%s %s/%s already exists, skipping\n", kind, obj.GetNamespace(), obj.GetName())
+				continue
+			}
+		}
+
+		withStatus.SetAnnotations(obj.GetAnnotations())
+		withStatus.SetResourceVersion(obj.GetResourceVersion())
+		if err := c.Status().Update(ctx, withStatus); err != nil {
+			return fmt.Errorf("unable to set the status of %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
+		}
+
+		annotations = withStatus.GetAnnotations()
+		delete(annotations, controller.AnnotationImporting)
+		withStatus.SetAnnotations(annotations)
+		if err := c.Update(ctx, withStatus); err != nil {
+			return fmt.Errorf("unable to remove the %s annotation of %s %s/%s: %w", controller.AnnotationImporting, kind, obj.GetNamespace(), obj.GetName(), err)
+		}
+		fmt.Fprintf(stdout, "%s %s/%s imported\n", kind, obj.GetNamespace(), obj.GetName())
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/controller"
+)
+
+func TestApplyImported(t *testing.T) {
+	importedPolicy := func(name, id string) *ingressv1alpha1.IPPolicy {
+		return &ingressv1alpha1.IPPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: ingressv1alpha1.GroupVersion.String(), Kind: "IPPolicy"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     ingressv1alpha1.IPPolicyStatus{ID: id},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing []client.Object
+		wantID   string
+		wantOut  string
+	}{
+		{
+			name:    "creates the resource with its status",
+			wantID:  "ipp_imported",
+			wantOut: "IPPolicy default/office imported\n",
+		},
+		{
+			name: "finishes the import of a resource still annotated",
+			existing: []client.Object{func() client.Object {
+				policy := importedPolicy("office", "")
+				policy.Annotations = map[string]string{controller.AnnotationImporting: "true"}
+				return policy
+			}()},
+			wantID:  "ipp_imported",
+			wantOut: "IPPolicy default/office imported\n",
+		},
+		{
+			name:     "skips existing resources",
+			existing: []client.Object{importedPolicy("office", "ipp_existing")},
+			wantID:   "ipp_existing",
+			wantOut:  "IPPolicy default/office already exists, skipping\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createdAnnotations := map[string]string{}
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(tt.existing...).
+				WithStatusSubresource(&ingressv1alpha1.IPPolicy{}).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+						createdAnnotations = obj.GetAnnotations()
+						return c.Create(ctx, obj, opts...)
+					},
+				}).
+				Build()
+
+			var out bytes.Buffer
+			objs := []client.Object{importedPolicy("office", "ipp_imported")}
+			require.NoError(t, applyImportedWithClient(context.Background(), c, objs, &out))
+			assert.Equal(t, tt.wantOut, out.String())
+			assert.Contains(t, createdAnnotations, controller.AnnotationImporting)
+
+			found := &ingressv1alpha1.IPPolicy{}
+			require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "office"}, found))
+			assert.Equal(t, tt.wantID, found.Status.ID)
+			assert.NotContains(t, found.Annotations, controller.AnnotationImporting)
+		})
+	}
+}
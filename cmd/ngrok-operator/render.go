@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/ngrok/ngrok-operator/internal/store"
+)
+
+type renderOpts struct {
+	filenames             []string
+	namespace             string
+	ingressClass          string
+	ingressControllerName string
+	operatorNamespace     string
+	managerName           string
+	clusterDomain         string
+	enableFeatureGateway  bool
+	verbose               bool
+}
+
+func renderCmd() *cobra.Command {
+	var opts renderOpts
+	c := &cobra.Command{
+		Use:   "render -f FILENAME...",
+		Short: "Print the ngrok resources the operator would create for the given manifests",
+		Long: `Reads Ingress, Service, IngressClass, Gateway, HTTPRoute, NgrokModuleSet and NgrokTrafficPolicy manifests
+from files and prints the Domain, HTTPSEdge and Tunnel resources the operator would create for them, without a cluster.
+
+When the manifests don't define an ngrok IngressClass, a default one named after --ingress-class is assumed.
+Errors found while translating the manifests are printed to stderr and make the command fail.`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			return render(c.Context(), opts, c.InOrStdin(), c.OutOrStdout(), c.ErrOrStderr())
+		},
+	}
+
+	c.Flags().StringSliceVarP(&opts.filenames, "filename", "f", nil, "Files or directories of manifests to render, - reads from stdin")
+	c.Flags().StringVarP(&opts.namespace, "namespace", "n", "default", "Namespace of the manifests that don't set one")
+	c.Flags().StringVar(&opts.ingressClass, "ingress-class", "ngrok", "Name of the default ngrok IngressClass assumed when the manifests don't define one")
+	c.Flags().StringVar(&opts.ingressControllerName, "ingress-controller-name", "k8s.ngrok.com/ingress-controller", "The name of the controller to use for matching ingresses classes")
+	c.Flags().StringVar(&opts.operatorNamespace, "operator-namespace", "ngrok-operator", "Namespace the operator is deployed to, used in the labels of the rendered resources")
+	c.Flags().StringVar(&opts.managerName, "manager-name", "ngrok-ingress-controller-manager", "Manager name to identify unique ngrok ingress controller instances")
+	c.Flags().StringVar(&opts.clusterDomain, "cluster-domain", "svc.cluster.local", "Cluster domain used in the cluster")
+	c.Flags().BoolVar(&opts.enableFeatureGateway, "enable-feature-gateway", false, "Renders the Gateway API manifests too")
+	c.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Print the logs of the translation to stderr")
+	_ = c.MarkFlagRequired("filename")
+
+	return c
+}
+
+func render(ctx context.Context, opts renderOpts, stdin io.Reader, stdout, stderr io.Writer) error {
+	objs, err := loadManifests(opts, stdin, stderr)
+	if err != nil {
+		return err
+	}
+	objs = withDefaultIngressClass(objs, opts)
+	if opts.enableFeatureGateway {
+		objs = withoutForeignGateways(objs)
+	}
+
+	logger := logr.Discard()
+	if opts.verbose {
+		logger = zap.New(zap.WriteTo(stderr), zap.UseDevMode(true))
+	}
+
+	driver := store.NewDriver(
+		logger,
+		scheme,
+		opts.ingressControllerName,
+		types.NamespacedName{
+			Namespace: opts.operatorNamespace,
+			Name:      opts.managerName,
+		},
+		store.WithGatewayEnabled(opts.enableFeatureGateway),
+		store.WithClusterDomain(opts.clusterDomain),
+		store.WithSyncAllowConcurrent(true),
+	)
+
+	// The driver is seeded through a client backed by the manifests rather than a cluster
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	if err := driver.Seed(ctx, c); err != nil {
+		return fmt.Errorf("unable to seed the driver from the manifests: %w", err)
+	}
+
+	result, err := driver.Render()
+	if err != nil {
+		return fmt.Errorf("unable to render the manifests: %w", err)
+	}
+
+	for i, obj := range result.Objects {
+		if i > 0 {
+			fmt.Fprintln(stdout, "---")
+		}
+		out, err := manifestYAML(obj, false)
+		if err != nil {
+			return err
+		}
+		if _, err := stdout.Write(out); err != nil {
+			return err
+		}
+	}
+
+	for _, err := range result.Errors {
+		fmt.Fprintln(stderr, "error:", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d errors found while translating the manifests", len(result.Errors))
+	}
+	return nil
+}
+
+// loadManifests decodes the objects of the manifest files, directories and stdin. Kinds the operator doesn't know
+// about, such as Deployments of other operators' resources, are skipped with a warning.
+func loadManifests(opts renderOpts, stdin io.Reader, stderr io.Writer) ([]client.Object, error) {
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+
+	var objs []client.Object
+	var decode func(source string, data []byte) error
+	decode = func(source string, data []byte) error {
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+		for {
+			doc, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("unable to read %s: %w", source, err)
+			}
+			if len(bytes.TrimSpace(doc)) == 0 {
+				continue
+			}
+
+			// Directories of manifests often hold other YAML files, such as kustomize patches
+			typeMeta := metav1.TypeMeta{}
+			if err := yaml.Unmarshal(doc, &typeMeta); err != nil || typeMeta.Kind == "" {
+				fmt.Fprintf(stderr, "warning: skipping document without a kind in %s\n", source)
+				continue
+			}
+
+			obj, gvk, err := decoder.Decode(doc, nil, nil)
+			if runtime.IsNotRegisteredError(err) {
+				fmt.Fprintf(stderr, "warning: skipping unsupported object in %s: %v\n", source, err)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("unable to decode %s: %w", source, err)
+			}
+
+			if list, ok := obj.(*corev1.List); ok {
+				for _, item := range list.Items {
+					if err := decode(source, item.Raw); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			clientObj, ok := obj.(client.Object)
+			if !ok {
+				fmt.Fprintf(stderr, "warning: skipping unsupported %s in %s\n", gvk, source)
+				continue
+			}
+			if clientObj.GetNamespace() == "" && !isClusterScoped(clientObj) {
+				clientObj.SetNamespace(opts.namespace)
+			}
+			objs = append(objs, clientObj)
+		}
+	}
+
+	for _, filename := range opts.filenames {
+		if filename == "-" {
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read stdin: %w", err)
+			}
+			if err := decode("stdin", data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		err := filepath.WalkDir(filename, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				return nil
+			}
+			// Only the manifests of directories are filtered by extension, files are always read
+			if path != filename && !isManifestFile(path) {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return decode(path, data)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return objs, nil
+}
+
+func isManifestFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+func isClusterScoped(obj client.Object) bool {
+	switch obj.(type) {
+	case *netv1.IngressClass, *gatewayv1.GatewayClass, *corev1.Namespace:
+		return true
+	default:
+		return false
+	}
+}
+
+// withDefaultIngressClass adds a default ngrok IngressClass when the manifests don't define an ngrok IngressClass,
+// so that rendering the Ingresses of an application doesn't require the manifests of the operator
+func withDefaultIngressClass(objs []client.Object, opts renderOpts) []client.Object {
+	for _, obj := range objs {
+		if class, ok := obj.(*netv1.IngressClass); ok && class.Spec.Controller == opts.ingressControllerName {
+			return objs
+		}
+	}
+
+	return append(objs, &netv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: opts.ingressClass,
+			Annotations: map[string]string{
+				netv1.AnnotationIsDefaultIngressClass: "true",
+			},
+		},
+		Spec: netv1.IngressClassSpec{
+			Controller: opts.ingressControllerName,
+		},
+	})
+}
+
+// withoutForeignGateways drops the Gateways of GatewayClasses the manifests define for other controllers. Gateways of
+// GatewayClasses the manifests don't define are assumed to be handled by the operator.
+func withoutForeignGateways(objs []client.Object) []client.Object {
+	foreignClasses := map[gatewayv1.ObjectName]bool{}
+	for _, obj := range objs {
+		if class, ok := obj.(*gatewayv1.GatewayClass); ok && class.Spec.ControllerName != store.GatewayControllerName {
+			foreignClasses[gatewayv1.ObjectName(class.Name)] = true
+		}
+	}
+
+	filtered := make([]client.Object, 0, len(objs))
+	for _, obj := range objs {
+		if gtw, ok := obj.(*gatewayv1.Gateway); ok && foreignClasses[gtw.Spec.GatewayClassName] {
+			continue
+		}
+		filtered = append(filtered, obj)
+	}
+	return filtered
+}
+
+// manifestYAML returns the YAML manifest of a resource, without the fields that are only set by the cluster. The
+// status is kept for imported resources, whose status holds the IDs of the ngrok resources they manage.
+func manifestYAML(obj client.Object, keepStatus bool) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	manifest := map[string]interface{}{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if !keepStatus {
+		delete(manifest, "status")
+	}
+	if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	return yaml.Marshal(manifest)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the render tests")
+
+// TestRender renders the manifests of each testdata/render directory and compares the output with the golden files of
+// the directory. Run the tests with -update to regenerate the golden files.
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		gateway bool
+		wantErr bool
+	}{
+		{name: "ingress"},
+		{name: "gateway", gateway: true},
+		{name: "errors", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join("testdata", "render", tt.name)
+			opts := renderOpts{
+				filenames:             []string{filepath.Join(dir, "input.yaml")},
+				namespace:             "default",
+				ingressClass:          "ngrok",
+				ingressControllerName: "k8s.ngrok.com/ingress-controller",
+				operatorNamespace:     "ngrok-operator",
+				managerName:           "ngrok-ingress-controller-manager",
+				clusterDomain:         "svc.cluster.local",
+				enableFeatureGateway:  tt.gateway,
+			}
+
+			var stdout, stderr bytes.Buffer
+			err := render(context.Background(), opts, nil, &stdout, &stderr)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assertGolden(t, filepath.Join(dir, "output.yaml"), stdout.Bytes())
+			assertGolden(t, filepath.Join(dir, "stderr.txt"), stderr.Bytes())
+		})
+	}
+}
+
+func assertGolden(t *testing.T, path string, actual []byte) {
+	t.Helper()
+	if *update {
+		require.NoError(t, os.WriteFile(path, actual, 0o644))
+		return
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual), "output differs from %s, run the tests with -update to regenerate it", path)
+}
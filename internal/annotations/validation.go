@@ -0,0 +1,109 @@
+package annotations
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/ngrok/ngrok-operator/internal/annotations/parser"
+	"github.com/ngrok/ngrok-operator/internal/errors"
+	corev1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	annotationsPath = field.NewPath("metadata", "annotations")
+
+	supportedTLSMinVersions = []string{"1.0", "1.1", "1.2", "1.3"}
+	supportedAppProtocols   = []string{"HTTP", "HTTPS"}
+)
+
+// Validate checks the ngrok annotations of an Ingress or a Service. The controllers only report these errors when
+// they translate the object, or ignore the annotations altogether.
+func Validate(obj client.Object) field.ErrorList {
+	errs := field.ErrorList{}
+
+	if _, err := ExtractNgrokTrafficPolicyFromAnnotations(obj); err != nil && !errors.IsMissingAnnotations(err) {
+		errs = append(errs, invalidAnnotation(obj, "traffic-policy", err.Error()))
+	}
+
+	switch obj.(type) {
+	case *networking.Ingress:
+		errs = append(errs, validateRouteModules(obj)...)
+	case *corev1.Service:
+		errs = append(errs, validateAppProtocols(obj)...)
+	}
+
+	return errs
+}
+
+// validateRouteModules checks the annotations the route modules of an Ingress are parsed from
+func validateRouteModules(obj client.Object) field.ErrorList {
+	errs := field.ErrorList{}
+
+	if _, err := parser.GetBoolAnnotation("https-compression", obj); err != nil && !errors.IsMissingAnnotations(err) {
+		errs = append(errs, invalidAnnotation(obj, "https-compression", "must be true or false"))
+	}
+
+	for _, name := range []string{"request-headers-add", "response-headers-add"} {
+		if _, err := parser.GetStringMapAnnotation(name, obj); err != nil && !errors.IsMissingAnnotations(err) {
+			errs = append(errs, invalidAnnotation(obj, name, "must be a JSON object of header names to values"))
+		}
+	}
+
+	if version, err := parser.GetStringAnnotation("tls-min-version", obj); err == nil {
+		if !slices.Contains(supportedTLSMinVersions, version) {
+			errs = append(errs, field.NotSupported(annotationPath("tls-min-version"), version, supportedTLSMinVersions))
+		}
+	}
+
+	// The webhook verification module is silently dropped when the secret of a provider other than sns is missing
+	if provider, err := parser.GetStringAnnotation("webhook-verification-provider", obj); err == nil && provider != "sns" {
+		for _, name := range []string{"webhook-verification-secret-name", "webhook-verification-secret-key"} {
+			if _, err := parser.GetStringAnnotation(name, obj); err != nil {
+				errs = append(errs, field.Required(annotationPath(name), "required by the "+provider+" webhook verification provider"))
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateAppProtocols checks the protocols of the app-protocols annotation of a Service, a JSON object of port names
+// to protocols
+func validateAppProtocols(obj client.Object) field.ErrorList {
+	value, ok := obj.GetAnnotations()[parser.GetAnnotationWithPrefix("app-protocols")]
+	if !ok || value == "" {
+		return nil
+	}
+
+	protocols := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &protocols); err != nil {
+		return field.ErrorList{invalidAnnotation(obj, "app-protocols", "must be a JSON object of port names to protocols")}
+	}
+
+	ports := make([]string, 0, len(protocols))
+	for port := range protocols {
+		ports = append(ports, port)
+	}
+	slices.Sort(ports)
+
+	errs := field.ErrorList{}
+	for _, port := range ports {
+		protocol := protocols[port]
+		if !slices.Contains(supportedAppProtocols, strings.ToUpper(protocol)) {
+			errs = append(errs, field.NotSupported(annotationPath("app-protocols").Key(port), protocol, supportedAppProtocols))
+		}
+	}
+	return errs
+}
+
+func invalidAnnotation(obj client.Object, name, detail string) *field.Error {
+	return field.Invalid(annotationPath(name), obj.GetAnnotations()[parser.GetAnnotationWithPrefix(name)], detail)
+}
+
+func annotationPath(name string) *field.Path {
+	return annotationsPath.Key(parser.GetAnnotationWithPrefix(name))
+}
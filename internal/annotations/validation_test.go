@@ -0,0 +1,116 @@
+package annotations
+
+import (
+	"testing"
+
+	"github.com/ngrok/ngrok-operator/internal/annotations/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateIngress(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		errors      []string
+	}{
+		{
+			name: "valid",
+			annotations: map[string]string{
+				"k8s.ngrok.com/traffic-policy":                   "policy",
+				"k8s.ngrok.com/https-compression":                "true",
+				"k8s.ngrok.com/request-headers-add":              `{"X-Foo":"bar"}`,
+				"k8s.ngrok.com/tls-min-version":                  "1.2",
+				"k8s.ngrok.com/webhook-verification-provider":    "github",
+				"k8s.ngrok.com/webhook-verification-secret-name": "github",
+				"k8s.ngrok.com/webhook-verification-secret-key":  "token",
+			},
+		},
+		{
+			name:        "multiple traffic policies",
+			annotations: map[string]string{"k8s.ngrok.com/traffic-policy": "a,b"},
+			errors:      []string{`metadata.annotations[k8s.ngrok.com/traffic-policy]: Invalid value: "a,b": multiple traffic policies are not supported: [a b]`},
+		},
+		{
+			name:        "invalid compression",
+			annotations: map[string]string{"k8s.ngrok.com/https-compression": "yes please"},
+			errors:      []string{`metadata.annotations[k8s.ngrok.com/https-compression]: Invalid value: "yes please": must be true or false`},
+		},
+		{
+			name:        "invalid headers",
+			annotations: map[string]string{"k8s.ngrok.com/response-headers-add": "X-Foo: bar"},
+			errors:      []string{`metadata.annotations[k8s.ngrok.com/response-headers-add]: Invalid value: "X-Foo: bar": must be a JSON object of header names to values`},
+		},
+		{
+			name:        "unsupported TLS version",
+			annotations: map[string]string{"k8s.ngrok.com/tls-min-version": "1.4"},
+			errors:      []string{`metadata.annotations[k8s.ngrok.com/tls-min-version]: Unsupported value: "1.4": supported values: "1.0", "1.1", "1.2", "1.3"`},
+		},
+		{
+			name:        "webhook verification without secret",
+			annotations: map[string]string{"k8s.ngrok.com/webhook-verification-provider": "github"},
+			errors: []string{
+				"metadata.annotations[k8s.ngrok.com/webhook-verification-secret-name]: Required value: required by the github webhook verification provider",
+				"metadata.annotations[k8s.ngrok.com/webhook-verification-secret-key]: Required value: required by the github webhook verification provider",
+			},
+		},
+		{
+			name:        "sns webhook verification",
+			annotations: map[string]string{"k8s.ngrok.com/webhook-verification-provider": "sns"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ing := testutil.NewIngress()
+			ing.SetAnnotations(tt.annotations)
+			assert.Equal(t, tt.errors, errorStrings(Validate(ing)))
+		})
+	}
+}
+
+func TestValidateService(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		errors      []string
+	}{
+		{
+			name:        "valid",
+			annotations: map[string]string{"k8s.ngrok.com/app-protocols": `{"web":"https","api":"HTTP"}`},
+		},
+		{
+			name:        "invalid JSON",
+			annotations: map[string]string{"k8s.ngrok.com/app-protocols": "web=https"},
+			errors:      []string{`metadata.annotations[k8s.ngrok.com/app-protocols]: Invalid value: "web=https": must be a JSON object of port names to protocols`},
+		},
+		{
+			name:        "unsupported protocol",
+			annotations: map[string]string{"k8s.ngrok.com/app-protocols": `{"web":"https","grpc":"h2c"}`},
+			errors:      []string{`metadata.annotations[k8s.ngrok.com/app-protocols][grpc]: Unsupported value: "h2c": supported values: "HTTP", "HTTPS"`},
+		},
+		{
+			name: "route module annotations are ignored",
+			annotations: map[string]string{
+				"k8s.ngrok.com/tls-min-version": "1.4",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "default", Annotations: tt.annotations}}
+			assert.Equal(t, tt.errors, errorStrings(Validate(svc)))
+		})
+	}
+}
+
+func errorStrings(errs []*field.Error) []string {
+	var s []string
+	for _, err := range errs {
+		s = append(s, err.Error())
+	}
+	return s
+}
@@ -0,0 +1,303 @@
+/*
+MIT License
+
+Copyright (c) 2024 ngrok, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/controller"
+	"github.com/ngrok/ngrok-operator/pkg/tunneldriver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	controllerruntime "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// agentEndpointConfigError is an error in the spec of an AgentEndpoint, or in the resources it references, that
+// retrying won't fix until they change
+type agentEndpointConfigError struct {
+	err error
+}
+
+func (e agentEndpointConfigError) Error() string {
+	return e.err.Error()
+}
+
+func (e agentEndpointConfigError) Unwrap() error {
+	return e.err
+}
+
+// AgentEndpointReconciler reconciles an AgentEndpoint object
+type AgentEndpointReconciler struct {
+	client.Client
+
+	Log           logr.Logger
+	Scheme        *runtime.Scheme
+	Recorder      record.EventRecorder
+	TunnelDriver  *tunneldriver.TunnelDriver
+	ClusterDomain string
+
+	controller *controller.BaseController[*ngrokv1alpha1.AgentEndpoint]
+
+	// statusEvents reconciles the AgentEndpoints whose status must be written again
+	statusEvents chan event.GenericEvent
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *AgentEndpointReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.TunnelDriver == nil {
+		return fmt.Errorf("TunnelDriver is nil")
+	}
+
+	r.controller = &controller.BaseController[*ngrokv1alpha1.AgentEndpoint]{
+		Kube:     r.Client,
+		Log:      r.Log,
+		Recorder: r.Recorder,
+
+		Update:    r.update,
+		Delete:    r.delete,
+		StatusID:  r.statusID,
+		ErrResult: r.errResult,
+	}
+
+	// ngrok-go can't share the URL of an endpoint between several agents, so only the elected replica runs the agent
+	// endpoints. The replica losing the election exits, which closes its endpoints, and the next elected replica
+	// starts them again.
+	r.statusEvents = make(chan event.GenericEvent)
+
+	cont, err := controllerruntime.NewUnmanaged("agentendpoint-controller", mgr, controllerruntime.Options{
+		Reconciler: r,
+		LogConstructor: func(_ *reconcile.Request) logr.Logger {
+			return r.Log
+		},
+		NeedLeaderElection: ptr.To(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := cont.Watch(
+		source.Kind(mgr.GetCache(), &ngrokv1alpha1.AgentEndpoint{}),
+		&handler.EnqueueRequestForObject{},
+		predicate.Or(
+			predicate.AnnotationChangedPredicate{},
+			predicate.GenerationChangedPredicate{},
+		),
+	); err != nil {
+		return err
+	}
+
+	if err := cont.Watch(
+		source.Kind(mgr.GetCache(), &corev1.Secret{}),
+		r.controller.NewEnqueueRequestForMapFunc(r.findAgentEndpointsForSecret),
+	); err != nil {
+		return err
+	}
+
+	if err := cont.Watch(
+		&source.Channel{Source: r.statusEvents},
+		&handler.EnqueueRequestForObject{},
+	); err != nil {
+		return err
+	}
+
+	if err := mgr.Add(cont); err != nil {
+		return err
+	}
+
+	// The runnable needs the leader election too
+	return mgr.Add(manager.RunnableFunc(r.reconcileStatuses))
+}
+
+// reconcileStatuses reconciles every AgentEndpoint each time the agent connects to, or disconnects from, the ngrok
+// service, so that their status reports it
+func (r *AgentEndpointReconciler) reconcileStatuses(ctx context.Context) error {
+	sessionChanges := r.TunnelDriver.SessionChanges()
+	for {
+		r.enqueueAgentEndpoints(ctx)
+		select {
+		case <-sessionChanges:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (r *AgentEndpointReconciler) enqueueAgentEndpoints(ctx context.Context) {
+	aeps := &ngrokv1alpha1.AgentEndpointList{}
+	if err := r.Client.List(ctx, aeps); err != nil {
+		r.Log.Error(err, "failed to list AgentEndpoints")
+		return
+	}
+	for i := range aeps.Items {
+		select {
+		case r.statusEvents <- event.GenericEvent{Object: &aeps.Items[i]}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+//+kubebuilder:rbac:groups=ngrok.k8s.ngrok.com,resources=agentendpoints,verbs=get;list;watch;patch;update
+//+kubebuilder:rbac:groups=ngrok.k8s.ngrok.com,resources=agentendpoints/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ngrok.k8s.ngrok.com,resources=agentendpoints/finalizers,verbs=update
+// The agent manager only caches the kubernetes.io/tls secrets, but RBAC can't restrict the secrets by type
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *AgentEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return r.controller.Reconcile(ctx, req, new(ngrokv1alpha1.AgentEndpoint))
+}
+
+func (r *AgentEndpointReconciler) update(ctx context.Context, aep *ngrokv1alpha1.AgentEndpoint) error {
+	opts, err := r.agentEndpointOpts(ctx, aep)
+	if err != nil {
+		setAgentEndpointReady(aep, "", ngrokv1alpha1.AgentEndpointReasonConfigError, err)
+		return r.controller.ReconcileStatus(ctx, aep, err)
+	}
+
+	url, err := r.TunnelDriver.CreateAgentEndpoint(ctx, r.statusID(aep), opts)
+	if err != nil {
+		setAgentEndpointReady(aep, "", ngrokv1alpha1.AgentEndpointReasonConnectionError, err)
+		return r.controller.ReconcileStatus(ctx, aep, err)
+	}
+
+	// The endpoint is started again once the agent reconnects, which reconciles the AgentEndpoints
+	if err := r.TunnelDriver.SessionErr(); err != nil {
+		setAgentEndpointReady(aep, "", ngrokv1alpha1.AgentEndpointReasonConnectionError, fmt.Errorf("the agent is disconnected from the ngrok service: %w", err))
+		return r.controller.ReconcileStatus(ctx, aep, nil)
+	}
+
+	setAgentEndpointReady(aep, url, ngrokv1alpha1.AgentEndpointReasonStarted, nil)
+	return r.controller.ReconcileStatus(ctx, aep, nil)
+}
+
+func (r *AgentEndpointReconciler) delete(ctx context.Context, aep *ngrokv1alpha1.AgentEndpoint) error {
+	return r.TunnelDriver.DeleteAgentEndpoint(ctx, r.statusID(aep))
+}
+
+func (r *AgentEndpointReconciler) statusID(aep *ngrokv1alpha1.AgentEndpoint) string {
+	return fmt.Sprintf("%s/%s", aep.Namespace, aep.Name)
+}
+
+// errResult doesn't retry the config errors, the AgentEndpoint is reconciled again when it or the resources it
+// references change
+func (r *AgentEndpointReconciler) errResult(_ controller.BaseControllerOp, _ *ngrokv1alpha1.AgentEndpoint, err error) (ctrl.Result, error) {
+	var configErr agentEndpointConfigError
+	if errors.As(err, &configErr) {
+		return ctrl.Result{}, nil
+	}
+	return controller.CtrlResultForErr(err)
+}
+
+// agentEndpointOpts resolves the upstream service and the resources the AgentEndpoint references to the options of its
+// agent endpoint
+func (r *AgentEndpointReconciler) agentEndpointOpts(ctx context.Context, aep *ngrokv1alpha1.AgentEndpoint) (tunneldriver.AgentEndpointOpts, error) {
+	opts := tunneldriver.AgentEndpointOpts{
+		URL:         aep.Spec.URL,
+		ForwardsTo:  fmt.Sprintf("%s.%s.%s:%d", aep.Spec.Upstream.Service, aep.Namespace, r.ClusterDomain, aep.Spec.Upstream.Port),
+		Protocol:    aep.Spec.Upstream.Protocol,
+		AppProtocol: aep.Spec.Upstream.AppProtocol,
+	}
+
+	if aep.Spec.ClientCertificate != nil {
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: aep.Namespace, Name: aep.Spec.ClientCertificate.SecretName}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return opts, agentEndpointConfigError{fmt.Errorf("client certificate secret %q not found, it must be a %s secret", aep.Spec.ClientCertificate.SecretName, corev1.SecretTypeTLS)}
+			}
+			return opts, err
+		}
+		cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return opts, agentEndpointConfigError{fmt.Errorf("invalid client certificate in secret %q: %w", secret.Name, err)}
+		}
+		opts.ClientCertificates = []tls.Certificate{cert}
+	}
+
+	return opts, nil
+}
+
+// setAgentEndpointReady records the assigned URL of the agent endpoint, and whether it could be started in its Ready
+// condition
+func setAgentEndpointReady(aep *ngrokv1alpha1.AgentEndpoint, url, reason string, err error) {
+	aep.Status.AssignedURL = url
+
+	condition := metav1.Condition{
+		Type:               ngrokv1alpha1.AgentEndpointConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            "the agent is listening on the endpoint",
+		ObservedGeneration: aep.Generation,
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(&aep.Status.Conditions, condition)
+}
+
+// findAgentEndpointsForSecret returns the AgentEndpoints using the secret as their client certificate
+func (r *AgentEndpointReconciler) findAgentEndpointsForSecret(ctx context.Context, o client.Object) []reconcile.Request {
+	return r.findAgentEndpoints(ctx, o.GetNamespace(), func(aep *ngrokv1alpha1.AgentEndpoint) bool {
+		return aep.Spec.ClientCertificate != nil && aep.Spec.ClientCertificate.SecretName == o.GetName()
+	})
+}
+
+func (r *AgentEndpointReconciler) findAgentEndpoints(ctx context.Context, namespace string, matches func(*ngrokv1alpha1.AgentEndpoint) bool) []reconcile.Request {
+	log := ctrl.LoggerFrom(ctx)
+
+	aeps := &ngrokv1alpha1.AgentEndpointList{}
+	if err := r.Client.List(ctx, aeps, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "failed to list AgentEndpoints")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range aeps.Items {
+		if matches(&aeps.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&aeps.Items[i])})
+		}
+	}
+	return requests
+}
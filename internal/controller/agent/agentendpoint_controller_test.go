@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// testClientCertificate returns the PEM encoded certificate and key of a self-signed client certificate
+func testClientCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "agent"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func Test_agentEndpointOpts(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = ngrokv1alpha1.AddToScheme(scheme)
+
+	certPEM, keyPEM := testClientCertificate(t)
+	objects := []client.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "client-cert", Namespace: "default"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid-cert", Namespace: "default"},
+			Data:       map[string][]byte{corev1.TLSCertKey: []byte("not a certificate")},
+		},
+	}
+
+	r := &AgentEndpointReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		ClusterDomain: "svc.cluster.local",
+	}
+
+	newAgentEndpoint := func() *ngrokv1alpha1.AgentEndpoint {
+		return &ngrokv1alpha1.AgentEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+			Spec: ngrokv1alpha1.AgentEndpointSpec{
+				URL: "https://api.internal",
+				Upstream: ngrokv1alpha1.AgentEndpointUpstream{
+					Service:     "api",
+					Port:        8443,
+					Protocol:    "HTTPS",
+					AppProtocol: "http2",
+				},
+			},
+		}
+	}
+
+	t.Run("upstream", func(t *testing.T) {
+		opts, err := r.agentEndpointOpts(context.Background(), newAgentEndpoint())
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.internal", opts.URL)
+		assert.Equal(t, "api.default.svc.cluster.local:8443", opts.ForwardsTo)
+		assert.Equal(t, "HTTPS", opts.Protocol)
+		assert.Equal(t, "http2", opts.AppProtocol)
+		assert.Empty(t, opts.ClientCertificates)
+	})
+
+	t.Run("client certificate", func(t *testing.T) {
+		aep := newAgentEndpoint()
+		aep.Spec.ClientCertificate = &ngrokv1alpha1.AgentEndpointClientCertificate{SecretName: "client-cert"}
+		opts, err := r.agentEndpointOpts(context.Background(), aep)
+		require.NoError(t, err)
+		assert.Len(t, opts.ClientCertificates, 1)
+	})
+
+	tests := []struct {
+		name   string
+		modify func(*ngrokv1alpha1.AgentEndpoint)
+		err    string
+	}{
+		{
+			name: "missing client certificate secret",
+			modify: func(aep *ngrokv1alpha1.AgentEndpoint) {
+				aep.Spec.ClientCertificate = &ngrokv1alpha1.AgentEndpointClientCertificate{SecretName: "missing"}
+			},
+			err: `client certificate secret "missing" not found`,
+		},
+		{
+			name: "invalid client certificate",
+			modify: func(aep *ngrokv1alpha1.AgentEndpoint) {
+				aep.Spec.ClientCertificate = &ngrokv1alpha1.AgentEndpointClientCertificate{SecretName: "invalid-cert"}
+			},
+			err: `invalid client certificate in secret "invalid-cert"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aep := newAgentEndpoint()
+			tt.modify(aep)
+			_, err := r.agentEndpointOpts(context.Background(), aep)
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.err)
+			assert.ErrorAs(t, err, &agentEndpointConfigError{})
+		})
+	}
+}
+
+func Test_setAgentEndpointReady(t *testing.T) {
+	aep := &ngrokv1alpha1.AgentEndpoint{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+
+	setAgentEndpointReady(aep, "https://api.internal", ngrokv1alpha1.AgentEndpointReasonStarted, nil)
+	assert.Equal(t, "https://api.internal", aep.Status.AssignedURL)
+	condition := meta.FindStatusCondition(aep.Status.Conditions, ngrokv1alpha1.AgentEndpointConditionReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, ngrokv1alpha1.AgentEndpointReasonStarted, condition.Reason)
+	assert.Equal(t, int64(2), condition.ObservedGeneration)
+
+	setAgentEndpointReady(aep, "", ngrokv1alpha1.AgentEndpointReasonConnectionError, assert.AnError)
+	assert.Empty(t, aep.Status.AssignedURL)
+	condition = meta.FindStatusCondition(aep.Status.Conditions, ngrokv1alpha1.AgentEndpointConditionReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ngrokv1alpha1.AgentEndpointReasonConnectionError, condition.Reason)
+	assert.Equal(t, assert.AnError.Error(), condition.Message)
+	assert.Len(t, aep.Status.Conditions, 1)
+}
+
+func Test_findAgentEndpointsForSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = ngrokv1alpha1.AddToScheme(scheme)
+
+	withCert := func(name, namespace, secretName string) *ngrokv1alpha1.AgentEndpoint {
+		return &ngrokv1alpha1.AgentEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: ngrokv1alpha1.AgentEndpointSpec{
+				ClientCertificate: &ngrokv1alpha1.AgentEndpointClientCertificate{SecretName: secretName},
+			},
+		}
+	}
+	r := &AgentEndpointReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			withCert("uses-secret", "default", "client-cert"),
+			withCert("other-secret", "default", "other"),
+			withCert("other-namespace", "other", "client-cert"),
+			&ngrokv1alpha1.AgentEndpoint{ObjectMeta: metav1.ObjectMeta{Name: "no-cert", Namespace: "default"}},
+		).Build(),
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "client-cert", Namespace: "default"}}
+	requests := r.findAgentEndpointsForSecret(context.Background(), secret)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "uses-secret", requests[0].Name)
+	assert.Equal(t, "default", requests[0].Namespace)
+}
@@ -58,8 +58,6 @@ type TunnelReconciler struct {
 
 // SetupWithManager sets up the controller with the Manager
 func (r *TunnelReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	var err error
-
 	if r.TunnelDriver == nil {
 		return fmt.Errorf("TunnelDriver is nil")
 	}
@@ -74,12 +72,24 @@ func (r *TunnelReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		StatusID: r.statusID,
 	}
 
-	cont, err := controllerruntime.NewUnmanaged("tunnel-controller", mgr, controllerruntime.Options{
+	// ngrok balances the traffic of labeled tunnels between the agents, so every replica runs them. The URL of the
+	// tunnels started as internal agent endpoints can only be bound by a single agent though, so only the elected
+	// replica runs those. The replica losing the election exits, and the next elected replica starts them again.
+	labeled := predicate.NewPredicateFuncs(func(o client.Object) bool { return !hasURL(o) })
+	if err := r.addController(mgr, "tunnel-controller", false, labeled); err != nil {
+		return err
+	}
+	return r.addController(mgr, "internal-endpoint-controller", true, predicate.NewPredicateFuncs(hasURL))
+}
+
+// addController adds a controller reconciling the Tunnels matching the predicate to the manager
+func (r *TunnelReconciler) addController(mgr ctrl.Manager, name string, needLeaderElection bool, tunnels predicate.Predicate) error {
+	cont, err := controllerruntime.NewUnmanaged(name, mgr, controllerruntime.Options{
 		Reconciler: r,
 		LogConstructor: func(_ *reconcile.Request) logr.Logger {
 			return r.Log
 		},
-		NeedLeaderElection: ptr.To(false),
+		NeedLeaderElection: ptr.To(needLeaderElection),
 	})
 	if err != nil {
 		return err
@@ -88,6 +98,7 @@ func (r *TunnelReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err := cont.Watch(
 		source.Kind(mgr.GetCache(), &ingressv1alpha1.Tunnel{}),
 		&handler.EnqueueRequestForObject{},
+		tunnels,
 		predicate.Or(
 			predicate.AnnotationChangedPredicate{},
 			predicate.GenerationChangedPredicate{},
@@ -99,6 +110,12 @@ func (r *TunnelReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return mgr.Add(cont)
 }
 
+// hasURL checks if a Tunnel is started as an agent endpoint listening on its URL rather than as a labeled tunnel
+func hasURL(o client.Object) bool {
+	tunnel, ok := o.(*ingressv1alpha1.Tunnel)
+	return ok && tunnel.Spec.URL != ""
+}
+
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=tunnels,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=tunnels/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=tunnels/finalizers,verbs=update
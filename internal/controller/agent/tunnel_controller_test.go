@@ -0,0 +1,15 @@
+package agent
+
+import (
+	"testing"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_hasURL(t *testing.T) {
+	assert.False(t, hasURL(&ingressv1alpha1.Tunnel{}))
+	assert.True(t, hasURL(&ingressv1alpha1.Tunnel{Spec: ingressv1alpha1.TunnelSpec{URL: "https://my-service.my-namespace.80.1a2b3c4d.internal"}}))
+	assert.False(t, hasURL(&corev1.Service{}))
+}
@@ -72,6 +72,12 @@ func (self *BaseController[T]) Reconcile(ctx context.Context, req ctrl.Request,
 
 	log.V(1).Info("Reconciling Resource", "ID", self.StatusID(obj))
 
+	if IsUpsert(obj) && IsImporting(obj) {
+		// the import command sets the status and removes the annotation next, which triggers another reconcile
+		log.V(1).Info("Skipping Resource until it's imported")
+		return ctrl.Result{}, nil
+	}
+
 	if IsUpsert(obj) {
 		if err := RegisterAndSyncFinalizer(ctx, self.Kube, obj); err != nil {
 			return ctrl.Result{}, err
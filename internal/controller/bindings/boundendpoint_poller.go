@@ -193,6 +193,7 @@ func (r *BoundEndpointPoller) reconcileBoundEndpointsFromAPI(ctx context.Context
 	}
 
 	// Fetch the mock endpoint data from the API
+	pollStart := time.Now()
 	var apiBindingEndpoints []v6.Endpoint
 	iter := r.NgrokClientset.KubernetesOperators().GetBoundEndpoints(r.koId, &ngrok.Paging{})
 	for iter.Next(ctx) {
@@ -204,9 +205,11 @@ func (r *BoundEndpointPoller) reconcileBoundEndpointsFromAPI(ctx context.Context
 
 	err := iter.Err()
 	if err != nil {
+		pollDuration.WithLabelValues("error").Observe(time.Since(pollStart).Seconds())
 		log.Error(err, "Failed to fetch binding_endpoints from API")
 		return err
 	}
+	pollDuration.WithLabelValues("success").Observe(time.Since(pollStart).Seconds())
 
 	desiredBoundEndpoints, err := ngrokapi.AggregateBindingEndpoints(apiBindingEndpoints)
 	if err != nil {
@@ -215,6 +218,7 @@ func (r *BoundEndpointPoller) reconcileBoundEndpointsFromAPI(ctx context.Context
 
 	// modify the desired BoundEndpoints updating their Allow/Deny status
 	allowDenyEndpointByURL(ctx, desiredBoundEndpoints, r.allowedUrlRegexes)
+	observeBoundEndpoints(desiredBoundEndpoints)
 
 	// Get all current BoundEndpoint resources in the cluster.
 	var epbList bindingsv1alpha1.BoundEndpointList
@@ -0,0 +1,42 @@
+package bindings
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/ngrok/ngrok-operator/internal/ngrokapi"
+)
+
+var (
+	boundEndpointsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "bound_endpoint_poller",
+		Name:      "bound_endpoints",
+		Help:      "Number of BoundEndpoints returned by the last poll of the ngrok API, by whether they're allowed to be projected into the cluster.",
+	}, []string{"allowed"})
+
+	pollDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "bound_endpoint_poller",
+		Name:      "poll_duration_seconds",
+		Help:      "Duration of the polls of the ngrok API for bound endpoints, by result (success or error).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(boundEndpointsGauge, pollDuration)
+}
+
+// observeBoundEndpoints records the number of allowed and denied bound endpoints
+func observeBoundEndpoints(endpoints ngrokapi.AggregatedEndpoints) {
+	counts := map[bool]int{true: 0, false: 0}
+	for _, endpoint := range endpoints {
+		counts[endpoint.Spec.Allowed]++
+	}
+	for allowed, count := range counts {
+		boundEndpointsGauge.WithLabelValues(strconv.FormatBool(allowed)).Set(float64(count))
+	}
+}
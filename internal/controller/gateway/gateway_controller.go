@@ -40,7 +40,7 @@ import (
 )
 
 const (
-	ControllerName gatewayv1.GatewayController = "ngrok.com/gateway-controller"
+	ControllerName gatewayv1.GatewayController = store.GatewayControllerName
 )
 
 // GatewayReconciler reconciles a Gateway object
@@ -26,23 +26,39 @@ package ingress
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"github.com/go-logr/logr"
 	"github.com/ngrok/ngrok-api-go/v6"
 	"github.com/ngrok/ngrok-api-go/v6/reserved_domains"
+	"github.com/ngrok/ngrok-api-go/v6/tls_certificates"
 	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
 	"github.com/ngrok/ngrok-operator/internal/controller"
 )
 
+const (
+	// DomainCertificateRefPath indexes domains by the "namespace/name" of the Secret their certificateRef points to
+	DomainCertificateRefPath = "spec.certificateRef"
+)
+
 // DomainReconciler reconciles a Domain object
 type DomainReconciler struct {
 	client.Client
@@ -51,6 +67,8 @@ type DomainReconciler struct {
 	Scheme        *runtime.Scheme
 	Recorder      record.EventRecorder
 	DomainsClient *reserved_domains.Client
+	// TLSCertificatesClient uploads the certificates referenced by a Domain's certificateRef
+	TLSCertificatesClient *tls_certificates.Client
 
 	controller *controller.BaseController[*ingressv1alpha1.Domain]
 }
@@ -60,6 +78,9 @@ func (r *DomainReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if r.DomainsClient == nil {
 		return fmt.Errorf("DomainsClient must be set")
 	}
+	if r.TLSCertificatesClient == nil {
+		return fmt.Errorf("TLSCertificatesClient must be set")
+	}
 
 	r.controller = &controller.BaseController[*ingressv1alpha1.Domain]{
 		Kube:     r.Client,
@@ -87,18 +108,36 @@ func (r *DomainReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		},
 	}
 
+	// Index the domains by the secret their certificate is read from
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &ingressv1alpha1.Domain{}, DomainCertificateRefPath, func(obj client.Object) []string {
+		domain, ok := obj.(*ingressv1alpha1.Domain)
+		if !ok || domain.Spec.CertificateRef == nil {
+			return nil
+		}
+		return []string{domain.CertificateSecretKey().String()}
+	})
+	if err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&ingressv1alpha1.Domain{}).
-		WithEventFilter(predicate.Or(
+		For(&ingressv1alpha1.Domain{}, builder.WithPredicates(predicate.Or(
 			predicate.AnnotationChangedPredicate{},
 			predicate.GenerationChangedPredicate{},
-		)).
+		))).
+		// Watch certificate secrets so rotated certificates are uploaded to ngrok
+		Watches(
+			&v1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findDomainsForSecret),
+		).
 		Complete(r)
 }
 
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=domains,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=domains/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=domains/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=referencegrants,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -131,7 +170,12 @@ func (r *DomainReconciler) create(ctx context.Context, domain *ingressv1alpha1.D
 		}
 	}
 
-	return r.updateStatus(ctx, domain, resp)
+	resp, certificateID, err := r.reconcileCertificate(ctx, domain, resp)
+	if err != nil {
+		return err
+	}
+
+	return r.updateStatus(ctx, domain, resp, certificateID)
 }
 
 func (r *DomainReconciler) update(ctx context.Context, domain *ingressv1alpha1.Domain) error {
@@ -146,20 +190,24 @@ func (r *DomainReconciler) update(ctx context.Context, domain *ingressv1alpha1.D
 		return err
 	}
 
-	if domain.Equal(resp) {
-		return nil
+	if !domain.Equal(resp) {
+		req := &ngrok.ReservedDomainUpdate{
+			ID:          domain.Status.ID,
+			Description: &domain.Spec.Description,
+			Metadata:    &domain.Spec.Metadata,
+		}
+		resp, err = r.DomainsClient.Update(ctx, req)
+		if err != nil {
+			return err
+		}
 	}
 
-	req := &ngrok.ReservedDomainUpdate{
-		ID:          domain.Status.ID,
-		Description: &domain.Spec.Description,
-		Metadata:    &domain.Spec.Metadata,
-	}
-	resp, err = r.DomainsClient.Update(ctx, req)
+	resp, certificateID, err := r.reconcileCertificate(ctx, domain, resp)
 	if err != nil {
 		return err
 	}
-	return r.updateStatus(ctx, domain, resp)
+
+	return r.updateStatus(ctx, domain, resp, certificateID)
 }
 
 func (r *DomainReconciler) delete(ctx context.Context, domain *ingressv1alpha1.Domain) error {
@@ -167,7 +215,212 @@ func (r *DomainReconciler) delete(ctx context.Context, domain *ingressv1alpha1.D
 	if err == nil || ngrok.IsNotFound(err) {
 		domain.Status.ID = ""
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	// The certificate can only be removed once the reserved domain no longer uses it
+	if err := r.deleteCertificate(ctx, domain.Status.CertificateID); err != nil {
+		return err
+	}
+	domain.Status.CertificateID = ""
+	return nil
+}
+
+// reconcileCertificate makes sure the reserved domain serves the certificate from the Secret referenced by the
+// domain's certificateRef. A new certificate is uploaded whenever the Secret's contents change, and the domain
+// goes back to an ngrok-managed certificate when the reference is removed. It returns the up to date reserved
+// domain along with the ID of the certificate uploaded by the operator, if any.
+func (r *DomainReconciler) reconcileCertificate(ctx context.Context, domain *ingressv1alpha1.Domain, ngrokDomain *ngrok.ReservedDomain) (*ngrok.ReservedDomain, string, error) {
+	currentID := domain.Status.CertificateID
+
+	if domain.Spec.CertificateRef == nil {
+		if currentID == "" {
+			return ngrokDomain, "", nil
+		}
+
+		resp, err := r.DomainsClient.Update(ctx, &ngrok.ReservedDomainUpdate{
+			ID: ngrokDomain.ID,
+			CertificateManagementPolicy: &ngrok.ReservedDomainCertPolicy{
+				Authority:      "letsencrypt",
+				PrivateKeyType: "ecdsa",
+			},
+		})
+		if err != nil {
+			return nil, currentID, err
+		}
+		return resp, "", r.deleteCertificate(ctx, currentID)
+	}
+
+	certPEM, keyPEM, err := r.getCertificateSecret(ctx, domain)
+	if err != nil {
+		return nil, currentID, err
+	}
+	metadata, err := certificateMetadata(certPEM, keyPEM)
+	if err != nil {
+		return nil, currentID, err
+	}
+
+	if currentID != "" {
+		cert, err := r.TLSCertificatesClient.Get(ctx, currentID)
+		if err != nil && !ngrok.IsNotFound(err) {
+			return nil, currentID, err
+		}
+		if cert != nil && cert.Metadata == metadata && ngrokDomain.Certificate != nil && ngrokDomain.Certificate.ID == cert.ID {
+			return ngrokDomain, currentID, nil
+		}
+	}
+
+	cert, err := r.TLSCertificatesClient.Create(ctx, &ngrok.TLSCertificateCreate{
+		Description:    fmt.Sprintf("Certificate for %s from secret %s", domain.Spec.Domain, domain.CertificateSecretKey()),
+		Metadata:       metadata,
+		CertificatePEM: certPEM,
+		PrivateKeyPEM:  keyPEM,
+	})
+	if err != nil {
+		return nil, currentID, err
+	}
+
+	resp, err := r.DomainsClient.Update(ctx, &ngrok.ReservedDomainUpdate{
+		ID:            ngrokDomain.ID,
+		CertificateID: &cert.ID,
+	})
+	if err != nil {
+		// Don't leave the new certificate dangling, it will be uploaded again on the next reconcile
+		if delErr := r.deleteCertificate(ctx, cert.ID); delErr != nil {
+			r.Log.Error(delErr, "error deleting unused certificate", "id", cert.ID)
+		}
+		return nil, currentID, err
+	}
+	r.Recorder.Event(domain, v1.EventTypeNormal, "CertificateUploaded", fmt.Sprintf("Uploaded certificate %s from secret %s", cert.ID, domain.CertificateSecretKey()))
+
+	if currentID != "" && currentID != cert.ID {
+		if err := r.deleteCertificate(ctx, currentID); err != nil {
+			r.Log.Error(err, "error deleting previous certificate", "id", currentID)
+		}
+	}
+	return resp, cert.ID, nil
+}
+
+// getCertificateSecret reads the PEM encoded certificate and private key from the Secret referenced by the domain
+func (r *DomainReconciler) getCertificateSecret(ctx context.Context, domain *ingressv1alpha1.Domain) (string, string, error) {
+	key := domain.CertificateSecretKey()
+	allowed, err := r.certificateRefAllowed(ctx, domain)
+	if err != nil {
+		return "", "", err
+	}
+	if !allowed {
+		return "", "", fmt.Errorf("secret '%s' is in another namespace than the domain and no ReferenceGrant allows the reference", key)
+	}
+
+	secret := &v1.Secret{}
+	if err := r.Client.Get(ctx, key, secret); err != nil {
+		return "", "", err
+	}
+
+	certPEM, ok := secret.Data[v1.TLSCertKey]
+	if !ok || len(certPEM) == 0 {
+		return "", "", fmt.Errorf("secret '%s' does not contain key '%s'", key, v1.TLSCertKey)
+	}
+	keyPEM, ok := secret.Data[v1.TLSPrivateKeyKey]
+	if !ok || len(keyPEM) == 0 {
+		return "", "", fmt.Errorf("secret '%s' does not contain key '%s'", key, v1.TLSPrivateKeyKey)
+	}
+	return string(certPEM), string(keyPEM), nil
+}
+
+// certificateRefAllowed checks that the Secret referenced by the domain's certificateRef is in the domain's namespace,
+// or that a ReferenceGrant in the Secret's namespace allows the reference. Otherwise anyone able to create a Domain
+// could upload the private key of any Secret in the cluster to ngrok.
+//
+// Grants from Domains are honoured, as well as grants from Gateways, since the Domains of Gateway listeners are created
+// in the namespace of the Gateway with the certificateRefs the Gateway's grants allow.
+func (r *DomainReconciler) certificateRefAllowed(ctx context.Context, domain *ingressv1alpha1.Domain) (bool, error) {
+	key := domain.CertificateSecretKey()
+	if key.Namespace == domain.Namespace {
+		return true, nil
+	}
+
+	grants := &gatewayv1beta1.ReferenceGrantList{}
+	if err := r.Client.List(ctx, grants, client.InNamespace(key.Namespace)); err != nil {
+		// without the Gateway API CRDs, nothing can allow the reference
+		if meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, grant := range grants.Items {
+		fromAllowed := false
+		for _, from := range grant.Spec.From {
+			if string(from.Namespace) != domain.Namespace {
+				continue
+			}
+			if (from.Group == gatewayv1.Group(ingressv1alpha1.GroupVersion.Group) && from.Kind == "Domain") ||
+				(from.Group == gatewayv1.GroupName && from.Kind == "Gateway") {
+				fromAllowed = true
+				break
+			}
+		}
+		if !fromAllowed {
+			continue
+		}
+		for _, to := range grant.Spec.To {
+			if to.Group == "" && to.Kind == "Secret" && (to.Name == nil || string(*to.Name) == key.Name) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// deleteCertificate deletes a certificate uploaded by the operator, ignoring certificates that are already gone
+func (r *DomainReconciler) deleteCertificate(ctx context.Context, id string) error {
+	if id == "" {
+		return nil
+	}
+	err := r.TLSCertificatesClient.Delete(ctx, id)
+	if err != nil && !ngrok.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// certificateMetadata returns the metadata stored on uploaded certificates. It records a digest of the
+// certificate and key so changes to the Secret can be detected without comparing PEM encodings.
+func certificateMetadata(certPEM, keyPEM string) (string, error) {
+	digest := sha256.Sum256([]byte(certPEM + keyPEM))
+	metadata, err := json.Marshal(map[string]string{
+		"owned-by": "ngrok-operator",
+		"sha256":   hex.EncodeToString(digest[:]),
+	})
+	return string(metadata), err
+}
+
+// findDomainsForSecret returns a reconcile request for every domain whose certificateRef points to the secret
+func (r *DomainReconciler) findDomainsForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	key := types.NamespacedName{Namespace: secret.GetNamespace(), Name: secret.GetName()}
+
+	domains := &ingressv1alpha1.DomainList{}
+	listOpts := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(DomainCertificateRefPath, key.String()),
+	}
+	if err := r.Client.List(ctx, domains, listOpts); err != nil {
+		r.Log.Error(err, "Failed to list domains for secret", "secret", key)
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, len(domains.Items))
+	for i, domain := range domains.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: domain.Namespace,
+				Name:      domain.Name,
+			},
+		}
+		r.Log.V(3).Info("Triggering reconciliation for domain", "namespace", domain.Namespace, "name", domain.Name)
+	}
+	return requests
 }
 
 // finds the reserved domain by the hostname. If it doesn't exist, returns nil
@@ -183,11 +436,12 @@ func (r *DomainReconciler) findReservedDomainByHostname(ctx context.Context, dom
 }
 
 // updateStatus updates the status fields of the domain resource only if any values have changed
-func (r *DomainReconciler) updateStatus(ctx context.Context, domain *ingressv1alpha1.Domain, ngrokDomain *ngrok.ReservedDomain) error {
-	if domain.Equal(ngrokDomain) {
+func (r *DomainReconciler) updateStatus(ctx context.Context, domain *ingressv1alpha1.Domain, ngrokDomain *ngrok.ReservedDomain, certificateID string) error {
+	if domain.Equal(ngrokDomain) && domain.Status.CertificateID == certificateID {
 		return nil
 	}
 	domain.SetStatus(ngrokDomain)
+	domain.Status.CertificateID = certificateID
 	r.Recorder.Event(domain, v1.EventTypeNormal, "Updated", fmt.Sprintf("Updating Domain %s", domain.Name))
 	return r.Status().Update(ctx, domain)
 }
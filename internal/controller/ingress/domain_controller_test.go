@@ -0,0 +1,125 @@
+package ingress
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+)
+
+var _ = Describe("DomainController", func() {
+	Describe("certificateMetadata", func() {
+		It("is stable for the same certificate and key", func() {
+			first, err := certificateMetadata("cert", "key")
+			Expect(err).ToNot(HaveOccurred())
+			second, err := certificateMetadata("cert", "key")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(first).To(Equal(second))
+			Expect(first).To(ContainSubstring(`"owned-by":"ngrok-operator"`))
+		})
+
+		It("changes when the certificate is rotated", func() {
+			before, err := certificateMetadata("cert", "key")
+			Expect(err).ToNot(HaveOccurred())
+			after, err := certificateMetadata("rotated-cert", "key")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(after).ToNot(Equal(before))
+		})
+
+		It("changes when only the key is rotated", func() {
+			before, err := certificateMetadata("cert", "key")
+			Expect(err).ToNot(HaveOccurred())
+			after, err := certificateMetadata("cert", "rotated-key")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(after).ToNot(Equal(before))
+		})
+	})
+
+	Describe("getCertificateSecret", func() {
+		var domain *ingressv1alpha1.Domain
+		var secret *v1.Secret
+
+		newReconciler := func(objects ...client.Object) *DomainReconciler {
+			scheme := runtime.NewScheme()
+			Expect(v1.AddToScheme(scheme)).To(Succeed())
+			Expect(ingressv1alpha1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayv1beta1.AddToScheme(scheme)).To(Succeed())
+			return &DomainReconciler{
+				Client: crfake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			}
+		}
+
+		BeforeEach(func() {
+			domain = &ingressv1alpha1.Domain{
+				ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "apps"},
+				Spec: ingressv1alpha1.DomainSpec{
+					Domain:         "example.com",
+					CertificateRef: &ingressv1alpha1.DomainCertificateRef{Name: "example-tls"},
+				},
+			}
+			secret = &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "example-tls", Namespace: "apps"},
+				Type:       v1.SecretTypeTLS,
+				Data: map[string][]byte{
+					v1.TLSCertKey:       []byte("cert"),
+					v1.TLSPrivateKeyKey: []byte("key"),
+				},
+			}
+		})
+
+		It("reads a Secret in the namespace of the domain", func() {
+			certPEM, keyPEM, err := newReconciler(secret).getCertificateSecret(context.Background(), domain)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(certPEM).To(Equal("cert"))
+			Expect(keyPEM).To(Equal("key"))
+		})
+
+		It("rejects a Secret in another namespace without a ReferenceGrant", func() {
+			secret.Namespace = "certs"
+			domain.Spec.CertificateRef.Namespace = "certs"
+
+			_, _, err := newReconciler(secret).getCertificateSecret(context.Background(), domain)
+			Expect(err).To(MatchError(ContainSubstring("no ReferenceGrant allows the reference")))
+		})
+
+		It("rejects a Secret in another namespace when the ReferenceGrant is for another Secret", func() {
+			secret.Namespace = "certs"
+			domain.Spec.CertificateRef.Namespace = "certs"
+			grant := &gatewayv1beta1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "domains", Namespace: "certs"},
+				Spec: gatewayv1beta1.ReferenceGrantSpec{
+					From: []gatewayv1beta1.ReferenceGrantFrom{{Group: "ingress.k8s.ngrok.com", Kind: "Domain", Namespace: "apps"}},
+					To:   []gatewayv1beta1.ReferenceGrantTo{{Group: "", Kind: "Secret", Name: ptr.To(gatewayv1beta1.ObjectName("other-tls"))}},
+				},
+			}
+
+			_, _, err := newReconciler(secret, grant).getCertificateSecret(context.Background(), domain)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("reads a Secret in another namespace when a ReferenceGrant allows it", func() {
+			secret.Namespace = "certs"
+			domain.Spec.CertificateRef.Namespace = "certs"
+			grant := &gatewayv1beta1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "domains", Namespace: "certs"},
+				Spec: gatewayv1beta1.ReferenceGrantSpec{
+					From: []gatewayv1beta1.ReferenceGrantFrom{{Group: "ingress.k8s.ngrok.com", Kind: "Domain", Namespace: "apps"}},
+					To:   []gatewayv1beta1.ReferenceGrantTo{{Group: "", Kind: "Secret"}},
+				},
+			}
+
+			certPEM, _, err := newReconciler(secret, grant).getCertificateSecret(context.Background(), domain)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(certPEM).To(Equal("cert"))
+		})
+	})
+})
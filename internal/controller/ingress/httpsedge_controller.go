@@ -288,23 +288,36 @@ func (r *HTTPSEdgeReconciler) reconcileRoutes(ctx context.Context, edge *ingress
 			return err
 		}
 
-		// The route modules were successfully applied, so now we update the route with its specified backend
-		backend, err := tunnelGroupReconciler.findOrCreate(routeCtx, routeSpec.Backend)
-		if err != nil {
-			return err
-		}
-		routeLog.Info("Updating route", "ngrok.backend.id", backend.ID)
-
 		// TODO: Do an entropy check here to avoid unnecessary updates
 		req := &ngrok.HTTPSEdgeRouteUpdate{
 			EdgeID:    edge.Status.ID,
 			ID:        route.ID,
 			Match:     routeSpec.Match,
 			MatchType: routeSpec.MatchType,
-			Backend: &ngrok.EndpointBackendMutate{
+		}
+
+		if len(routeSpec.Backend.Labels) > 0 {
+			// The route modules were successfully applied, so now we update the route with its specified backend
+			backend, err := tunnelGroupReconciler.findOrCreate(routeCtx, routeSpec.Backend)
+			if err != nil {
+				return err
+			}
+			routeLog.Info("Updating route", "ngrok.backend.id", backend.ID)
+			req.Backend = &ngrok.EndpointBackendMutate{
 				BackendID: backend.ID,
-			},
+			}
+		} else {
+			// Routes without backend labels forward their requests with their traffic policy, such as the
+			// forward-internal action of ingress resource backends, a tunnel group backend would match no tunnel
+			if route.Backend != nil {
+				routeLog.Info("Removing route backend", "ngrok.backend.id", route.Backend.Backend.ID)
+				if err := routeModuleUpdater.clientset.Backend().Delete(routeCtx, &ngrok.EdgeRouteItem{EdgeID: edge.Status.ID, ID: route.ID}); err != nil {
+					return err
+				}
+			}
+			routeLog.Info("Updating route")
 		}
+
 		route, err = edgeRoutes.Update(routeCtx, req)
 		if err != nil {
 			return err
@@ -39,6 +39,7 @@ func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		&ingressv1alpha1.Tunnel{},
 		&ingressv1alpha1.NgrokModuleSet{},
 		&ngrokv1alpha1.NgrokTrafficPolicy{},
+		&ngrokv1alpha1.CloudEndpoint{},
 	}
 
 	builder := ctrl.NewControllerManagedBy(mgr).For(&netv1.Ingress{})
@@ -60,6 +61,7 @@ func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingressclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=ngrokmodulesets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=ngrok.k8s.ngrok.com,resources=ngroktrafficpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ngrok.k8s.ngrok.com,resources=cloudendpoints,verbs=get;list;watch;create;update;delete
 
 // This reconcile function is called by the controller-runtime manager.
 // It is invoked whenever there is an event that occurs for a resource
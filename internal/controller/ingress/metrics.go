@@ -0,0 +1,28 @@
+package ingress
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var orphansGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "ngrok_operator",
+	Subsystem: "orphan_collector",
+	Name:      "orphans",
+	Help:      "Number of orphaned ngrok resources found by the last collection, by kind.",
+}, []string{"kind"})
+
+func init() {
+	metrics.Registry.MustRegister(orphansGauge)
+}
+
+// observeOrphans records the number of orphans of each kind
+func observeOrphans(orphans []Orphan) {
+	counts := map[string]int{}
+	for _, orphan := range orphans {
+		counts[orphan.Kind]++
+	}
+	for _, kind := range orphanKinds {
+		orphansGauge.WithLabelValues(kind).Set(float64(counts[kind]))
+	}
+}
@@ -1,12 +1,22 @@
 package ingress
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
 
 	"github.com/go-logr/logr"
 	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/controller"
 	"github.com/ngrok/ngrok-operator/internal/store"
+	"github.com/ngrok/ngrok-operator/internal/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,12 +43,93 @@ func (r *ModuleSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 // +kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=ngrokmodulesets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=ngrokmodulesets/status,verbs=get;update;patch
 
 // This reconcile function is called by the controller-runtime manager.
 // It is invoked whenever there is an event that occurs for a resource
 // being watched (in our case, NgrokModuleSets). If you tail the controller
 // logs and delete, update, edit ngrokmoduleset objects, you see the events come in.
 func (r *ModuleSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if err := r.updateGeneratedPolicy(ctx, req.NamespacedName); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	err := r.Driver.SyncEdges(ctx, r.Client)
 	return ctrl.Result{}, err
 }
+
+// updateGeneratedPolicy converts the modules of the module set to traffic policy, and records the policy and whether
+// the conversion succeeded in its status
+func (r *ModuleSetReconciler) updateGeneratedPolicy(ctx context.Context, key types.NamespacedName) error {
+	ms := &ingressv1alpha1.NgrokModuleSet{}
+	if err := r.Get(ctx, key, ms); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	condition := metav1.Condition{
+		Type:    ingressv1alpha1.NgrokModuleSetConditionPolicyGenerated,
+		Status:  metav1.ConditionTrue,
+		Reason:  ingressv1alpha1.NgrokModuleSetReasonPolicyGenerated,
+		Message: "The modules were converted to traffic policy",
+	}
+
+	var generatedPolicy json.RawMessage
+	resolver := &redactingModuleSetResolver{
+		ipPolicyResolver: controller.IpPolicyResolver{Client: r.Client},
+		namespace:        ms.Namespace,
+	}
+	policy, err := util.ModuleSetToTrafficPolicy(ctx, ms.Modules, resolver)
+	if err == nil {
+		generatedPolicy, err = policy.ToAPIJson()
+	}
+
+	var unsupported *util.UnsupportedModuleError
+	switch {
+	case errors.As(err, &unsupported):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ingressv1alpha1.NgrokModuleSetReasonUnsupportedModule
+		condition.Message = err.Error()
+	case err != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ingressv1alpha1.NgrokModuleSetReasonConversionFailed
+		condition.Message = err.Error()
+	}
+
+	condition.ObservedGeneration = ms.Generation
+	changed := meta.SetStatusCondition(&ms.Status.Conditions, condition)
+	if changed || !bytes.Equal(ms.Status.GeneratedPolicy, generatedPolicy) {
+		ms.Status.GeneratedPolicy = generatedPolicy
+		if err := r.Status().Update(ctx, ms); err != nil {
+			return err
+		}
+	}
+
+	// The conversion is retried when a reference couldn't be resolved, such as an IPPolicy that has no ID yet
+	if condition.Reason == ingressv1alpha1.NgrokModuleSetReasonConversionFailed {
+		return err
+	}
+	return nil
+}
+
+// redactingModuleSetResolver resolves the references of a module set for the policy recorded in its status, which
+// mustn't contain the values of the secrets
+type redactingModuleSetResolver struct {
+	ipPolicyResolver controller.IpPolicyResolver
+	namespace        string
+}
+
+func (r *redactingModuleSetResolver) ResolveSecret(_ context.Context, ref ingressv1alpha1.SecretKeyRef) (string, error) {
+	return fmt.Sprintf("<secret %s/%s>", ref.Name, ref.Key), nil
+}
+
+func (r *redactingModuleSetResolver) ResolveIPPolicies(ctx context.Context, namesOrIDs []string) ([]string, error) {
+	ids, err := r.ipPolicyResolver.ResolveIPPolicyNamesorIds(ctx, r.namespace, namesOrIDs)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(ids, "") {
+		return nil, fmt.Errorf("some of the IP policies %v aren't created in the ngrok API yet", namesOrIDs)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
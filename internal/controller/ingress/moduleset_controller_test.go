@@ -0,0 +1,58 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+)
+
+func TestModuleSetGeneratedPolicy(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, ingressv1alpha1.AddToScheme(scheme))
+
+	converted := &ingressv1alpha1.NgrokModuleSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhooks", Namespace: "default"},
+		Modules: ingressv1alpha1.NgrokModuleSetModules{
+			WebhookVerification: &ingressv1alpha1.EndpointWebhookVerification{
+				Provider:  "github",
+				SecretRef: &ingressv1alpha1.SecretKeyRef{Name: "github", Key: "token"},
+			},
+		},
+	}
+	unsupported := &ingressv1alpha1.NgrokModuleSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "saml", Namespace: "default"},
+		Modules: ingressv1alpha1.NgrokModuleSetModules{
+			SAML: &ingressv1alpha1.EndpointSAML{IdPMetadata: "<xml/>"},
+		},
+	}
+	c := crfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(converted, unsupported).
+		WithStatusSubresource(converted, unsupported).
+		Build()
+	r := &ModuleSetReconciler{Client: c}
+
+	require.NoError(t, r.updateGeneratedPolicy(ctx, client.ObjectKeyFromObject(converted)))
+	ms := &ingressv1alpha1.NgrokModuleSet{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(converted), ms))
+	assert.True(t, meta.IsStatusConditionTrue(ms.Status.Conditions, ingressv1alpha1.NgrokModuleSetConditionPolicyGenerated))
+	assert.JSONEq(t, `{"on_http_request":[{"name":"Webhook Verification","actions":[{"type":"verify-webhook","config":{"provider":"github","secret":"<secret github/token>"}}]}]}`, string(ms.Status.GeneratedPolicy))
+
+	require.NoError(t, r.updateGeneratedPolicy(ctx, client.ObjectKeyFromObject(unsupported)))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(unsupported), ms))
+	condition := meta.FindStatusCondition(ms.Status.Conditions, ingressv1alpha1.NgrokModuleSetConditionPolicyGenerated)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ingressv1alpha1.NgrokModuleSetReasonUnsupportedModule, condition.Reason)
+	assert.Empty(t, ms.Status.GeneratedPolicy)
+}
@@ -0,0 +1,403 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ngrok/ngrok-api-go/v6"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/ngrokapi"
+)
+
+// Kinds of the ngrok resources the OrphanCollector collects
+const (
+	OrphanKindHTTPSEdge          = "HTTPSEdge"
+	OrphanKindTCPEdge            = "TCPEdge"
+	OrphanKindTLSEdge            = "TLSEdge"
+	OrphanKindTunnelGroupBackend = "TunnelGroupBackend"
+	OrphanKindDomain             = "Domain"
+	OrphanKindIPPolicy           = "IPPolicy"
+)
+
+// orphanKinds are the kinds of the collected resources, in the order they're deleted in: the edges go first as they
+// reference the other resources, which the ngrok API doesn't delete while they're in use. The domains are only
+// reported, see OrphanCollector.
+var orphanKinds = []string{
+	OrphanKindHTTPSEdge,
+	OrphanKindTCPEdge,
+	OrphanKindTLSEdge,
+	OrphanKindTunnelGroupBackend,
+	OrphanKindDomain,
+	OrphanKindIPPolicy,
+}
+
+// Orphan is an ngrok resource created by the operator whose resource in the cluster is gone
+type Orphan struct {
+	Kind string
+	ID   string
+	// Name is a human readable name of the resource, such as the domain or the hostports of an edge
+	Name string
+}
+
+// OrphanCollector is a runnable that periodically finds the ngrok resources created by the operator that no resource
+// in the cluster accounts for anymore, and deletes them. They're left behind when a CR is force-deleted, or when its
+// finalizer is removed while the ngrok API is down.
+//
+// The orphaned domains are never deleted, only reported: deleting a domain releases it, and a custom domain must then
+// be set up again in the DNS, which the operator mustn't do on its own.
+//
+// The operator's resources are found by the owner metadata the driver sets on them. Operators sharing an ngrok
+// account must set distinct custom metadata, such as the name of their cluster, so that they don't collect each
+// other's resources.
+type OrphanCollector struct {
+	client.Client
+	Log logr.Logger
+
+	// NgrokClientset is the ngrok API clientset
+	NgrokClientset ngrokapi.Clientset
+
+	// OwnerMetadata marks the resources created by the operator. A resource is owned by the operator when its
+	// metadata has all the keys and values of one of them.
+	OwnerMetadata []map[string]string
+
+	// Interval is how often the ngrok resources are collected
+	Interval time.Duration
+
+	// MinAge is the age below which the resources aren't collected, as the CRs of the resources that were just
+	// created may not record their IDs yet
+	MinAge time.Duration
+
+	// ReportOnly only logs the orphaned resources instead of deleting them
+	ReportOnly bool
+
+	// now returns the current time, it's replaced in tests
+	now func() time.Time
+}
+
+// Start implements the manager.Runnable interface.
+func (c *OrphanCollector) Start(ctx context.Context) error {
+	log := c.Log.WithValues("interval", c.Interval, "reportOnly", c.ReportOnly)
+	log.Info("Starting the orphaned ngrok resources collector")
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Stopping the orphaned ngrok resources collector")
+			return nil
+		case <-ticker.C:
+			if _, err := c.Collect(ctx); err != nil {
+				log.Error(err, "failed to collect the orphaned ngrok resources")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements the manager.LeaderElectionRunnable interface, so that only the leader deletes
+// resources
+func (c *OrphanCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect finds the orphaned ngrok resources and deletes them, unless the collector only reports them or they're
+// domains. It returns the orphans it found, along with the errors listing the resources or deleting them.
+func (c *OrphanCollector) Collect(ctx context.Context) ([]Orphan, error) {
+	crs, err := c.loadCRs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans, err := c.findOrphans(ctx, crs)
+	if err != nil {
+		return nil, err
+	}
+	observeOrphans(orphans)
+
+	var errs []error
+	for _, orphan := range orphans {
+		log := c.Log.WithValues("kind", orphan.Kind, "id", orphan.ID, "name", orphan.Name)
+		if c.ReportOnly || orphan.Kind == OrphanKindDomain {
+			log.Info("found orphaned ngrok resource")
+			continue
+		}
+
+		if err := c.delete(ctx, orphan); err != nil && !ngrok.IsNotFound(err) {
+			log.Error(err, "failed to delete orphaned ngrok resource")
+			errs = append(errs, fmt.Errorf("deleting %s %s: %w", orphan.Kind, orphan.ID, err))
+			continue
+		}
+		log.Info("deleted orphaned ngrok resource")
+	}
+	return orphans, errors.Join(errs...)
+}
+
+// clusterResources are the references the CRs in the cluster hold to ngrok resources
+type clusterResources struct {
+	ids       map[string]bool
+	domains   map[string]bool
+	hostports map[string][][]string
+	labels    []map[string]string
+}
+
+func (c *OrphanCollector) loadCRs(ctx context.Context) (*clusterResources, error) {
+	crs := &clusterResources{ids: map[string]bool{}, domains: map[string]bool{}, hostports: map[string][][]string{}}
+	addID := func(id string) {
+		if id != "" {
+			crs.ids[id] = true
+		}
+	}
+
+	domains := &ingressv1alpha1.DomainList{}
+	if err := c.List(ctx, domains); err != nil {
+		return nil, err
+	}
+	for _, domain := range domains.Items {
+		addID(domain.Status.ID)
+		crs.domains[domain.Spec.Domain] = true
+	}
+
+	httpsEdges := &ingressv1alpha1.HTTPSEdgeList{}
+	if err := c.List(ctx, httpsEdges); err != nil {
+		return nil, err
+	}
+	for _, edge := range httpsEdges.Items {
+		addID(edge.Status.ID)
+		crs.hostports[OrphanKindHTTPSEdge] = append(crs.hostports[OrphanKindHTTPSEdge], edge.Spec.Hostports)
+		for _, route := range edge.Status.Routes {
+			addID(route.Backend.ID)
+		}
+		for _, route := range edge.Spec.Routes {
+			crs.labels = append(crs.labels, route.Backend.Labels)
+		}
+	}
+
+	tcpEdges := &ingressv1alpha1.TCPEdgeList{}
+	if err := c.List(ctx, tcpEdges); err != nil {
+		return nil, err
+	}
+	for _, edge := range tcpEdges.Items {
+		addID(edge.Status.ID)
+		addID(edge.Status.Backend.ID)
+		crs.hostports[OrphanKindTCPEdge] = append(crs.hostports[OrphanKindTCPEdge], edge.Status.Hostports)
+		crs.labels = append(crs.labels, edge.Spec.Backend.Labels)
+	}
+
+	tlsEdges := &ingressv1alpha1.TLSEdgeList{}
+	if err := c.List(ctx, tlsEdges); err != nil {
+		return nil, err
+	}
+	for _, edge := range tlsEdges.Items {
+		addID(edge.Status.ID)
+		addID(edge.Status.Backend.ID)
+		crs.hostports[OrphanKindTLSEdge] = append(crs.hostports[OrphanKindTLSEdge], edge.Spec.Hostports)
+		crs.labels = append(crs.labels, edge.Spec.Backend.Labels)
+	}
+
+	ipPolicies := &ingressv1alpha1.IPPolicyList{}
+	if err := c.List(ctx, ipPolicies); err != nil {
+		return nil, err
+	}
+	for _, policy := range ipPolicies.Items {
+		addID(policy.Status.ID)
+	}
+
+	return crs, nil
+}
+
+// hasHostports returns whether an edge CR of the kind serves the hostports, as the controllers adopt the existing
+// edges with the same hostports
+func (crs *clusterResources) hasHostports(kind string, hostports []string) bool {
+	for _, crHostports := range crs.hostports[kind] {
+		if len(crHostports) == len(hostports) && !slices.ContainsFunc(hostports, func(h string) bool { return !slices.Contains(crHostports, h) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLabels returns whether a CR has a backend with the labels, as the controllers reuse the existing backends
+// with the same labels
+func (crs *clusterResources) hasLabels(labels map[string]string) bool {
+	return slices.ContainsFunc(crs.labels, func(crLabels map[string]string) bool { return maps.Equal(crLabels, labels) })
+}
+
+// inUse are the resources referenced by the edges that are kept
+type inUse struct {
+	ids   map[string]bool
+	hosts map[string]bool
+}
+
+func (u *inUse) addRefs(backend *ngrok.EndpointBackend, ipRestriction *ngrok.EndpointIPPolicy) {
+	if backend != nil {
+		u.ids[backend.Backend.ID] = true
+	}
+	if ipRestriction != nil {
+		for _, policy := range ipRestriction.IPPolicies {
+			u.ids[policy.ID] = true
+		}
+	}
+}
+
+func (u *inUse) addHosts(hostports []string) {
+	for _, hostport := range hostports {
+		if host, _, err := net.SplitHostPort(hostport); err == nil {
+			u.hosts[host] = true
+		}
+	}
+}
+
+func (c *OrphanCollector) findOrphans(ctx context.Context, crs *clusterResources) ([]Orphan, error) {
+	orphans := []Orphan{}
+	used := &inUse{ids: map[string]bool{}, hosts: map[string]bool{}}
+	// orphanedEdge returns whether an edge is orphaned, recording what it references otherwise
+	orphanedEdge := func(kind, id, metadata, createdAt string, hostports []string) bool {
+		if crs.ids[id] || crs.hasHostports(kind, hostports) || !c.collectable(metadata, createdAt) {
+			used.addHosts(hostports)
+			return false
+		}
+		orphans = append(orphans, Orphan{Kind: kind, ID: id, Name: fmt.Sprint(hostports)})
+		return true
+	}
+
+	httpsEdges := c.NgrokClientset.HTTPSEdges().List(&ngrok.Paging{})
+	for httpsEdges.Next(ctx) {
+		edge := httpsEdges.Item()
+		if orphanedEdge(OrphanKindHTTPSEdge, edge.ID, edge.Metadata, edge.CreatedAt, edge.Hostports) {
+			continue
+		}
+		for _, route := range edge.Routes {
+			used.addRefs(route.Backend, route.IpRestriction)
+		}
+	}
+	if err := httpsEdges.Err(); err != nil {
+		return nil, err
+	}
+
+	tcpEdges := c.NgrokClientset.TCPEdges().List(&ngrok.Paging{})
+	for tcpEdges.Next(ctx) {
+		edge := tcpEdges.Item()
+		if !orphanedEdge(OrphanKindTCPEdge, edge.ID, edge.Metadata, edge.CreatedAt, edge.Hostports) {
+			used.addRefs(edge.Backend, edge.IpRestriction)
+		}
+	}
+	if err := tcpEdges.Err(); err != nil {
+		return nil, err
+	}
+
+	tlsEdges := c.NgrokClientset.TLSEdges().List(&ngrok.Paging{})
+	for tlsEdges.Next(ctx) {
+		edge := tlsEdges.Item()
+		if !orphanedEdge(OrphanKindTLSEdge, edge.ID, edge.Metadata, edge.CreatedAt, edge.Hostports) {
+			used.addRefs(edge.Backend, edge.IpRestriction)
+		}
+	}
+	if err := tlsEdges.Err(); err != nil {
+		return nil, err
+	}
+
+	backends := c.NgrokClientset.TunnelGroupBackends().List(&ngrok.Paging{})
+	for backends.Next(ctx) {
+		backend := backends.Item()
+		if crs.ids[backend.ID] || used.ids[backend.ID] || crs.hasLabels(backend.Labels) || !c.collectable(backend.Metadata, backend.CreatedAt) {
+			continue
+		}
+		orphans = append(orphans, Orphan{Kind: OrphanKindTunnelGroupBackend, ID: backend.ID, Name: fmt.Sprint(backend.Labels)})
+	}
+	if err := backends.Err(); err != nil {
+		return nil, err
+	}
+
+	domains := c.NgrokClientset.Domains().List(&ngrok.Paging{})
+	for domains.Next(ctx) {
+		domain := domains.Item()
+		if crs.ids[domain.ID] || crs.domains[domain.Domain] || used.hosts[domain.Domain] || !c.collectable(domain.Metadata, domain.CreatedAt) {
+			continue
+		}
+		orphans = append(orphans, Orphan{Kind: OrphanKindDomain, ID: domain.ID, Name: domain.Domain})
+	}
+	if err := domains.Err(); err != nil {
+		return nil, err
+	}
+
+	ipPolicies := c.NgrokClientset.IPPolicies().List(&ngrok.Paging{})
+	for ipPolicies.Next(ctx) {
+		policy := ipPolicies.Item()
+		if crs.ids[policy.ID] || used.ids[policy.ID] || !c.collectable(policy.Metadata, policy.CreatedAt) {
+			continue
+		}
+		orphans = append(orphans, Orphan{Kind: OrphanKindIPPolicy, ID: policy.ID, Name: policy.Description})
+	}
+	if err := ipPolicies.Err(); err != nil {
+		return nil, err
+	}
+
+	return orphans, nil
+}
+
+// collectable returns whether a resource is owned by the operator and old enough to be collected
+func (c *OrphanCollector) collectable(metadata, createdAt string) bool {
+	if !c.ownedByOperator(metadata) {
+		return false
+	}
+	created, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		return false
+	}
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	return now().Sub(created) >= c.MinAge
+}
+
+// ownedByOperator returns whether the metadata has the keys and values of one of the owner metadata
+func (c *OrphanCollector) ownedByOperator(metadata string) bool {
+	values := map[string]string{}
+	if err := json.Unmarshal([]byte(metadata), &values); err != nil {
+		return false
+	}
+	for _, owner := range c.OwnerMetadata {
+		if len(owner) == 0 {
+			continue
+		}
+		owned := true
+		for k, v := range owner {
+			if value, ok := values[k]; !ok || value != v {
+				owned = false
+				break
+			}
+		}
+		if owned {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *OrphanCollector) delete(ctx context.Context, orphan Orphan) error {
+	switch orphan.Kind {
+	case OrphanKindHTTPSEdge:
+		return c.NgrokClientset.HTTPSEdges().Delete(ctx, orphan.ID)
+	case OrphanKindTCPEdge:
+		return c.NgrokClientset.TCPEdges().Delete(ctx, orphan.ID)
+	case OrphanKindTLSEdge:
+		return c.NgrokClientset.TLSEdges().Delete(ctx, orphan.ID)
+	case OrphanKindTunnelGroupBackend:
+		return c.NgrokClientset.TunnelGroupBackends().Delete(ctx, orphan.ID)
+	case OrphanKindIPPolicy:
+		return c.NgrokClientset.IPPolicies().Delete(ctx, orphan.ID)
+	default:
+		return fmt.Errorf("unknown kind %q", orphan.Kind)
+	}
+}
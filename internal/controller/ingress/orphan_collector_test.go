@@ -0,0 +1,146 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ngrok/ngrok-api-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	ngrokfake "github.com/ngrok/ngrok-operator/internal/ngrokapi/fake"
+)
+
+const ownedMetadata = `{"owned-by":"kubernetes-ingress-controller","cluster":"prod"}`
+
+func newOrphanCollector(t *testing.T, objects ...client.Object) (*OrphanCollector, *ngrokfake.Server) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, ingressv1alpha1.AddToScheme(scheme))
+
+	server := ngrokfake.NewServer()
+	t.Cleanup(server.Close)
+
+	return &OrphanCollector{
+		Client:         crfake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Log:            logr.Discard(),
+		NgrokClientset: server.Clientset(),
+		OwnerMetadata:  []map[string]string{{"owned-by": "kubernetes-ingress-controller", "cluster": "prod"}},
+		MinAge:         time.Minute,
+		now:            func() time.Time { return time.Now().Add(time.Hour) },
+	}, server
+}
+
+func TestOrphanCollectorReportsOrphans(t *testing.T) {
+	ctx := context.Background()
+	domainCR := &ingressv1alpha1.Domain{
+		ObjectMeta: metav1.ObjectMeta{Name: "kept", Namespace: "default"},
+		Spec:       ingressv1alpha1.DomainSpec{Domain: "kept.ngrok.app"},
+	}
+	c, _ := newOrphanCollector(t, domainCR)
+	c.ReportOnly = true
+	cs := c.NgrokClientset
+
+	_, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "kept.ngrok.app", Metadata: ownedMetadata})
+	require.NoError(t, err)
+	orphan, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "orphan.ngrok.app", Metadata: ownedMetadata})
+	require.NoError(t, err)
+	_, err = cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "other-cluster.ngrok.app", Metadata: `{"owned-by":"kubernetes-ingress-controller","cluster":"dev"}`})
+	require.NoError(t, err)
+	_, err = cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "manual.ngrok.app"})
+	require.NoError(t, err)
+
+	orphans, err := c.Collect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Orphan{{Kind: OrphanKindDomain, ID: orphan.ID, Name: "orphan.ngrok.app"}}, orphans)
+
+	_, err = cs.Domains().Get(ctx, orphan.ID)
+	assert.NoError(t, err, "orphans are only reported in report mode")
+}
+
+func TestOrphanCollectorDeletesOrphans(t *testing.T) {
+	ctx := context.Background()
+	edgeCR := &ingressv1alpha1.HTTPSEdge{
+		ObjectMeta: metav1.ObjectMeta{Name: "kept", Namespace: "default"},
+		Spec:       ingressv1alpha1.HTTPSEdgeSpec{Hostports: []string{"kept.ngrok.app:443"}},
+	}
+	domainCR := &ingressv1alpha1.Domain{
+		ObjectMeta: metav1.ObjectMeta{Name: "kept", Namespace: "default"},
+		Spec:       ingressv1alpha1.DomainSpec{Domain: "kept.ngrok.app"},
+	}
+	c, _ := newOrphanCollector(t, edgeCR, domainCR)
+	cs := c.NgrokClientset
+
+	// an orphaned edge, along with its domain and backend
+	orphanDomain, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "orphan.ngrok.app", Metadata: ownedMetadata})
+	require.NoError(t, err)
+	orphanBackend, err := cs.TunnelGroupBackends().Create(ctx, &ngrok.TunnelGroupBackendCreate{Metadata: ownedMetadata, Labels: map[string]string{"service": "orphan"}})
+	require.NoError(t, err)
+	orphanEdge, err := cs.HTTPSEdges().Create(ctx, &ngrok.HTTPSEdgeCreate{Hostports: []string{"orphan.ngrok.app:443"}, Metadata: ownedMetadata})
+	require.NoError(t, err)
+	_, err = cs.HTTPSEdgeRoutes().Create(ctx, &ngrok.HTTPSEdgeRouteCreate{
+		EdgeID: orphanEdge.ID, MatchType: "path_prefix", Match: "/", Backend: &ngrok.EndpointBackendMutate{BackendID: orphanBackend.ID},
+	})
+	require.NoError(t, err)
+
+	// an edge whose CR is still there, adopted by its hostports, and whose backend is kept as the edge uses it
+	keptDomain, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "kept.ngrok.app", Metadata: ownedMetadata})
+	require.NoError(t, err)
+	keptBackend, err := cs.TunnelGroupBackends().Create(ctx, &ngrok.TunnelGroupBackendCreate{Metadata: ownedMetadata, Labels: map[string]string{"service": "kept"}})
+	require.NoError(t, err)
+	keptEdge, err := cs.HTTPSEdges().Create(ctx, &ngrok.HTTPSEdgeCreate{Hostports: []string{"kept.ngrok.app:443"}, Metadata: ownedMetadata})
+	require.NoError(t, err)
+	_, err = cs.HTTPSEdgeRoutes().Create(ctx, &ngrok.HTTPSEdgeRouteCreate{
+		EdgeID: keptEdge.ID, MatchType: "path_prefix", Match: "/", Backend: &ngrok.EndpointBackendMutate{BackendID: keptBackend.ID},
+	})
+	require.NoError(t, err)
+
+	orphanPolicy, err := cs.IPPolicies().Create(ctx, &ngrok.IPPolicyCreate{Metadata: ownedMetadata})
+	require.NoError(t, err)
+
+	orphans, err := c.Collect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{orphanEdge.ID, orphanBackend.ID, orphanDomain.ID, orphanPolicy.ID}, orphanIDs(orphans))
+
+	_, err = cs.HTTPSEdges().Get(ctx, orphanEdge.ID)
+	assert.True(t, ngrok.IsNotFound(err))
+	_, err = cs.TunnelGroupBackends().Get(ctx, orphanBackend.ID)
+	assert.True(t, ngrok.IsNotFound(err))
+	_, err = cs.Domains().Get(ctx, orphanDomain.ID)
+	assert.NoError(t, err, "orphaned domains are only reported")
+	_, err = cs.IPPolicies().Get(ctx, orphanPolicy.ID)
+	assert.True(t, ngrok.IsNotFound(err))
+
+	for _, id := range []string{keptEdge.ID, keptBackend.ID, keptDomain.ID} {
+		assert.NotContains(t, orphanIDs(orphans), id)
+	}
+	_, err = cs.HTTPSEdges().Get(ctx, keptEdge.ID)
+	assert.NoError(t, err)
+}
+
+func TestOrphanCollectorSkipsRecentResources(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newOrphanCollector(t)
+	c.now = time.Now
+
+	_, err := c.NgrokClientset.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "new.ngrok.app", Metadata: ownedMetadata})
+	require.NoError(t, err)
+
+	orphans, err := c.Collect(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+}
+
+func orphanIDs(orphans []Orphan) []string {
+	ids := []string{}
+	for _, orphan := range orphans {
+		ids = append(ids, orphan.ID)
+	}
+	return ids
+}
@@ -26,6 +26,7 @@ package ngrok
 
 import (
 	"context"
+	"strings"
 
 	"github.com/go-logr/logr"
 	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
@@ -33,7 +34,10 @@ import (
 	"github.com/ngrok/ngrok-operator/internal/store"
 	"github.com/ngrok/ngrok-operator/internal/util"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -71,6 +75,11 @@ func (r *NgrokTrafficPolicyReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 
+	errs, warnings := util.ValidateTrafficPolicy(policy.Spec.Policy, field.NewPath("spec", "policy"))
+	if err := r.setValidationConditions(ctx, policy, errs, warnings); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	parsedTrafficPolicy, err := util.NewTrafficPolicyFromJson(policy.Spec.Policy)
 	if err != nil {
 		r.Recorder.Eventf(policy, v1.EventTypeWarning, events.TrafficPolicyParseFailed, "Failed to parse Traffic Policy, possibly malformed.")
@@ -89,6 +98,60 @@ func (r *NgrokTrafficPolicyReconciler) Reconcile(ctx context.Context, req ctrl.R
 	return ctrl.Result{}, err
 }
 
+// setValidationConditions records the findings of the validation of the policy in its status conditions, so that
+// policy authors get feedback on it before the ngrok API rejects the edges and endpoints using it
+func (r *NgrokTrafficPolicyReconciler) setValidationConditions(ctx context.Context, policy *ngrokv1alpha1.NgrokTrafficPolicy, errs field.ErrorList, warnings []string) error {
+	valid := metav1.Condition{
+		Type:    ngrokv1alpha1.NgrokTrafficPolicyConditionValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  ngrokv1alpha1.NgrokTrafficPolicyReasonValid,
+		Message: "Traffic Policy is valid",
+	}
+	if len(errs) > 0 {
+		valid.Status = metav1.ConditionFalse
+		valid.Reason = ngrokv1alpha1.NgrokTrafficPolicyReasonInvalid
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		valid.Message = conditionMessage(messages)
+	}
+
+	warning := metav1.Condition{
+		Type:    ngrokv1alpha1.NgrokTrafficPolicyConditionWarnings,
+		Status:  metav1.ConditionFalse,
+		Reason:  ngrokv1alpha1.NgrokTrafficPolicyReasonNoWarnings,
+		Message: "Traffic Policy has no warnings",
+	}
+	if len(warnings) > 0 {
+		warning.Status = metav1.ConditionTrue
+		warning.Reason = ngrokv1alpha1.NgrokTrafficPolicyReasonWarnings
+		warning.Message = conditionMessage(warnings)
+	}
+
+	changed := false
+	for _, condition := range []metav1.Condition{valid, warning} {
+		condition.ObservedGeneration = policy.Generation
+		changed = meta.SetStatusCondition(&policy.Status.Conditions, condition) || changed
+	}
+	if !changed {
+		return nil
+	}
+	return r.Status().Update(ctx, policy)
+}
+
+// maxConditionMessageLength is the maximum length of the message of a metav1.Condition
+const maxConditionMessageLength = 32768
+
+// conditionMessage joins the findings of the validation of a policy into a condition message
+func conditionMessage(findings []string) string {
+	message := strings.Join(findings, "; ")
+	if len(message) > maxConditionMessageLength {
+		message = message[:maxConditionMessageLength-3] + "..."
+	}
+	return message
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *NgrokTrafficPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -0,0 +1,61 @@
+package ngrok
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_setValidationConditions(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, ngrokv1alpha1.AddToScheme(scheme))
+
+	policy := &ngrokv1alpha1.NgrokTrafficPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "default", Generation: 2},
+		Spec: ngrokv1alpha1.NgrokTrafficPolicySpec{
+			Policy: json.RawMessage(`{"inbound":[{"expressions":["req.method =="],"actions":[{"type":"deny"}]}]}`),
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(policy).
+		WithStatusSubresource(policy).
+		Build()
+	r := &NgrokTrafficPolicyReconciler{Client: fakeClient}
+
+	errs, warnings := util.ValidateTrafficPolicy(policy.Spec.Policy, field.NewPath("spec", "policy"))
+	require.NoError(t, r.setValidationConditions(ctx, policy, errs, warnings))
+
+	updated := &ngrokv1alpha1.NgrokTrafficPolicy{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(policy), updated))
+
+	valid := meta.FindStatusCondition(updated.Status.Conditions, ngrokv1alpha1.NgrokTrafficPolicyConditionValid)
+	require.NotNil(t, valid)
+	assert.Equal(t, metav1.ConditionFalse, valid.Status)
+	assert.Equal(t, ngrokv1alpha1.NgrokTrafficPolicyReasonInvalid, valid.Reason)
+	assert.Equal(t, `spec.policy.inbound[0].expressions[0]: Invalid value: "req.method ==": syntax error at line 1, column 14: mismatched input '<EOF>' expecting {'[', '{', '(', '.', '-', '!', 'true', 'false', 'null', NUM_FLOAT, NUM_INT, NUM_UINT, STRING, BYTES, IDENTIFIER}`, valid.Message)
+	assert.Equal(t, int64(2), valid.ObservedGeneration)
+
+	warning := meta.FindStatusCondition(updated.Status.Conditions, ngrokv1alpha1.NgrokTrafficPolicyConditionWarnings)
+	require.NotNil(t, warning)
+	assert.Equal(t, metav1.ConditionTrue, warning.Status)
+	assert.Contains(t, warning.Message, "the legacy directions 'inbound' and 'outbound' are deprecated")
+
+	updated.Spec.Policy = json.RawMessage(`{"on_http_request":[{"actions":[{"type":"deny"}]}]}`)
+	errs, warnings = util.ValidateTrafficPolicy(updated.Spec.Policy, field.NewPath("spec", "policy"))
+	require.NoError(t, r.setValidationConditions(ctx, updated, errs, warnings))
+	assert.True(t, meta.IsStatusConditionTrue(updated.Status.Conditions, ngrokv1alpha1.NgrokTrafficPolicyConditionValid))
+	assert.True(t, meta.IsStatusConditionFalse(updated.Status.Conditions, ngrokv1alpha1.NgrokTrafficPolicyConditionWarnings))
+}
@@ -9,6 +9,11 @@ import (
 
 const (
 	finalizerName = "k8s.ngrok.com/finalizer"
+
+	// AnnotationImporting is set on the resources created by the import command until their status, holding the IDs
+	// of the imported ngrok resources, is written. The controllers skip them until then so they don't create the
+	// ngrok resources again.
+	AnnotationImporting = "k8s.ngrok.com/importing"
 )
 
 func IsUpsert(o client.Object) bool {
@@ -19,6 +24,11 @@ func IsDelete(o client.Object) bool {
 	return !o.GetDeletionTimestamp().IsZero()
 }
 
+func IsImporting(o client.Object) bool {
+	_, ok := o.GetAnnotations()[AnnotationImporting]
+	return ok
+}
+
 func HasFinalizer(o client.Object) bool {
 	return controllerutil.ContainsFinalizer(o, finalizerName)
 }
@@ -0,0 +1,374 @@
+// Package importer translates the HTTPS edges, domains and IP policies of an ngrok account into the CRs the
+// operator manages them with, so that config created in the ngrok dashboard can be moved to GitOps.
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/ngrokapi"
+)
+
+// Importer reads the resources of an ngrok account and translates them into CRs whose status already holds the IDs
+// of the resources, so that the controllers manage the existing resources instead of creating new ones.
+type Importer struct {
+	Clientset ngrokapi.Clientset
+	// Namespace of the imported CRs
+	Namespace string
+	// IncludeOwned imports the resources whose metadata has an owned-by marker too. These are created by an
+	// operator from CRs that already exist, so they're skipped by default.
+	IncludeOwned bool
+}
+
+// Result holds the imported CRs, domains first, then IP policies and HTTPS edges, and the warnings about the parts
+// of the resources that couldn't be imported
+type Result struct {
+	Objects  []client.Object
+	Warnings []string
+}
+
+func (r *Result) warnf(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Import lists the domains, IP policies and HTTPS edges of the ngrok account and returns their CRs
+func (i *Importer) Import(ctx context.Context) (*Result, error) {
+	result := &Result{}
+
+	if err := i.importDomains(ctx, result); err != nil {
+		return nil, err
+	}
+	policyNames, err := i.importIPPolicies(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.importHTTPSEdges(ctx, result, policyNames); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (i *Importer) importDomains(ctx context.Context, result *Result) error {
+	names := map[string]bool{}
+	iter := i.Clientset.Domains().List(&ngrok.Paging{})
+	for iter.Next(ctx) {
+		domain := iter.Item()
+		if i.skip(domain.Metadata) {
+			continue
+		}
+
+		// The controller leaves the certificate of the domain alone as long as it doesn't reference a Secret
+		if domain.Certificate != nil && domain.CertificateManagementPolicy == nil {
+			result.warnf("domain %s: its custom TLS certificate %s is kept, but isn't managed by the Domain", domain.Domain, domain.Certificate.ID)
+		}
+
+		cr := &ingressv1alpha1.Domain{
+			TypeMeta:   typeMeta("Domain"),
+			ObjectMeta: i.objectMeta(names, ingressv1alpha1.HyphenatedDomainNameFromURL(domain.Domain)),
+			Spec: ingressv1alpha1.DomainSpec{
+				Domain: domain.Domain,
+				Region: domain.Region,
+			},
+			Status: ingressv1alpha1.DomainStatus{
+				ID:          domain.ID,
+				Domain:      domain.Domain,
+				Region:      domain.Region,
+				URI:         domain.URI,
+				CNAMETarget: domain.CNAMETarget,
+			},
+		}
+		cr.Spec.Description = domain.Description
+		cr.Spec.Metadata = domain.Metadata
+		result.Objects = append(result.Objects, cr)
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("unable to list domains: %w", err)
+	}
+	return nil
+}
+
+// importIPPolicies imports the IP policies and returns the names of their CRs by policy ID
+func (i *Importer) importIPPolicies(ctx context.Context, result *Result) (map[string]string, error) {
+	rulesByPolicy := map[string][]*ngrok.IPPolicyRule{}
+	ruleIter := i.Clientset.IPPolicyRules().List(&ngrok.Paging{})
+	for ruleIter.Next(ctx) {
+		rule := ruleIter.Item()
+		rulesByPolicy[rule.IPPolicy.ID] = append(rulesByPolicy[rule.IPPolicy.ID], rule)
+	}
+	if err := ruleIter.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list IP policy rules: %w", err)
+	}
+
+	names := map[string]bool{}
+	policyNames := map[string]string{}
+	iter := i.Clientset.IPPolicies().List(&ngrok.Paging{})
+	for iter.Next(ctx) {
+		policy := iter.Item()
+		if i.skip(policy.Metadata) {
+			continue
+		}
+
+		cr := &ingressv1alpha1.IPPolicy{
+			TypeMeta:   typeMeta("IPPolicy"),
+			ObjectMeta: i.objectMeta(names, nameFromID(policy.ID)),
+			Status: ingressv1alpha1.IPPolicyStatus{
+				ID: policy.ID,
+			},
+		}
+		cr.Spec.Description = policy.Description
+		cr.Spec.Metadata = policy.Metadata
+		for _, rule := range rulesByPolicy[policy.ID] {
+			specRule := ingressv1alpha1.IPPolicyRule{
+				CIDR:   rule.CIDR,
+				Action: rule.Action,
+			}
+			specRule.Description = rule.Description
+			specRule.Metadata = rule.Metadata
+			cr.Spec.Rules = append(cr.Spec.Rules, specRule)
+			cr.Status.Rules = append(cr.Status.Rules, ingressv1alpha1.IPPolicyRuleStatus{
+				ID:     rule.ID,
+				CIDR:   rule.CIDR,
+				Action: rule.Action,
+			})
+		}
+
+		policyNames[policy.ID] = cr.Name
+		result.Objects = append(result.Objects, cr)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list IP policies: %w", err)
+	}
+	return policyNames, nil
+}
+
+func (i *Importer) importHTTPSEdges(ctx context.Context, result *Result, policyNames map[string]string) error {
+	backends := map[string]*ngrok.TunnelGroupBackend{}
+	names := map[string]bool{}
+	iter := i.Clientset.HTTPSEdges().List(&ngrok.Paging{})
+	for iter.Next(ctx) {
+		edge := iter.Item()
+		if i.skip(edge.Metadata) {
+			continue
+		}
+
+		name := nameFromID(edge.ID)
+		if len(edge.Hostports) > 0 {
+			host, _, err := net.SplitHostPort(edge.Hostports[0])
+			if err != nil {
+				host = edge.Hostports[0]
+			}
+			name = ingressv1alpha1.HyphenatedDomainNameFromURL(host)
+		}
+
+		cr := &ingressv1alpha1.HTTPSEdge{
+			TypeMeta:   typeMeta("HTTPSEdge"),
+			ObjectMeta: i.objectMeta(names, name),
+			Spec: ingressv1alpha1.HTTPSEdgeSpec{
+				Hostports: edge.Hostports,
+			},
+			Status: ingressv1alpha1.HTTPSEdgeStatus{
+				ID:  edge.ID,
+				URI: edge.URI,
+			},
+		}
+		cr.Spec.Description = edge.Description
+		cr.Spec.Metadata = edge.Metadata
+		if edge.TlsTermination != nil && edge.TlsTermination.MinVersion != nil {
+			cr.Spec.TLSTermination = &ingressv1alpha1.EndpointTLSTerminationAtEdge{
+				MinVersion: *edge.TlsTermination.MinVersion,
+			}
+		}
+		if edge.MutualTls != nil && len(edge.MutualTls.CertificateAuthorities) > 0 {
+			cr.Spec.MutualTLS = &ingressv1alpha1.EndpointMutualTLS{}
+			for _, ca := range edge.MutualTls.CertificateAuthorities {
+				cr.Spec.MutualTLS.CertificateAuthorities = append(cr.Spec.MutualTLS.CertificateAuthorities, ca.ID)
+			}
+		}
+
+		for _, route := range edge.Routes {
+			routeSpec, err := i.importRoute(ctx, result, edge, &route, backends, policyNames)
+			if err != nil {
+				return err
+			}
+			cr.Spec.Routes = append(cr.Spec.Routes, routeSpec)
+
+			routeStatus := ingressv1alpha1.HTTPSEdgeRouteStatus{
+				ID:        route.ID,
+				URI:       route.URI,
+				Match:     route.Match,
+				MatchType: route.MatchType,
+			}
+			if route.Backend != nil {
+				routeStatus.Backend.ID = route.Backend.Backend.ID
+			}
+			cr.Status.Routes = append(cr.Status.Routes, routeStatus)
+		}
+
+		result.Objects = append(result.Objects, cr)
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("unable to list HTTPS edges: %w", err)
+	}
+	return nil
+}
+
+func (i *Importer) importRoute(ctx context.Context, result *Result, edge *ngrok.HTTPSEdge, route *ngrok.HTTPSEdgeRoute, backends map[string]*ngrok.TunnelGroupBackend, policyNames map[string]string) (ingressv1alpha1.HTTPSEdgeRouteSpec, error) {
+	warnf := func(format string, args ...any) {
+		result.warnf("HTTPS edge %s, route %s: %s", edge.ID, route.Match, fmt.Sprintf(format, args...))
+	}
+
+	spec := ingressv1alpha1.HTTPSEdgeRouteSpec{
+		MatchType: route.MatchType,
+		Match:     route.Match,
+	}
+	spec.Description = route.Description
+	spec.Metadata = route.Metadata
+
+	if route.Backend == nil {
+		warnf("the route has no backend")
+	} else {
+		backendID := route.Backend.Backend.ID
+		backend, ok := backends[backendID]
+		if !ok {
+			var err error
+			backend, err = i.Clientset.TunnelGroupBackends().Get(ctx, backendID)
+			if err != nil && !ngrok.IsNotFound(err) {
+				return spec, fmt.Errorf("unable to get tunnel group backend %s: %w", backendID, err)
+			}
+			backends[backendID] = backend
+		}
+		if backend == nil {
+			warnf("backend %s isn't a tunnel group backend", backendID)
+		} else {
+			spec.Backend.Labels = backend.Labels
+			spec.Backend.Description = backend.Description
+			spec.Backend.Metadata = backend.Metadata
+		}
+	}
+
+	if cb := route.CircuitBreaker; cb != nil && enabled(cb.Enabled, "circuit breaker", warnf) {
+		spec.CircuitBreaker = &ingressv1alpha1.EndpointCircuitBreaker{
+			TrippedDuration:          metav1.Duration{Duration: seconds(cb.TrippedDuration)},
+			RollingWindow:            metav1.Duration{Duration: seconds(cb.RollingWindow)},
+			NumBuckets:               cb.NumBuckets,
+			VolumeThreshold:          cb.VolumeThreshold,
+			ErrorThresholdPercentage: resource.MustParse(strconv.FormatFloat(cb.ErrorThresholdPercentage, 'f', -1, 64)),
+		}
+	}
+
+	if route.Compression != nil {
+		spec.Compression = &ingressv1alpha1.EndpointCompression{
+			Enabled: route.Compression.Enabled == nil || *route.Compression.Enabled,
+		}
+	}
+
+	if ipr := route.IpRestriction; ipr != nil && len(ipr.IPPolicies) > 0 && enabled(ipr.Enabled, "IP restriction", warnf) {
+		spec.IPRestriction = &ingressv1alpha1.EndpointIPPolicy{}
+		for _, policy := range ipr.IPPolicies {
+			// Policies that weren't imported are referenced by ID, which the controller resolves too
+			nameOrID := policy.ID
+			if name, ok := policyNames[policy.ID]; ok {
+				nameOrID = name
+			}
+			spec.IPRestriction.IPPolicies = append(spec.IPRestriction.IPPolicies, nameOrID)
+		}
+	}
+
+	if headers := route.RequestHeaders; headers != nil && enabled(headers.Enabled, "request headers", warnf) {
+		spec.Headers = &ingressv1alpha1.EndpointHeaders{
+			Request: &ingressv1alpha1.EndpointRequestHeaders{Add: headers.Add, Remove: headers.Remove},
+		}
+	}
+	if headers := route.ResponseHeaders; headers != nil && enabled(headers.Enabled, "response headers", warnf) {
+		if spec.Headers == nil {
+			spec.Headers = &ingressv1alpha1.EndpointHeaders{}
+		}
+		spec.Headers.Response = &ingressv1alpha1.EndpointResponseHeaders{Add: headers.Add, Remove: headers.Remove}
+	}
+
+	if policy := route.TrafficPolicy; policy != nil && enabled(policy.Enabled, "traffic policy", warnf) {
+		if json.Valid([]byte(policy.Value)) {
+			spec.Policy = json.RawMessage(policy.Value)
+		} else {
+			warnf("the traffic policy isn't valid JSON and isn't imported")
+		}
+	}
+
+	// These modules need secrets, such as OAuth client secrets, that the API doesn't return, or have no CR equivalent
+	unsupported := []struct {
+		module string
+		set    bool
+	}{
+		{"OAuth", route.OAuth != nil},
+		{"OIDC", route.OIDC != nil},
+		{"SAML", route.SAML != nil},
+		{"webhook verification", route.WebhookVerification != nil},
+		{"websocket TCP converter", route.WebsocketTCPConverter != nil},
+		{"user agent filter", route.UserAgentFilter != nil},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			warnf("the %s module isn't imported and must be added to the HTTPSEdge by hand", u.module)
+		}
+	}
+
+	return spec, nil
+}
+
+// skip returns whether the resource is created by an operator, which is the case when its metadata has an owned-by
+// marker
+func (i *Importer) skip(metadata string) bool {
+	if i.IncludeOwned || metadata == "" {
+		return false
+	}
+	values := map[string]any{}
+	if err := json.Unmarshal([]byte(metadata), &values); err != nil {
+		return false
+	}
+	_, owned := values["owned-by"]
+	return owned
+}
+
+// objectMeta returns the metadata of an imported CR, whose name is made unique among the CRs of its kind
+func (i *Importer) objectMeta(names map[string]bool, name string) metav1.ObjectMeta {
+	unique := name
+	for n := 2; names[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", name, n)
+	}
+	names[unique] = true
+	return metav1.ObjectMeta{Name: unique, Namespace: i.Namespace}
+}
+
+func seconds(n uint32) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+func typeMeta(kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: ingressv1alpha1.GroupVersion.String(), Kind: kind}
+}
+
+// nameFromID returns a CR name for an ngrok ID, such as ipp-2abc for ipp_2ABC
+func nameFromID(id string) string {
+	return strings.ToLower(strings.ReplaceAll(id, "_", "-"))
+}
+
+// enabled returns whether a module is enabled, warning about the disabled modules, which the CRs can't express
+func enabled(enabled *bool, module string, warnf func(string, ...any)) bool {
+	if enabled != nil && !*enabled {
+		warnf("the %s module is disabled and isn't imported", module)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	ngrokfake "github.com/ngrok/ngrok-operator/internal/ngrokapi/fake"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	server := ngrokfake.NewServer()
+	t.Cleanup(server.Close)
+	cs := server.Clientset()
+
+	domain, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "app.ngrok.app", Description: "my app"})
+	require.NoError(t, err)
+	_, err = cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "owned.ngrok.app", Metadata: `{"owned-by":"kubernetes-ingress-controller"}`})
+	require.NoError(t, err)
+
+	policy, err := cs.IPPolicies().Create(ctx, &ngrok.IPPolicyCreate{Description: "office"})
+	require.NoError(t, err)
+	rule, err := cs.IPPolicyRules().Create(ctx, &ngrok.IPPolicyRuleCreate{IPPolicyID: policy.ID, CIDR: "10.0.0.0/8", Action: ptr.To("allow")})
+	require.NoError(t, err)
+
+	backend, err := cs.TunnelGroupBackends().Create(ctx, &ngrok.TunnelGroupBackendCreate{Labels: map[string]string{"app": "web"}})
+	require.NoError(t, err)
+	edge, err := cs.HTTPSEdges().Create(ctx, &ngrok.HTTPSEdgeCreate{Hostports: []string{"app.ngrok.app:443"}})
+	require.NoError(t, err)
+	route, err := cs.HTTPSEdgeRoutes().Create(ctx, &ngrok.HTTPSEdgeRouteCreate{
+		EdgeID:         edge.ID,
+		MatchType:      "path_prefix",
+		Match:          "/",
+		Backend:        &ngrok.EndpointBackendMutate{BackendID: backend.ID},
+		IPRestriction:  &ngrok.EndpointIPPolicyMutate{IPPolicyIDs: []string{policy.ID}},
+		Compression:    &ngrok.EndpointCompression{},
+		RequestHeaders: &ngrok.EndpointRequestHeaders{Add: map[string]string{"x-app": "web"}},
+		OAuth:          &ngrok.EndpointOAuth{Provider: ngrok.EndpointOAuthProvider{Google: &ngrok.EndpointOAuthGoogle{}}},
+		TrafficPolicy:  &ngrok.EndpointTrafficPolicy{Value: `{"inbound":[]}`},
+	})
+	require.NoError(t, err)
+
+	importer := &Importer{Clientset: cs, Namespace: "apps"}
+	result, err := importer.Import(ctx)
+	require.NoError(t, err)
+	require.Len(t, result.Objects, 3)
+
+	domainCR, ok := result.Objects[0].(*ingressv1alpha1.Domain)
+	require.True(t, ok)
+	assert.Equal(t, "app-ngrok-app", domainCR.Name)
+	assert.Equal(t, "apps", domainCR.Namespace)
+	assert.Equal(t, "Domain", domainCR.Kind)
+	assert.Equal(t, "app.ngrok.app", domainCR.Spec.Domain)
+	assert.Equal(t, "my app", domainCR.Spec.Description)
+	assert.Equal(t, domain.ID, domainCR.Status.ID)
+
+	policyCR, ok := result.Objects[1].(*ingressv1alpha1.IPPolicy)
+	require.True(t, ok)
+	assert.Equal(t, nameFromID(policy.ID), policyCR.Name)
+	assert.Equal(t, policy.ID, policyCR.Status.ID)
+	require.Len(t, policyCR.Spec.Rules, 1)
+	assert.Equal(t, "10.0.0.0/8", policyCR.Spec.Rules[0].CIDR)
+	assert.Equal(t, "allow", policyCR.Spec.Rules[0].Action)
+	assert.Equal(t, []ingressv1alpha1.IPPolicyRuleStatus{{ID: rule.ID, CIDR: "10.0.0.0/8", Action: "allow"}}, policyCR.Status.Rules)
+
+	edgeCR, ok := result.Objects[2].(*ingressv1alpha1.HTTPSEdge)
+	require.True(t, ok)
+	assert.Equal(t, "app-ngrok-app", edgeCR.Name)
+	assert.Equal(t, []string{"app.ngrok.app:443"}, edgeCR.Spec.Hostports)
+	assert.Equal(t, edge.ID, edgeCR.Status.ID)
+	require.Len(t, edgeCR.Spec.Routes, 1)
+	routeSpec := edgeCR.Spec.Routes[0]
+	assert.Equal(t, map[string]string{"app": "web"}, routeSpec.Backend.Labels)
+	assert.Equal(t, &ingressv1alpha1.EndpointIPPolicy{IPPolicies: []string{policyCR.Name}}, routeSpec.IPRestriction)
+	assert.Equal(t, &ingressv1alpha1.EndpointCompression{Enabled: true}, routeSpec.Compression)
+	assert.Equal(t, map[string]string{"x-app": "web"}, routeSpec.Headers.Request.Add)
+	assert.Nil(t, routeSpec.Headers.Response)
+	assert.Nil(t, routeSpec.OAuth)
+	assert.JSONEq(t, `{"inbound":[]}`, string(routeSpec.Policy))
+	assert.Equal(t, []ingressv1alpha1.HTTPSEdgeRouteStatus{{
+		ID:        route.ID,
+		URI:       route.URI,
+		Match:     "/",
+		MatchType: "path_prefix",
+		Backend:   ingressv1alpha1.TunnelGroupBackendStatus{ID: backend.ID},
+	}}, edgeCR.Status.Routes)
+
+	assert.Equal(t, []string{
+		"HTTPS edge " + edge.ID + ", route /: the OAuth module isn't imported and must be added to the HTTPSEdge by hand",
+	}, result.Warnings)
+}
+
+func TestImportIncludeOwned(t *testing.T) {
+	ctx := context.Background()
+	server := ngrokfake.NewServer()
+	t.Cleanup(server.Close)
+	cs := server.Clientset()
+
+	_, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "owned.ngrok.app", Metadata: `{"owned-by":"kubernetes-ingress-controller"}`})
+	require.NoError(t, err)
+
+	result, err := (&Importer{Clientset: cs, Namespace: "default"}).Import(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, result.Objects)
+
+	result, err = (&Importer{Clientset: cs, Namespace: "default", IncludeOwned: true}).Import(ctx)
+	require.NoError(t, err)
+	require.Len(t, result.Objects, 1)
+	assert.Equal(t, "owned-ngrok-app", result.Objects[0].GetName())
+}
+
+func TestImportUniqueNames(t *testing.T) {
+	ctx := context.Background()
+	server := ngrokfake.NewServer()
+	t.Cleanup(server.Close)
+	cs := server.Clientset()
+
+	_, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "app.ngrok.app"})
+	require.NoError(t, err)
+	_, err = cs.HTTPSEdges().Create(ctx, &ngrok.HTTPSEdgeCreate{Hostports: []string{"app.ngrok.app:443"}})
+	require.NoError(t, err)
+	_, err = cs.HTTPSEdges().Create(ctx, &ngrok.HTTPSEdgeCreate{Hostports: []string{"app.ngrok.app:443"}})
+	require.NoError(t, err)
+
+	result, err := (&Importer{Clientset: cs, Namespace: "default"}).Import(ctx)
+	require.NoError(t, err)
+	names := []string{}
+	for _, obj := range result.Objects {
+		names = append(names, obj.GetObjectKind().GroupVersionKind().Kind+"/"+obj.GetName())
+	}
+	assert.Equal(t, []string{"Domain/app-ngrok-app", "HTTPSEdge/app-ngrok-app", "HTTPSEdge/app-ngrok-app-2"}, names)
+}
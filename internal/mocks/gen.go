@@ -5,3 +5,5 @@ package mocks
 //go:generate go run github.com/golang/mock/mockgen -package mocks -destination tunnel.go golang.ngrok.com/ngrok Tunnel
 
 //go:generate go run github.com/golang/mock/mockgen -package mocks -destination dialer.go github.com/ngrok/ngrok-operator/pkg/tunneldriver Dialer
+
+//go:generate go run github.com/golang/mock/mockgen -package mocks -destination session.go golang.ngrok.com/ngrok Session
@@ -13,6 +13,7 @@ import (
 	"github.com/ngrok/ngrok-api-go/v6/kubernetes_operators"
 	"github.com/ngrok/ngrok-api-go/v6/reserved_addrs"
 	"github.com/ngrok/ngrok-api-go/v6/reserved_domains"
+	"github.com/ngrok/ngrok-api-go/v6/tls_certificates"
 )
 
 type Clientset interface {
@@ -26,6 +27,7 @@ type Clientset interface {
 	KubernetesOperators() *kubernetes_operators.Client
 	TCPAddresses() *reserved_addrs.Client
 	TCPEdges() *tcp_edges.Client
+	TLSCertificates() *tls_certificates.Client
 	TLSEdges() *tls_edges.Client
 	TunnelGroupBackends() *tunnel_group_backends.Client
 }
@@ -41,25 +43,30 @@ type DefaultClientset struct {
 	kubernetesOperatorsClient *kubernetes_operators.Client
 	tcpAddrsClient            *reserved_addrs.Client
 	tcpEdgesClient            *tcp_edges.Client
+	tlsCertificatesClient     *tls_certificates.Client
 	tlsEdgesClient            *tls_edges.Client
 	tunnelGroupBackendsClient *tunnel_group_backends.Client
 }
 
-// NewClientSet creates a new ClientSet from an ngrok client config.
-func NewClientSet(config *ngrok.ClientConfig) *DefaultClientset {
+// NewClientSet creates a new ClientSet from an ngrok client config. The requests of each client are recorded in the
+// ngrok API metrics, labeled with the name of the clientset the client belongs to, and go through the middleware
+// configured by the options, which is shared by all the clients.
+func NewClientSet(config *ngrok.ClientConfig, opts ...ClientsetOpt) *DefaultClientset {
+	mw := newMiddleware(opts...)
 	return &DefaultClientset{
-		domainsClient:             reserved_domains.NewClient(config),
-		edgeModulesClientset:      newEdgeModulesClientset(config),
-		endpointsClient:           endpoints.NewClient(config),
-		httpsEdgesClient:          https_edges.NewClient(config),
-		httpsEdgeRoutesClient:     https_edge_routes.NewClient(config),
-		ipPoliciesClient:          ip_policies.NewClient(config),
-		ipPolicyRulesClient:       ip_policy_rules.NewClient(config),
-		kubernetesOperatorsClient: kubernetes_operators.NewClient(config),
-		tcpAddrsClient:            reserved_addrs.NewClient(config),
-		tcpEdgesClient:            tcp_edges.NewClient(config),
-		tlsEdgesClient:            tls_edges.NewClient(config),
-		tunnelGroupBackendsClient: tunnel_group_backends.NewClient(config),
+		domainsClient:             reserved_domains.NewClient(instrumentedConfig(config, "domains", mw)),
+		edgeModulesClientset:      newEdgeModulesClientset(instrumentedConfig(config, "edge_modules", mw)),
+		endpointsClient:           endpoints.NewClient(instrumentedConfig(config, "endpoints", mw)),
+		httpsEdgesClient:          https_edges.NewClient(instrumentedConfig(config, "https_edges", mw)),
+		httpsEdgeRoutesClient:     https_edge_routes.NewClient(instrumentedConfig(config, "https_edge_routes", mw)),
+		ipPoliciesClient:          ip_policies.NewClient(instrumentedConfig(config, "ip_policies", mw)),
+		ipPolicyRulesClient:       ip_policy_rules.NewClient(instrumentedConfig(config, "ip_policy_rules", mw)),
+		kubernetesOperatorsClient: kubernetes_operators.NewClient(instrumentedConfig(config, "kubernetes_operators", mw)),
+		tcpAddrsClient:            reserved_addrs.NewClient(instrumentedConfig(config, "tcp_addresses", mw)),
+		tcpEdgesClient:            tcp_edges.NewClient(instrumentedConfig(config, "tcp_edges", mw)),
+		tlsCertificatesClient:     tls_certificates.NewClient(instrumentedConfig(config, "tls_certificates", mw)),
+		tlsEdgesClient:            tls_edges.NewClient(instrumentedConfig(config, "tls_edges", mw)),
+		tunnelGroupBackendsClient: tunnel_group_backends.NewClient(instrumentedConfig(config, "tunnel_group_backends", mw)),
 	}
 }
 
@@ -99,6 +106,10 @@ func (c *DefaultClientset) TCPAddresses() *reserved_addrs.Client {
 	return c.tcpAddrsClient
 }
 
+func (c *DefaultClientset) TLSCertificates() *tls_certificates.Client {
+	return c.tlsCertificatesClient
+}
+
 func (c *DefaultClientset) TLSEdges() *tls_edges.Client {
 	return c.tlsEdgesClient
 }
@@ -0,0 +1,226 @@
+package fake
+
+import (
+	"net"
+	"net/http"
+	"slices"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+	"k8s.io/utils/ptr"
+)
+
+func (s *Server) registerBackends() {
+	s.handle("POST /backends/tunnel_group", http.StatusCreated, s.createBackend)
+	s.handle("GET /backends/tunnel_group", http.StatusOK, s.listBackends)
+	s.handle("GET /backends/tunnel_group/{id}", http.StatusOK, s.getBackend)
+	s.handle("PATCH /backends/tunnel_group/{id}", http.StatusOK, s.updateBackend)
+	s.handle("DELETE /backends/tunnel_group/{id}", http.StatusNoContent, s.deleteBackend)
+}
+
+func (s *Server) createBackend(r *http.Request) (any, error) {
+	create, err := decode[ngrok.TunnelGroupBackendCreate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := newID("bkdtg")
+	backend := &ngrok.TunnelGroupBackend{
+		ID:          id,
+		URI:         s.uri("/backends/tunnel_group/%s", id),
+		CreatedAt:   now(),
+		Description: create.Description,
+		Metadata:    create.Metadata,
+		Labels:      create.Labels,
+		Tunnels:     []ngrok.Ref{},
+	}
+	s.backends.insert(id, backend)
+	return backend, nil
+}
+
+func (s *Server) listBackends(r *http.Request) (any, error) {
+	items, next, err := s.backends.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.TunnelGroupBackendList{Backends: items, URI: s.uri("/backends/tunnel_group"), NextPageURI: next}, nil
+}
+
+func (s *Server) getBackend(r *http.Request) (any, error) {
+	return s.backends.get(r.PathValue("id"))
+}
+
+func (s *Server) updateBackend(r *http.Request) (any, error) {
+	backend, err := s.backends.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.TunnelGroupBackendUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Description != nil {
+		backend.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		backend.Metadata = *update.Metadata
+	}
+	if update.Labels != nil {
+		backend.Labels = update.Labels
+	}
+	return backend, nil
+}
+
+func (s *Server) deleteBackend(r *http.Request) (any, error) {
+	return nil, s.backends.remove(r.PathValue("id"))
+}
+
+func (s *Server) registerIPPolicies() {
+	s.handle("POST /ip_policies", http.StatusCreated, s.createIPPolicy)
+	s.handle("GET /ip_policies", http.StatusOK, s.listIPPolicies)
+	s.handle("GET /ip_policies/{id}", http.StatusOK, s.getIPPolicy)
+	s.handle("PATCH /ip_policies/{id}", http.StatusOK, s.updateIPPolicy)
+	s.handle("DELETE /ip_policies/{id}", http.StatusNoContent, s.deleteIPPolicy)
+
+	s.handle("POST /ip_policy_rules", http.StatusCreated, s.createIPPolicyRule)
+	s.handle("GET /ip_policy_rules", http.StatusOK, s.listIPPolicyRules)
+	s.handle("GET /ip_policy_rules/{id}", http.StatusOK, s.getIPPolicyRule)
+	s.handle("PATCH /ip_policy_rules/{id}", http.StatusOK, s.updateIPPolicyRule)
+	s.handle("DELETE /ip_policy_rules/{id}", http.StatusNoContent, s.deleteIPPolicyRule)
+}
+
+func (s *Server) createIPPolicy(r *http.Request) (any, error) {
+	create, err := decode[ngrok.IPPolicyCreate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := newID("ipp")
+	policy := &ngrok.IPPolicy{
+		ID:          id,
+		URI:         s.uri("/ip_policies/%s", id),
+		CreatedAt:   now(),
+		Description: create.Description,
+		Metadata:    create.Metadata,
+	}
+	s.ipPolicies.insert(id, policy)
+	return policy, nil
+}
+
+func (s *Server) listIPPolicies(r *http.Request) (any, error) {
+	items, next, err := s.ipPolicies.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.IPPolicyList{IPPolicies: items, URI: s.uri("/ip_policies"), NextPageURI: next}, nil
+}
+
+func (s *Server) getIPPolicy(r *http.Request) (any, error) {
+	return s.ipPolicies.get(r.PathValue("id"))
+}
+
+func (s *Server) updateIPPolicy(r *http.Request) (any, error) {
+	policy, err := s.ipPolicies.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.IPPolicyUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Description != nil {
+		policy.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		policy.Metadata = *update.Metadata
+	}
+	return policy, nil
+}
+
+// deleteIPPolicy deletes the policy along with its rules
+func (s *Server) deleteIPPolicy(r *http.Request) (any, error) {
+	id := r.PathValue("id")
+	if err := s.ipPolicies.remove(id); err != nil {
+		return nil, err
+	}
+	for _, rule := range s.ipPolicyRules.all() {
+		if rule.IPPolicy.ID == id {
+			_ = s.ipPolicyRules.remove(rule.ID)
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) createIPPolicyRule(r *http.Request) (any, error) {
+	create, err := decode[ngrok.IPPolicyRuleCreate](r)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.ipPolicies.get(create.IPPolicyID)
+	if err != nil {
+		return nil, badRequest("The IP policy %q doesn't exist.", create.IPPolicyID)
+	}
+	if _, _, err := net.ParseCIDR(create.CIDR); err != nil {
+		return nil, badRequest("The CIDR %q is invalid.", create.CIDR)
+	}
+	action := ptr.Deref(create.Action, "allow")
+	if !slices.Contains([]string{"allow", "deny"}, action) {
+		return nil, badRequest("The action %q is invalid, it must be allow or deny.", action)
+	}
+
+	id := newID("ipx")
+	rule := &ngrok.IPPolicyRule{
+		ID:          id,
+		URI:         s.uri("/ip_policy_rules/%s", id),
+		CreatedAt:   now(),
+		Description: create.Description,
+		Metadata:    create.Metadata,
+		CIDR:        create.CIDR,
+		IPPolicy:    ngrok.Ref{ID: policy.ID, URI: policy.URI},
+		Action:      action,
+	}
+	s.ipPolicyRules.insert(id, rule)
+	return rule, nil
+}
+
+func (s *Server) listIPPolicyRules(r *http.Request) (any, error) {
+	items, next, err := s.ipPolicyRules.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.IPPolicyRuleList{IPPolicyRules: items, URI: s.uri("/ip_policy_rules"), NextPageURI: next}, nil
+}
+
+func (s *Server) getIPPolicyRule(r *http.Request) (any, error) {
+	return s.ipPolicyRules.get(r.PathValue("id"))
+}
+
+func (s *Server) updateIPPolicyRule(r *http.Request) (any, error) {
+	rule, err := s.ipPolicyRules.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.IPPolicyRuleUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.CIDR != nil {
+		if _, _, err := net.ParseCIDR(*update.CIDR); err != nil {
+			return nil, badRequest("The CIDR %q is invalid.", *update.CIDR)
+		}
+		rule.CIDR = *update.CIDR
+	}
+	if update.Description != nil {
+		rule.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		rule.Metadata = *update.Metadata
+	}
+	return rule, nil
+}
+
+func (s *Server) deleteIPPolicyRule(r *http.Request) (any, error) {
+	return nil, s.ipPolicyRules.remove(r.PathValue("id"))
+}
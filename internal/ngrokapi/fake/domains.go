@@ -0,0 +1,359 @@
+package fake
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+	"k8s.io/utils/ptr"
+)
+
+// ngrokDomainSuffixes are the suffixes of the domains ngrok serves itself, which don't need a CNAME record
+var ngrokDomainSuffixes = []string{".ngrok.app", ".ngrok.dev", ".ngrok-free.app", ".ngrok-free.dev", ".ngrok.io", ".ngrok.pizza"}
+
+func (s *Server) registerDomains() {
+	s.handle("POST /reserved_domains", http.StatusCreated, s.createDomain)
+	s.handle("GET /reserved_domains", http.StatusOK, s.listDomains)
+	s.handle("GET /reserved_domains/{id}", http.StatusOK, s.getDomain)
+	s.handle("PATCH /reserved_domains/{id}", http.StatusOK, s.updateDomain)
+	s.handle("DELETE /reserved_domains/{id}", http.StatusNoContent, s.deleteDomain)
+	s.handle("DELETE /reserved_domains/{id}/certificate", http.StatusNoContent, s.deleteDomainCertificate)
+	s.handle("DELETE /reserved_domains/{id}/certificate_management_policy", http.StatusNoContent, s.deleteDomainCertificateManagementPolicy)
+}
+
+func (s *Server) createDomain(r *http.Request) (any, error) {
+	create, err := decode[ngrok.ReservedDomainCreate](r)
+	if err != nil {
+		return nil, err
+	}
+	if create.Domain == "" {
+		return nil, badRequest("A domain is required.")
+	}
+	if s.findDomain(create.Domain) != nil {
+		return nil, badRequest("The domain %q is already reserved.", create.Domain)
+	}
+
+	id := newID("rd")
+	domain := &ngrok.ReservedDomain{
+		ID:          id,
+		URI:         s.uri("/reserved_domains/%s", id),
+		CreatedAt:   now(),
+		Description: create.Description,
+		Metadata:    create.Metadata,
+		Domain:      create.Domain,
+		Region:      create.Region,
+	}
+	if !isNgrokDomain(create.Domain) {
+		domain.CNAMETarget = ptr.To(fmt.Sprintf("%s.%s.ngrok-cname.com", randomString(hostnameAlphabet, 7), randomString(hostnameAlphabet, 17)))
+		domain.CertificateManagementPolicy = &ngrok.ReservedDomainCertPolicy{Authority: "letsencrypt", PrivateKeyType: "ecdsa"}
+	}
+	if err := s.setDomainCertificate(domain, create.CertificateID, create.CertificateManagementPolicy); err != nil {
+		return nil, err
+	}
+
+	s.domains.insert(id, domain)
+	return domain, nil
+}
+
+func (s *Server) listDomains(r *http.Request) (any, error) {
+	items, next, err := s.domains.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.ReservedDomainList{ReservedDomains: items, URI: s.uri("/reserved_domains"), NextPageURI: next}, nil
+}
+
+func (s *Server) getDomain(r *http.Request) (any, error) {
+	return s.domains.get(r.PathValue("id"))
+}
+
+func (s *Server) updateDomain(r *http.Request) (any, error) {
+	domain, err := s.domains.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.ReservedDomainUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setDomainCertificate(domain, update.CertificateID, update.CertificateManagementPolicy); err != nil {
+		return nil, err
+	}
+	if update.Description != nil {
+		domain.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		domain.Metadata = *update.Metadata
+	}
+	return domain, nil
+}
+
+func (s *Server) deleteDomain(r *http.Request) (any, error) {
+	id := r.PathValue("id")
+	domain, err := s.domains.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.domainInUse(domain.Domain) {
+		return nil, newAPIError(http.StatusBadRequest, "ERR_NGROK_446", "The domain %q is in use by an edge and can't be deleted.", domain.Domain)
+	}
+	return nil, s.domains.remove(id)
+}
+
+func (s *Server) deleteDomainCertificate(r *http.Request) (any, error) {
+	domain, err := s.domains.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	domain.Certificate = nil
+	return nil, nil
+}
+
+func (s *Server) deleteDomainCertificateManagementPolicy(r *http.Request) (any, error) {
+	domain, err := s.domains.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	domain.CertificateManagementPolicy = nil
+	return nil, nil
+}
+
+// setDomainCertificate makes the domain serve the uploaded certificate with the given ID, or the certificates
+// managed by ngrok with the given policy. The two are mutually exclusive.
+func (s *Server) setDomainCertificate(domain *ngrok.ReservedDomain, certificateID *string, policy *ngrok.ReservedDomainCertPolicy) error {
+	if certificateID != nil && *certificateID != "" && policy != nil {
+		return badRequest("A certificate and a certificate management policy can't be both set.")
+	}
+
+	if certificateID != nil {
+		domain.Certificate = nil
+		if *certificateID != "" {
+			certificate, err := s.certificates.get(*certificateID)
+			if err != nil {
+				return badRequest("The TLS certificate %q doesn't exist.", *certificateID)
+			}
+			domain.Certificate = &ngrok.Ref{ID: certificate.ID, URI: certificate.URI}
+			domain.CertificateManagementPolicy = nil
+		}
+	}
+	if policy != nil {
+		domain.CertificateManagementPolicy = policy
+		domain.Certificate = nil
+	}
+	return nil
+}
+
+// findDomain returns the reserved domain with the given name, if any
+func (s *Server) findDomain(name string) *ngrok.ReservedDomain {
+	for _, domain := range s.domains.all() {
+		if domain.Domain == name {
+			return domain
+		}
+	}
+	return nil
+}
+
+// domainInUse returns whether an HTTPS or TLS edge serves the domain
+func (s *Server) domainInUse(name string) bool {
+	var hostports []string
+	for _, edge := range s.httpsEdges.all() {
+		hostports = append(hostports, edge.Hostports...)
+	}
+	for _, edge := range s.tlsEdges.all() {
+		hostports = append(hostports, edge.Hostports...)
+	}
+	return slices.ContainsFunc(hostports, func(hostport string) bool {
+		host, _, _ := net.SplitHostPort(hostport)
+		return host == name
+	})
+}
+
+func isNgrokDomain(name string) bool {
+	return slices.ContainsFunc(ngrokDomainSuffixes, func(suffix string) bool {
+		return strings.HasSuffix(name, suffix)
+	})
+}
+
+func (s *Server) registerAddrs() {
+	s.handle("POST /reserved_addrs", http.StatusCreated, s.createAddr)
+	s.handle("GET /reserved_addrs", http.StatusOK, s.listAddrs)
+	s.handle("GET /reserved_addrs/{id}", http.StatusOK, s.getAddr)
+	s.handle("PATCH /reserved_addrs/{id}", http.StatusOK, s.updateAddr)
+	s.handle("DELETE /reserved_addrs/{id}", http.StatusNoContent, s.deleteAddr)
+}
+
+func (s *Server) createAddr(r *http.Request) (any, error) {
+	create, err := decode[ngrok.ReservedAddrCreate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := newID("ra")
+	addr := &ngrok.ReservedAddr{
+		ID:          id,
+		URI:         s.uri("/reserved_addrs/%s", id),
+		CreatedAt:   now(),
+		Description: create.Description,
+		Metadata:    create.Metadata,
+		Addr:        fmt.Sprintf("1.tcp.ngrok.io:%d", s.nextAddrPort),
+		Region:      create.Region,
+	}
+	s.nextAddrPort++
+
+	s.addrs.insert(id, addr)
+	return addr, nil
+}
+
+func (s *Server) listAddrs(r *http.Request) (any, error) {
+	items, next, err := s.addrs.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.ReservedAddrList{ReservedAddrs: items, URI: s.uri("/reserved_addrs"), NextPageURI: next}, nil
+}
+
+func (s *Server) getAddr(r *http.Request) (any, error) {
+	return s.addrs.get(r.PathValue("id"))
+}
+
+func (s *Server) updateAddr(r *http.Request) (any, error) {
+	addr, err := s.addrs.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.ReservedAddrUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Description != nil {
+		addr.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		addr.Metadata = *update.Metadata
+	}
+	return addr, nil
+}
+
+func (s *Server) deleteAddr(r *http.Request) (any, error) {
+	id := r.PathValue("id")
+	addr, err := s.addrs.get(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, edge := range s.tcpEdges.all() {
+		if slices.Contains(edge.Hostports, addr.Addr) {
+			return nil, badRequest("The addr %q is in use by the TCP edge %q and can't be deleted.", addr.Addr, edge.ID)
+		}
+	}
+	return nil, s.addrs.remove(id)
+}
+
+// findAddr returns the reserved addr with the given address, if any
+func (s *Server) findAddr(address string) *ngrok.ReservedAddr {
+	for _, addr := range s.addrs.all() {
+		if addr.Addr == address {
+			return addr
+		}
+	}
+	return nil
+}
+
+func (s *Server) registerCertificates() {
+	s.handle("POST /tls_certificates", http.StatusCreated, s.createCertificate)
+	s.handle("GET /tls_certificates", http.StatusOK, s.listCertificates)
+	s.handle("GET /tls_certificates/{id}", http.StatusOK, s.getCertificate)
+	s.handle("PATCH /tls_certificates/{id}", http.StatusOK, s.updateCertificate)
+	s.handle("DELETE /tls_certificates/{id}", http.StatusNoContent, s.deleteCertificate)
+}
+
+func (s *Server) createCertificate(r *http.Request) (any, error) {
+	create, err := decode[ngrok.TLSCertificateCreate](r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tls.X509KeyPair([]byte(create.CertificatePEM), []byte(create.PrivateKeyPEM)); err != nil {
+		return nil, badRequest("The certificate and private key are invalid: %s", err)
+	}
+	block, _ := pem.Decode([]byte(create.CertificatePEM))
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, badRequest("The certificate is invalid: %s", err)
+	}
+
+	id := newID("cert")
+	certificate := &ngrok.TLSCertificate{
+		ID:                id,
+		URI:               s.uri("/tls_certificates/%s", id),
+		CreatedAt:         now(),
+		Description:       create.Description,
+		Metadata:          create.Metadata,
+		CertificatePEM:    create.CertificatePEM,
+		SubjectCommonName: parsed.Subject.CommonName,
+		SubjectAlternativeNames: ngrok.TLSCertificateSANs{
+			DNSNames: parsed.DNSNames,
+		},
+		NotBefore:        parsed.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:         parsed.NotAfter.UTC().Format(time.RFC3339),
+		IssuerCommonName: parsed.Issuer.CommonName,
+		SerialNumber:     parsed.SerialNumber.Text(16),
+	}
+	for _, ip := range parsed.IPAddresses {
+		certificate.SubjectAlternativeNames.IPs = append(certificate.SubjectAlternativeNames.IPs, ip.String())
+	}
+
+	s.certificates.insert(id, certificate)
+	return certificate, nil
+}
+
+func (s *Server) listCertificates(r *http.Request) (any, error) {
+	items, next, err := s.certificates.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.TLSCertificateList{TLSCertificates: items, URI: s.uri("/tls_certificates"), NextPageURI: next}, nil
+}
+
+func (s *Server) getCertificate(r *http.Request) (any, error) {
+	return s.certificates.get(r.PathValue("id"))
+}
+
+func (s *Server) updateCertificate(r *http.Request) (any, error) {
+	certificate, err := s.certificates.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.TLSCertificateUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Description != nil {
+		certificate.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		certificate.Metadata = *update.Metadata
+	}
+	return certificate, nil
+}
+
+func (s *Server) deleteCertificate(r *http.Request) (any, error) {
+	id := r.PathValue("id")
+	if _, err := s.certificates.get(id); err != nil {
+		return nil, err
+	}
+	for _, domain := range s.domains.all() {
+		if domain.Certificate != nil && domain.Certificate.ID == id {
+			return nil, badRequest("The TLS certificate %q is in use by the reserved domain %q and can't be deleted.", id, domain.Domain)
+		}
+	}
+	return nil, s.certificates.remove(id)
+}
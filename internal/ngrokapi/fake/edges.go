@@ -0,0 +1,641 @@
+package fake
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+)
+
+// module is an edge module, which can be replaced, read and deleted on its own. E is the type of the edge or route
+// the module belongs to.
+type module[E any] struct {
+	// get returns the module, or nil if it isn't set
+	get func(e *E) any
+	// replace sets the module from the body of a request and returns it
+	replace func(e *E, body []byte) (any, error)
+	remove  func(e *E)
+}
+
+// plainModule returns a module that is stored as it's sent
+func plainModule[E, M any](field func(*E) **M) module[E] {
+	return convertedModule(field, func(m *M) (*M, error) { return m, nil })
+}
+
+// convertedModule returns a module that is converted before being stored, such as the modules referencing other
+// resources by ID, which the ngrok API returns as Refs
+func convertedModule[E, Mutate, M any](field func(*E) **M, convert func(*Mutate) (*M, error)) module[E] {
+	return module[E]{
+		get: func(e *E) any {
+			if m := *field(e); m != nil {
+				return m
+			}
+			return nil
+		},
+		replace: func(e *E, body []byte) (any, error) {
+			mutate := new(Mutate)
+			if err := json.Unmarshal(body, mutate); err != nil {
+				return nil, badRequest("The module is invalid: %s", err)
+			}
+			m, err := convert(mutate)
+			if err != nil {
+				return nil, err
+			}
+			*field(e) = m
+			return m, nil
+		},
+		remove: func(e *E) {
+			*field(e) = nil
+		},
+	}
+}
+
+// handleModules registers the handlers of the modules of the edges or routes found by lookup under path
+func handleModules[E any](s *Server, path string, lookup func(r *http.Request) (*E, error), modules map[string]module[E]) {
+	for name, m := range modules {
+		s.handle("GET "+path+"/"+name, http.StatusOK, func(r *http.Request) (any, error) {
+			e, err := lookup(r)
+			if err != nil {
+				return nil, err
+			}
+			res := m.get(e)
+			if res == nil {
+				return nil, notFound(name+" module", r.URL.Path)
+			}
+			return res, nil
+		})
+		s.handle("PUT "+path+"/"+name, http.StatusOK, func(r *http.Request) (any, error) {
+			e, err := lookup(r)
+			if err != nil {
+				return nil, err
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				return nil, err
+			}
+			return m.replace(e, body)
+		})
+		s.handle("DELETE "+path+"/"+name, http.StatusNoContent, func(r *http.Request) (any, error) {
+			e, err := lookup(r)
+			if err != nil {
+				return nil, err
+			}
+			m.remove(e)
+			return nil, nil
+		})
+	}
+}
+
+// backendModule converts a backend module, whose backend must be a tunnel group backend
+func (s *Server) backendModule(m *ngrok.EndpointBackendMutate) (*ngrok.EndpointBackend, error) {
+	backend, err := s.backends.get(m.BackendID)
+	if err != nil {
+		return nil, badRequest("The backend %q doesn't exist.", m.BackendID)
+	}
+	return &ngrok.EndpointBackend{Enabled: m.Enabled, Backend: ngrok.Ref{ID: backend.ID, URI: backend.URI}}, nil
+}
+
+// ipRestrictionModule converts an IP restriction module, whose IP policies must exist
+func (s *Server) ipRestrictionModule(m *ngrok.EndpointIPPolicyMutate) (*ngrok.EndpointIPPolicy, error) {
+	module := &ngrok.EndpointIPPolicy{Enabled: m.Enabled}
+	for _, id := range m.IPPolicyIDs {
+		policy, err := s.ipPolicies.get(id)
+		if err != nil {
+			return nil, badRequest("The IP policy %q doesn't exist.", id)
+		}
+		module.IPPolicies = append(module.IPPolicies, ngrok.Ref{ID: policy.ID, URI: policy.URI})
+	}
+	return module, nil
+}
+
+// mutualTLSModule converts a mutual TLS module. The certificate authorities aren't checked as the server doesn't
+// store them.
+func (s *Server) mutualTLSModule(m *ngrok.EndpointMutualTLSMutate) (*ngrok.EndpointMutualTLS, error) {
+	module := &ngrok.EndpointMutualTLS{Enabled: m.Enabled}
+	for _, id := range m.CertificateAuthorityIDs {
+		module.CertificateAuthorities = append(module.CertificateAuthorities, ngrok.Ref{ID: id, URI: s.uri("/certificate_authorities/%s", id)})
+	}
+	return module, nil
+}
+
+func tlsTerminationAtEdgeModule(m *ngrok.EndpointTLSTerminationAtEdge) (*ngrok.EndpointTLSTermination, error) {
+	return &ngrok.EndpointTLSTermination{Enabled: m.Enabled, TerminateAt: "edge", MinVersion: m.MinVersion}, nil
+}
+
+func samlModule(m *ngrok.EndpointSAMLMutate) (*ngrok.EndpointSAML, error) {
+	return &ngrok.EndpointSAML{
+		Enabled:            m.Enabled,
+		OptionsPassthrough: m.OptionsPassthrough,
+		CookiePrefix:       m.CookiePrefix,
+		InactivityTimeout:  m.InactivityTimeout,
+		MaximumDuration:    m.MaximumDuration,
+		IdPMetadata:        m.IdPMetadata,
+		ForceAuthn:         m.ForceAuthn,
+		AllowIdPInitiated:  m.AllowIdPInitiated,
+		AuthorizedGroups:   m.AuthorizedGroups,
+		NameIDFormat:       m.NameIDFormat,
+	}, nil
+}
+
+// checkDomainHostports checks that the hostports of an HTTPS or TLS edge are on port 443 of reserved domains
+func (s *Server) checkDomainHostports(hostports []string) error {
+	for _, hostport := range hostports {
+		host, port, err := net.SplitHostPort(hostport)
+		if err != nil || port != "443" {
+			return badRequest("The hostport %q is invalid, it must be a domain on port 443.", hostport)
+		}
+		if s.findDomain(host) == nil {
+			return newAPIError(http.StatusBadRequest, "ERR_NGROK_7117", "The domain %q is not reserved.", host)
+		}
+	}
+	return nil
+}
+
+func (s *Server) registerHTTPSEdges() {
+	s.handle("POST /edges/https", http.StatusCreated, s.createHTTPSEdge)
+	s.handle("GET /edges/https", http.StatusOK, s.listHTTPSEdges)
+	s.handle("GET /edges/https/{id}", http.StatusOK, s.getHTTPSEdge)
+	s.handle("PATCH /edges/https/{id}", http.StatusOK, s.updateHTTPSEdge)
+	s.handle("DELETE /edges/https/{id}", http.StatusNoContent, s.deleteHTTPSEdge)
+	handleModules(s, "/edges/https/{id}", s.lookupHTTPSEdge, map[string]module[ngrok.HTTPSEdge]{
+		"mutual_tls":      convertedModule(func(e *ngrok.HTTPSEdge) **ngrok.EndpointMutualTLS { return &e.MutualTls }, s.mutualTLSModule),
+		"tls_termination": convertedModule(func(e *ngrok.HTTPSEdge) **ngrok.EndpointTLSTermination { return &e.TlsTermination }, tlsTerminationAtEdgeModule),
+	})
+
+	s.handle("POST /edges/https/{edge_id}/routes", http.StatusCreated, s.createHTTPSEdgeRoute)
+	s.handle("GET /edges/https/{edge_id}/routes/{id}", http.StatusOK, s.getHTTPSEdgeRoute)
+	s.handle("PATCH /edges/https/{edge_id}/routes/{id}", http.StatusOK, s.updateHTTPSEdgeRoute)
+	s.handle("DELETE /edges/https/{edge_id}/routes/{id}", http.StatusNoContent, s.deleteHTTPSEdgeRoute)
+	handleModules(s, "/edges/https/{edge_id}/routes/{id}", s.lookupHTTPSEdgeRoute, map[string]module[ngrok.HTTPSEdgeRoute]{
+		"backend":                 convertedModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointBackend { return &r.Backend }, s.backendModule),
+		"ip_restriction":          convertedModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointIPPolicy { return &r.IpRestriction }, s.ipRestrictionModule),
+		"circuit_breaker":         plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointCircuitBreaker { return &r.CircuitBreaker }),
+		"compression":             plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointCompression { return &r.Compression }),
+		"request_headers":         plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointRequestHeaders { return &r.RequestHeaders }),
+		"response_headers":        plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointResponseHeaders { return &r.ResponseHeaders }),
+		"webhook_verification":    plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointWebhookValidation { return &r.WebhookVerification }),
+		"oauth":                   plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointOAuth { return &r.OAuth }),
+		"saml":                    convertedModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointSAML { return &r.SAML }, samlModule),
+		"oidc":                    plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointOIDC { return &r.OIDC }),
+		"websocket_tcp_converter": plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointWebsocketTCPConverter { return &r.WebsocketTCPConverter }),
+		"user_agent_filter":       plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointUserAgentFilter { return &r.UserAgentFilter }),
+		"traffic_policy":          plainModule(func(r *ngrok.HTTPSEdgeRoute) **ngrok.EndpointTrafficPolicy { return &r.TrafficPolicy }),
+	})
+}
+
+func (s *Server) lookupHTTPSEdge(r *http.Request) (*ngrok.HTTPSEdge, error) {
+	return s.httpsEdges.get(r.PathValue("id"))
+}
+
+func (s *Server) createHTTPSEdge(r *http.Request) (any, error) {
+	create, err := decode[ngrok.HTTPSEdgeCreate](r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDomainHostports(create.Hostports); err != nil {
+		return nil, err
+	}
+
+	id := newID("edghts")
+	edge := &ngrok.HTTPSEdge{
+		ID:          id,
+		URI:         s.uri("/edges/https/%s", id),
+		CreatedAt:   now(),
+		Description: create.Description,
+		Metadata:    create.Metadata,
+		Hostports:   create.Hostports,
+	}
+	s.setHTTPSEdgeModules(edge, create.MutualTLS, create.TLSTermination)
+
+	s.httpsEdges.insert(id, edge)
+	return edge, nil
+}
+
+func (s *Server) listHTTPSEdges(r *http.Request) (any, error) {
+	items, next, err := s.httpsEdges.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.HTTPSEdgeList{HTTPSEdges: items, URI: s.uri("/edges/https"), NextPageURI: next}, nil
+}
+
+func (s *Server) getHTTPSEdge(r *http.Request) (any, error) {
+	return s.lookupHTTPSEdge(r)
+}
+
+func (s *Server) updateHTTPSEdge(r *http.Request) (any, error) {
+	edge, err := s.lookupHTTPSEdge(r)
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.HTTPSEdgeUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Hostports != nil {
+		if err := s.checkDomainHostports(update.Hostports); err != nil {
+			return nil, err
+		}
+		edge.Hostports = update.Hostports
+	}
+	if update.Description != nil {
+		edge.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		edge.Metadata = *update.Metadata
+	}
+	s.setHTTPSEdgeModules(edge, update.MutualTLS, update.TLSTermination)
+	return edge, nil
+}
+
+func (s *Server) deleteHTTPSEdge(r *http.Request) (any, error) {
+	return nil, s.httpsEdges.remove(r.PathValue("id"))
+}
+
+// setHTTPSEdgeModules replaces the modules of the edge that are set
+func (s *Server) setHTTPSEdgeModules(edge *ngrok.HTTPSEdge, mutualTLS *ngrok.EndpointMutualTLSMutate, tlsTermination *ngrok.EndpointTLSTerminationAtEdge) {
+	if mutualTLS != nil {
+		edge.MutualTls, _ = s.mutualTLSModule(mutualTLS)
+	}
+	if tlsTermination != nil {
+		edge.TlsTermination, _ = tlsTerminationAtEdgeModule(tlsTermination)
+	}
+}
+
+func (s *Server) lookupHTTPSEdgeRoute(r *http.Request) (*ngrok.HTTPSEdgeRoute, error) {
+	edge, err := s.httpsEdges.get(r.PathValue("edge_id"))
+	if err != nil {
+		return nil, err
+	}
+	id := r.PathValue("id")
+	for i := range edge.Routes {
+		if edge.Routes[i].ID == id {
+			return &edge.Routes[i], nil
+		}
+	}
+	return nil, notFound("HTTPS edge route", id)
+}
+
+func (s *Server) createHTTPSEdgeRoute(r *http.Request) (any, error) {
+	edge, err := s.httpsEdges.get(r.PathValue("edge_id"))
+	if err != nil {
+		return nil, err
+	}
+	// the fields of a route update are the ones of a route creation, so that the modules are set the same way
+	create, err := decode[ngrok.HTTPSEdgeRouteUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRouteMatch(create.MatchType, create.Match); err != nil {
+		return nil, err
+	}
+	for _, route := range edge.Routes {
+		if route.MatchType == create.MatchType && route.Match == create.Match {
+			return nil, badRequest("The edge %q already has a route matching %s %q.", edge.ID, create.MatchType, create.Match)
+		}
+	}
+
+	id := newID("edghtsrt")
+	route := ngrok.HTTPSEdgeRoute{
+		EdgeID:      edge.ID,
+		ID:          id,
+		URI:         s.uri("/edges/https/%s/routes/%s", edge.ID, id),
+		CreatedAt:   now(),
+		MatchType:   create.MatchType,
+		Match:       create.Match,
+		Description: create.Description,
+		Metadata:    create.Metadata,
+	}
+	if err := s.setHTTPSEdgeRouteModules(&route, create); err != nil {
+		return nil, err
+	}
+
+	edge.Routes = append(edge.Routes, route)
+	return &edge.Routes[len(edge.Routes)-1], nil
+}
+
+func (s *Server) getHTTPSEdgeRoute(r *http.Request) (any, error) {
+	return s.lookupHTTPSEdgeRoute(r)
+}
+
+func (s *Server) updateHTTPSEdgeRoute(r *http.Request) (any, error) {
+	route, err := s.lookupHTTPSEdgeRoute(r)
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.HTTPSEdgeRouteUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRouteMatch(update.MatchType, update.Match); err != nil {
+		return nil, err
+	}
+
+	// the modules are converted first, so that an invalid module leaves the route untouched
+	updated := *route
+	updated.MatchType = update.MatchType
+	updated.Match = update.Match
+	updated.Description = update.Description
+	updated.Metadata = update.Metadata
+	if err := s.setHTTPSEdgeRouteModules(&updated, update); err != nil {
+		return nil, err
+	}
+	*route = updated
+	return route, nil
+}
+
+func (s *Server) deleteHTTPSEdgeRoute(r *http.Request) (any, error) {
+	edge, err := s.httpsEdges.get(r.PathValue("edge_id"))
+	if err != nil {
+		return nil, err
+	}
+	id := r.PathValue("id")
+	i := slices.IndexFunc(edge.Routes, func(route ngrok.HTTPSEdgeRoute) bool { return route.ID == id })
+	if i < 0 {
+		return nil, notFound("HTTPS edge route", id)
+	}
+	edge.Routes = slices.Delete(edge.Routes, i, i+1)
+	return nil, nil
+}
+
+func checkRouteMatch(matchType, match string) error {
+	if matchType != "exact_path" && matchType != "path_prefix" {
+		return badRequest("The match type %q is invalid, it must be exact_path or path_prefix.", matchType)
+	}
+	if match == "" {
+		return badRequest("A match is required.")
+	}
+	return nil
+}
+
+// setHTTPSEdgeRouteModules replaces the modules of the route that are set in the update
+func (s *Server) setHTTPSEdgeRouteModules(route *ngrok.HTTPSEdgeRoute, update *ngrok.HTTPSEdgeRouteUpdate) error {
+	if update.Backend != nil {
+		m, err := s.backendModule(update.Backend)
+		if err != nil {
+			return err
+		}
+		route.Backend = m
+	}
+	if update.IPRestriction != nil {
+		m, err := s.ipRestrictionModule(update.IPRestriction)
+		if err != nil {
+			return err
+		}
+		route.IpRestriction = m
+	}
+	if update.SAML != nil {
+		route.SAML, _ = samlModule(update.SAML)
+	}
+	setIfNotNil(&route.CircuitBreaker, update.CircuitBreaker)
+	setIfNotNil(&route.Compression, update.Compression)
+	setIfNotNil(&route.RequestHeaders, update.RequestHeaders)
+	setIfNotNil(&route.ResponseHeaders, update.ResponseHeaders)
+	setIfNotNil(&route.WebhookVerification, update.WebhookVerification)
+	setIfNotNil(&route.OAuth, update.OAuth)
+	setIfNotNil(&route.OIDC, update.OIDC)
+	setIfNotNil(&route.WebsocketTCPConverter, update.WebsocketTCPConverter)
+	setIfNotNil(&route.UserAgentFilter, update.UserAgentFilter)
+	setIfNotNil(&route.TrafficPolicy, update.TrafficPolicy)
+	return nil
+}
+
+func setIfNotNil[T any](field **T, value *T) {
+	if value != nil {
+		*field = value
+	}
+}
+
+func (s *Server) registerTCPEdges() {
+	s.handle("POST /edges/tcp", http.StatusCreated, s.createTCPEdge)
+	s.handle("GET /edges/tcp", http.StatusOK, s.listTCPEdges)
+	s.handle("GET /edges/tcp/{id}", http.StatusOK, s.getTCPEdge)
+	s.handle("PATCH /edges/tcp/{id}", http.StatusOK, s.updateTCPEdge)
+	s.handle("DELETE /edges/tcp/{id}", http.StatusNoContent, s.deleteTCPEdge)
+	handleModules(s, "/edges/tcp/{id}", s.lookupTCPEdge, map[string]module[ngrok.TCPEdge]{
+		"backend":        convertedModule(func(e *ngrok.TCPEdge) **ngrok.EndpointBackend { return &e.Backend }, s.backendModule),
+		"ip_restriction": convertedModule(func(e *ngrok.TCPEdge) **ngrok.EndpointIPPolicy { return &e.IpRestriction }, s.ipRestrictionModule),
+		"traffic_policy": plainModule(func(e *ngrok.TCPEdge) **ngrok.EndpointTrafficPolicy { return &e.TrafficPolicy }),
+	})
+}
+
+// checkAddrHostports checks that the hostports of a TCP edge are reserved addrs
+func (s *Server) checkAddrHostports(hostports []string) error {
+	for _, hostport := range hostports {
+		if s.findAddr(hostport) == nil {
+			return badRequest("The hostport %q is not a reserved addr.", hostport)
+		}
+	}
+	return nil
+}
+
+func (s *Server) lookupTCPEdge(r *http.Request) (*ngrok.TCPEdge, error) {
+	return s.tcpEdges.get(r.PathValue("id"))
+}
+
+func (s *Server) createTCPEdge(r *http.Request) (any, error) {
+	create, err := decode[ngrok.TCPEdgeCreate](r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAddrHostports(create.Hostports); err != nil {
+		return nil, err
+	}
+
+	id := newID("edgtcp")
+	edge := &ngrok.TCPEdge{
+		ID:          id,
+		URI:         s.uri("/edges/tcp/%s", id),
+		CreatedAt:   now(),
+		Description: create.Description,
+		Metadata:    create.Metadata,
+		Hostports:   create.Hostports,
+	}
+	if err := s.setTCPEdgeModules(edge, create.Backend, create.IPRestriction, create.TrafficPolicy); err != nil {
+		return nil, err
+	}
+
+	s.tcpEdges.insert(id, edge)
+	return edge, nil
+}
+
+func (s *Server) listTCPEdges(r *http.Request) (any, error) {
+	items, next, err := s.tcpEdges.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.TCPEdgeList{TCPEdges: items, URI: s.uri("/edges/tcp"), NextPageURI: next}, nil
+}
+
+func (s *Server) getTCPEdge(r *http.Request) (any, error) {
+	return s.lookupTCPEdge(r)
+}
+
+func (s *Server) updateTCPEdge(r *http.Request) (any, error) {
+	edge, err := s.lookupTCPEdge(r)
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.TCPEdgeUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Hostports != nil {
+		if err := s.checkAddrHostports(update.Hostports); err != nil {
+			return nil, err
+		}
+		edge.Hostports = update.Hostports
+	}
+	if update.Description != nil {
+		edge.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		edge.Metadata = *update.Metadata
+	}
+	if err := s.setTCPEdgeModules(edge, update.Backend, update.IPRestriction, update.TrafficPolicy); err != nil {
+		return nil, err
+	}
+	return edge, nil
+}
+
+func (s *Server) deleteTCPEdge(r *http.Request) (any, error) {
+	return nil, s.tcpEdges.remove(r.PathValue("id"))
+}
+
+// setTCPEdgeModules replaces the modules of the edge that are set
+func (s *Server) setTCPEdgeModules(edge *ngrok.TCPEdge, backend *ngrok.EndpointBackendMutate, ipRestriction *ngrok.EndpointIPPolicyMutate, trafficPolicy *ngrok.EndpointTrafficPolicy) error {
+	if backend != nil {
+		m, err := s.backendModule(backend)
+		if err != nil {
+			return err
+		}
+		edge.Backend = m
+	}
+	if ipRestriction != nil {
+		m, err := s.ipRestrictionModule(ipRestriction)
+		if err != nil {
+			return err
+		}
+		edge.IpRestriction = m
+	}
+	setIfNotNil(&edge.TrafficPolicy, trafficPolicy)
+	return nil
+}
+
+func (s *Server) registerTLSEdges() {
+	s.handle("POST /edges/tls", http.StatusCreated, s.createTLSEdge)
+	s.handle("GET /edges/tls", http.StatusOK, s.listTLSEdges)
+	s.handle("GET /edges/tls/{id}", http.StatusOK, s.getTLSEdge)
+	s.handle("PATCH /edges/tls/{id}", http.StatusOK, s.updateTLSEdge)
+	s.handle("DELETE /edges/tls/{id}", http.StatusNoContent, s.deleteTLSEdge)
+	handleModules(s, "/edges/tls/{id}", s.lookupTLSEdge, map[string]module[ngrok.TLSEdge]{
+		"backend":         convertedModule(func(e *ngrok.TLSEdge) **ngrok.EndpointBackend { return &e.Backend }, s.backendModule),
+		"ip_restriction":  convertedModule(func(e *ngrok.TLSEdge) **ngrok.EndpointIPPolicy { return &e.IpRestriction }, s.ipRestrictionModule),
+		"mutual_tls":      convertedModule(func(e *ngrok.TLSEdge) **ngrok.EndpointMutualTLS { return &e.MutualTls }, s.mutualTLSModule),
+		"tls_termination": plainModule(func(e *ngrok.TLSEdge) **ngrok.EndpointTLSTermination { return &e.TlsTermination }),
+		"traffic_policy":  plainModule(func(e *ngrok.TLSEdge) **ngrok.EndpointTrafficPolicy { return &e.TrafficPolicy }),
+	})
+}
+
+func (s *Server) lookupTLSEdge(r *http.Request) (*ngrok.TLSEdge, error) {
+	return s.tlsEdges.get(r.PathValue("id"))
+}
+
+func (s *Server) createTLSEdge(r *http.Request) (any, error) {
+	create, err := decode[ngrok.TLSEdgeCreate](r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDomainHostports(create.Hostports); err != nil {
+		return nil, err
+	}
+
+	id := newID("edgtls")
+	edge := &ngrok.TLSEdge{
+		ID:          id,
+		URI:         s.uri("/edges/tls/%s", id),
+		CreatedAt:   now(),
+		Description: create.Description,
+		Metadata:    create.Metadata,
+		Hostports:   create.Hostports,
+	}
+	if err := s.setTLSEdgeModules(edge, create.Backend, create.IPRestriction, create.MutualTLS, create.TLSTermination, create.TrafficPolicy); err != nil {
+		return nil, err
+	}
+
+	s.tlsEdges.insert(id, edge)
+	return edge, nil
+}
+
+func (s *Server) listTLSEdges(r *http.Request) (any, error) {
+	items, next, err := s.tlsEdges.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.TLSEdgeList{TLSEdges: items, URI: s.uri("/edges/tls"), NextPageURI: next}, nil
+}
+
+func (s *Server) getTLSEdge(r *http.Request) (any, error) {
+	return s.lookupTLSEdge(r)
+}
+
+func (s *Server) updateTLSEdge(r *http.Request) (any, error) {
+	edge, err := s.lookupTLSEdge(r)
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.TLSEdgeUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Hostports != nil {
+		if err := s.checkDomainHostports(update.Hostports); err != nil {
+			return nil, err
+		}
+		edge.Hostports = update.Hostports
+	}
+	if update.Description != nil {
+		edge.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		edge.Metadata = *update.Metadata
+	}
+	if err := s.setTLSEdgeModules(edge, update.Backend, update.IPRestriction, update.MutualTLS, update.TLSTermination, update.TrafficPolicy); err != nil {
+		return nil, err
+	}
+	return edge, nil
+}
+
+func (s *Server) deleteTLSEdge(r *http.Request) (any, error) {
+	return nil, s.tlsEdges.remove(r.PathValue("id"))
+}
+
+// setTLSEdgeModules replaces the modules of the edge that are set
+func (s *Server) setTLSEdgeModules(edge *ngrok.TLSEdge, backend *ngrok.EndpointBackendMutate, ipRestriction *ngrok.EndpointIPPolicyMutate, mutualTLS *ngrok.EndpointMutualTLSMutate, tlsTermination *ngrok.EndpointTLSTermination, trafficPolicy *ngrok.EndpointTrafficPolicy) error {
+	if backend != nil {
+		m, err := s.backendModule(backend)
+		if err != nil {
+			return err
+		}
+		edge.Backend = m
+	}
+	if ipRestriction != nil {
+		m, err := s.ipRestrictionModule(ipRestriction)
+		if err != nil {
+			return err
+		}
+		edge.IpRestriction = m
+	}
+	if mutualTLS != nil {
+		edge.MutualTls, _ = s.mutualTLSModule(mutualTLS)
+	}
+	setIfNotNil(&edge.TlsTermination, tlsTermination)
+	setIfNotNil(&edge.TrafficPolicy, trafficPolicy)
+	return nil
+}
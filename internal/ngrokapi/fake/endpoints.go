@@ -0,0 +1,191 @@
+package fake
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+)
+
+// endpointBindings are the bindings an endpoint can have
+var endpointBindings = []string{"public", "internal", "kubernetes"}
+
+func (s *Server) registerEndpoints() {
+	s.handle("POST /endpoints", http.StatusCreated, s.createEndpoint)
+	s.handle("GET /endpoints", http.StatusOK, s.listEndpoints)
+	s.handle("GET /endpoints/{id}", http.StatusOK, s.getEndpoint)
+	s.handle("PATCH /endpoints/{id}", http.StatusOK, s.updateEndpoint)
+	s.handle("DELETE /endpoints/{id}", http.StatusNoContent, s.deleteEndpoint)
+}
+
+// AddEndpoint adds an endpoint to the server as is, such as the agent endpoints which aren't created through the API.
+// The ID, URI and creation time are set if they're empty. It returns the endpoint that was added.
+func (s *Server) AddEndpoint(endpoint ngrok.Endpoint) *ngrok.Endpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if endpoint.ID == "" {
+		endpoint.ID = newID("ep")
+	}
+	if endpoint.URI == "" {
+		endpoint.URI = s.uri("/endpoints/%s", endpoint.ID)
+	}
+	if endpoint.CreatedAt == "" {
+		endpoint.CreatedAt = now()
+		endpoint.UpdatedAt = endpoint.CreatedAt
+	}
+	s.endpoints.insert(endpoint.ID, &endpoint)
+	return &endpoint
+}
+
+func (s *Server) createEndpoint(r *http.Request) (any, error) {
+	create, err := decode[ngrok.EndpointCreate](r)
+	if err != nil {
+		return nil, err
+	}
+	if create.Type == "" {
+		create.Type = "cloud"
+	}
+	if create.Type != "cloud" {
+		return nil, badRequest("The type %q is invalid, only cloud endpoints can be created.", create.Type)
+	}
+	if create.Bindings == nil {
+		create.Bindings = []string{"public"}
+	}
+	if err := checkEndpointBindings(create.Bindings); err != nil {
+		return nil, err
+	}
+
+	id := newID("ep")
+	endpoint := &ngrok.Endpoint{
+		ID:            id,
+		URI:           s.uri("/endpoints/%s", id),
+		CreatedAt:     now(),
+		Type:          create.Type,
+		TrafficPolicy: create.TrafficPolicy,
+		Bindings:      create.Bindings,
+	}
+	endpoint.UpdatedAt = endpoint.CreatedAt
+	if create.Description != nil {
+		endpoint.Description = *create.Description
+	}
+	if create.Metadata != nil {
+		endpoint.Metadata = *create.Metadata
+	}
+	if err := s.setEndpointURL(endpoint, create.URL); err != nil {
+		return nil, err
+	}
+
+	s.endpoints.insert(id, endpoint)
+	return endpoint, nil
+}
+
+func (s *Server) listEndpoints(r *http.Request) (any, error) {
+	items, next, err := s.endpoints.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.EndpointList{Endpoints: items, URI: s.uri("/endpoints"), NextPageURI: next}, nil
+}
+
+func (s *Server) getEndpoint(r *http.Request) (any, error) {
+	return s.endpoints.get(r.PathValue("id"))
+}
+
+func (s *Server) updateEndpoint(r *http.Request) (any, error) {
+	endpoint, err := s.endpoints.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Type != "cloud" {
+		return nil, badRequest("The endpoint %q can't be updated, only cloud endpoints can.", endpoint.ID)
+	}
+	update, err := decode[ngrok.EndpointUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Url != nil {
+		if err := s.setEndpointURL(endpoint, *update.Url); err != nil {
+			return nil, err
+		}
+	}
+	if update.Bindings != nil {
+		if err := checkEndpointBindings(update.Bindings); err != nil {
+			return nil, err
+		}
+		endpoint.Bindings = update.Bindings
+	}
+	if update.TrafficPolicy != nil {
+		endpoint.TrafficPolicy = *update.TrafficPolicy
+	}
+	if update.Description != nil {
+		endpoint.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		endpoint.Metadata = *update.Metadata
+	}
+	endpoint.UpdatedAt = now()
+	return endpoint, nil
+}
+
+func (s *Server) deleteEndpoint(r *http.Request) (any, error) {
+	return nil, s.endpoints.remove(r.PathValue("id"))
+}
+
+// setEndpointURL sets the URL of the endpoint along with the fields derived from it. The domain of the endpoint is
+// set when the host is a reserved domain, but it isn't required to be one.
+func (s *Server) setEndpointURL(endpoint *ngrok.Endpoint, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Hostname() == "" {
+		return badRequest("The URL %q is invalid.", rawURL)
+	}
+
+	var defaultPort string
+	switch u.Scheme {
+	case "http":
+		defaultPort = "80"
+	case "https", "tls":
+		defaultPort = "443"
+	case "tcp":
+		if u.Port() == "" {
+			return badRequest("The URL %q is invalid, TCP endpoints need a port.", rawURL)
+		}
+	default:
+		return badRequest("The URL %q is invalid, the scheme must be http, https, tcp or tls.", rawURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	portNumber, err := strconv.ParseInt(port, 10, 64)
+	if err != nil {
+		return badRequest("The URL %q has an invalid port.", rawURL)
+	}
+
+	endpoint.URL = rawURL
+	endpoint.PublicURL = rawURL
+	endpoint.Proto = u.Scheme
+	endpoint.Scheme = u.Scheme
+	endpoint.Host = u.Hostname()
+	endpoint.Port = portNumber
+	endpoint.Hostport = net.JoinHostPort(endpoint.Host, port)
+	endpoint.Domain = nil
+	if domain := s.findDomain(endpoint.Host); domain != nil {
+		endpoint.Domain = &ngrok.Ref{ID: domain.ID, URI: domain.URI}
+	}
+	return nil
+}
+
+func checkEndpointBindings(bindings []string) error {
+	if len(bindings) != 1 {
+		return badRequest("An endpoint must have exactly one binding.")
+	}
+	if !slices.Contains(endpointBindings, bindings[0]) {
+		return badRequest("The binding %q is invalid.", bindings[0])
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package fake
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+)
+
+// bindingCertValidity is how long the certificates issued for the bindings of the operators are valid for
+const bindingCertValidity = 365 * 24 * time.Hour
+
+func (s *Server) registerKubernetesOperators() {
+	s.handle("POST /kubernetes_operators", http.StatusCreated, s.createOperator)
+	s.handle("GET /kubernetes_operators", http.StatusOK, s.listOperators)
+	s.handle("GET /kubernetes_operators/{id}", http.StatusOK, s.getOperator)
+	s.handle("PATCH /kubernetes_operators/{id}", http.StatusOK, s.updateOperator)
+	s.handle("DELETE /kubernetes_operators/{id}", http.StatusNoContent, s.deleteOperator)
+	s.handle("GET /kubernetes_operators/{id}/bound_endpoints", http.StatusOK, s.listBoundEndpoints)
+}
+
+func (s *Server) createOperator(r *http.Request) (any, error) {
+	create, err := decode[ngrok.KubernetesOperatorCreate](r)
+	if err != nil {
+		return nil, err
+	}
+	if create.Deployment.Name == "" || create.Deployment.Namespace == "" {
+		return nil, badRequest("The deployment name and namespace are required.")
+	}
+
+	id := newID("k8sop")
+	operator := &ngrok.KubernetesOperator{
+		ID:              id,
+		URI:             s.uri("/kubernetes_operators/%s", id),
+		CreatedAt:       now(),
+		Description:     create.Description,
+		Metadata:        create.Metadata,
+		EnabledFeatures: create.EnabledFeatures,
+		Region:          create.Region,
+		Deployment:      create.Deployment,
+	}
+	operator.UpdatedAt = operator.CreatedAt
+	if operator.Region == "" {
+		operator.Region = "global"
+	}
+	if create.Binding != nil {
+		binding := &ngrok.KubernetesOperatorBinding{
+			Name:        create.Binding.Name,
+			AllowedURLs: create.Binding.AllowedURLs,
+		}
+		if create.Binding.IngressEndpoint != nil {
+			binding.IngressEndpoint = *create.Binding.IngressEndpoint
+		}
+		if err := s.signBinding(binding, create.Binding.CSR); err != nil {
+			return nil, err
+		}
+		operator.Binding = binding
+	}
+
+	s.operators.insert(id, operator)
+	return operator, nil
+}
+
+func (s *Server) listOperators(r *http.Request) (any, error) {
+	items, next, err := s.operators.page(s, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.KubernetesOperatorList{Operators: items, URI: s.uri("/kubernetes_operators"), NextPageURI: next}, nil
+}
+
+func (s *Server) getOperator(r *http.Request) (any, error) {
+	return s.operators.get(r.PathValue("id"))
+}
+
+func (s *Server) updateOperator(r *http.Request) (any, error) {
+	operator, err := s.operators.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	update, err := decode[ngrok.KubernetesOperatorUpdate](r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the binding is updated on a copy first, so that an invalid CSR leaves the operator untouched
+	if update.Binding != nil {
+		binding := &ngrok.KubernetesOperatorBinding{}
+		if operator.Binding != nil {
+			*binding = *operator.Binding
+		}
+		if update.Binding.Name != nil {
+			binding.Name = *update.Binding.Name
+		}
+		if update.Binding.AllowedURLs != nil {
+			binding.AllowedURLs = update.Binding.AllowedURLs
+		}
+		if update.Binding.IngressEndpoint != nil {
+			binding.IngressEndpoint = *update.Binding.IngressEndpoint
+		}
+		if update.Binding.CSR != nil {
+			if err := s.signBinding(binding, *update.Binding.CSR); err != nil {
+				return nil, err
+			}
+		}
+		operator.Binding = binding
+	}
+	if update.Description != nil {
+		operator.Description = *update.Description
+	}
+	if update.Metadata != nil {
+		operator.Metadata = *update.Metadata
+	}
+	if update.EnabledFeatures != nil {
+		operator.EnabledFeatures = update.EnabledFeatures
+	}
+	if update.Region != nil {
+		operator.Region = *update.Region
+	}
+	operator.UpdatedAt = now()
+	return operator, nil
+}
+
+func (s *Server) deleteOperator(r *http.Request) (any, error) {
+	return nil, s.operators.remove(r.PathValue("id"))
+}
+
+// listBoundEndpoints lists the endpoints with the kubernetes binding, which are the ones the operators project into
+// their cluster
+func (s *Server) listBoundEndpoints(r *http.Request) (any, error) {
+	id := r.PathValue("id")
+	if _, err := s.operators.get(id); err != nil {
+		return nil, err
+	}
+	items, next, err := s.endpoints.page(s, r, func(e *ngrok.Endpoint) bool {
+		return slices.Contains(e.Bindings, "kubernetes")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ngrok.EndpointList{Endpoints: items, URI: s.uri("/kubernetes_operators/%s/bound_endpoints", id), NextPageURI: next}, nil
+}
+
+// signBinding issues the certificate of the binding for the CSR
+func (s *Server) signBinding(binding *ngrok.KubernetesOperatorBinding, csrPEM string) error {
+	if s.bindingsSigner == nil {
+		signer, err := newSigner()
+		if err != nil {
+			return err
+		}
+		s.bindingsSigner = signer
+	}
+
+	cert, err := s.bindingsSigner.sign(csrPEM)
+	if err != nil {
+		return err
+	}
+	binding.Cert = ngrok.KubernetesOperatorCert{
+		Cert:      string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+		NotBefore: cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:  cert.NotAfter.UTC().Format(time.RFC3339),
+	}
+	return nil
+}
+
+// signer is a certificate authority that signs certificate requests
+type signer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newSigner() (*signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake ngrok bindings CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * bindingCertValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &signer{cert: cert, key: key}, nil
+}
+
+func (s *signer) sign(csrPEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, badRequest("The CSR is invalid, it must be a PEM encoded certificate request.")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, badRequest("The CSR is invalid: %s", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, badRequest("The signature of the CSR is invalid: %s", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(bindingCertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, s.cert, csr.PublicKey, s.key)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
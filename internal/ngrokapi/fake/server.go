@@ -0,0 +1,324 @@
+// Package fake implements an in-memory ngrok API server for tests. The clients of a Clientset pointed at it with
+// their BaseURL work as they would against the ngrok API, so that the reconcile loops of the controllers can be
+// tested end to end without network access or an ngrok account.
+//
+// The server keeps the resources in memory and enforces the rules of the ngrok API the operator relies on, such as
+// edges only serving reserved domains, or domains not being deleted while an edge uses them.
+package fake
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+
+	"github.com/ngrok/ngrok-operator/internal/ngrokapi"
+)
+
+const (
+	// defaultPageLimit is the number of items in a page when the request doesn't set a limit
+	defaultPageLimit = 100
+	// maxPageLimit is the maximum number of items in a page
+	maxPageLimit = 100
+)
+
+// Server is an in-memory ngrok API server
+type Server struct {
+	// URL is the base URL of the server, to use as the BaseURL of the ngrok clients
+	URL string
+
+	httpServer *httptest.Server
+	mux        *http.ServeMux
+
+	mu              sync.Mutex
+	domains         *collection[ngrok.ReservedDomain]
+	addrs           *collection[ngrok.ReservedAddr]
+	certificates    *collection[ngrok.TLSCertificate]
+	httpsEdges      *collection[ngrok.HTTPSEdge]
+	tcpEdges        *collection[ngrok.TCPEdge]
+	tlsEdges        *collection[ngrok.TLSEdge]
+	backends        *collection[ngrok.TunnelGroupBackend]
+	ipPolicies      *collection[ngrok.IPPolicy]
+	ipPolicyRules   *collection[ngrok.IPPolicyRule]
+	endpoints       *collection[ngrok.Endpoint]
+	operators       *collection[ngrok.KubernetesOperator]
+	nextAddrPort    int
+	bindingsSigner  *signer
+	requestsHandled int
+}
+
+// NewServer starts a new in-memory ngrok API server. Callers should Close it when they're done.
+func NewServer() *Server {
+	s := &Server{
+		mux:           http.NewServeMux(),
+		domains:       newCollection[ngrok.ReservedDomain]("reserved domain"),
+		addrs:         newCollection[ngrok.ReservedAddr]("reserved addr"),
+		certificates:  newCollection[ngrok.TLSCertificate]("TLS certificate"),
+		httpsEdges:    newCollection[ngrok.HTTPSEdge]("HTTPS edge"),
+		tcpEdges:      newCollection[ngrok.TCPEdge]("TCP edge"),
+		tlsEdges:      newCollection[ngrok.TLSEdge]("TLS edge"),
+		backends:      newCollection[ngrok.TunnelGroupBackend]("tunnel group backend"),
+		ipPolicies:    newCollection[ngrok.IPPolicy]("IP policy"),
+		ipPolicyRules: newCollection[ngrok.IPPolicyRule]("IP policy rule"),
+		endpoints:     newCollection[ngrok.Endpoint]("endpoint"),
+		operators:     newCollection[ngrok.KubernetesOperator]("kubernetes operator"),
+		nextAddrPort:  20000,
+	}
+
+	s.registerDomains()
+	s.registerAddrs()
+	s.registerCertificates()
+	s.registerHTTPSEdges()
+	s.registerTCPEdges()
+	s.registerTLSEdges()
+	s.registerBackends()
+	s.registerIPPolicies()
+	s.registerEndpoints()
+	s.registerKubernetesOperators()
+
+	s.httpServer = httptest.NewServer(s.mux)
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// ClientConfig returns a config for the ngrok clients that sends their requests to the server
+func (s *Server) ClientConfig() *ngrok.ClientConfig {
+	return ngrok.NewClientConfig("fake-api-key", ngrok.WithBaseURL(s.URL))
+}
+
+// Clientset returns a clientset whose clients send their requests to the server
+func (s *Server) Clientset(opts ...ngrokapi.ClientsetOpt) *ngrokapi.DefaultClientset {
+	return ngrokapi.NewClientSet(s.ClientConfig(), opts...)
+}
+
+// Requests returns the number of requests the server handled
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requestsHandled
+}
+
+// apiError is an error returned to the client in the format of the ngrok API
+type apiError struct {
+	status int
+	code   string
+	msg    string
+}
+
+func (e *apiError) Error() string {
+	return e.msg
+}
+
+func newAPIError(status int, code string, format string, args ...any) *apiError {
+	return &apiError{status: status, code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+func badRequest(format string, args ...any) *apiError {
+	return newAPIError(http.StatusBadRequest, "", format, args...)
+}
+
+func notFound(kind, id string) *apiError {
+	return newAPIError(http.StatusNotFound, "ERR_NGROK_404", "The %s %q was not found.", kind, id)
+}
+
+// handlerFunc handles a request with the lock of the server held. It returns the resource to send back, or nil for
+// an empty response.
+type handlerFunc func(r *http.Request) (any, error)
+
+// handle registers the handler for the pattern, responding with status when it succeeds
+func (s *Server) handle(pattern string, status int, h handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			writeError(w, newAPIError(http.StatusUnauthorized, "ERR_NGROK_401", "The request is missing an API key."))
+			return
+		}
+
+		body, err := func() ([]byte, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.requestsHandled++
+
+			res, err := h(r)
+			if err != nil || res == nil {
+				return nil, err
+			}
+			// encode while holding the lock, the resource may be updated as soon as it's released
+			return json.Marshal(res)
+		}()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if body == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		apiErr = newAPIError(http.StatusInternalServerError, "", "%s", err.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.status)
+	_ = json.NewEncoder(w).Encode(&ngrok.Error{
+		ErrorCode:  apiErr.code,
+		StatusCode: int32(apiErr.status),
+		Msg:        apiErr.msg,
+		Details:    map[string]string{"operation_id": newID("op")},
+	})
+}
+
+// decode decodes the JSON body of the request into a new T
+func decode[T any](r *http.Request) (*T, error) {
+	v := new(T)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return v, nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return nil, badRequest("The request body is invalid: %s", err)
+	}
+	return v, nil
+}
+
+// uri returns the URI of the resource at path
+func (s *Server) uri(path string, args ...any) string {
+	return s.URL + fmt.Sprintf(path, args...)
+}
+
+// collection holds the resources of a kind, in the order they were created in
+type collection[T any] struct {
+	kind  string
+	items map[string]*T
+	ids   []string
+}
+
+func newCollection[T any](kind string) *collection[T] {
+	return &collection[T]{kind: kind, items: map[string]*T{}}
+}
+
+func (c *collection[T]) insert(id string, item *T) {
+	c.items[id] = item
+	c.ids = append(c.ids, id)
+}
+
+func (c *collection[T]) get(id string) (*T, error) {
+	item, ok := c.items[id]
+	if !ok {
+		return nil, notFound(c.kind, id)
+	}
+	return item, nil
+}
+
+func (c *collection[T]) remove(id string) error {
+	if _, ok := c.items[id]; !ok {
+		return notFound(c.kind, id)
+	}
+	delete(c.items, id)
+	for i, existing := range c.ids {
+		if existing == id {
+			c.ids = append(c.ids[:i], c.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// all returns the resources, oldest first
+func (c *collection[T]) all() []*T {
+	items := make([]*T, 0, len(c.ids))
+	for _, id := range c.ids {
+		items = append(items, c.items[id])
+	}
+	return items
+}
+
+// page returns the page of the resources matching the filter the request asks for, newest first as the ngrok API
+// does, along with the URI of the next page if there is one.
+func (c *collection[T]) page(s *Server, r *http.Request, matches func(*T) bool) ([]T, *string, error) {
+	limit := defaultPageLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			return nil, nil, badRequest("The limit %q is invalid.", value)
+		}
+		limit = min(limit, maxPageLimit)
+	}
+
+	ids := c.ids
+	if beforeID := r.URL.Query().Get("before_id"); beforeID != "" {
+		ids = nil
+		for i, id := range c.ids {
+			if id == beforeID {
+				ids = c.ids[:i]
+				break
+			}
+		}
+	}
+
+	items := []T{}
+	var next *string
+	lastID := ""
+	for i := len(ids) - 1; i >= 0; i-- {
+		item := c.items[ids[i]]
+		if matches != nil && !matches(item) {
+			continue
+		}
+		if len(items) == limit {
+			uri := s.uri("%s?before_id=%s&limit=%d", r.URL.Path, lastID, limit)
+			next = &uri
+			break
+		}
+		items = append(items, *item)
+		lastID = ids[i]
+	}
+	return items, next, nil
+}
+
+const (
+	idAlphabet       = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	hostnameAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+// newID returns a new ID for a resource, made of the prefix of its kind and a random suffix like ngrok IDs
+func newID(prefix string) string {
+	return prefix + "_" + randomString(idAlphabet, 27)
+}
+
+func randomString(alphabet string, length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		b[i] = alphabet[n.Int64()]
+	}
+	return string(b)
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,246 @@
+package fake
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net/http"
+	"testing"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/ngrok/ngrok-operator/internal/ngrokapi"
+)
+
+func newTestServer(t *testing.T) (*Server, *ngrokapi.DefaultClientset) {
+	s := NewServer()
+	t.Cleanup(s.Close)
+	return s, s.Clientset()
+}
+
+func TestDomains(t *testing.T) {
+	ctx := context.Background()
+	_, cs := newTestServer(t)
+
+	domain, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "example.com", Description: "created"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, domain.ID)
+	require.NotNil(t, domain.CNAMETarget, "custom domains get a CNAME target")
+	assert.NotNil(t, domain.CertificateManagementPolicy)
+
+	ngrokDomain, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "example.ngrok.app"})
+	require.NoError(t, err)
+	assert.Nil(t, ngrokDomain.CNAMETarget)
+
+	_, err = cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "example.com"})
+	assert.Error(t, err, "a domain can only be reserved once")
+
+	updated, err := cs.Domains().Update(ctx, &ngrok.ReservedDomainUpdate{ID: domain.ID, Description: ptr.To("updated")})
+	require.NoError(t, err)
+	assert.Equal(t, "updated", updated.Description)
+
+	got, err := cs.Domains().Get(ctx, domain.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "updated", got.Description)
+	assert.Equal(t, "example.com", got.Domain)
+
+	require.NoError(t, cs.Domains().Delete(ctx, domain.ID))
+	_, err = cs.Domains().Get(ctx, domain.ID)
+	assert.True(t, ngrok.IsNotFound(err))
+	assert.True(t, ngrok.IsNotFound(cs.Domains().Delete(ctx, domain.ID)))
+}
+
+func TestListPaging(t *testing.T) {
+	ctx := context.Background()
+	s, cs := newTestServer(t)
+
+	for _, name := range []string{"a.ngrok.app", "b.ngrok.app", "c.ngrok.app"} {
+		_, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: name})
+		require.NoError(t, err)
+	}
+
+	before := s.Requests()
+	iter := cs.Domains().List(&ngrok.Paging{Limit: ptr.To("2")})
+	names := []string{}
+	for iter.Next(ctx) {
+		names = append(names, iter.Item().Domain)
+	}
+	require.NoError(t, iter.Err())
+	assert.Equal(t, []string{"c.ngrok.app", "b.ngrok.app", "a.ngrok.app"}, names, "the newest domains come first")
+	assert.Equal(t, 2, s.Requests()-before, "the items are listed in pages")
+}
+
+func TestRequestsNeedAnAPIKey(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	res, err := http.Get(s.URL + "/reserved_domains")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestHTTPSEdges(t *testing.T) {
+	ctx := context.Background()
+	_, cs := newTestServer(t)
+
+	_, err := cs.HTTPSEdges().Create(ctx, &ngrok.HTTPSEdgeCreate{Hostports: []string{"example.com:443"}})
+	assert.True(t, ngrok.IsErrorCode(err, 7117), "edges can only serve reserved domains")
+
+	domain, err := cs.Domains().Create(ctx, &ngrok.ReservedDomainCreate{Domain: "example.com"})
+	require.NoError(t, err)
+	edge, err := cs.HTTPSEdges().Create(ctx, &ngrok.HTTPSEdgeCreate{Hostports: []string{"example.com:443"}})
+	require.NoError(t, err)
+
+	err = cs.Domains().Delete(ctx, domain.ID)
+	assert.True(t, ngrok.IsErrorCode(err, 446), "domains can't be deleted while an edge uses them")
+
+	backend, err := cs.TunnelGroupBackends().Create(ctx, &ngrok.TunnelGroupBackendCreate{Labels: map[string]string{"k8s.ngrok.com/service": "web"}})
+	require.NoError(t, err)
+	route, err := cs.HTTPSEdgeRoutes().Create(ctx, &ngrok.HTTPSEdgeRouteCreate{
+		EdgeID:    edge.ID,
+		MatchType: "path_prefix",
+		Match:     "/",
+		Backend:   &ngrok.EndpointBackendMutate{BackendID: backend.ID},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, route.Backend)
+	assert.Equal(t, backend.ID, route.Backend.Backend.ID)
+
+	_, err = cs.EdgeModules().HTTPS().Routes().Compression().Replace(ctx, &ngrok.EdgeRouteCompressionReplace{
+		EdgeID: edge.ID,
+		ID:     route.ID,
+		Module: ngrok.EndpointCompression{Enabled: ptr.To(true)},
+	})
+	require.NoError(t, err)
+	_, err = cs.EdgeModules().HTTPS().Routes().Backend().Replace(ctx, &ngrok.EdgeRouteBackendReplace{
+		EdgeID: edge.ID,
+		ID:     route.ID,
+		Module: ngrok.EndpointBackendMutate{BackendID: "bkdtg_missing"},
+	})
+	assert.Error(t, err, "route backends must exist")
+
+	got, err := cs.HTTPSEdges().Get(ctx, edge.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Routes, 1)
+	require.NotNil(t, got.Routes[0].Compression)
+	assert.Equal(t, backend.ID, got.Routes[0].Backend.Backend.ID)
+
+	require.NoError(t, cs.EdgeModules().HTTPS().Routes().Compression().Delete(ctx, &ngrok.EdgeRouteItem{EdgeID: edge.ID, ID: route.ID}))
+	_, err = cs.EdgeModules().HTTPS().Routes().Compression().Get(ctx, &ngrok.EdgeRouteItem{EdgeID: edge.ID, ID: route.ID})
+	assert.True(t, ngrok.IsNotFound(err))
+
+	_, err = cs.EdgeModules().HTTPS().TLSTermination().Replace(ctx, &ngrok.EdgeTLSTerminationAtEdgeReplace{
+		ID:     edge.ID,
+		Module: ngrok.EndpointTLSTerminationAtEdge{MinVersion: ptr.To("1.3")},
+	})
+	require.NoError(t, err)
+	got, err = cs.HTTPSEdges().Get(ctx, edge.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.TlsTermination)
+	assert.Equal(t, "edge", got.TlsTermination.TerminateAt)
+
+	require.NoError(t, cs.HTTPSEdgeRoutes().Delete(ctx, &ngrok.EdgeRouteItem{EdgeID: edge.ID, ID: route.ID}))
+	require.NoError(t, cs.HTTPSEdges().Delete(ctx, edge.ID))
+	require.NoError(t, cs.Domains().Delete(ctx, domain.ID))
+}
+
+func TestTCPEdges(t *testing.T) {
+	ctx := context.Background()
+	_, cs := newTestServer(t)
+
+	_, err := cs.TCPEdges().Create(ctx, &ngrok.TCPEdgeCreate{Hostports: []string{"1.tcp.ngrok.io:12345"}})
+	assert.Error(t, err, "TCP edges can only serve reserved addrs")
+
+	addr, err := cs.TCPAddresses().Create(ctx, &ngrok.ReservedAddrCreate{})
+	require.NoError(t, err)
+	edge, err := cs.TCPEdges().Create(ctx, &ngrok.TCPEdgeCreate{Hostports: []string{addr.Addr}})
+	require.NoError(t, err)
+
+	policy, err := cs.IPPolicies().Create(ctx, &ngrok.IPPolicyCreate{})
+	require.NoError(t, err)
+	_, err = cs.EdgeModules().TCP().IPRestriction().Replace(ctx, &ngrok.EdgeIPRestrictionReplace{
+		ID:     edge.ID,
+		Module: ngrok.EndpointIPPolicyMutate{IPPolicyIDs: []string{policy.ID}},
+	})
+	require.NoError(t, err)
+
+	got, err := cs.TCPEdges().Get(ctx, edge.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.IpRestriction)
+	require.Len(t, got.IpRestriction.IPPolicies, 1)
+	assert.Equal(t, policy.ID, got.IpRestriction.IPPolicies[0].ID)
+}
+
+func TestIPPolicies(t *testing.T) {
+	ctx := context.Background()
+	_, cs := newTestServer(t)
+
+	policy, err := cs.IPPolicies().Create(ctx, &ngrok.IPPolicyCreate{Description: "office"})
+	require.NoError(t, err)
+
+	_, err = cs.IPPolicyRules().Create(ctx, &ngrok.IPPolicyRuleCreate{IPPolicyID: policy.ID, CIDR: "not-a-cidr"})
+	assert.Error(t, err)
+
+	rule, err := cs.IPPolicyRules().Create(ctx, &ngrok.IPPolicyRuleCreate{IPPolicyID: policy.ID, CIDR: "10.0.0.0/8"})
+	require.NoError(t, err)
+	assert.Equal(t, "allow", rule.Action)
+	assert.Equal(t, policy.ID, rule.IPPolicy.ID)
+
+	require.NoError(t, cs.IPPolicies().Delete(ctx, policy.ID))
+	_, err = cs.IPPolicyRules().Get(ctx, rule.ID)
+	assert.True(t, ngrok.IsNotFound(err), "the rules are deleted with their policy")
+}
+
+func TestKubernetesOperators(t *testing.T) {
+	ctx := context.Background()
+	s, cs := newTestServer(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "operator"}}, key)
+	require.NoError(t, err)
+
+	_, err = cs.KubernetesOperators().Create(ctx, &ngrok.KubernetesOperatorCreate{
+		Deployment: ngrok.KubernetesOperatorDeployment{Name: "ngrok-operator", Namespace: "ngrok"},
+		Binding:    &ngrok.KubernetesOperatorBindingCreate{Name: "k8s", CSR: "invalid"},
+	})
+	assert.Error(t, err)
+
+	operator, err := cs.KubernetesOperators().Create(ctx, &ngrok.KubernetesOperatorCreate{
+		EnabledFeatures: []string{"Bindings"},
+		Deployment:      ngrok.KubernetesOperatorDeployment{Name: "ngrok-operator", Namespace: "ngrok"},
+		Binding: &ngrok.KubernetesOperatorBindingCreate{
+			Name: "k8s",
+			CSR:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})),
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, operator.Binding)
+
+	block, _ := pem.Decode([]byte(operator.Binding.Cert.Cert))
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, "operator", cert.Subject.CommonName)
+	assert.True(t, key.PublicKey.Equal(cert.PublicKey), "the certificate is issued for the key of the CSR")
+
+	s.AddEndpoint(ngrok.Endpoint{URL: "https://web.ngrok.app", Type: "agent", Bindings: []string{"public"}})
+	bound := s.AddEndpoint(ngrok.Endpoint{URL: "http://web.default", Type: "agent", Bindings: []string{"kubernetes"}})
+	_, err = cs.Endpoints().Create(ctx, &ngrok.EndpointCreate{URL: "https://api.ngrok.app"})
+	require.NoError(t, err)
+
+	iter := cs.KubernetesOperators().GetBoundEndpoints(operator.ID, &ngrok.Paging{})
+	ids := []string{}
+	for iter.Next(ctx) {
+		ids = append(ids, iter.Item().ID)
+	}
+	require.NoError(t, iter.Err())
+	assert.Equal(t, []string{bound.ID}, ids)
+}
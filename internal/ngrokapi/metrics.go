@@ -0,0 +1,74 @@
+package ngrokapi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	apiRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "ngrok_api",
+		Name:      "requests_total",
+		Help:      "Number of requests made to the ngrok API, by clientset, method and status code. Requests that failed without a response have the code \"error\".",
+	}, []string{"clientset", "method", "code"})
+
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "ngrok_api",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the requests made to the ngrok API, by clientset and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"clientset", "method"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(apiRequestsTotal, apiRequestDuration)
+}
+
+// instrumentedConfig returns a copy of the config whose requests are counted and timed under the given clientset name.
+// When mw isn't nil, the requests also go through it, each attempt being recorded in the metrics.
+func instrumentedConfig(config *ngrok.ClientConfig, clientset string, mw *middleware) *ngrok.ClientConfig {
+	instrumented := *config
+
+	httpClient := http.DefaultClient
+	if config.HTTPClient != nil {
+		httpClient = config.HTTPClient
+	}
+	instrumentedClient := *httpClient
+	next := instrumentedClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	instrumentedClient.Transport = &metricsRoundTripper{next: next, clientset: clientset}
+	if mw != nil {
+		instrumentedClient.Transport = mw.roundTripper(instrumentedClient.Transport, clientset)
+	}
+	instrumented.HTTPClient = &instrumentedClient
+
+	return &instrumented
+}
+
+// metricsRoundTripper records the requests made through it in the ngrok API metrics
+type metricsRoundTripper struct {
+	next      http.RoundTripper
+	clientset string
+}
+
+func (rt *metricsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	apiRequestDuration.WithLabelValues(rt.clientset, req.Method).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	apiRequestsTotal.WithLabelValues(rt.clientset, req.Method, code).Inc()
+	return resp, err
+}
@@ -0,0 +1,52 @@
+package ngrokapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientsetRecordsAPIRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/reserved_domains/rd_limited" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error_code":"ERR_NGROK_226","status_code":429,"msg":"rate limited"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"rd_ok","domain":"example.com"}`))
+	}))
+	defer server.Close()
+
+	cs := NewClientSet(ngrok.NewClientConfig("api-key", ngrok.WithBaseURL(server.URL)))
+
+	okBefore := testutil.ToFloat64(apiRequestsTotal.WithLabelValues("domains", http.MethodGet, "200"))
+	limitedBefore := testutil.ToFloat64(apiRequestsTotal.WithLabelValues("domains", http.MethodGet, "429"))
+
+	_, err := cs.Domains().Get(context.Background(), "rd_ok")
+	require.NoError(t, err)
+	_, err = cs.Domains().Get(context.Background(), "rd_limited")
+	require.Error(t, err)
+
+	assert.Equal(t, okBefore+1, testutil.ToFloat64(apiRequestsTotal.WithLabelValues("domains", http.MethodGet, "200")))
+	assert.Equal(t, limitedBefore+1, testutil.ToFloat64(apiRequestsTotal.WithLabelValues("domains", http.MethodGet, "429")))
+}
+
+func TestInstrumentedConfigKeepsTheOriginalConfig(t *testing.T) {
+	httpClient := &http.Client{}
+	config := ngrok.NewClientConfig("api-key", ngrok.WithHTTPClient(httpClient))
+
+	instrumented := instrumentedConfig(config, "domains", nil)
+
+	assert.Same(t, httpClient, config.HTTPClient)
+	assert.Nil(t, httpClient.Transport)
+	assert.NotSame(t, httpClient, instrumented.HTTPClient)
+	assert.IsType(t, &metricsRoundTripper{}, instrumented.HTTPClient.Transport)
+	assert.Equal(t, config.APIKey, instrumented.APIKey)
+}
@@ -0,0 +1,331 @@
+package ngrokapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+)
+
+// CorrelationIDHeader is the header carrying the ID shared by all the attempts of a request to the ngrok API, so
+// that they can be matched with the logs of the operator.
+const CorrelationIDHeader = "X-Correlation-Id"
+
+// ErrCircuitOpen is returned for the requests made while the circuit breaker is open, after too many consecutive
+// requests to the ngrok API failed.
+var ErrCircuitOpen = errors.New("ngrok API circuit breaker is open")
+
+// ClientsetOpt configures the middleware the requests of the clients of a clientset go through
+type ClientsetOpt func(*middleware)
+
+// WithLogger logs the requests to the ngrok API, with their correlation IDs, to the given logger
+func WithLogger(log logr.Logger) ClientsetOpt {
+	return func(m *middleware) {
+		m.log = log
+	}
+}
+
+// WithRateLimit limits the requests of all the clients of the clientset to requestsPerSecond, with bursts of up to
+// burst requests. A limit of 0 or less disables the limiter.
+func WithRateLimit(requestsPerSecond float64, burst int) ClientsetOpt {
+	return func(m *middleware) {
+		if requestsPerSecond <= 0 {
+			m.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		m.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	}
+}
+
+// WithRetries retries the requests rate limited by the ngrok API, and the idempotent ones that failed with a server
+// error, up to maxRetries times. Retries wait for an exponential backoff from baseDelay with full jitter, or for the
+// Retry-After of the response when it has one. Requests asking to wait for longer than maxDelay aren't retried, so
+// that they're requeued instead of blocking a reconcile.
+func WithRetries(maxRetries int, baseDelay, maxDelay time.Duration) ClientsetOpt {
+	return func(m *middleware) {
+		m.maxRetries = maxRetries
+		m.baseDelay = baseDelay
+		m.maxDelay = maxDelay
+	}
+}
+
+// WithCircuitBreaker fails the requests fast with ErrCircuitOpen for cooldown once threshold consecutive requests
+// failed with a server or network error. A single request is then let through, closing the breaker if it succeeds. A
+// threshold of 0 or less disables the circuit breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientsetOpt {
+	return func(m *middleware) {
+		if threshold <= 0 {
+			m.breaker = nil
+			return
+		}
+		m.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+	}
+}
+
+// middleware holds the state shared by the clients of a clientset: its limiter and circuit breaker protect the
+// quota of the account, whatever the API the requests are for.
+type middleware struct {
+	log        logr.Logger
+	limiter    *rate.Limiter
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	breaker    *circuitBreaker
+}
+
+func newMiddleware(opts ...ClientsetOpt) *middleware {
+	m := &middleware{log: logr.Discard()}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.breaker != nil {
+		m.breaker.log = m.log
+	}
+	return m
+}
+
+// roundTripper returns a RoundTripper sending the requests of the given clientset to next through the middleware
+func (m *middleware) roundTripper(next http.RoundTripper, clientset string) http.RoundTripper {
+	return &middlewareRoundTripper{next: next, middleware: m, clientset: clientset}
+}
+
+type middlewareRoundTripper struct {
+	*middleware
+	next      http.RoundTripper
+	clientset string
+}
+
+func (rt *middlewareRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	id := req.Header.Get(CorrelationIDHeader)
+	if id == "" {
+		id = newCorrelationID()
+	}
+	log := rt.log.WithValues("clientset", rt.clientset, "correlationID", id, "method", req.Method, "path", req.URL.Path)
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req.Clone(ctx)
+		attemptReq.Header.Set(CorrelationIDHeader, id)
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+
+		start := time.Now()
+		resp, err := rt.attempt(attemptReq)
+		if err != nil {
+			log.V(3).Info("ngrok API request failed", "attempt", attempt, "duration", time.Since(start), "error", err.Error())
+		} else {
+			log.V(3).Info("ngrok API request", "attempt", attempt, "duration", time.Since(start), "code", resp.StatusCode)
+		}
+
+		if attempt >= rt.maxRetries || !isRetryable(req, resp, err) {
+			return resp, err
+		}
+		delay, ok := rt.retryDelay(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+
+		log.V(1).Info("Retrying ngrok API request", "attempt", attempt+1, "delay", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+}
+
+// attempt sends a single request, once the circuit breaker and the limiter let it through
+func (rt *middlewareRoundTripper) attempt(req *http.Request) (*http.Response, error) {
+	probe := false
+	if rt.breaker != nil {
+		var err error
+		if probe, err = rt.breaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+	if rt.limiter != nil {
+		if err := rt.limiter.Wait(req.Context()); err != nil {
+			if rt.breaker != nil {
+				rt.breaker.release(probe)
+			}
+			return nil, err
+		}
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	if rt.breaker != nil {
+		failed := (err != nil && req.Context().Err() == nil) || (resp != nil && resp.StatusCode >= http.StatusInternalServerError)
+		rt.breaker.record(probe, failed)
+	}
+	return resp, err
+}
+
+// retryDelay returns how long to wait before the retry following the given attempt, and false if the ngrok API asked
+// to wait for longer than the maximum delay
+func (m *middleware) retryDelay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if m.maxDelay > 0 && retryAfter > m.maxDelay {
+				return 0, false
+			}
+			// spread the retries of the requests rate limited together
+			return retryAfter + jitter(m.baseDelay), true
+		}
+	}
+
+	backoff := m.baseDelay
+	for i := 0; i < attempt && (m.maxDelay <= 0 || backoff < m.maxDelay); i++ {
+		backoff *= 2
+	}
+	if m.maxDelay > 0 && backoff > m.maxDelay {
+		backoff = m.maxDelay
+	}
+	return jitter(backoff), true
+}
+
+// isRetryable returns whether a request that ended with resp or err can be sent again. Rate limited requests weren't
+// processed and are always retryable, while the others are only retried if sending them twice is harmless.
+func isRetryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		if errors.Is(err, ErrCircuitOpen) || req.Context().Err() != nil {
+			return false
+		}
+		return isIdempotent(req.Method)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+	default:
+		return false
+	}
+}
+
+func isIdempotent(method string) bool {
+	return method != http.MethodPost
+}
+
+// parseRetryAfter parses a Retry-After header, either a number of seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// jitter returns a random duration between 0 and d
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(mathrand.Int63n(int64(d) + 1))
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// circuitBreaker counts the consecutive failed requests and opens once they reach the threshold
+type circuitBreaker struct {
+	log       logr.Logger
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow returns ErrCircuitOpen if the breaker is open, and whether the request is the probe of a breaker whose
+// cooldown elapsed
+func (b *circuitBreaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return false, nil
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false, ErrCircuitOpen
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record records the outcome of a request the breaker let through
+func (b *circuitBreaker) record(probe, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	if !failed {
+		if b.failures >= b.threshold {
+			b.log.Info("ngrok API circuit breaker closed")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold || probe {
+			b.log.Info("ngrok API circuit breaker opened", "failures", b.failures, "cooldown", b.cooldown)
+		}
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// release gives up the probe of a request that wasn't sent
+func (b *circuitBreaker) release(probe bool) {
+	if !probe {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
@@ -0,0 +1,216 @@
+package ngrokapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ngrok/ngrok-api-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingServer answers the requests with the statuses in order, then with 200, and records the requests it got
+type recordingServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	requests   []*http.Request
+	bodies     []string
+}
+
+func newRecordingServer(t *testing.T, statuses ...int) *recordingServer {
+	s := &recordingServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, string(body))
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if status != http.StatusOK {
+			if s.retryAfter != "" {
+				w.Header().Set("Retry-After", s.retryAfter)
+			}
+			w.WriteHeader(status)
+			_, _ = fmt.Fprintf(w, `{"status_code":%d,"msg":"failed"}`, status)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"rd_123","domain":"example.com"}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *recordingServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+func (s *recordingServer) clientset(opts ...ClientsetOpt) *DefaultClientset {
+	return NewClientSet(ngrok.NewClientConfig("api-key", ngrok.WithBaseURL(s.URL)), opts...)
+}
+
+func TestMiddlewareRetriesRateLimitedRequests(t *testing.T) {
+	server := newRecordingServer(t, http.StatusTooManyRequests, http.StatusTooManyRequests)
+	server.retryAfter = "0"
+	cs := server.clientset(WithRetries(3, time.Millisecond, time.Second))
+
+	_, err := cs.Domains().Create(context.Background(), &ngrok.ReservedDomainCreate{Domain: "example.com"})
+	require.NoError(t, err)
+
+	require.Equal(t, 3, server.count())
+	id := server.requests[0].Header.Get(CorrelationIDHeader)
+	assert.NotEmpty(t, id)
+	for i, req := range server.requests {
+		assert.Equal(t, id, req.Header.Get(CorrelationIDHeader), "the attempts of a request share its correlation ID")
+		assert.Equal(t, server.bodies[0], server.bodies[i], "the body is sent again with each attempt")
+	}
+	assert.Contains(t, server.bodies[0], "example.com")
+}
+
+func TestMiddlewareGivesUpAfterMaxRetries(t *testing.T) {
+	server := newRecordingServer(t, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests)
+	cs := server.clientset(WithRetries(1, time.Millisecond, time.Second))
+
+	_, err := cs.Domains().Get(context.Background(), "rd_123")
+	require.Error(t, err)
+	var apiErr *ngrok.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.EqualValues(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, 2, server.count())
+}
+
+func TestMiddlewareDoesNotWaitForLongRetryAfter(t *testing.T) {
+	server := newRecordingServer(t, http.StatusTooManyRequests)
+	server.retryAfter = "120"
+	cs := server.clientset(WithRetries(3, time.Millisecond, time.Second))
+
+	_, err := cs.Domains().Get(context.Background(), "rd_123")
+	require.Error(t, err)
+	assert.Equal(t, 1, server.count())
+}
+
+func TestMiddlewareOnlyRetriesIdempotentRequestsOnServerErrors(t *testing.T) {
+	server := newRecordingServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	cs := server.clientset(WithRetries(3, time.Millisecond, time.Second))
+
+	_, err := cs.Domains().Create(context.Background(), &ngrok.ReservedDomainCreate{Domain: "example.com"})
+	require.Error(t, err)
+	assert.Equal(t, 1, server.count())
+
+	_, err = cs.Domains().Get(context.Background(), "rd_123")
+	require.NoError(t, err)
+	assert.Equal(t, 3, server.count())
+}
+
+func TestMiddlewareDoesNotRetryByDefault(t *testing.T) {
+	server := newRecordingServer(t, http.StatusTooManyRequests)
+	cs := server.clientset()
+
+	_, err := cs.Domains().Get(context.Background(), "rd_123")
+	require.Error(t, err)
+	assert.Equal(t, 1, server.count())
+	assert.NotEmpty(t, server.requests[0].Header.Get(CorrelationIDHeader))
+}
+
+func TestMiddlewareRateLimitIsSharedByTheClients(t *testing.T) {
+	server := newRecordingServer(t)
+	cs := server.clientset(WithRateLimit(0.001, 1))
+
+	_, err := cs.Domains().Get(context.Background(), "rd_123")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = cs.HTTPSEdges().Get(ctx, "edghts_123")
+	require.Error(t, err)
+	assert.Equal(t, 1, server.count(), "the request waiting for the limiter isn't sent")
+}
+
+func TestMiddlewareCircuitBreaker(t *testing.T) {
+	server := newRecordingServer(t, http.StatusInternalServerError, http.StatusInternalServerError)
+	cs := server.clientset(WithCircuitBreaker(2, time.Minute))
+
+	for i := 0; i < 2; i++ {
+		_, err := cs.Domains().Get(context.Background(), "rd_123")
+		require.Error(t, err)
+	}
+
+	_, err := cs.HTTPSEdges().Get(context.Background(), "edghts_123")
+	assert.ErrorIs(t, err, ErrCircuitOpen, "the breaker is shared by the clients")
+	assert.Equal(t, 2, server.count())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := &circuitBreaker{threshold: 2, cooldown: time.Minute, now: func() time.Time { return now }}
+
+	for i := 0; i < 2; i++ {
+		probe, err := b.allow()
+		require.NoError(t, err)
+		b.record(probe, true)
+	}
+
+	_, err := b.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen, "the breaker opens once the threshold is reached")
+
+	now = now.Add(time.Minute)
+	probe, err := b.allow()
+	require.NoError(t, err)
+	assert.True(t, probe, "a single request is let through once the cooldown elapsed")
+	_, err = b.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen, "the other requests fail fast while the probe is running")
+
+	b.record(probe, true)
+	_, err = b.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen, "a failed probe opens the breaker again")
+
+	now = now.Add(time.Minute)
+	probe, err = b.allow()
+	require.NoError(t, err)
+	b.record(probe, false)
+	probe, err = b.allow()
+	require.NoError(t, err, "a successful probe closes the breaker")
+	assert.False(t, probe)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		delay  time.Duration
+		wantOK bool
+	}{
+		{name: "empty", value: ""},
+		{name: "seconds", value: "3", delay: 3 * time.Second, wantOK: true},
+		{name: "negative seconds", value: "-1"},
+		{name: "date", value: now.Add(10 * time.Second).Format(http.TimeFormat), delay: 10 * time.Second, wantOK: true},
+		{name: "past date", value: now.Add(-time.Minute).Format(http.TimeFormat), delay: 0, wantOK: true},
+		{name: "invalid", value: "soon"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delay, ok := parseRetryAfter(test.value, now)
+			assert.Equal(t, test.wantOK, ok)
+			assert.Equal(t, test.delay, delay)
+		})
+	}
+}
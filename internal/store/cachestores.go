@@ -26,6 +26,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/cache"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 // CacheStores stores cache.Store for all Kinds of k8s objects that
@@ -35,11 +37,17 @@ type CacheStores struct {
 	IngressV1      cache.Store
 	IngressClassV1 cache.Store
 	ServiceV1      cache.Store
+	NamespaceV1    cache.Store
 
 	// Gateway API Stores
 	Gateway      cache.Store
 	GatewayClass cache.Store
 	HTTPRoute    cache.Store
+	TCPRoute     cache.Store
+	TLSRoute     cache.Store
+	GRPCRoute    cache.Store
+	// ReferenceGrant is served by the v1beta1 API only
+	ReferenceGrant cache.Store
 
 	// Ngrok Stores
 	DomainV1             cache.Store
@@ -47,6 +55,11 @@ type CacheStores struct {
 	HTTPSEdgeV1          cache.Store
 	NgrokModuleV1        cache.Store
 	NgrokTrafficPolicyV1 cache.Store
+	CloudEndpointV1      cache.Store
+
+	// onChange is called with the previous version of the objects added to or deleted from the stores, and with their
+	// new version, or nil when they're deleted
+	onChange func(old, obj runtime.Object)
 
 	log logr.Logger
 	l   *sync.RWMutex
@@ -59,16 +72,22 @@ func NewCacheStores(logger logr.Logger) CacheStores {
 		IngressV1:      cache.NewStore(keyFunc),
 		IngressClassV1: cache.NewStore(clusterResourceKeyFunc),
 		ServiceV1:      cache.NewStore(keyFunc),
+		NamespaceV1:    cache.NewStore(clusterResourceKeyFunc),
 		// Gateway API Stores
-		Gateway:      cache.NewStore(keyFunc),
-		GatewayClass: cache.NewStore(keyFunc),
-		HTTPRoute:    cache.NewStore(keyFunc),
+		Gateway:        cache.NewStore(keyFunc),
+		GatewayClass:   cache.NewStore(clusterResourceKeyFunc),
+		HTTPRoute:      cache.NewStore(keyFunc),
+		TCPRoute:       cache.NewStore(keyFunc),
+		TLSRoute:       cache.NewStore(keyFunc),
+		GRPCRoute:      cache.NewStore(keyFunc),
+		ReferenceGrant: cache.NewStore(keyFunc),
 		// Ngrok Stores
 		DomainV1:             cache.NewStore(keyFunc),
 		TunnelV1:             cache.NewStore(keyFunc),
 		HTTPSEdgeV1:          cache.NewStore(keyFunc),
 		NgrokModuleV1:        cache.NewStore(keyFunc),
 		NgrokTrafficPolicyV1: cache.NewStore(keyFunc),
+		CloudEndpointV1:      cache.NewStore(keyFunc),
 		l:                    &sync.RWMutex{},
 		log:                  logger,
 	}
@@ -95,7 +114,10 @@ func clusterResourceKeyFunc(obj interface{}) (string, error) {
 func (c CacheStores) Get(obj runtime.Object) (item interface{}, exists bool, err error) {
 	c.l.RLock()
 	defer c.l.RUnlock()
+	return c.get(obj)
+}
 
+func (c CacheStores) get(obj runtime.Object) (item interface{}, exists bool, err error) {
 	switch obj := obj.(type) {
 	// ----------------------------------------------------------------------------
 	// Kubernetes Core API Support
@@ -106,12 +128,22 @@ func (c CacheStores) Get(obj runtime.Object) (item interface{}, exists bool, err
 		return c.IngressClassV1.Get(obj)
 	case *corev1.Service:
 		return c.ServiceV1.Get(obj)
+	case *corev1.Namespace:
+		return c.NamespaceV1.Get(obj)
 
 	// ----------------------------------------------------------------------------
 	// Kubernetes Gateway API Support
 	// ----------------------------------------------------------------------------
 	case *gatewayv1.HTTPRoute:
 		return c.HTTPRoute.Get(obj)
+	case *gatewayv1alpha2.TCPRoute:
+		return c.TCPRoute.Get(obj)
+	case *gatewayv1alpha2.TLSRoute:
+		return c.TLSRoute.Get(obj)
+	case *gatewayv1alpha2.GRPCRoute:
+		return c.GRPCRoute.Get(obj)
+	case *gatewayv1beta1.ReferenceGrant:
+		return c.ReferenceGrant.Get(obj)
 	case *gatewayv1.Gateway:
 		return c.Gateway.Get(obj)
 	case *gatewayv1.GatewayClass:
@@ -130,6 +162,8 @@ func (c CacheStores) Get(obj runtime.Object) (item interface{}, exists bool, err
 		return c.NgrokModuleV1.Get(obj)
 	case *ngrokv1alpha1.NgrokTrafficPolicy:
 		return c.NgrokTrafficPolicyV1.Get(obj)
+	case *ngrokv1alpha1.CloudEndpoint:
+		return c.CloudEndpointV1.Get(obj)
 	default:
 		return nil, false, fmt.Errorf("unsupported object type: %T", obj)
 	}
@@ -141,6 +175,18 @@ func (c CacheStores) Add(obj runtime.Object) error {
 	c.l.Lock()
 	defer c.l.Unlock()
 
+	old, _, _ := c.get(obj)
+	if err := c.add(obj); err != nil {
+		return err
+	}
+	if c.onChange != nil {
+		oldObj, _ := old.(runtime.Object)
+		c.onChange(oldObj, obj)
+	}
+	return nil
+}
+
+func (c CacheStores) add(obj runtime.Object) error {
 	switch obj := obj.(type) {
 	// ----------------------------------------------------------------------------
 	// Kubernetes Core API Support
@@ -151,12 +197,22 @@ func (c CacheStores) Add(obj runtime.Object) error {
 		return c.IngressClassV1.Add(obj)
 	case *corev1.Service:
 		return c.ServiceV1.Add(obj)
+	case *corev1.Namespace:
+		return c.NamespaceV1.Add(obj)
 
 	// ----------------------------------------------------------------------------
 	// Kubernetes Gateway API Support
 	// ----------------------------------------------------------------------------
 	case *gatewayv1.HTTPRoute:
 		return c.HTTPRoute.Add(obj)
+	case *gatewayv1alpha2.TCPRoute:
+		return c.TCPRoute.Add(obj)
+	case *gatewayv1alpha2.TLSRoute:
+		return c.TLSRoute.Add(obj)
+	case *gatewayv1alpha2.GRPCRoute:
+		return c.GRPCRoute.Add(obj)
+	case *gatewayv1beta1.ReferenceGrant:
+		return c.ReferenceGrant.Add(obj)
 	case *gatewayv1.Gateway:
 		return c.Gateway.Add(obj)
 	case *gatewayv1.GatewayClass:
@@ -175,6 +231,8 @@ func (c CacheStores) Add(obj runtime.Object) error {
 		return c.NgrokModuleV1.Add(obj)
 	case *ngrokv1alpha1.NgrokTrafficPolicy:
 		return c.NgrokTrafficPolicyV1.Add(obj)
+	case *ngrokv1alpha1.CloudEndpoint:
+		return c.CloudEndpointV1.Add(obj)
 
 	default:
 		return fmt.Errorf("unsupported object type: %T", obj)
@@ -187,6 +245,17 @@ func (c CacheStores) Delete(obj runtime.Object) error {
 	c.l.Lock()
 	defer c.l.Unlock()
 
+	old, exists, _ := c.get(obj)
+	if err := c.remove(obj); err != nil {
+		return err
+	}
+	if oldObj, ok := old.(runtime.Object); exists && ok && c.onChange != nil {
+		c.onChange(oldObj, nil)
+	}
+	return nil
+}
+
+func (c CacheStores) remove(obj runtime.Object) error {
 	switch obj := obj.(type) {
 	// ----------------------------------------------------------------------------
 	// Kubernetes Core API Support
@@ -197,12 +266,22 @@ func (c CacheStores) Delete(obj runtime.Object) error {
 		return c.IngressClassV1.Delete(obj)
 	case *corev1.Service:
 		return c.ServiceV1.Delete(obj)
+	case *corev1.Namespace:
+		return c.NamespaceV1.Delete(obj)
 
 	// ----------------------------------------------------------------------------
 	// Kubernetes Gateway API Support
 	// ----------------------------------------------------------------------------
 	case *gatewayv1.HTTPRoute:
 		return c.HTTPRoute.Delete(obj)
+	case *gatewayv1alpha2.TCPRoute:
+		return c.TCPRoute.Delete(obj)
+	case *gatewayv1alpha2.TLSRoute:
+		return c.TLSRoute.Delete(obj)
+	case *gatewayv1alpha2.GRPCRoute:
+		return c.GRPCRoute.Delete(obj)
+	case *gatewayv1beta1.ReferenceGrant:
+		return c.ReferenceGrant.Delete(obj)
 	case *gatewayv1.Gateway:
 		return c.Gateway.Delete(obj)
 	case *gatewayv1.GatewayClass:
@@ -221,6 +300,8 @@ func (c CacheStores) Delete(obj runtime.Object) error {
 		return c.NgrokModuleV1.Delete(obj)
 	case *ngrokv1alpha1.NgrokTrafficPolicy:
 		return c.NgrokTrafficPolicyV1.Delete(obj)
+	case *ngrokv1alpha1.CloudEndpoint:
+		return c.CloudEndpointV1.Delete(obj)
 	default:
 		return fmt.Errorf("unsupported object type: %T", obj)
 	}
@@ -5,20 +5,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math"
+	"net"
+	"net/url"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
 	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
@@ -31,6 +42,9 @@ import (
 
 const defaultClusterDomain = "svc.cluster.local"
 
+// GatewayControllerName is the controller name GatewayClasses must reference to be handled by the operator
+const GatewayControllerName gatewayv1.GatewayController = "ngrok.com/gateway-controller"
+
 const (
 	labelControllerNamespace = "k8s.ngrok.com/controller-namespace"
 	labelControllerName      = "k8s.ngrok.com/controller-name"
@@ -38,8 +52,15 @@ const (
 	labelServiceUID          = "k8s.ngrok.com/service-uid"
 	labelService             = "k8s.ngrok.com/service"
 	labelPort                = "k8s.ngrok.com/port"
+	labelWeightedGroup       = "k8s.ngrok.com/weighted-group"
+	labelWeightedReplica     = "k8s.ngrok.com/weighted-replica"
+	labelServiceNamespace    = "k8s.ngrok.com/service-namespace"
+	labelAppProtocol         = "k8s.ngrok.com/app-protocol"
 )
 
+// annotationRouteConflicts is set on ingresses whose routes are shadowed by the same routes of older ingresses
+const annotationRouteConflicts = "k8s.ngrok.com/route-conflicts"
+
 // Driver maintains the store of information, can derive new information from the store, and can
 // synchronize the desired state of the store to the actual state of the cluster.
 type Driver struct {
@@ -52,14 +73,35 @@ type Driver struct {
 	gatewayNgrokMetadata string
 	managerName          types.NamespacedName
 	clusterDomain        string
+	recorder             record.EventRecorder
+	syncErrors           *syncErrors
+	dryRun               bool
+
+	lastSyncDiffMu sync.Mutex
+	lastSyncDiff   SyncDiff
+
+	// ingressRouteConflicts are the conflicts between the routes of the ingresses found by the last sync, reported
+	// on the ingresses whenever their statuses are updated
+	ingressRouteConflictsMu sync.Mutex
+	ingressRouteConflicts   map[types.NamespacedName][]ingressRouteConflict
 
 	syncMu              sync.Mutex
 	syncRunning         bool
+	syncRunningPartial  bool
+	syncDebouncing      bool
+	syncResult          *syncResult
 	syncFullCh          chan error
 	syncPartialCh       chan error
 	syncAllowConcurrent bool
+	syncDebounce        time.Duration
+
+	syncTracker     *syncTracker
+	incrementalSync bool
 
 	gatewayEnabled bool
+
+	// mappingStrategy is the mapping strategy of the ingresses whose IngressClass doesn't set one
+	mappingStrategy IngressMappingStrategy
 }
 
 type DriverOpt func(*Driver)
@@ -82,9 +124,19 @@ func WithClusterDomain(domain string) DriverOpt {
 	}
 }
 
+// WithEventRecorder sets the recorder used to emit events about the resources the driver handles, such as
+// ingresses whose routes are shadowed by older ingresses
+func WithEventRecorder(recorder record.EventRecorder) DriverOpt {
+	return func(d *Driver) {
+		d.recorder = recorder
+	}
+}
+
 // NewDriver creates a new driver with a basic logger and cache store setup
 func NewDriver(logger logr.Logger, scheme *runtime.Scheme, controllerName string, managerName types.NamespacedName, opts ...DriverOpt) *Driver {
+	tracker := newSyncTracker()
 	cacheStores := NewCacheStores(logger)
+	cacheStores.onChange = tracker.observe
 	s := New(cacheStores, controllerName, logger)
 	d := &Driver{
 		store:          s,
@@ -94,11 +146,19 @@ func NewDriver(logger logr.Logger, scheme *runtime.Scheme, controllerName string
 		managerName:    managerName,
 		gatewayEnabled: false,
 		clusterDomain:  defaultClusterDomain,
+		syncErrors:     newSyncErrors(),
+		syncTracker:    tracker,
+
+		mappingStrategy: IngressMappingStrategyEdges,
 	}
 
 	for _, opt := range opts {
 		opt(d)
 	}
+	if d.dryRun {
+		// events are written to the cluster too
+		d.recorder = nil
+	}
 	return d
 }
 
@@ -139,6 +199,28 @@ func (d *Driver) setNgrokMetadataOwner(owner string, customNgrokMetadata map[str
 	return string(jsonString), nil
 }
 
+// OwnerMetadata returns the metadata marking the ngrok resources created for the driver's resources as owned by the
+// operator, one per owner. Without custom metadata, the resources get the default metadata of the CRDs.
+func (d *Driver) OwnerMetadata() []map[string]string {
+	owners := []string{d.ingressNgrokMetadata}
+	if d.gatewayEnabled {
+		owners = append(owners, d.gatewayNgrokMetadata)
+	}
+
+	ownerMetadata := []map[string]string{}
+	for _, owner := range owners {
+		metadata := map[string]string{}
+		if owner == "" {
+			metadata["owned-by"] = "kubernetes-ingress-controller"
+		} else if err := json.Unmarshal([]byte(owner), &metadata); err != nil {
+			d.log.Error(err, "error unmarshalling ngrok metadata", "metadata", owner)
+			continue
+		}
+		ownerMetadata = append(ownerMetadata, metadata)
+	}
+	return ownerMetadata
+}
+
 func listObjectsForType(ctx context.Context, client client.Reader, v interface{}) ([]client.Object, error) {
 	switch v.(type) {
 
@@ -157,6 +239,10 @@ func listObjectsForType(ctx context.Context, client client.Reader, v interface{}
 		ingressClasses := &netv1.IngressClassList{}
 		err := client.List(ctx, ingressClasses)
 		return util.ToClientObjects(ingressClasses.Items), err
+	case *corev1.Namespace:
+		namespaces := &corev1.NamespaceList{}
+		err := client.List(ctx, namespaces)
+		return util.ToClientObjects(namespaces.Items), err
 
 	// ----------------------------------------------------------------------------
 	// Kubernetes Gateway API Support
@@ -173,6 +259,22 @@ func listObjectsForType(ctx context.Context, client client.Reader, v interface{}
 		httproutes := &gatewayv1.HTTPRouteList{}
 		err := client.List(ctx, httproutes)
 		return util.ToClientObjects(httproutes.Items), err
+	case *gatewayv1alpha2.TCPRoute:
+		tcproutes := &gatewayv1alpha2.TCPRouteList{}
+		err := client.List(ctx, tcproutes)
+		return util.ToClientObjects(tcproutes.Items), err
+	case *gatewayv1alpha2.TLSRoute:
+		tlsroutes := &gatewayv1alpha2.TLSRouteList{}
+		err := client.List(ctx, tlsroutes)
+		return util.ToClientObjects(tlsroutes.Items), err
+	case *gatewayv1alpha2.GRPCRoute:
+		grpcroutes := &gatewayv1alpha2.GRPCRouteList{}
+		err := client.List(ctx, grpcroutes)
+		return util.ToClientObjects(grpcroutes.Items), err
+	case *gatewayv1beta1.ReferenceGrant:
+		grants := &gatewayv1beta1.ReferenceGrantList{}
+		err := client.List(ctx, grants)
+		return util.ToClientObjects(grants.Items), err
 
 	// ----------------------------------------------------------------------------
 	// Ngrok API Support
@@ -197,6 +299,10 @@ func listObjectsForType(ctx context.Context, client client.Reader, v interface{}
 		policies := &ngrokv1alpha1.NgrokTrafficPolicyList{}
 		err := client.List(ctx, policies)
 		return util.ToClientObjects(policies.Items), err
+	case *ngrokv1alpha1.CloudEndpoint:
+		endpoints := &ngrokv1alpha1.CloudEndpointList{}
+		err := client.List(ctx, endpoints)
+		return util.ToClientObjects(endpoints.Items), err
 	}
 	return nil, fmt.Errorf("unsupported type %T", v)
 }
@@ -208,12 +314,18 @@ func listObjectsForType(ctx context.Context, client client.Reader, v interface{}
 // - IngressClasses
 // - Gateways
 // - HTTPRoutes
+// - TCPRoutes
+// - TLSRoutes
+// - GRPCRoutes
+// - ReferenceGrants
+// - Namespaces
 // - Services
 // - Domains
 // - Edges
 // - Tunnels
 // - ModuleSets
 // - TrafficPolicies
+// - CloudEndpoints
 // When the sync method becomes a background process, this likely won't be needed anymore
 func (d *Driver) Seed(ctx context.Context, c client.Reader) error {
 	typesToSeed := []interface{}{
@@ -226,23 +338,43 @@ func (d *Driver) Seed(ctx context.Context, c client.Reader) error {
 		&ingressv1alpha1.Tunnel{},
 		&ingressv1alpha1.NgrokModuleSet{},
 		&ngrokv1alpha1.NgrokTrafficPolicy{},
+		&ngrokv1alpha1.CloudEndpoint{},
 	}
 
 	if d.gatewayEnabled {
 		typesToSeed = append(typesToSeed,
-			&gatewayv1.Gateway{},
+			// GatewayClasses are seeded before the Gateways to skip the ones of other controllers
 			&gatewayv1.GatewayClass{},
+			&gatewayv1.Gateway{},
 			&gatewayv1.HTTPRoute{},
+			&gatewayv1alpha2.TCPRoute{},
+			&gatewayv1alpha2.TLSRoute{},
+			&gatewayv1alpha2.GRPCRoute{},
+			&gatewayv1beta1.ReferenceGrant{},
+			// Namespaces are only needed to evaluate the namespace selectors of Gateway listeners
+			&corev1.Namespace{},
 		)
 	}
 
 	for _, v := range typesToSeed {
 		objects, err := listObjectsForType(ctx, c, v)
 		if err != nil {
+			// TCPRoutes, TLSRoutes and GRPCRoutes are part of the experimental channel of the Gateway API,
+			// so their CRDs may not be installed even when the rest of the Gateway API is
+			switch v.(type) {
+			case *gatewayv1alpha2.TCPRoute, *gatewayv1alpha2.TLSRoute, *gatewayv1alpha2.GRPCRoute:
+				if meta.IsNoMatchError(err) {
+					d.log.Info("CRD is not installed, skipping seeding", "type", fmt.Sprintf("%T", v))
+					continue
+				}
+			}
 			return err
 		}
 
 		for _, obj := range objects {
+			if gtw, ok := obj.(*gatewayv1.Gateway); ok && !d.handlesGatewayClass(gtw.Spec.GatewayClassName) {
+				continue
+			}
 			if err := d.store.Update(obj); err != nil {
 				return err
 			}
@@ -251,6 +383,16 @@ func (d *Driver) Seed(ctx context.Context, c client.Reader) error {
 	return nil
 }
 
+// handlesGatewayClass checks if the GatewayClass is in the store and handled by the ngrok gateway controller
+func (d *Driver) handlesGatewayClass(name gatewayv1.ObjectName) bool {
+	item, exists, err := d.store.Get(&gatewayv1.GatewayClass{ObjectMeta: metav1.ObjectMeta{Name: string(name)}})
+	if err != nil || !exists {
+		return false
+	}
+	gatewayClass, ok := item.(*gatewayv1.GatewayClass)
+	return ok && gatewayClass.Spec.ControllerName == GatewayControllerName
+}
+
 func (d *Driver) PrintState(setupLog logr.Logger) {
 	ings := d.store.ListNgrokIngressesV1()
 	for _, ing := range ings {
@@ -293,6 +435,35 @@ func (d *Driver) UpdateHTTPRoute(httproute *gatewayv1.HTTPRoute) (*gatewayv1.HTT
 	return d.store.GetHTTPRoute(httproute.Name, httproute.Namespace)
 }
 
+func (d *Driver) UpdateTCPRoute(tcproute *gatewayv1alpha2.TCPRoute) (*gatewayv1alpha2.TCPRoute, error) {
+	if err := d.store.Update(tcproute); err != nil {
+		return nil, err
+	}
+	return d.store.GetTCPRoute(tcproute.Name, tcproute.Namespace)
+}
+
+func (d *Driver) UpdateTLSRoute(tlsroute *gatewayv1alpha2.TLSRoute) (*gatewayv1alpha2.TLSRoute, error) {
+	if err := d.store.Update(tlsroute); err != nil {
+		return nil, err
+	}
+	return d.store.GetTLSRoute(tlsroute.Name, tlsroute.Namespace)
+}
+
+func (d *Driver) UpdateGRPCRoute(grpcroute *gatewayv1alpha2.GRPCRoute) (*gatewayv1alpha2.GRPCRoute, error) {
+	if err := d.store.Update(grpcroute); err != nil {
+		return nil, err
+	}
+	return d.store.GetGRPCRoute(grpcroute.Name, grpcroute.Namespace)
+}
+
+func (d *Driver) UpdateReferenceGrant(grant *gatewayv1beta1.ReferenceGrant) error {
+	return d.store.Update(grant)
+}
+
+func (d *Driver) UpdateNamespace(namespace *corev1.Namespace) error {
+	return d.store.Update(namespace)
+}
+
 func (d *Driver) DeleteIngress(ingress *netv1.Ingress) error {
 	return d.store.Delete(ingress)
 }
@@ -305,6 +476,18 @@ func (d *Driver) DeleteHTTPRoute(httproute *gatewayv1.HTTPRoute) error {
 	return d.store.Delete(httproute)
 }
 
+func (d *Driver) DeleteTCPRoute(tcproute *gatewayv1alpha2.TCPRoute) error {
+	return d.store.Delete(tcproute)
+}
+
+func (d *Driver) DeleteTLSRoute(tlsroute *gatewayv1alpha2.TLSRoute) error {
+	return d.store.Delete(tlsroute)
+}
+
+func (d *Driver) DeleteGRPCRoute(grpcroute *gatewayv1alpha2.GRPCRoute) error {
+	return d.store.Delete(grpcroute)
+}
+
 // Delete an ingress object given the NamespacedName
 // Takes a namespacedName string as a parameter and
 // deletes the ingress object from the cacheStores map
@@ -332,11 +515,50 @@ func (d *Driver) DeleteNamedHTTPRoute(n types.NamespacedName) error {
 	return d.cacheStores.Delete(httproute)
 }
 
+func (d *Driver) DeleteNamedTCPRoute(n types.NamespacedName) error {
+	tcproute := &gatewayv1alpha2.TCPRoute{}
+	// set NamespacedName on the tcproute object
+	tcproute.SetNamespace(n.Namespace)
+	tcproute.SetName(n.Name)
+	return d.cacheStores.Delete(tcproute)
+}
+
+func (d *Driver) DeleteNamedTLSRoute(n types.NamespacedName) error {
+	tlsroute := &gatewayv1alpha2.TLSRoute{}
+	// set NamespacedName on the tlsroute object
+	tlsroute.SetNamespace(n.Namespace)
+	tlsroute.SetName(n.Name)
+	return d.cacheStores.Delete(tlsroute)
+}
+
+func (d *Driver) DeleteNamedGRPCRoute(n types.NamespacedName) error {
+	grpcroute := &gatewayv1alpha2.GRPCRoute{}
+	// set NamespacedName on the grpcroute object
+	grpcroute.SetNamespace(n.Namespace)
+	grpcroute.SetName(n.Name)
+	return d.cacheStores.Delete(grpcroute)
+}
+
+func (d *Driver) DeleteNamedReferenceGrant(n types.NamespacedName) error {
+	grant := &gatewayv1beta1.ReferenceGrant{}
+	// set NamespacedName on the referencegrant object
+	grant.SetNamespace(n.Namespace)
+	grant.SetName(n.Name)
+	return d.cacheStores.Delete(grant)
+}
+
+func (d *Driver) DeleteNamedNamespace(name string) error {
+	namespace := &corev1.Namespace{}
+	namespace.SetName(name)
+	return d.cacheStores.Delete(namespace)
+}
+
 // syncStart will:
 //   - let the first caller proceed, indicated by returning true
 //   - while the first one is running any subsequent calls will be batched to the last call
 //   - the callers between first and last will be assumed "success" and wait will return nil
 //   - the last one will return an error, which will retrigger reconciliation
+//   - the callers arriving while the running sync waits for its debounce window share its result instead
 func (d *Driver) syncStart(partial bool) (bool, func(ctx context.Context) error) {
 	d.log.Info("sync start")
 	d.syncMu.Lock()
@@ -345,9 +567,25 @@ func (d *Driver) syncStart(partial bool) (bool, func(ctx context.Context) error)
 	if !d.syncRunning {
 		// not running, we can take action
 		d.syncRunning = true
+		d.syncRunningPartial = partial
+		d.syncResult = &syncResult{done: make(chan struct{})}
 		return true, nil
 	}
 
+	// the running sync hasn't read the store yet, so it includes the changes of this caller, unless this caller
+	// needs a full sync and the running one is partial
+	if d.syncDebouncing && (partial || !d.syncRunningPartial) {
+		result := d.syncResult
+		return false, func(ctx context.Context) error {
+			select {
+			case <-result.done:
+				return result.err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
 	// already running, overtake any other waiters
 	if d.syncFullCh != nil {
 		if partial {
@@ -385,11 +623,14 @@ func (d *Driver) syncStart(partial bool) (bool, func(ctx context.Context) error)
 
 var errSyncDone = errors.New("sync done")
 
-func (d *Driver) syncDone() {
+func (d *Driver) syncDone(err error) {
 	d.log.Info("sync done")
 	d.syncMu.Lock()
 	defer d.syncMu.Unlock()
 
+	d.syncResult.err = err
+	close(d.syncResult.done)
+
 	if d.syncFullCh != nil {
 		d.syncFullCh <- errSyncDone
 		close(d.syncFullCh)
@@ -405,7 +646,7 @@ func (d *Driver) syncDone() {
 
 // Sync calculates what the desired state for each of our CRDs should be based on the ingresses and other
 // objects in the store. It then compares that to the actual state of the cluster and updates the cluster
-func (d *Driver) Sync(ctx context.Context, c client.Client) error {
+func (d *Driver) Sync(ctx context.Context, c client.Client) (err error) {
 	// This function gets called a lot in the current architecture. At the end it also syncs
 	// resources which in turn triggers more reconcile events. Its all eventually consistent, but
 	// its noisy and can make us hit ngrok api limits. We should probably just change this to be
@@ -413,19 +654,54 @@ func (d *Driver) Sync(ctx context.Context, c client.Client) error {
 	// keeps it in check and syncs in batches
 	if !d.syncAllowConcurrent {
 		if proceed, wait := d.syncStart(false); proceed {
-			defer d.syncDone()
+			defer func() { d.syncDone(err) }()
 		} else {
 			return wait(ctx)
 		}
+		if err := d.syncDebounceWait(ctx); err != nil {
+			return err
+		}
 	}
 
 	d.log.Info("syncing driver state!!")
+	if d.dryRun {
+		dryRunClient := newDryRunClient(c)
+		c = dryRunClient
+		defer func() {
+			diff := dryRunClient.diff()
+			d.setLastSyncDiff(diff)
+			d.logSyncDiff(diff)
+		}()
+	}
+	d.syncErrors.reset()
+
+	changes := d.syncTracker.take()
+	start := time.Now()
+	if d.canSyncIncrementally(changes) {
+		err = d.syncIncremental(ctx, c, changes)
+		observeSync(syncModeIncremental, start, err)
+	} else {
+		err = d.syncFull(ctx, c)
+		observeSync(syncModeFull, start, err)
+	}
+	if err != nil {
+		// the next sync retries the changes
+		d.syncTracker.restore(changes)
+	}
+	return err
+}
+
+// syncFull syncs the resources derived from everything in the store
+func (d *Driver) syncFull(ctx context.Context, c client.Client) error {
 	desiredDomains, desiredIngressDomains, desiredGatewayDomainMap := d.calculateDomains()
-	desiredEdges := d.calculateHTTPSEdges(&desiredIngressDomains, desiredGatewayDomainMap)
+	desiredEdges, edgeConflicts := d.calculateHTTPSEdges(&desiredIngressDomains, desiredGatewayDomainMap)
+	desiredEndpoints, endpointConflicts := d.calculateCloudEndpoints(desiredIngressDomains)
 	desiredTunnels := d.calculateTunnels()
+	d.setIngressRouteConflicts(mergeIngressRouteConflicts(edgeConflicts, endpointConflicts))
 
 	currDomains := &ingressv1alpha1.DomainList{}
 	currEdges := &ingressv1alpha1.HTTPSEdgeList{}
+	currEndpoints := &ngrokv1alpha1.CloudEndpointList{}
 	currTunnels := &ingressv1alpha1.TunnelList{}
 
 	if err := c.List(ctx, currDomains); err != nil {
@@ -439,6 +715,13 @@ func (d *Driver) Sync(ctx context.Context, c client.Client) error {
 		d.log.Error(err, "error listing edges")
 		return err
 	}
+	if err := c.List(ctx, currEndpoints, client.MatchingLabels{
+		labelControllerNamespace: d.managerName.Namespace,
+		labelControllerName:      d.managerName.Name,
+	}); err != nil {
+		d.log.Error(err, "error listing cloud endpoints")
+		return err
+	}
 	if err := c.List(ctx, currTunnels, client.MatchingLabels{
 		labelControllerNamespace: d.managerName.Namespace,
 		labelControllerName:      d.managerName.Name,
@@ -447,6 +730,11 @@ func (d *Driver) Sync(ctx context.Context, c client.Client) error {
 		return err
 	}
 
+	observeSyncObjects("Domain", len(desiredDomains), len(currDomains.Items))
+	observeSyncObjects("HTTPSEdge", len(desiredEdges), len(currEdges.Items))
+	observeSyncObjects("CloudEndpoint", len(desiredEndpoints), len(currEndpoints.Items))
+	observeSyncObjects("Tunnel", len(desiredTunnels), len(currTunnels.Items))
+
 	if err := d.applyDomains(ctx, c, desiredDomains, currDomains.Items); err != nil {
 		return err
 	}
@@ -455,40 +743,99 @@ func (d *Driver) Sync(ctx context.Context, c client.Client) error {
 		return err
 	}
 
+	if err := d.applyCloudEndpoints(ctx, c, desiredEndpoints, currEndpoints.Items); err != nil {
+		return err
+	}
+
 	if err := d.applyTunnels(ctx, c, desiredTunnels, currTunnels.Items); err != nil {
 		return err
 	}
 
+	if d.gatewayEnabled {
+		desiredTCPEdges := d.calculateTCPEdges()
+		currTCPEdges := &ingressv1alpha1.TCPEdgeList{}
+		if err := c.List(ctx, currTCPEdges, client.MatchingLabels{
+			labelControllerNamespace: d.managerName.Namespace,
+			labelControllerName:      d.managerName.Name,
+		}); err != nil {
+			d.log.Error(err, "error listing tcp edges")
+			return err
+		}
+		observeSyncObjects("TCPEdge", len(desiredTCPEdges), len(currTCPEdges.Items))
+
+		if err := d.applyTCPEdges(ctx, c, desiredTCPEdges, currTCPEdges.Items); err != nil {
+			return err
+		}
+
+		desiredTLSEdges := d.calculateTLSEdges()
+		currTLSEdges := &ingressv1alpha1.TLSEdgeList{}
+		if err := c.List(ctx, currTLSEdges, client.MatchingLabels{
+			labelControllerNamespace: d.managerName.Namespace,
+			labelControllerName:      d.managerName.Name,
+		}); err != nil {
+			d.log.Error(err, "error listing tls edges")
+			return err
+		}
+		observeSyncObjects("TLSEdge", len(desiredTLSEdges), len(currTLSEdges.Items))
+
+		if err := d.applyTLSEdges(ctx, c, desiredTLSEdges, currTLSEdges.Items); err != nil {
+			return err
+		}
+	}
+
 	if err := d.updateIngressStatuses(ctx, c); err != nil {
 		return err
 	}
 
-	// UpdateGatewayStatuses
-	//if err := d.updateGatewayStatuses(ctx, c); err != nil {
-	//	return err
-	//}
-
-	// UpdateHTTPRouteStatuses
-	//if err := d.updateHTTPRouteStatuses(ctx, c); err != nil {
-	//	return err
-	//}
+	if d.gatewayEnabled {
+		if err := d.updateGatewayStatuses(ctx, c); err != nil {
+			return err
+		}
+		if err := d.updateHTTPRouteStatuses(ctx, c); err != nil {
+			return err
+		}
+		if err := d.updateTCPRouteStatuses(ctx, c); err != nil {
+			return err
+		}
+		if err := d.updateTLSRouteStatuses(ctx, c); err != nil {
+			return err
+		}
+		if err := d.updateGRPCRouteStatuses(ctx, c); err != nil {
+			return err
+		}
+	}
 
-	return nil
+	return d.updateSyncStatuses(ctx, c)
 }
 
-func (d *Driver) SyncEdges(ctx context.Context, c client.Client) error {
+func (d *Driver) SyncEdges(ctx context.Context, c client.Client) (err error) {
 	if !d.syncAllowConcurrent {
 		if proceed, wait := d.syncStart(true); proceed {
-			defer d.syncDone()
+			defer func() { d.syncDone(err) }()
 		} else {
 			return wait(ctx)
 		}
+		if err := d.syncDebounceWait(ctx); err != nil {
+			return err
+		}
 	}
+	defer func(start time.Time) { observeSync(syncModeEdges, start, err) }(time.Now())
 
 	d.log.Info("syncing edges state!!")
+	if d.dryRun {
+		dryRunClient := newDryRunClient(c)
+		c = dryRunClient
+		defer func() {
+			diff := dryRunClient.diff()
+			d.setLastSyncDiff(diff)
+			d.logSyncDiff(diff)
+		}()
+	}
+	d.syncErrors.reset()
+
 	_, desiredIngressDomains, desiredGatewayDomainMap := d.calculateDomains()
 
-	desiredEdges := d.calculateHTTPSEdges(&desiredIngressDomains, desiredGatewayDomainMap)
+	desiredEdges, edgeConflicts := d.calculateHTTPSEdges(&desiredIngressDomains, desiredGatewayDomainMap)
 	currEdges := &ingressv1alpha1.HTTPSEdgeList{}
 	if err := c.List(ctx, currEdges, client.MatchingLabels{
 		labelControllerNamespace: d.managerName.Namespace,
@@ -502,7 +849,19 @@ func (d *Driver) SyncEdges(ctx context.Context, c client.Client) error {
 		return err
 	}
 
-	return nil
+	// The traffic policies of the ingresses using the endpoints mapping strategy are in their CloudEndpoints
+	desiredEndpoints, endpointConflicts := d.calculateCloudEndpoints(desiredIngressDomains)
+	d.setIngressRouteConflicts(mergeIngressRouteConflicts(edgeConflicts, endpointConflicts))
+	currEndpoints := &ngrokv1alpha1.CloudEndpointList{}
+	if err := c.List(ctx, currEndpoints, client.MatchingLabels{
+		labelControllerNamespace: d.managerName.Namespace,
+		labelControllerName:      d.managerName.Name,
+	}); err != nil {
+		d.log.Error(err, "error listing cloud endpoints")
+		return err
+	}
+
+	return d.applyCloudEndpoints(ctx, c, desiredEndpoints, currEndpoints.Items)
 }
 
 func (d *Driver) applyDomains(ctx context.Context, c client.Client, desiredDomains, currentDomains []ingressv1alpha1.Domain) error {
@@ -638,484 +997,2291 @@ func (d *Driver) applyTunnels(ctx context.Context, c client.Client, desiredTunne
 	return nil
 }
 
-func (d *Driver) updateIngressStatuses(ctx context.Context, c client.Client) error {
-	ingresses := d.store.ListNgrokIngressesV1()
-	for _, ingress := range ingresses {
-		newLBIPStatus := d.calculateIngressLoadBalancerIPStatus(ingress, c)
-		if !reflect.DeepEqual(ingress.Status.LoadBalancer.Ingress, newLBIPStatus) {
-			ingress.Status.LoadBalancer.Ingress = newLBIPStatus
-			if err := c.Status().Update(ctx, ingress); err != nil {
-				d.log.Error(err, "error updating ingress status", "ingress", ingress)
-				return err
-			}
+func (d *Driver) applyTCPEdges(ctx context.Context, c client.Client, desiredEdges map[string]ingressv1alpha1.TCPEdge, currentEdges []ingressv1alpha1.TCPEdge) error {
+	// update or delete edges we don't need anymore
+	for _, currEdge := range currentEdges {
+		key, ok := tcpEdgeKeyFromEdge(currEdge)
+		if !ok {
+			d.log.Error(nil, "Existing owned tcp edge is not owned by a TCPRoute", "edge", currEdge)
+			continue
 		}
-	}
-	return nil
-}
-
-func (d *Driver) calculateDomains() ([]ingressv1alpha1.Domain, []ingressv1alpha1.Domain, map[string]ingressv1alpha1.Domain) {
-	var domains, ingressDomains []ingressv1alpha1.Domain
-	ingressDomainMap := d.calculateDomainsFromIngress()
-
-	ingressDomains = make([]ingressv1alpha1.Domain, 0, len(ingressDomainMap))
-	for _, domain := range ingressDomainMap {
-		ingressDomains = append(ingressDomains, domain)
-		domains = append(domains, domain)
-	}
 
-	var gatewayDomainMap map[string]ingressv1alpha1.Domain
-	if d.gatewayEnabled {
-		gatewayDomainMap = d.calculateDomainsFromGateway(ingressDomainMap)
-		for _, domain := range gatewayDomainMap {
-			domains = append(domains, domain)
-		}
-	}
+		if desiredEdge, ok := desiredEdges[key]; ok {
+			needsUpdate := false
 
-	return domains, ingressDomains, gatewayDomainMap
-}
+			if !slices.Equal(desiredEdge.OwnerReferences, currEdge.OwnerReferences) {
+				currEdge.OwnerReferences = desiredEdge.OwnerReferences
+				needsUpdate = true
+			}
 
-func (d *Driver) calculateDomainsFromIngress() map[string]ingressv1alpha1.Domain {
-	domainMap := make(map[string]ingressv1alpha1.Domain)
+			if !reflect.DeepEqual(desiredEdge.Spec, currEdge.Spec) {
+				currEdge.Spec = desiredEdge.Spec
+				needsUpdate = true
+			}
 
-	ingresses := d.store.ListNgrokIngressesV1()
-	for _, ingress := range ingresses {
-		for _, rule := range ingress.Spec.Rules {
-			if rule.Host == "" {
-				continue
+			if needsUpdate {
+				if err := c.Update(ctx, &currEdge); err != nil {
+					d.log.Error(err, "error updating tcp edge", "desiredEdge", desiredEdge, "currEdge", currEdge)
+					return err
+				}
 			}
 
-			domain := ingressv1alpha1.Domain{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      ingressv1alpha1.HyphenatedDomainNameFromURL(rule.Host),
-					Namespace: ingress.Namespace,
-				},
-				Spec: ingressv1alpha1.DomainSpec{
-					Domain: rule.Host,
-				},
+			// matched and updated the edge, no longer desired
+			delete(desiredEdges, key)
+		} else {
+			if err := c.Delete(ctx, &currEdge); client.IgnoreNotFound(err) != nil {
+				d.log.Error(err, "error deleting tcp edge", "edge", currEdge)
+				return err
 			}
-			domain.Spec.Metadata = d.ingressNgrokMetadata
-			domainMap[rule.Host] = domain
 		}
 	}
 
-	return domainMap
+	// the set of desired edges now only contains new edges, create them
+	for _, edge := range desiredEdges {
+		if err := c.Create(ctx, &edge); err != nil {
+			d.log.Error(err, "error creating tcp edge", "edge", edge)
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (d *Driver) calculateDomainsFromGateway(ingressDomains map[string]ingressv1alpha1.Domain) map[string]ingressv1alpha1.Domain {
-	domainMap := make(map[string]ingressv1alpha1.Domain)
+func (d *Driver) applyTLSEdges(ctx context.Context, c client.Client, desiredEdges map[string]ingressv1alpha1.TLSEdge, currentEdges []ingressv1alpha1.TLSEdge) error {
+	// update or delete edges we don't need anymore
+	for _, currEdge := range currentEdges {
+		key, ok := tlsEdgeKeyFromEdge(currEdge)
+		if !ok {
+			d.log.Error(nil, "Existing owned tls edge is not owned by a TLSRoute", "edge", currEdge)
+			continue
+		}
 
-	gateways := d.store.ListGateways()
-	for _, gw := range gateways {
-		for _, listener := range gw.Spec.Listeners {
-			if listener.Hostname == nil {
-				continue
+		if desiredEdge, ok := desiredEdges[key]; ok {
+			needsUpdate := false
+
+			if !slices.Equal(desiredEdge.OwnerReferences, currEdge.OwnerReferences) {
+				currEdge.OwnerReferences = desiredEdge.OwnerReferences
+				needsUpdate = true
 			}
-			domainName := string(*listener.Hostname)
-			if _, hasVal := ingressDomains[domainName]; hasVal {
-				// TODO update gateway status
-				// also add error to error page
-				continue
+
+			if !reflect.DeepEqual(desiredEdge.Spec, currEdge.Spec) {
+				currEdge.Spec = desiredEdge.Spec
+				needsUpdate = true
 			}
-			domain := ingressv1alpha1.Domain{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      ingressv1alpha1.HyphenatedDomainNameFromURL(domainName),
-					Namespace: gw.Namespace,
-				},
-				Spec: ingressv1alpha1.DomainSpec{
-					Domain: domainName,
-				},
+
+			if needsUpdate {
+				if err := c.Update(ctx, &currEdge); err != nil {
+					d.log.Error(err, "error updating tls edge", "desiredEdge", desiredEdge, "currEdge", currEdge)
+					return err
+				}
+			}
+
+			// matched and updated the edge, no longer desired
+			delete(desiredEdges, key)
+		} else {
+			if err := c.Delete(ctx, &currEdge); client.IgnoreNotFound(err) != nil {
+				d.log.Error(err, "error deleting tls edge", "edge", currEdge)
+				return err
 			}
-			domain.Spec.Metadata = d.gatewayNgrokMetadata
-			domainMap[domainName] = domain
 		}
 	}
 
-	return domainMap
+	// the set of desired edges now only contains new edges, create them
+	for _, edge := range desiredEdges {
+		if err := c.Create(ctx, &edge); err != nil {
+			d.log.Error(err, "error creating tls edge", "edge", edge)
+			return err
+		}
+	}
+
+	return nil
 }
 
-// Given an ingress, it will resolve any ngrok modulesets defined on the ingress to the
-// CRDs and then will merge them in to a single moduleset
-func (d *Driver) getNgrokModuleSetForIngress(ing *netv1.Ingress) (*ingressv1alpha1.NgrokModuleSet, error) {
-	computedModSet := &ingressv1alpha1.NgrokModuleSet{}
+// updateIngressStatuses updates the statuses of every ingress with the route conflicts found by the last sync
+func (d *Driver) updateIngressStatuses(ctx context.Context, c client.Client) error {
+	d.ingressRouteConflictsMu.Lock()
+	conflicts := d.ingressRouteConflicts
+	d.ingressRouteConflictsMu.Unlock()
+	return d.updateStatusesOfIngresses(ctx, c, d.store.ListNgrokIngressesV1(), conflicts)
+}
 
-	modules, err := annotations.ExtractNgrokModuleSetsFromAnnotations(ing)
+// updateStatusesOfIngresses updates the route conflicts and load balancer statuses of the given ingresses
+func (d *Driver) updateStatusesOfIngresses(ctx context.Context, c client.Client, ingresses []*netv1.Ingress, conflicts map[types.NamespacedName][]ingressRouteConflict) error {
+	domainsByDomain, err := d.listDomainsByDomain(ctx, c)
 	if err != nil {
-		if errors.IsMissingAnnotations(err) {
-			return computedModSet, nil
+		return err
+	}
+
+	for _, ingress := range ingresses {
+		ingress, err := d.updateIngressRouteConflicts(ctx, c, ingress, conflicts[types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}])
+		if err != nil {
+			return err
+		}
+
+		newLBIPStatus := ingressLoadBalancerIPStatus(ingress, domainsByDomain)
+		if !reflect.DeepEqual(ingress.Status.LoadBalancer.Ingress, newLBIPStatus) {
+			ingress = ingress.DeepCopy()
+			ingress.Status.LoadBalancer.Ingress = newLBIPStatus
+			if err := c.Status().Update(ctx, ingress); err != nil {
+				d.log.Error(err, "error updating ingress status", "ingress", ingress)
+				return err
+			}
+			if err := d.updateStoreAfterWrite(ingress); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateSyncStatuses reports the errors found while translating the Ingresses, Gateways and HTTPRoutes during the sync
+// through their sync status annotation, and with an event whenever it changes
+func (d *Driver) updateSyncStatuses(ctx context.Context, c client.Client) error {
+	for _, ingress := range d.store.ListNgrokIngressesV1() {
+		if err := d.updateSyncStatus(ctx, c, "Ingress", ingress); err != nil {
+			return err
+		}
+	}
+
+	if !d.gatewayEnabled {
+		return nil
+	}
+	for _, gtw := range d.store.ListNgrokGateways() {
+		if err := d.updateSyncStatus(ctx, c, "Gateway", gtw); err != nil {
+			return err
+		}
+	}
+	// the routes are only annotated when they're attached to one of our gateways, the others belong to other controllers
+	for _, httproute := range d.store.ListHTTPRoutes() {
+		if !d.hasNgrokParent(httproute.Spec.ParentRefs, httproute.Namespace) {
+			continue
+		}
+		if err := d.updateSyncStatus(ctx, c, "HTTPRoute", httproute); err != nil {
+			return err
+		}
+	}
+	for _, grpcroute := range d.store.ListGRPCRoutes() {
+		if !d.hasNgrokParent(grpcroute.Spec.ParentRefs, grpcroute.Namespace) {
+			continue
+		}
+		if err := d.updateSyncStatus(ctx, c, "GRPCRoute", grpcroute); err != nil {
+			return err
+		}
+	}
+	for _, tcproute := range d.store.ListTCPRoutes() {
+		if !d.hasNgrokParent(tcproute.Spec.ParentRefs, tcproute.Namespace) {
+			continue
+		}
+		if err := d.updateSyncStatus(ctx, c, "TCPRoute", tcproute); err != nil {
+			return err
+		}
+	}
+	for _, tlsroute := range d.store.ListTLSRoutes() {
+		if !d.hasNgrokParent(tlsroute.Spec.ParentRefs, tlsroute.Namespace) {
+			continue
+		}
+		if err := d.updateSyncStatus(ctx, c, "TLSRoute", tlsroute); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateSyncStatus sets the sync status annotation of a resource from the errors recorded for it during the sync
+func (d *Driver) updateSyncStatus(ctx context.Context, c client.Client, kind string, obj client.Object) error {
+	errs := d.syncErrors.get(kind, obj)
+	status := syncStatus(errs)
+	previous, annotated := obj.GetAnnotations()[annotationSyncStatus]
+	if previous == status {
+		return nil
+	}
+
+	obj = obj.DeepCopyObject().(client.Object)
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[annotationSyncStatus] = status
+	obj.SetAnnotations(annotations)
+	if err := c.Update(ctx, obj); err != nil {
+		d.log.Error(err, "error updating sync status", strings.ToLower(kind), client.ObjectKeyFromObject(obj))
+		return err
+	}
+	if err := d.updateStoreAfterWrite(obj); err != nil {
+		return err
+	}
+
+	if d.recorder != nil {
+		switch {
+		case len(errs) > 0:
+			d.recorder.Event(obj, corev1.EventTypeWarning, "SyncError", strings.Join(errs, "; "))
+		case annotated:
+			d.recorder.Event(obj, corev1.EventTypeNormal, "Synced", "Resource synced without errors")
+		}
+	}
+	return nil
+}
+
+// setIngressRouteConflicts records the route conflicts found by a sync of every ingress
+func (d *Driver) setIngressRouteConflicts(conflicts map[types.NamespacedName][]ingressRouteConflict) {
+	d.ingressRouteConflictsMu.Lock()
+	defer d.ingressRouteConflictsMu.Unlock()
+	d.ingressRouteConflicts = conflicts
+}
+
+// updateIngressRouteConflictsOf records the route conflicts found by a sync of the given ingresses only, keeping the
+// conflicts of the other ingresses
+func (d *Driver) updateIngressRouteConflictsOf(conflicts map[types.NamespacedName][]ingressRouteConflict, ingresses map[types.NamespacedName]bool) {
+	d.ingressRouteConflictsMu.Lock()
+	defer d.ingressRouteConflictsMu.Unlock()
+	merged := make(map[types.NamespacedName][]ingressRouteConflict, len(d.ingressRouteConflicts))
+	for ingress, ingressConflicts := range d.ingressRouteConflicts {
+		merged[ingress] = ingressConflicts
+	}
+	for ingress := range ingresses {
+		delete(merged, ingress)
+	}
+	for ingress, ingressConflicts := range conflicts {
+		merged[ingress] = ingressConflicts
+	}
+	d.ingressRouteConflicts = merged
+}
+
+// mergeIngressRouteConflicts merges the conflicts of the ingresses using the edges and endpoints mapping strategies.
+// An ingress uses a single mapping strategy, so the conflicts of both are for different ingresses.
+func mergeIngressRouteConflicts(edgeConflicts, endpointConflicts map[types.NamespacedName][]ingressRouteConflict) map[types.NamespacedName][]ingressRouteConflict {
+	for ingress, ingressConflicts := range endpointConflicts {
+		edgeConflicts[ingress] = ingressConflicts
+	}
+	return edgeConflicts
+}
+
+// updateIngressRouteConflicts keeps the route conflicts annotation of the ingress up to date and emits a warning
+// event when new conflicts are found. It returns the ingress as it is after the update.
+func (d *Driver) updateIngressRouteConflicts(ctx context.Context, c client.Client, ingress *netv1.Ingress, conflicts []ingressRouteConflict) (*netv1.Ingress, error) {
+	descriptions := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		descriptions = append(descriptions, conflict.String())
+	}
+	value := strings.Join(descriptions, ", ")
+
+	if ingress.Annotations[annotationRouteConflicts] == value {
+		return ingress, nil
+	}
+
+	ingress = ingress.DeepCopy()
+	if value == "" {
+		delete(ingress.Annotations, annotationRouteConflicts)
+	} else {
+		if ingress.Annotations == nil {
+			ingress.Annotations = map[string]string{}
+		}
+		ingress.Annotations[annotationRouteConflicts] = value
+	}
+	if err := c.Update(ctx, ingress); err != nil {
+		d.log.Error(err, "error updating ingress route conflicts", "ingress", ingress)
+		return nil, err
+	}
+	if err := d.updateStoreAfterWrite(ingress); err != nil {
+		return nil, err
+	}
+
+	if value != "" && d.recorder != nil {
+		d.recorder.Event(ingress, corev1.EventTypeWarning, "RouteConflict", fmt.Sprintf("Routes are shadowed by older ingresses: %s", value))
+	}
+	return ingress, nil
+}
+
+func (d *Driver) updateGatewayStatuses(ctx context.Context, c client.Client) error {
+	domains := &ingressv1alpha1.DomainList{}
+	if err := c.List(ctx, domains); err != nil {
+		d.log.Error(err, "failed to list domains")
+		return err
+	}
+	domainsByDomain := map[string]ingressv1alpha1.Domain{}
+	for _, domain := range domains.Items {
+		domainsByDomain[domain.Spec.Domain] = domain
+	}
+
+	tcpEdges := &ingressv1alpha1.TCPEdgeList{}
+	if err := c.List(ctx, tcpEdges, client.MatchingLabels{
+		labelControllerNamespace: d.managerName.Namespace,
+		labelControllerName:      d.managerName.Name,
+	}); err != nil {
+		d.log.Error(err, "failed to list tcp edges")
+		return err
+	}
+	tcpHostports := map[string][]string{}
+	for _, edge := range tcpEdges.Items {
+		if key, ok := tcpEdgeKeyFromEdge(edge); ok {
+			tcpHostports[key] = edge.Status.Hostports
+		}
+	}
+
+	_, _, gatewayDomainMap := d.calculateDomains()
+	for _, gtw := range d.store.ListNgrokGateways() {
+		newStatus := d.calculateGatewayStatus(gtw, gatewayDomainMap, domainsByDomain, tcpHostports)
+		if reflect.DeepEqual(gtw.Status, newStatus) {
+			continue
+		}
+
+		gtw = gtw.DeepCopy()
+		gtw.Status = newStatus
+		if err := c.Status().Update(ctx, gtw); err != nil {
+			d.log.Error(err, "error updating gateway status", "gateway", gtw)
+			return err
+		}
+		if err := d.updateStoreAfterWrite(gtw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) updateHTTPRouteStatuses(ctx context.Context, c client.Client) error {
+	_, _, gatewayDomainMap := d.calculateDomains()
+	for _, httproute := range d.store.ListHTTPRoutes() {
+		newStatus := d.calculateHTTPRouteStatus(httproute, gatewayDomainMap)
+		if reflect.DeepEqual(httproute.Status.RouteStatus, newStatus) {
+			continue
+		}
+
+		httproute = httproute.DeepCopy()
+		httproute.Status.RouteStatus = newStatus
+		if err := c.Status().Update(ctx, httproute); err != nil {
+			d.log.Error(err, "error updating httproute status", "httproute", httproute)
+			return err
+		}
+		if err := d.updateStoreAfterWrite(httproute); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) updateGRPCRouteStatuses(ctx context.Context, c client.Client) error {
+	_, _, gatewayDomainMap := d.calculateDomains()
+	for _, grpcroute := range d.store.ListGRPCRoutes() {
+		newStatus := d.calculateGRPCRouteStatus(grpcroute, gatewayDomainMap)
+		if reflect.DeepEqual(grpcroute.Status.RouteStatus, newStatus) {
+			continue
+		}
+
+		grpcroute = grpcroute.DeepCopy()
+		grpcroute.Status.RouteStatus = newStatus
+		if err := c.Status().Update(ctx, grpcroute); err != nil {
+			d.log.Error(err, "error updating grpcroute status", "grpcroute", grpcroute)
+			return err
+		}
+		if err := d.updateStoreAfterWrite(grpcroute); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) updateTCPRouteStatuses(ctx context.Context, c client.Client) error {
+	for _, tcproute := range d.store.ListTCPRoutes() {
+		newStatus := d.calculateTCPRouteStatus(tcproute)
+		if reflect.DeepEqual(tcproute.Status.RouteStatus, newStatus) {
+			continue
+		}
+
+		tcproute = tcproute.DeepCopy()
+		tcproute.Status.RouteStatus = newStatus
+		if err := c.Status().Update(ctx, tcproute); err != nil {
+			d.log.Error(err, "error updating tcproute status", "tcproute", tcproute)
+			return err
+		}
+		if err := d.updateStoreAfterWrite(tcproute); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) updateTLSRouteStatuses(ctx context.Context, c client.Client) error {
+	for _, tlsroute := range d.store.ListTLSRoutes() {
+		newStatus := d.calculateTLSRouteStatus(tlsroute)
+		if reflect.DeepEqual(tlsroute.Status.RouteStatus, newStatus) {
+			continue
+		}
+
+		tlsroute = tlsroute.DeepCopy()
+		tlsroute.Status.RouteStatus = newStatus
+		if err := c.Status().Update(ctx, tlsroute); err != nil {
+			d.log.Error(err, "error updating tlsroute status", "tlsroute", tlsroute)
+			return err
+		}
+		if err := d.updateStoreAfterWrite(tlsroute); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) calculateDomains() ([]ingressv1alpha1.Domain, []ingressv1alpha1.Domain, map[string]ingressv1alpha1.Domain) {
+	var domains, ingressDomains []ingressv1alpha1.Domain
+	ingressDomainMap := d.calculateDomainsFromIngress()
+
+	ingressDomains = make([]ingressv1alpha1.Domain, 0, len(ingressDomainMap))
+	for _, domain := range ingressDomainMap {
+		ingressDomains = append(ingressDomains, domain)
+		domains = append(domains, domain)
+	}
+
+	var gatewayDomainMap map[string]ingressv1alpha1.Domain
+	if d.gatewayEnabled {
+		gatewayDomainMap = d.calculateDomainsFromGateway(ingressDomainMap)
+		for _, domain := range gatewayDomainMap {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains, ingressDomains, gatewayDomainMap
+}
+
+func (d *Driver) calculateDomainsFromIngress() map[string]ingressv1alpha1.Domain {
+	return d.calculateDomainsFromIngresses(d.store.ListNgrokIngressesV1())
+}
+
+// calculateDomainsFromIngresses returns the domains of the hosts of the given ingresses, keyed by host
+func (d *Driver) calculateDomainsFromIngresses(ingresses []*netv1.Ingress) map[string]ingressv1alpha1.Domain {
+	domainMap := make(map[string]ingressv1alpha1.Domain)
+
+	for _, ingress := range ingresses {
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host == "" {
+				continue
+			}
+
+			domain := ingressv1alpha1.Domain{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ingressv1alpha1.HyphenatedDomainNameFromURL(rule.Host),
+					Namespace: ingress.Namespace,
+				},
+				Spec: ingressv1alpha1.DomainSpec{
+					Domain: rule.Host,
+				},
+			}
+			domain.Spec.Metadata = d.ingressNgrokMetadata
+			domainMap[rule.Host] = domain
+		}
+	}
+
+	return domainMap
+}
+
+func (d *Driver) calculateDomainsFromGateway(ingressDomains map[string]ingressv1alpha1.Domain) map[string]ingressv1alpha1.Domain {
+	domainMap := make(map[string]ingressv1alpha1.Domain)
+
+	gateways := d.store.ListNgrokGateways()
+	for _, gw := range gateways {
+		for _, listener := range gw.Spec.Listeners {
+			if listener.Hostname == nil {
+				continue
+			}
+			domainName := string(*listener.Hostname)
+			if _, hasVal := ingressDomains[domainName]; hasVal {
+				// TODO update gateway status
+				d.recordSyncError("Gateway", types.NamespacedName{Namespace: gw.Namespace, Name: gw.Name}, nil, fmt.Sprintf("hostname %q of listener %q is already used by an ingress", domainName, listener.Name))
+				continue
+			}
+			domain := ingressv1alpha1.Domain{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ingressv1alpha1.HyphenatedDomainNameFromURL(domainName),
+					Namespace: gw.Namespace,
+				},
+				Spec: ingressv1alpha1.DomainSpec{
+					Domain: domainName,
+				},
+			}
+			domain.Spec.Metadata = d.gatewayNgrokMetadata
+			// Several listeners can share a hostname, keep the certificate of whichever one references it
+			if ref, _, _ := d.listenerCertificateRef(gw, listener); ref != nil {
+				domain.Spec.CertificateRef = ref
+			} else if existing, ok := domainMap[domainName]; ok {
+				domain.Spec.CertificateRef = existing.Spec.CertificateRef
+			}
+			domainMap[domainName] = domain
+		}
+	}
+
+	d.calculateDomainsFromTLSRoutes(domainMap, ingressDomains)
+
+	return domainMap
+}
+
+// calculateDomainsFromTLSRoutes adds a Domain for the hostnames of TLSRoutes attached to TLS listeners. ngrok TLS edges
+// only serve hostports of reserved domains, so route hostnames that aren't the listener hostname, like the ones matching
+// a wildcard listener or any hostname on a listener without one, need their own Domain. Listeners terminating at the
+// edge with a certificateRef use it for the Domain certificate.
+func (d *Driver) calculateDomainsFromTLSRoutes(domainMap map[string]ingressv1alpha1.Domain, ingressDomains map[string]ingressv1alpha1.Domain) {
+	for _, tlsroute := range d.store.ListTLSRoutes() {
+		for _, parent := range tlsroute.Spec.ParentRefs {
+			listeners, _, _ := d.tlsRouteParentListeners(tlsroute, parent)
+			if len(listeners) == 0 {
+				continue
+			}
+			gtw := d.findGatewayForParentRef(parent, tlsroute.Namespace)
+			for _, listener := range listeners {
+				certificateRef, reason, _ := d.listenerCertificateRef(gtw, listener)
+				if reason != "" {
+					continue
+				}
+				for _, hostname := range routeHostnamesForListener(listener, tlsroute.Spec.Hostnames) {
+					if _, ok := domainMap[hostname]; ok {
+						continue
+					}
+					if _, ok := ingressDomains[hostname]; ok {
+						continue
+					}
+					domain := ingressv1alpha1.Domain{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      ingressv1alpha1.HyphenatedDomainNameFromURL(hostname),
+							Namespace: gtw.Namespace,
+						},
+						Spec: ingressv1alpha1.DomainSpec{
+							Domain:         hostname,
+							CertificateRef: certificateRef,
+						},
+					}
+					domain.Spec.Metadata = d.gatewayNgrokMetadata
+					domainMap[hostname] = domain
+				}
+			}
+		}
+	}
+}
+
+// Given an ingress, it will resolve any ngrok modulesets defined on the ingress to the
+// CRDs and then will merge them in to a single moduleset
+func (d *Driver) getNgrokModuleSetForIngress(ing *netv1.Ingress) (*ingressv1alpha1.NgrokModuleSet, error) {
+	computedModSet := &ingressv1alpha1.NgrokModuleSet{}
+
+	modules, err := annotations.ExtractNgrokModuleSetsFromAnnotations(ing)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return computedModSet, nil
+		}
+		return computedModSet, err
+	}
+
+	for _, module := range modules {
+		resolvedMod, err := d.store.GetNgrokModuleSetV1(module, ing.Namespace)
+		if err != nil {
+			return computedModSet, err
+		}
+		computedModSet.Merge(resolvedMod)
+	}
+
+	return computedModSet, nil
+}
+
+func (d *Driver) getNgrokTrafficPolicyForIngress(ing *netv1.Ingress) (*ngrokv1alpha1.NgrokTrafficPolicy, error) {
+	policy, err := annotations.ExtractNgrokTrafficPolicyFromAnnotations(ing)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return d.store.GetNgrokTrafficPolicyV1(policy, ing.Namespace)
+}
+
+// calculateHTTPSEdges returns the HTTPSEdges of the ingresses and gateways, keyed by domain, and the routes of each
+// ingress shadowed by the same routes of older ingresses
+func (d *Driver) calculateHTTPSEdges(ingressDomains *[]ingressv1alpha1.Domain, gatewayDomainMap map[string]ingressv1alpha1.Domain) (map[string]ingressv1alpha1.HTTPSEdge, map[types.NamespacedName][]ingressRouteConflict) {
+	edgeIngresses, _ := d.ingressesByMappingStrategy(d.store.ListNgrokIngressesV1())
+	edgeHosts := ingressesHosts(edgeIngresses)
+	edgeMap := make(map[string]ingressv1alpha1.HTTPSEdge, len(*ingressDomains))
+	for _, domain := range *ingressDomains {
+		if edgeHosts[domain.Spec.Domain] {
+			edgeMap[domain.Spec.Domain] = d.newIngressHTTPSEdge(domain)
+		}
+	}
+	// Conflicts between ingresses are reported on the ingresses when their statuses are updated
+	conflicts := d.calculateHTTPSEdgesFromIngress(edgeMap)
+
+	if d.gatewayEnabled {
+		gatewayEdgeMap := make(map[string]ingressv1alpha1.HTTPSEdge)
+		httproutes := d.store.ListHTTPRoutes()
+		for _, httproute := range httproutes {
+			routeDomains := httpsRouteDomains(httproute.Spec.ParentRefs, func(parent gatewayv1.ParentReference) (*gatewayv1.Gateway, []gatewayv1.Listener, gatewayv1.RouteConditionReason, string) {
+				return d.httpRouteParentListeners(httproute, parent, gatewayDomainMap)
+			})
+			if len(routeDomains) == 0 {
+				// no usable domains in route
+				continue
+			}
+			gatewayEdgeMap[routeDomains[0]] = d.newGatewayHTTPSEdge(httproute.Name, httproute.Namespace, routeDomains)
+		}
+		for _, grpcroute := range d.store.ListGRPCRoutes() {
+			routeDomains := httpsRouteDomains(grpcroute.Spec.ParentRefs, func(parent gatewayv1.ParentReference) (*gatewayv1.Gateway, []gatewayv1.Listener, gatewayv1.RouteConditionReason, string) {
+				return d.grpcRouteParentListeners(grpcroute, parent, gatewayDomainMap)
+			})
+			if len(routeDomains) == 0 {
+				continue
+			}
+			if _, ok := gatewayEdgeMap[routeDomains[0]]; ok {
+				// GRPCRoutes share the edge of the HTTPRoutes on the same domain
+				continue
+			}
+			gatewayEdgeMap[routeDomains[0]] = d.newGatewayHTTPSEdge(grpcroute.Name, grpcroute.Namespace, routeDomains)
+		}
+		d.calculateHTTPSEdgesFromGateway(gatewayEdgeMap, gatewayDomainMap)
+
+		// merge edge maps
+		for k, v := range gatewayEdgeMap {
+			edgeMap[k] = v
+		}
+	}
+
+	return edgeMap, conflicts
+}
+
+// newIngressHTTPSEdge returns the edge of an ingress domain, without its routes
+func (d *Driver) newIngressHTTPSEdge(domain ingressv1alpha1.Domain) ingressv1alpha1.HTTPSEdge {
+	edge := ingressv1alpha1.HTTPSEdge{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: domain.Name + "-",
+			Namespace:    domain.Namespace,
+			Labels:       d.edgeLabels(),
+		},
+		Spec: ingressv1alpha1.HTTPSEdgeSpec{
+			Hostports: []string{domain.Spec.Domain + ":443"},
+		},
+	}
+	edge.Spec.Metadata = d.ingressNgrokMetadata
+	return edge
+}
+
+// httpsRouteDomains returns the hostnames of the HTTPS listeners a route is attached to by its parents, in order
+func httpsRouteDomains(parents []gatewayv1.ParentReference, parentListeners func(gatewayv1.ParentReference) (*gatewayv1.Gateway, []gatewayv1.Listener, gatewayv1.RouteConditionReason, string)) []string {
+	var routeDomains []string
+	for _, parent := range parents {
+		_, listeners, reason, _ := parentListeners(parent)
+		if reason != gatewayv1.RouteReasonAccepted {
+			continue
+		}
+		for _, listener := range listeners {
+			if !slices.Contains(routeDomains, string(*listener.Hostname)) {
+				routeDomains = append(routeDomains, string(*listener.Hostname))
+			}
+		}
+	}
+	return routeDomains
+}
+
+// newGatewayHTTPSEdge returns an HTTPSEdge without routes serving the domains of a Gateway API route
+func (d *Driver) newGatewayHTTPSEdge(routeName, routeNamespace string, routeDomains []string) ingressv1alpha1.HTTPSEdge {
+	var hostPorts []string
+	for _, domain := range routeDomains {
+		hostPorts = append(hostPorts, domain+":443")
+	}
+	edge := ingressv1alpha1.HTTPSEdge{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: routeName + "-",
+			Namespace:    routeNamespace,
+			Labels:       d.edgeLabels(),
+		},
+		Spec: ingressv1alpha1.HTTPSEdgeSpec{
+			Hostports: hostPorts,
+		},
+	}
+	edge.Spec.Metadata = d.gatewayNgrokMetadata
+	return edge
+}
+
+// ingressRouteKey identifies an edge route of an ingress. Two ingresses defining the same key conflict with each other.
+type ingressRouteKey struct {
+	Host      string
+	Match     string
+	MatchType string
+}
+
+func (k ingressRouteKey) String() string {
+	return fmt.Sprintf("%s%s (%s)", k.Host, k.Match, k.MatchType)
+}
+
+// ingressRouteConflict records a route of an ingress that was shadowed by the same route of an older ingress
+type ingressRouteConflict struct {
+	Route ingressRouteKey
+	Owner types.NamespacedName
+}
+
+func (c ingressRouteConflict) String() string {
+	return fmt.Sprintf("%s shadowed by %s", c.Route, c.Owner)
+}
+
+// calculateHTTPSEdgesFromIngress adds the routes of the ingresses to the edges of their hosts. When several ingresses
+// define the same host, path and match type, the ingress with the oldest creationTimestamp wins and the routes of
+// the other ingresses are returned as conflicts, keyed by the ingress that lost.
+func (d *Driver) calculateHTTPSEdgesFromIngress(edgeMap map[string]ingressv1alpha1.HTTPSEdge) map[types.NamespacedName][]ingressRouteConflict {
+	return d.calculateHTTPSEdgesFromIngresses(edgeMap, d.store.ListNgrokIngressesV1())
+}
+
+// calculateHTTPSEdgesFromIngresses is calculateHTTPSEdgesFromIngress for the given ingresses only. The ingresses
+// must include every ingress defining one of their hosts for the conflicts between them to be resolved.
+func (d *Driver) calculateHTTPSEdgesFromIngresses(edgeMap map[string]ingressv1alpha1.HTTPSEdge, ingresses []*netv1.Ingress) map[types.NamespacedName][]ingressRouteConflict {
+	// The hosts of the ingresses using the endpoints mapping strategy are translated to CloudEndpoints instead
+	ingresses, _ = d.ingressesByMappingStrategy(ingresses)
+	slices.SortStableFunc(ingresses, compareIngressAge)
+
+	routes := newIngressRouteOwners(d.log)
+	claimRoute := routes.claim
+
+	for _, ingress := range ingresses {
+		ingressName := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+		modSet, err := d.getNgrokModuleSetForIngress(ingress)
+		if err != nil {
+			d.recordSyncError("Ingress", ingressName, err, "error getting ngrok moduleset for ingress")
+			continue
+		}
+
+		policyJSON, err := d.getTrafficPolicyJSON(ingress, modSet)
+		if err != nil {
+			d.recordSyncError("Ingress", ingressName, err, "error getting traffic policy for ingress")
+			continue
+		}
+
+		for _, rule := range ingress.Spec.Rules {
+			edge, ok := edgeMap[rule.Host]
+			if !ok {
+				d.log.Error(nil, "could not find edge associated with rule", "host", rule.Host)
+				continue
+			}
+
+			if modSet.Modules.TLSTermination != nil && modSet.Modules.TLSTermination.MinVersion != nil {
+				edge.Spec.TLSTermination = &ingressv1alpha1.EndpointTLSTerminationAtEdge{
+					MinVersion: ptr.Deref(modSet.Modules.TLSTermination.MinVersion, ""),
+				}
+			}
+
+			if modSet.Modules.MutualTLS != nil {
+				edge.Spec.MutualTLS = modSet.Modules.MutualTLS
+			}
+
+			// If any rule for an ingress matches, then it applies to this ingress
+			var paths []netv1.HTTPIngressPath
+			if rule.HTTP != nil {
+				paths = rule.HTTP.Paths
+			}
+			for _, httpIngressPath := range paths {
+				matchType, err := ingressPathMatchType(httpIngressPath)
+				if err != nil {
+					d.recordSyncError("Ingress", ingressName, err, "unsupported ingress path", "path", httpIngressPath.Path)
+					continue
+				}
+
+				route, err := d.ingressEdgeRoute(ingress, httpIngressPath.Backend, httpIngressPath.Path, matchType, modSet, policyJSON)
+				if err != nil {
+					d.recordSyncError("Ingress", ingressName, err, fmt.Sprintf("could not resolve backend for ingress path %q", httpIngressPath.Path))
+					continue
+				}
+
+				if !claimRoute(ingress, ingressRouteKey{Host: rule.Host, Match: route.Match, MatchType: route.MatchType}) {
+					continue
+				}
+
+				edge.Spec.Routes = append(edge.Spec.Routes, *route)
+			}
+
+			// The default backend catches everything the paths of the edge don't, unless a path already does
+			if ingress.Spec.DefaultBackend != nil && claimRoute(ingress, ingressRouteKey{Host: rule.Host, Match: "/", MatchType: "path_prefix"}) {
+				route, err := d.ingressEdgeRoute(ingress, *ingress.Spec.DefaultBackend, "/", "path_prefix", modSet, policyJSON)
+				if err != nil {
+					d.recordSyncError("Ingress", ingressName, err, "could not resolve default backend for ingress")
+				} else {
+					edge.Spec.Routes = append(edge.Spec.Routes, *route)
+				}
+			}
+
+			edgeMap[rule.Host] = edge
+		}
+	}
+	return routes.conflicts
+}
+
+// ingressRouteOwners records which ingress owns each route of the ingress hosts. The ingresses must claim their routes
+// from the oldest to the newest, so that the oldest ingress defining a route owns it.
+type ingressRouteOwners struct {
+	log       logr.Logger
+	owners    map[ingressRouteKey]types.NamespacedName
+	conflicts map[types.NamespacedName][]ingressRouteConflict
+}
+
+func newIngressRouteOwners(log logr.Logger) *ingressRouteOwners {
+	return &ingressRouteOwners{
+		log:       log,
+		owners:    map[ingressRouteKey]types.NamespacedName{},
+		conflicts: map[types.NamespacedName][]ingressRouteConflict{},
+	}
+}
+
+// claim returns true if the ingress owns the route, recording a conflict when an older ingress already does
+func (o *ingressRouteOwners) claim(ingress *netv1.Ingress, key ingressRouteKey) bool {
+	ingressName := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+	owner, ok := o.owners[key]
+	if !ok {
+		o.owners[key] = ingressName
+		return true
+	}
+	if owner != ingressName {
+		o.log.Info("route is already defined by an older ingress, ignoring it", "route", key.String(), "ingress", ingressName, "owner", owner)
+		o.conflicts[ingressName] = append(o.conflicts[ingressName], ingressRouteConflict{Route: key, Owner: owner})
+	}
+	return false
+}
+
+// ingressPathMatchType returns the match type of the edge route of an ingress path
+func ingressPathMatchType(path netv1.HTTPIngressPath) (string, error) {
+	if path.PathType == nil {
+		return "path_prefix", nil
+	}
+	switch *path.PathType {
+	case netv1.PathTypePrefix:
+		return "path_prefix", nil
+	case netv1.PathTypeExact:
+		return "exact_path", nil
+	case netv1.PathTypeImplementationSpecific:
+		return "path_prefix", nil // Path Prefix seems like a sane default for most cases
+	default:
+		return "", fmt.Errorf("unknown path type %q", *path.PathType)
+	}
+}
+
+// compareIngressAge orders ingresses from the oldest to the newest, falling back to their namespace and name
+// so that ingresses created in the same second are still ordered deterministically.
+func compareIngressAge(a, b *netv1.Ingress) int {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		if a.CreationTimestamp.Before(&b.CreationTimestamp) {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Or(
+		cmp.Compare(a.Namespace, b.Namespace),
+		cmp.Compare(a.Name, b.Name),
+	)
+}
+
+// ingressEdgeRoute returns the edge route for an ingress backend. Service backends are routed to the service's
+// tunnels, while resource backends forward to the internal URL of the ngrok resource they reference.
+func (d *Driver) ingressEdgeRoute(ingress *netv1.Ingress, backend netv1.IngressBackend, match, matchType string, modSet *ingressv1alpha1.NgrokModuleSet, policyJSON json.RawMessage) (*ingressv1alpha1.HTTPSEdgeRouteSpec, error) {
+	route := ingressv1alpha1.HTTPSEdgeRouteSpec{
+		Match:               match,
+		MatchType:           matchType,
+		CircuitBreaker:      modSet.Modules.CircuitBreaker,
+		Compression:         modSet.Modules.Compression,
+		IPRestriction:       modSet.Modules.IPRestriction,
+		Headers:             modSet.Modules.Headers,
+		OAuth:               modSet.Modules.OAuth,
+		Policy:              policyJSON,
+		OIDC:                modSet.Modules.OIDC,
+		SAML:                modSet.Modules.SAML,
+		WebhookVerification: modSet.Modules.WebhookVerification,
+	}
+	route.Metadata = d.ingressNgrokMetadata
+
+	switch {
+	case backend.Service != nil:
+		serviceUID, servicePort, err := d.getEdgeBackend(*backend.Service, ingress.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		route.Backend = ingressv1alpha1.TunnelGroupBackend{
+			Labels: d.ngrokLabels(ingress.Namespace, serviceUID, backend.Service.Name, servicePort),
+		}
+	case backend.Resource != nil:
+		url, err := d.ingressResourceBackendURL(backend.Resource, ingress.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		policy, err := forwardInternalPolicy(policyJSON, url)
+		if err != nil {
+			return nil, err
+		}
+		route.Policy = policy
+	default:
+		return nil, fmt.Errorf("ingress backend has neither a service nor a resource")
+	}
+
+	return &route, nil
+}
+
+// ingressResourceBackendURL returns the URL of the ngrok resource an ingress resource backend references
+func (d *Driver) ingressResourceBackendURL(resource *corev1.TypedLocalObjectReference, namespace string) (string, error) {
+	if !isSupportedIngressResourceBackend(resource) {
+		return "", fmt.Errorf("unsupported resource backend %s", resource.Kind)
+	}
+
+	switch resource.Kind {
+	case "CloudEndpoint":
+		endpoint, err := d.store.GetCloudEndpointV1(resource.Name, namespace)
+		if err != nil {
+			return "", err
+		}
+		// the forward-internal action only forwards to internal endpoints
+		if !isInternalEndpointURL(endpoint.Spec.URL) {
+			return "", fmt.Errorf("CloudEndpoint %s/%s has the URL %q, ingress resource backends must reference an internal endpoint whose hostname ends with .internal", namespace, resource.Name, endpoint.Spec.URL)
+		}
+		return endpoint.Spec.URL, nil
+	}
+	return "", fmt.Errorf("unsupported resource backend %s", resource.Kind)
+}
+
+// isInternalEndpointURL checks if the hostname of an endpoint URL, with or without a scheme, ends with .internal
+func isInternalEndpointURL(endpointURL string) bool {
+	if !strings.Contains(endpointURL, "://") {
+		endpointURL = "https://" + endpointURL
+	}
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(u.Hostname(), ".internal")
+}
+
+// forwardInternalPolicy appends a rule forwarding every request to the internal URL to the traffic policy.
+// It runs last so the rules of the policy still apply to the requests before they're forwarded.
+func forwardInternalPolicy(policyJSON json.RawMessage, url string) (json.RawMessage, error) {
+	trafficPolicy := util.NewTrafficPolicy()
+	if len(policyJSON) > 0 {
+		existingPolicy, err := util.NewTrafficPolicyFromJson(policyJSON)
+		if err != nil {
+			return nil, err
 		}
-		return computedModSet, err
+		if existingPolicy.IsLegacyPolicy() {
+			existingPolicy.ConvertLegacyDirectionsToPhases()
+		}
+		trafficPolicy.Merge(existingPolicy)
 	}
 
-	for _, module := range modules {
-		resolvedMod, err := d.store.GetNgrokModuleSetV1(module, ing.Namespace)
+	config, err := json.Marshal(ForwardInternalConfig{URL: url})
+	if err != nil {
+		return nil, err
+	}
+	rawAction, err := json.Marshal(&util.EndpointAction{
+		Type:   "forward-internal",
+		Config: config,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := trafficPolicy.MergeEndpointRule(util.EndpointRule{
+		Name:    "Forward to " + url,
+		Actions: []util.RawAction{rawAction},
+	}, util.PhaseOnHttpRequest); err != nil {
+		return nil, err
+	}
+	return trafficPolicy.ToCRDJson()
+}
+
+// getTrafficPolicyJSON retrieves the traffic policy for an ingress and falls back to the modSet policy if it doesn't exist.
+func (d *Driver) getTrafficPolicyJSON(ingress *netv1.Ingress, modSet *ingressv1alpha1.NgrokModuleSet) (json.RawMessage, error) {
+	var err error
+	var policyJSON json.RawMessage
+
+	trafficPolicy, err := d.getNgrokTrafficPolicyForIngress(ingress)
+
+	if err != nil {
+		d.log.Error(err, "error getting ngrok traffic policy for ingress", "ingress", ingress)
+		return nil, err
+	}
+
+	if modSet.Modules.Policy != nil && trafficPolicy != nil {
+		return nil, fmt.Errorf("cannot have both a traffic policy and a moduleset policy on ingress: %s", ingress.Name)
+	}
+
+	if trafficPolicy != nil {
+		return trafficPolicy.Spec.Policy, nil
+	}
+
+	if modSet == nil {
+		return policyJSON, nil
+	}
+
+	if policyJSON, err = json.Marshal(modSet.Modules.Policy); err != nil {
+		d.log.Error(err, "cannot convert module-set policy json", "ingress", ingress, "Policy", modSet.Modules.Policy)
+		return nil, err
+	}
+
+	return policyJSON, nil
+}
+
+func (d *Driver) calculateHTTPSEdgesFromGateway(edgeMap map[string]ingressv1alpha1.HTTPSEdge, gatewayDomainMap map[string]ingressv1alpha1.Domain) {
+	gateways := d.store.ListNgrokGateways()
+
+	for _, gtw := range gateways {
+		for _, listener := range gtw.Spec.Listeners {
+			if listener.Hostname == nil {
+				continue
+			}
+			domainName := string(*listener.Hostname)
+			edge, ok := edgeMap[domainName]
+			if !ok {
+				continue
+			}
+			branches := d.listenerRouteBranches(gtw, listener, gatewayDomainMap)
+			for _, branch := range branches {
+				if branch.err != nil {
+					d.recordSyncError(branch.source.Kind, branch.source.NamespacedName, branch.err, branch.errMsg)
+				}
+			}
+
+			routes, conflicts := mergeRouteBranches(branches)
+			for source, errs := range conflicts {
+				for _, err := range errs {
+					d.recordSyncError(source.Kind, source.NamespacedName, nil, err)
+				}
+			}
+
+			for _, route := range routes {
+				policy, err := route.trafficPolicy()
+				if err != nil {
+					// the edge route is dropped, report it on every route merged into it
+					for _, branch := range route.branches {
+						d.recordSyncError(branch.source.Kind, branch.source.NamespacedName, err,
+							fmt.Sprintf("error merging the traffic policies of the edge route for %s%s", domainName, route.path))
+					}
+					continue
+				}
+
+				edgeRoute := ingressv1alpha1.HTTPSEdgeRouteSpec{
+					Match:     route.path,
+					MatchType: route.pathType,
+					Policy:    policy,
+					Backend:   route.backend,
+				}
+				edgeRoute.Metadata = d.gatewayNgrokMetadata
+
+				edge.Spec.Routes = append(edge.Spec.Routes, edgeRoute)
+			}
+
+			edgeMap[domainName] = edge
+		}
+	}
+}
+
+// edgeRouteBackend returns the backend of the edge route for a Gateway API route rule. It's the tunnels of the
+// rule's backend, or the rule's weighted tunnel group when traffic is split between several backends.
+func (d *Driver) edgeRouteBackend(backendRefs []gatewayv1.HTTPBackendRef, routeKind gatewayv1.Kind, namespace, routeName string, ruleIdx int) (ingressv1alpha1.TunnelGroupBackend, error) {
+	// rules with too many weighted backends are reported by calculateBackendWeightsCondition and get no backend
+	backends, _, _ := weightedBackends(d.resolvedHTTPBackendRefs(backendRefs, routeKind, namespace))
+	switch {
+	case len(backends) == 1:
+		backendref := backends[0].backendRef
+		refName := string(backendref.Name)
+		serviceUID, servicePort, err := d.getEdgeBackendRef(backendref, routeKind, namespace)
 		if err != nil {
-			return computedModSet, err
+			return ingressv1alpha1.TunnelGroupBackend{}, fmt.Errorf("could not find port for service %q: %w", refName, err)
+		}
+
+		labels := d.ngrokLabels(namespace, serviceUID, refName, servicePort)
+		if routeKind == "GRPCRoute" {
+			// see calculateTunnelForBackendRef, gRPC backends have HTTP/2 tunnels of their own
+			labels[labelAppProtocol] = "http2"
+		}
+		return ingressv1alpha1.TunnelGroupBackend{
+			Labels: labels,
+		}, nil
+	case len(backends) > 1:
+		// traffic is split between the backends by the number of tunnels each of them
+		// has in the rule's weighted tunnel group, see calculateTunnelsFromGateway
+		return ingressv1alpha1.TunnelGroupBackend{
+			Labels: d.weightedGroupLabels(namespace, weightedGroupName(routeKind, namespace, routeName, ruleIdx)),
+		}, nil
+	}
+	return ingressv1alpha1.TunnelGroupBackend{}, nil
+}
+
+// calculateTCPEdges builds a TCPEdge for each TCPRoute attached to a TCP listener of one of our Gateways.
+// The edges are keyed by the namespace/name of the TCPRoute that owns them.
+func (d *Driver) calculateTCPEdges() map[string]ingressv1alpha1.TCPEdge {
+	edgeMap := make(map[string]ingressv1alpha1.TCPEdge)
+
+	for _, tcproute := range d.store.ListTCPRoutes() {
+		if !d.tcpRouteAccepted(tcproute) {
+			continue
+		}
+
+		backendRef, err := tcpRouteBackendRef(tcproute)
+		if err != nil {
+			d.recordSyncError("TCPRoute", client.ObjectKeyFromObject(tcproute), err, "no usable backend for tcproute")
+			continue
+		}
+
+		if _, err := d.checkBackendRef(*backendRef, "TCPRoute", tcproute.Namespace); err != nil {
+			d.recordSyncError("TCPRoute", client.ObjectKeyFromObject(tcproute), err, "could not resolve backend for tcproute")
+			continue
+		}
+
+		refName := string(backendRef.Name)
+		serviceUID, servicePort, err := d.getEdgeBackendRef(*backendRef, "TCPRoute", tcproute.Namespace)
+		if err != nil {
+			d.recordSyncError("TCPRoute", client.ObjectKeyFromObject(tcproute), err, "could not find port for service", "service", refName)
+			continue
+		}
+
+		edge := ingressv1alpha1.TCPEdge{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: tcproute.Name + "-",
+				Namespace:    tcproute.Namespace,
+				Labels:       d.edgeLabels(),
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: gatewayv1alpha2.GroupVersion.String(),
+						Kind:       "TCPRoute",
+						Name:       tcproute.Name,
+						UID:        tcproute.UID,
+					},
+				},
+			},
+			Spec: ingressv1alpha1.TCPEdgeSpec{
+				Backend: ingressv1alpha1.TunnelGroupBackend{
+					Labels: d.ngrokLabels(tcproute.Namespace, serviceUID, refName, servicePort),
+				},
+			},
+		}
+		edge.Spec.Metadata = d.gatewayNgrokMetadata
+		edgeMap[getKey(tcproute.Name, tcproute.Namespace)] = edge
+	}
+
+	return edgeMap
+}
+
+// tcpEdgeKeyFromEdge returns the key of the TCPRoute owning the edge, matching the keys of calculateTCPEdges
+func tcpEdgeKeyFromEdge(edge ingressv1alpha1.TCPEdge) (string, bool) {
+	for _, ref := range edge.OwnerReferences {
+		if ref.Kind == "TCPRoute" {
+			return getKey(ref.Name, edge.Namespace), true
+		}
+	}
+	return "", false
+}
+
+// tcpRouteBackendRef returns the backendRef a TCPEdge will forward to. A TCPEdge has a single
+// tunnel group backend, so routes with more than one backendRef aren't supported.
+func tcpRouteBackendRef(tcproute *gatewayv1alpha2.TCPRoute) (*gatewayv1.BackendRef, error) {
+	var backendRefs []gatewayv1.BackendRef
+	for _, rule := range tcproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	return singleRouteBackendRef(backendRefs)
+}
+
+// singleRouteBackendRef returns the only backendRef of a TCPRoute or TLSRoute
+func singleRouteBackendRef(backendRefs []gatewayv1.BackendRef) (*gatewayv1.BackendRef, error) {
+	switch len(backendRefs) {
+	case 0:
+		return nil, fmt.Errorf("the route has no backendRefs")
+	case 1:
+		return &backendRefs[0], nil
+	default:
+		return nil, fmt.Errorf("ngrok TCP and TLS edges forward to a single backend, the route has %d backendRefs", len(backendRefs))
+	}
+}
+
+// tcpRouteParentAccepted checks whether the TCPRoute can attach to a TCP listener of the Gateway selected by parent.
+// It returns an empty reason when the parent isn't one of our Gateways.
+func (d *Driver) tcpRouteParentAccepted(tcproute *gatewayv1alpha2.TCPRoute, parent gatewayv1.ParentReference) (bool, gatewayv1.RouteConditionReason, string) {
+	_, listeners, reason, message := d.routeParentListeners(parent, "TCPRoute", tcproute.Namespace, gatewayv1.TCPProtocolType)
+	return len(listeners) > 0, reason, message
+}
+
+// tcpRouteAccepted checks if one of the parents of the TCPRoute is a TCP listener of our Gateways accepting it
+func (d *Driver) tcpRouteAccepted(tcproute *gatewayv1alpha2.TCPRoute) bool {
+	for _, parent := range tcproute.Spec.ParentRefs {
+		if ok, _, _ := d.tcpRouteParentAccepted(tcproute, parent); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// routeParentListeners returns the Gateway selected by a route's parentRef and the listeners of the given protocol
+// the route is allowed to attach to. When there are no such listeners, the reason and message explain why.
+// The gateway is nil and the reason empty when the parent isn't one of our Gateways.
+func (d *Driver) routeParentListeners(parent gatewayv1.ParentReference, routeKind gatewayv1.Kind, routeNamespace string, protocol gatewayv1.ProtocolType) (*gatewayv1.Gateway, []gatewayv1.Listener, gatewayv1.RouteConditionReason, string) {
+	gtw := d.findGatewayForParentRef(parent, routeNamespace)
+	if gtw == nil {
+		return nil, nil, "", ""
+	}
+
+	matchedListener := false
+	var listeners []gatewayv1.Listener
+	for _, listener := range gtw.Spec.Listeners {
+		if !parentRefMatchesListener(parent, listener) {
+			continue
+		}
+		matchedListener = true
+
+		if listener.Protocol != protocol {
+			continue
+		}
+		if d.listenerAllowsRoute(gtw, listener, routeKind, routeNamespace) {
+			listeners = append(listeners, listener)
+		}
+	}
+
+	switch {
+	case len(listeners) > 0:
+		return gtw, listeners, gatewayv1.RouteReasonAccepted, "Route is accepted"
+	case !matchedListener:
+		return gtw, nil, gatewayv1.RouteReasonNoMatchingParent, "No listener matches the parentRef sectionName and port"
+	default:
+		return gtw, nil, gatewayv1.RouteReasonNotAllowedByListeners, fmt.Sprintf("No %s listener of the gateway allows this route", protocol)
+	}
+}
+
+// calculateTCPRouteStatus computes the status of a TCPRoute for the parents handled by this controller,
+// leaving the status reported by other controllers untouched.
+func (d *Driver) calculateTCPRouteStatus(tcproute *gatewayv1alpha2.TCPRoute) gatewayv1.RouteStatus {
+	var backendRefs []gatewayv1.BackendRef
+	for _, rule := range tcproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	resolvedReason, resolvedErr := d.checkRouteBackendRefs(backendRefs, "TCPRoute", tcproute.Namespace)
+
+	return calculateRouteStatus(tcproute.Status.RouteStatus, tcproute.Spec.ParentRefs, tcproute.Generation, resolvedReason, resolvedErr,
+		func(parent gatewayv1.ParentReference) (bool, gatewayv1.RouteConditionReason, string) {
+			ok, reason, message := d.tcpRouteParentAccepted(tcproute, parent)
+			return singleBackendRefAccepted(backendRefs, ok, reason, message)
+		},
+	)
+}
+
+// checkRouteBackendRefs checks the backendRefs of a TCPRoute or TLSRoute, returning the reason of the
+// ResolvedRefs condition and the error of the first backendRef that can't be resolved
+func (d *Driver) checkRouteBackendRefs(backendRefs []gatewayv1.BackendRef, routeKind gatewayv1.Kind, namespace string) (gatewayv1.RouteConditionReason, error) {
+	if len(backendRefs) == 0 {
+		return gatewayv1.RouteReasonBackendNotFound, fmt.Errorf("the route has no backendRefs")
+	}
+	for _, backendRef := range backendRefs {
+		if reason, err := d.checkBackendRef(backendRef, routeKind, namespace); err != nil {
+			return reason, err
+		}
+	}
+	return gatewayv1.RouteReasonResolvedRefs, nil
+}
+
+// singleBackendRefAccepted rejects routes with more than one backendRef on the parents that would otherwise
+// accept them, since TCP and TLS edges forward to a single backend
+func singleBackendRefAccepted(backendRefs []gatewayv1.BackendRef, ok bool, reason gatewayv1.RouteConditionReason, message string) (bool, gatewayv1.RouteConditionReason, string) {
+	if ok && len(backendRefs) > 1 {
+		_, err := singleRouteBackendRef(backendRefs)
+		return false, gatewayv1.RouteReasonUnsupportedValue, err.Error()
+	}
+	return ok, reason, message
+}
+
+// httpRouteParentListeners returns the HTTPS listeners of the Gateway selected by parent that the HTTPRoute is attached to
+func (d *Driver) httpRouteParentListeners(httproute *gatewayv1.HTTPRoute, parent gatewayv1.ParentReference, gatewayDomainMap map[string]ingressv1alpha1.Domain) (*gatewayv1.Gateway, []gatewayv1.Listener, gatewayv1.RouteConditionReason, string) {
+	return d.httpsRouteParentListeners(parent, "HTTPRoute", httproute.Namespace, httproute.Spec.Hostnames, gatewayDomainMap)
+}
+
+// grpcRouteParentListeners returns the HTTPS listeners of the Gateway selected by parent that the GRPCRoute is attached to
+func (d *Driver) grpcRouteParentListeners(grpcroute *gatewayv1alpha2.GRPCRoute, parent gatewayv1.ParentReference, gatewayDomainMap map[string]ingressv1alpha1.Domain) (*gatewayv1.Gateway, []gatewayv1.Listener, gatewayv1.RouteConditionReason, string) {
+	return d.httpsRouteParentListeners(parent, "GRPCRoute", grpcroute.Namespace, grpcroute.Spec.Hostnames, gatewayDomainMap)
+}
+
+// httpsRouteParentListeners returns the HTTPS listeners of the Gateway selected by parent that a route served by
+// HTTPS edges is attached to. ngrok HTTPS edges are only served on port 443 for listener hostnames reserved as gateway
+// Domains, and the listener hostname must be one of the route's hostnames. The reason is empty when the parent isn't
+// one of our Gateways.
+func (d *Driver) httpsRouteParentListeners(parent gatewayv1.ParentReference, routeKind gatewayv1.Kind, routeNamespace string, hostnames []gatewayv1.Hostname, gatewayDomainMap map[string]ingressv1alpha1.Domain) (*gatewayv1.Gateway, []gatewayv1.Listener, gatewayv1.RouteConditionReason, string) {
+	gtw, listeners, reason, message := d.routeParentListeners(parent, routeKind, routeNamespace, gatewayv1.HTTPSProtocolType)
+	if len(listeners) == 0 {
+		return gtw, nil, reason, message
+	}
+
+	listeners = slices.DeleteFunc(listeners, func(listener gatewayv1.Listener) bool {
+		if listener.Port != 443 || listener.Hostname == nil {
+			return true
+		}
+		_, hasDomain := gatewayDomainMap[string(*listener.Hostname)]
+		return !hasDomain
+	})
+	if len(listeners) == 0 {
+		return gtw, nil, gatewayv1.RouteReasonNotAllowedByListeners, "ngrok HTTPS edges are only served on port 443 for listener hostnames that aren't used by an Ingress"
+	}
+
+	listeners = slices.DeleteFunc(listeners, func(listener gatewayv1.Listener) bool {
+		return len(routeHostnamesForListener(listener, hostnames)) == 0
+	})
+	if len(listeners) == 0 {
+		return gtw, nil, gatewayv1.RouteReasonNoMatchingListenerHostname, "No hostname of the route matches a listener hostname"
+	}
+
+	return gtw, listeners, gatewayv1.RouteReasonAccepted, "Route is accepted"
+}
+
+// httpRouteAttachedToListener checks if one of the parents of the HTTPRoute attaches it to the listener of gtw
+func (d *Driver) httpRouteAttachedToListener(httproute *gatewayv1.HTTPRoute, gtw *gatewayv1.Gateway, listener gatewayv1.Listener, gatewayDomainMap map[string]ingressv1alpha1.Domain) bool {
+	return slices.ContainsFunc(httproute.Spec.ParentRefs, func(parent gatewayv1.ParentReference) bool {
+		parentGateway, listeners, _, _ := d.httpRouteParentListeners(httproute, parent, gatewayDomainMap)
+		return isGatewayListener(gtw, listener, parentGateway, listeners)
+	})
+}
+
+// grpcRouteAttachedToListener checks if one of the parents of the GRPCRoute attaches it to the listener of gtw
+func (d *Driver) grpcRouteAttachedToListener(grpcroute *gatewayv1alpha2.GRPCRoute, gtw *gatewayv1.Gateway, listener gatewayv1.Listener, gatewayDomainMap map[string]ingressv1alpha1.Domain) bool {
+	return slices.ContainsFunc(grpcroute.Spec.ParentRefs, func(parent gatewayv1.ParentReference) bool {
+		parentGateway, listeners, _, _ := d.grpcRouteParentListeners(grpcroute, parent, gatewayDomainMap)
+		return isGatewayListener(gtw, listener, parentGateway, listeners)
+	})
+}
+
+// isGatewayListener checks if listener of gtw is one of the listeners of parentGateway
+func isGatewayListener(gtw *gatewayv1.Gateway, listener gatewayv1.Listener, parentGateway *gatewayv1.Gateway, listeners []gatewayv1.Listener) bool {
+	if parentGateway == nil || parentGateway.Namespace != gtw.Namespace || parentGateway.Name != gtw.Name {
+		return false
+	}
+	return slices.ContainsFunc(listeners, func(l gatewayv1.Listener) bool { return l.Name == listener.Name })
+}
+
+// calculateGatewayStatus computes the status of a Gateway. Its addresses are the targets of the Domains reserved for
+// its listener hostnames and the hosts of the TCP addresses reserved for its TCPRoutes, keyed by route in
+// tcpHostports. Each listener reports whether ngrok can serve it along with its attached routes.
+func (d *Driver) calculateGatewayStatus(gtw *gatewayv1.Gateway, gatewayDomainMap map[string]ingressv1alpha1.Domain, domainsByDomain map[string]ingressv1alpha1.Domain, tcpHostports map[string][]string) gatewayv1.GatewayStatus {
+	status := *gtw.Status.DeepCopy()
+	addAddress := func(hostname string) {
+		if hostname == "" || slices.ContainsFunc(status.Addresses, func(address gatewayv1.GatewayStatusAddress) bool { return address.Value == hostname }) {
+			return
+		}
+		status.Addresses = append(status.Addresses, gatewayv1.GatewayStatusAddress{
+			Type:  ptr.To(gatewayv1.HostnameAddressType),
+			Value: hostname,
+		})
+	}
+
+	status.Addresses = nil
+	needsAddress := false
+	for _, listener := range gtw.Spec.Listeners {
+		if listener.Hostname == nil {
+			continue
+		}
+		needsAddress = true
+		domain, ok := domainsByDomain[string(*listener.Hostname)]
+		if !ok {
+			continue
+		}
+		addAddress(domainAddress(domain))
+	}
+
+	// The TCP edges are served on the address reserved for each of them, not on the listener port
+	listenerHostports := map[gatewayv1.SectionName][]string{}
+	listenerHostportsReserved := map[gatewayv1.SectionName]bool{}
+	for _, listener := range gtw.Spec.Listeners {
+		if listener.Protocol != gatewayv1.TCPProtocolType {
+			continue
+		}
+		hostports, reserved, hasEdges := d.listenerTCPHostports(gtw, listener, tcpHostports)
+		listenerHostports[listener.Name] = hostports
+		listenerHostportsReserved[listener.Name] = reserved
+		needsAddress = needsAddress || hasEdges
+		for _, hostport := range hostports {
+			host, _, err := net.SplitHostPort(hostport)
+			if err != nil {
+				host = hostport
+			}
+			addAddress(host)
+		}
+	}
+
+	listenerStatuses := make([]gatewayv1.ListenerStatus, 0, len(gtw.Spec.Listeners))
+	for _, listener := range gtw.Spec.Listeners {
+		listenerStatus := gatewayv1.ListenerStatus{Name: listener.Name}
+		for _, current := range status.Listeners {
+			if current.Name == listener.Name {
+				listenerStatus = *current.DeepCopy()
+				break
+			}
+		}
+
+		supportedKinds, invalidKinds := listenerSupportedKinds(listener)
+		listenerStatus.SupportedKinds = supportedKinds
+		listenerStatus.AttachedRoutes = d.listenerAttachedRoutes(gtw, listener, gatewayDomainMap)
+
+		accepted, acceptedReason, acceptedMessage := listenerAccepted(listener)
+		programmedReason, programmedMessage := gatewayv1.ListenerReasonProgrammed, "Listener is programmed"
+		if !accepted {
+			programmedReason, programmedMessage = gatewayv1.ListenerReasonInvalid, acceptedMessage
+		}
+		conflictedReason, conflictedMessage := gatewayv1.ListenerReasonNoConflicts, "Listener has no conflicts"
+		if listener.Hostname != nil {
+			if _, hasDomain := gatewayDomainMap[string(*listener.Hostname)]; !hasDomain {
+				conflictedReason, conflictedMessage = gatewayv1.ListenerReasonHostnameConflict, "The listener hostname is already used by an Ingress"
+				programmedReason, programmedMessage = gatewayv1.ListenerReasonInvalid, conflictedMessage
+			}
+		}
+		if listener.Protocol == gatewayv1.TCPProtocolType && programmedReason == gatewayv1.ListenerReasonProgrammed {
+			if !listenerHostportsReserved[listener.Name] {
+				programmedReason, programmedMessage = gatewayv1.ListenerReasonPending, "Waiting for the TCP addresses of the attached routes to be reserved"
+			} else if hostports := listenerHostports[listener.Name]; len(hostports) > 0 {
+				programmedMessage = fmt.Sprintf("Listener is programmed, the attached routes are served on %s", strings.Join(hostports, ", "))
+			}
+		}
+		resolvedReason, resolvedMessage := gatewayv1.ListenerReasonResolvedRefs, "All references are resolved"
+		if len(invalidKinds) > 0 {
+			resolvedReason, resolvedMessage = gatewayv1.ListenerReasonInvalidRouteKinds, fmt.Sprintf("Unsupported route kinds %s", strings.Join(invalidKinds, ", "))
+		} else if _, certReason, certMessage := d.listenerCertificateRef(gtw, listener); certReason != "" {
+			resolvedReason, resolvedMessage = certReason, certMessage
+		}
+
+		for _, condition := range []metav1.Condition{
+			newListenerCondition(gatewayv1.ListenerConditionAccepted, accepted, acceptedReason, acceptedMessage),
+			newListenerCondition(gatewayv1.ListenerConditionProgrammed, programmedReason == gatewayv1.ListenerReasonProgrammed, programmedReason, programmedMessage),
+			newListenerCondition(gatewayv1.ListenerConditionConflicted, conflictedReason != gatewayv1.ListenerReasonNoConflicts, conflictedReason, conflictedMessage),
+			newListenerCondition(gatewayv1.ListenerConditionResolvedRefs, resolvedReason == gatewayv1.ListenerReasonResolvedRefs, resolvedReason, resolvedMessage),
+		} {
+			condition.ObservedGeneration = gtw.Generation
+			meta.SetStatusCondition(&listenerStatus.Conditions, condition)
+		}
+		listenerStatuses = append(listenerStatuses, listenerStatus)
+	}
+	status.Listeners = listenerStatuses
+
+	programmed := metav1.Condition{
+		Type:    string(gatewayv1.GatewayConditionProgrammed),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1.GatewayReasonProgrammed),
+		Message: "Gateway is programmed",
+	}
+	if needsAddress && len(status.Addresses) == 0 {
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = string(gatewayv1.GatewayReasonAddressNotAssigned)
+		programmed.Message = "Waiting for the domains of the listener hostnames and the TCP addresses of the routes to be reserved"
+	}
+	for _, condition := range []metav1.Condition{
+		{
+			Type:    string(gatewayv1.GatewayConditionAccepted),
+			Status:  metav1.ConditionTrue,
+			Reason:  string(gatewayv1.GatewayReasonAccepted),
+			Message: "Gateway is accepted",
+		},
+		programmed,
+	} {
+		condition.ObservedGeneration = gtw.Generation
+		meta.SetStatusCondition(&status.Conditions, condition)
+	}
+
+	return status
+}
+
+// listenerTCPHostports returns the hostports of the TCP edges of the TCPRoutes attached to a TCP listener of gtw,
+// whether every edge has its hostports reserved, and whether any attached route has an edge
+func (d *Driver) listenerTCPHostports(gtw *gatewayv1.Gateway, listener gatewayv1.Listener, tcpHostports map[string][]string) ([]string, bool, bool) {
+	var hostports []string
+	reserved, hasEdges := true, false
+	for _, tcproute := range d.store.ListTCPRoutes() {
+		routeHostports, ok := tcpHostports[getKey(tcproute.Name, tcproute.Namespace)]
+		if !ok {
+			continue
+		}
+		if !slices.ContainsFunc(tcproute.Spec.ParentRefs, func(parent gatewayv1.ParentReference) bool {
+			parentGateway, listeners, _, _ := d.routeParentListeners(parent, "TCPRoute", tcproute.Namespace, gatewayv1.TCPProtocolType)
+			return isGatewayListener(gtw, listener, parentGateway, listeners)
+		}) {
+			continue
+		}
+		hasEdges = true
+		if len(routeHostports) == 0 {
+			reserved = false
+		}
+		for _, hostport := range routeHostports {
+			if !slices.Contains(hostports, hostport) {
+				hostports = append(hostports, hostport)
+			}
+		}
+	}
+	slices.Sort(hostports)
+	return hostports, reserved, hasEdges
+}
+
+// listenerSupportedKinds returns the route kinds a listener supports, restricted to its allowed route kinds if set,
+// along with the allowed kinds it can't support
+func listenerSupportedKinds(listener gatewayv1.Listener) ([]gatewayv1.RouteGroupKind, []string) {
+	var kinds []gatewayv1.Kind
+	switch listener.Protocol {
+	case gatewayv1.HTTPSProtocolType:
+		kinds = []gatewayv1.Kind{"HTTPRoute", "GRPCRoute"}
+	case gatewayv1.TLSProtocolType:
+		kinds = []gatewayv1.Kind{"TLSRoute"}
+	case gatewayv1.TCPProtocolType:
+		kinds = []gatewayv1.Kind{"TCPRoute"}
+	}
+
+	supported := []gatewayv1.RouteGroupKind{}
+	if listener.AllowedRoutes == nil || len(listener.AllowedRoutes.Kinds) == 0 {
+		for _, kind := range kinds {
+			supported = append(supported, gatewayv1.RouteGroupKind{Group: ptr.To(gatewayv1.Group(gatewayv1.GroupName)), Kind: kind})
+		}
+		return supported, nil
+	}
+
+	invalid := []string{}
+	for _, allowed := range listener.AllowedRoutes.Kinds {
+		if (allowed.Group == nil || *allowed.Group == gatewayv1.GroupName) && slices.Contains(kinds, allowed.Kind) {
+			supported = append(supported, allowed)
+			continue
+		}
+		invalid = append(invalid, string(allowed.Kind))
+	}
+	return supported, invalid
+}
+
+// listenerAccepted checks if ngrok can serve a listener. HTTPS and TLS listeners are served by edges on port 443,
+// HTTPS edges also need a hostname. TCP listeners are served on the addresses reserved for the TCP edge of each of
+// their routes, their port isn't honoured.
+func listenerAccepted(listener gatewayv1.Listener) (bool, gatewayv1.ListenerConditionReason, string) {
+	switch listener.Protocol {
+	case gatewayv1.HTTPSProtocolType:
+		if listener.Port != 443 {
+			return false, gatewayv1.ListenerReasonPortUnavailable, "ngrok HTTPS edges are only served on port 443"
+		}
+		if listener.Hostname == nil {
+			return false, gatewayv1.ListenerReasonInvalid, "ngrok HTTPS edges need a listener hostname"
+		}
+	case gatewayv1.TLSProtocolType:
+		if listener.Port != 443 {
+			return false, gatewayv1.ListenerReasonPortUnavailable, "ngrok TLS edges are only served on port 443"
+		}
+	case gatewayv1.TCPProtocolType:
+	default:
+		return false, gatewayv1.ListenerReasonUnsupportedProtocol, fmt.Sprintf("Protocol %s is not supported", listener.Protocol)
+	}
+	return true, gatewayv1.ListenerReasonAccepted, "Listener is accepted"
+}
+
+// listenerAttachedRoutes counts the routes attached to a listener of gtw
+func (d *Driver) listenerAttachedRoutes(gtw *gatewayv1.Gateway, listener gatewayv1.Listener, gatewayDomainMap map[string]ingressv1alpha1.Domain) int32 {
+	attachedRoutes := int32(0)
+	switch listener.Protocol {
+	case gatewayv1.HTTPSProtocolType:
+		for _, httproute := range d.store.ListHTTPRoutes() {
+			if d.httpRouteAttachedToListener(httproute, gtw, listener, gatewayDomainMap) {
+				attachedRoutes++
+			}
+		}
+		for _, grpcroute := range d.store.ListGRPCRoutes() {
+			if d.grpcRouteAttachedToListener(grpcroute, gtw, listener, gatewayDomainMap) {
+				attachedRoutes++
+			}
+		}
+	case gatewayv1.TLSProtocolType:
+		for _, tlsroute := range d.store.ListTLSRoutes() {
+			if slices.ContainsFunc(tlsroute.Spec.ParentRefs, func(parent gatewayv1.ParentReference) bool {
+				listeners, _, _ := d.tlsRouteParentListeners(tlsroute, parent)
+				return isGatewayListener(gtw, listener, d.findGatewayForParentRef(parent, tlsroute.Namespace), listeners)
+			}) {
+				attachedRoutes++
+			}
+		}
+	case gatewayv1.TCPProtocolType:
+		for _, tcproute := range d.store.ListTCPRoutes() {
+			if slices.ContainsFunc(tcproute.Spec.ParentRefs, func(parent gatewayv1.ParentReference) bool {
+				parentGateway, listeners, _, _ := d.routeParentListeners(parent, "TCPRoute", tcproute.Namespace, gatewayv1.TCPProtocolType)
+				return isGatewayListener(gtw, listener, parentGateway, listeners)
+			}) {
+				attachedRoutes++
+			}
+		}
+	}
+	return attachedRoutes
+}
+
+func newListenerCondition(conditionType gatewayv1.ListenerConditionType, status bool, reason gatewayv1.ListenerConditionReason, message string) metav1.Condition {
+	conditionStatus := metav1.ConditionFalse
+	if status {
+		conditionStatus = metav1.ConditionTrue
+	}
+	return metav1.Condition{
+		Type:    string(conditionType),
+		Status:  conditionStatus,
+		Reason:  string(reason),
+		Message: message,
+	}
+}
+
+// calculateHTTPRouteStatus computes the status of an HTTPRoute for the parents handled by this controller, leaving
+// the status reported by other controllers untouched. Routes with rule matches that can't be represented aren't
+// accepted, and routes splitting traffic between several backends report whether the weights are represented exactly.
+func (d *Driver) calculateHTTPRouteStatus(httproute *gatewayv1.HTTPRoute, gatewayDomainMap map[string]ingressv1alpha1.Domain) gatewayv1.RouteStatus {
+	resolvedReason := gatewayv1.RouteReasonResolvedRefs
+	var resolvedErr error
+	for _, rule := range httproute.Spec.Rules {
+		for _, backendRef := range rule.BackendRefs {
+			if resolvedReason, resolvedErr = d.checkBackendRef(backendRef.BackendRef, "HTTPRoute", httproute.Namespace); resolvedErr != nil {
+				break
+			}
+		}
+		if resolvedErr != nil {
+			break
 		}
-		computedModSet.Merge(resolvedMod)
 	}
 
-	return computedModSet, nil
-}
+	matchesCondition := calculateHTTPRouteMatchesCondition(httproute)
+	source := syncSource{Kind: "HTTPRoute", NamespacedName: types.NamespacedName{Namespace: httproute.Namespace, Name: httproute.Name}}
+	status := calculateRouteStatus(httproute.Status.RouteStatus, httproute.Spec.ParentRefs, httproute.Generation, resolvedReason, resolvedErr,
+		func(parent gatewayv1.ParentReference) (bool, gatewayv1.RouteConditionReason, string) {
+			gtw, listeners, reason, message := d.httpRouteParentListeners(httproute, parent, gatewayDomainMap)
+			if reason != "" && matchesCondition != nil {
+				return false, gatewayv1.RouteConditionReason(matchesCondition.Reason), matchesCondition.Message
+			}
+			if conflicts := d.listenerBranchConflicts(source, gtw, listeners, gatewayDomainMap); len(conflicts) > 0 {
+				return false, gatewayv1.RouteReasonUnsupportedValue, strings.Join(conflicts, "; ")
+			}
+			return len(listeners) > 0, reason, message
+		},
+	)
 
-func (d *Driver) getNgrokTrafficPolicyForIngress(ing *netv1.Ingress) (*ngrokv1alpha1.NgrokTrafficPolicy, error) {
-	policy, err := annotations.ExtractNgrokTrafficPolicyFromAnnotations(ing)
-	if err != nil {
-		if errors.IsMissingAnnotations(err) {
-			return nil, nil
-		}
-		return nil, err
+	rulesBackendRefs := make([][]gatewayv1.HTTPBackendRef, 0, len(httproute.Spec.Rules))
+	for _, rule := range httproute.Spec.Rules {
+		rulesBackendRefs = append(rulesBackendRefs, rule.BackendRefs)
 	}
+	weightsCondition := d.calculateBackendWeightsCondition(rulesBackendRefs, "HTTPRoute", httproute.Namespace)
+	d.setBackendWeightsCondition(&status, httproute.Spec.ParentRefs, httproute.Namespace, httproute.Generation, weightsCondition)
 
-	return d.store.GetNgrokTrafficPolicyV1(policy, ing.Namespace)
+	return status
 }
 
-func (d *Driver) calculateHTTPSEdges(ingressDomains *[]ingressv1alpha1.Domain, gatewayDomainMap map[string]ingressv1alpha1.Domain) map[string]ingressv1alpha1.HTTPSEdge {
-	edgeMap := make(map[string]ingressv1alpha1.HTTPSEdge, len(*ingressDomains))
-	for _, domain := range *ingressDomains {
-		edge := ingressv1alpha1.HTTPSEdge{
-			ObjectMeta: metav1.ObjectMeta{
-				GenerateName: domain.Name + "-",
-				Namespace:    domain.Namespace,
-				Labels:       d.edgeLabels(),
-			},
-			Spec: ingressv1alpha1.HTTPSEdgeSpec{
-				Hostports: []string{domain.Spec.Domain + ":443"},
-			},
+// calculateGRPCRouteStatus computes the status of a GRPCRoute for the parents handled by this controller, the same
+// way as calculateHTTPRouteStatus
+func (d *Driver) calculateGRPCRouteStatus(grpcroute *gatewayv1alpha2.GRPCRoute, gatewayDomainMap map[string]ingressv1alpha1.Domain) gatewayv1.RouteStatus {
+	resolvedReason := gatewayv1.RouteReasonResolvedRefs
+	var resolvedErr error
+	for _, rule := range grpcroute.Spec.Rules {
+		for _, backendRef := range rule.BackendRefs {
+			if resolvedReason, resolvedErr = d.checkBackendRef(backendRef.BackendRef, "GRPCRoute", grpcroute.Namespace); resolvedErr != nil {
+				break
+			}
+		}
+		if resolvedErr != nil {
+			break
 		}
-		edge.Spec.Metadata = d.ingressNgrokMetadata
-		edgeMap[domain.Spec.Domain] = edge
 	}
-	d.calculateHTTPSEdgesFromIngress(edgeMap)
 
-	if d.gatewayEnabled {
-		gatewayEdgeMap := make(map[string]ingressv1alpha1.HTTPSEdge)
-		httproutes := d.store.ListHTTPRoutes()
-		gateways := d.store.ListGateways()
-		for _, gtw := range gateways {
-			gatewayDomains := make(map[string]string)
-			for _, listener := range gtw.Spec.Listeners {
-				if listener.Hostname == nil {
-					continue
-				}
-				if listener.Protocol != gatewayv1.HTTPSProtocolType || int(listener.Port) != 443 {
-					continue
-				}
-				if _, hasDomain := gatewayDomainMap[string(*listener.Hostname)]; !hasDomain {
-					continue
-				}
-				gatewayDomains[string(*listener.Hostname)] = string(*listener.Hostname)
+	matchesCondition := calculateGRPCRouteMatchesCondition(grpcroute)
+	source := syncSource{Kind: "GRPCRoute", NamespacedName: types.NamespacedName{Namespace: grpcroute.Namespace, Name: grpcroute.Name}}
+	status := calculateRouteStatus(grpcroute.Status.RouteStatus, grpcroute.Spec.ParentRefs, grpcroute.Generation, resolvedReason, resolvedErr,
+		func(parent gatewayv1.ParentReference) (bool, gatewayv1.RouteConditionReason, string) {
+			gtw, listeners, reason, message := d.grpcRouteParentListeners(grpcroute, parent, gatewayDomainMap)
+			if reason != "" && matchesCondition != nil {
+				return false, gatewayv1.RouteConditionReason(matchesCondition.Reason), matchesCondition.Message
 			}
-			if len(gatewayDomains) == 0 {
-				d.log.Info("no usable domains in gateway, may be missing https listener", "gateway", gtw.Name)
-				continue
+			if conflicts := d.listenerBranchConflicts(source, gtw, listeners, gatewayDomainMap); len(conflicts) > 0 {
+				return false, gatewayv1.RouteReasonUnsupportedValue, strings.Join(conflicts, "; ")
 			}
-			for _, httproute := range httproutes {
-				var routeDomains []string
-				for _, parent := range httproute.Spec.ParentRefs {
-					if string(parent.Name) != gtw.Name {
-						continue
-					}
-					var domainOverlap []string
-					for _, hostname := range httproute.Spec.Hostnames {
-						domain := string(hostname)
-						if _, hasDomain := gatewayDomains[domain]; hasDomain {
-							domainOverlap = append(domainOverlap, domain)
-						}
-					}
-					if len(domainOverlap) == 0 {
-						// no hostnames overlap with gateway
-						continue
-					}
-					routeDomains = append(routeDomains, domainOverlap...)
-				}
-				if len(routeDomains) == 0 {
-					// no usable domains in route
-					continue
-				}
-				var hostPorts []string
+			return len(listeners) > 0, reason, message
+		},
+	)
 
-				for _, domain := range routeDomains {
-					hostPorts = append(hostPorts, domain+":443")
-				}
-				edge := ingressv1alpha1.HTTPSEdge{
-					ObjectMeta: metav1.ObjectMeta{
-						GenerateName: httproute.Name + "-",
-						Namespace:    httproute.Namespace,
-						Labels:       d.edgeLabels(),
-					},
-					Spec: ingressv1alpha1.HTTPSEdgeSpec{
-						Hostports: hostPorts,
-					},
-				}
-				edge.Spec.Metadata = d.gatewayNgrokMetadata
-				gatewayEdgeMap[routeDomains[0]] = edge
+	rulesBackendRefs := make([][]gatewayv1.HTTPBackendRef, 0, len(grpcroute.Spec.Rules))
+	for _, rule := range grpcroute.Spec.Rules {
+		rulesBackendRefs = append(rulesBackendRefs, grpcHTTPBackendRefs(rule.BackendRefs))
+	}
+	weightsCondition := d.calculateBackendWeightsCondition(rulesBackendRefs, "GRPCRoute", grpcroute.Namespace)
+	d.setBackendWeightsCondition(&status, grpcroute.Spec.ParentRefs, grpcroute.Namespace, grpcroute.Generation, weightsCondition)
+
+	return status
+}
 
+// setBackendWeightsCondition sets the BackendWeightsExact condition on the status of the route's parents handled by
+// this controller, or removes it when the condition is nil
+func (d *Driver) setBackendWeightsCondition(status *gatewayv1.RouteStatus, parents []gatewayv1.ParentReference, routeNamespace string, generation int64, weightsCondition *metav1.Condition) {
+	for _, parent := range parents {
+		if d.findGatewayForParentRef(parent, routeNamespace) == nil {
+			// not one of our gateways
+			continue
+		}
+		if weightsCondition != nil {
+			setRouteParentConditions(status, parent, generation, *weightsCondition)
+			continue
+		}
+		for i := range status.Parents {
+			if status.Parents[i].ControllerName == GatewayControllerName && reflect.DeepEqual(status.Parents[i].ParentRef, parent) {
+				meta.RemoveStatusCondition(&status.Parents[i].Conditions, string(RouteConditionBackendWeightsExact))
 			}
 		}
-		d.calculateHTTPSEdgesFromGateway(gatewayEdgeMap)
+	}
+}
 
-		// merge edge maps
-		for k, v := range gatewayEdgeMap {
-			edgeMap[k] = v
+// calculateHTTPRouteMatchesCondition returns a False Accepted condition when the matches of one of the route's rules
+// can't be represented with ngrok edge routes and traffic policy, nil otherwise
+func calculateHTTPRouteMatchesCondition(httproute *gatewayv1.HTTPRoute) *metav1.Condition {
+	for ruleIdx, rule := range httproute.Spec.Rules {
+		for _, match := range rule.Matches {
+			_, _, err := httpRouteMatchPath(match)
+			if err == nil {
+				_, err = httpRouteMatchExpression(match)
+			}
+			if err != nil {
+				condition := newRouteCondition(gatewayv1.RouteConditionAccepted, false, gatewayv1.RouteReasonUnsupportedValue,
+					fmt.Sprintf("rule %d: %s", ruleIdx, err))
+				return &condition
+			}
 		}
 	}
+	return nil
+}
 
-	return edgeMap
+// calculateGRPCRouteMatchesCondition returns a False Accepted condition when the matches of one of the route's rules
+// can't be represented with ngrok edge routes and traffic policy, nil otherwise
+func calculateGRPCRouteMatchesCondition(grpcroute *gatewayv1alpha2.GRPCRoute) *metav1.Condition {
+	for ruleIdx, rule := range grpcroute.Spec.Rules {
+		for _, match := range rule.Matches {
+			if _, _, err := grpcRouteMatchPath(match); err != nil {
+				condition := newRouteCondition(gatewayv1.RouteConditionAccepted, false, gatewayv1.RouteReasonUnsupportedValue,
+					fmt.Sprintf("rule %d: %s", ruleIdx, err))
+				return &condition
+			}
+		}
+	}
+	return nil
 }
 
-func (d *Driver) calculateHTTPSEdgesFromIngress(edgeMap map[string]ingressv1alpha1.HTTPSEdge) {
-	ingresses := d.store.ListNgrokIngressesV1()
-	for _, ingress := range ingresses {
-		modSet, err := d.getNgrokModuleSetForIngress(ingress)
+// calculateBackendWeightsCondition returns the BackendWeightsExact condition of a route given the backendRefs of each
+// of its rules, or nil when none of its rules split traffic between several backends
+func (d *Driver) calculateBackendWeightsCondition(rulesBackendRefs [][]gatewayv1.HTTPBackendRef, routeKind gatewayv1.Kind, namespace string) *metav1.Condition {
+	weighted := false
+	approximations := []string{}
+	rejections := []string{}
+	for ruleIdx, backendRefs := range rulesBackendRefs {
+		backends, exact, err := weightedBackends(d.resolvedHTTPBackendRefs(backendRefs, routeKind, namespace))
 		if err != nil {
-			d.log.Error(err, "error getting ngrok moduleset for ingress", "ingress", ingress)
+			weighted = true
+			rejections = append(rejections, fmt.Sprintf("rule %d: %v", ruleIdx, err))
+			continue
+		}
+		if len(backends) < 2 {
+			continue
+		}
+		weighted = true
+		if exact {
 			continue
 		}
 
-		policyJSON, err := d.getTrafficPolicyJSON(ingress, modSet)
-		if err != nil {
-			d.log.Error(err, "error marshalling JSON Policy for ingress", "ingress", ingress)
+		weights := make([]string, 0, len(backends))
+		tunnels := make([]string, 0, len(backends))
+		for _, backend := range backends {
+			weights = append(weights, strconv.Itoa(int(backend.weight)))
+			tunnels = append(tunnels, strconv.Itoa(backend.tunnels))
+		}
+		approximations = append(approximations, fmt.Sprintf("rule %d: weights %s are approximated as %s",
+			ruleIdx, strings.Join(weights, "/"), strings.Join(tunnels, "/")))
+	}
+	if !weighted {
+		return nil
+	}
+
+	if len(rejections) > 0 {
+		message := fmt.Sprintf("Traffic of rules with more than %d weighted backends is not routed, %s", maxWeightedTunnels, strings.Join(rejections, "; "))
+		condition := newRouteCondition(RouteConditionBackendWeightsExact, false, RouteReasonTooManyWeightedBackends, message)
+		return &condition
+	}
+	if len(approximations) == 0 {
+		condition := newRouteCondition(RouteConditionBackendWeightsExact, true, RouteReasonBackendWeightsExact, "All backend weights are represented exactly")
+		return &condition
+	}
+	message := fmt.Sprintf("Backend weights are approximated with at most %d tunnels per rule, %s", maxWeightedTunnels, strings.Join(approximations, "; "))
+	condition := newRouteCondition(RouteConditionBackendWeightsExact, false, RouteReasonBackendWeightsApproximated, message)
+	return &condition
+}
+
+// calculateRouteStatus computes the Accepted and ResolvedRefs conditions of a route for each parent handled by this
+// controller. The accepted func reports an empty reason for parents that aren't ours, their status is left untouched.
+func calculateRouteStatus(current gatewayv1.RouteStatus, parents []gatewayv1.ParentReference, generation int64, resolvedReason gatewayv1.RouteConditionReason, resolvedErr error, accepted func(gatewayv1.ParentReference) (bool, gatewayv1.RouteConditionReason, string)) gatewayv1.RouteStatus {
+	status := *current.DeepCopy()
+	pruneRouteParentStatuses(&status, parents)
+
+	resolvedMessage := "All references are resolved"
+	if resolvedErr != nil {
+		resolvedMessage = resolvedErr.Error()
+	}
+
+	for _, parent := range parents {
+		ok, acceptedReason, acceptedMessage := accepted(parent)
+		if acceptedReason == "" {
+			// not one of our gateways
 			continue
 		}
 
-		for _, rule := range ingress.Spec.Rules {
-			edge, ok := edgeMap[rule.Host]
-			if !ok {
-				d.log.Error(err, "could not find edge associated with rule", "host", rule.Host)
-				continue
-			}
+		setRouteParentConditions(&status, parent, generation,
+			newRouteCondition(gatewayv1.RouteConditionAccepted, ok, acceptedReason, acceptedMessage),
+			newRouteCondition(gatewayv1.RouteConditionResolvedRefs, resolvedReason == gatewayv1.RouteReasonResolvedRefs, resolvedReason, resolvedMessage),
+		)
+	}
 
-			if modSet.Modules.TLSTermination != nil && modSet.Modules.TLSTermination.MinVersion != nil {
-				edge.Spec.TLSTermination = &ingressv1alpha1.EndpointTLSTerminationAtEdge{
-					MinVersion: ptr.Deref(modSet.Modules.TLSTermination.MinVersion, ""),
+	return status
+}
+
+// calculateTLSEdges builds a TLSEdge for each TLSRoute attached to a TLS listener of one of our Gateways.
+// The edges are keyed by the namespace/name of the TLSRoute that owns them.
+func (d *Driver) calculateTLSEdges() map[string]ingressv1alpha1.TLSEdge {
+	edgeMap := make(map[string]ingressv1alpha1.TLSEdge)
+
+	for _, tlsroute := range d.store.ListTLSRoutes() {
+		var hostnames []string
+		terminateAt := "upstream"
+		for _, parent := range tlsroute.Spec.ParentRefs {
+			listeners, _, _ := d.tlsRouteParentListeners(tlsroute, parent)
+			for _, listener := range listeners {
+				hostnames = append(hostnames, routeHostnamesForListener(listener, tlsroute.Spec.Hostnames)...)
+				// A TLSEdge has a single TLS termination setting, terminate at the edge
+				// as soon as one of the listeners asks for it
+				if !listenerIsPassthrough(listener) {
+					terminateAt = "edge"
 				}
 			}
+		}
+		if len(hostnames) == 0 {
+			continue
+		}
 
-			if modSet.Modules.MutualTLS != nil {
-				edge.Spec.MutualTLS = modSet.Modules.MutualTLS
-			}
+		backendRef, err := tlsRouteBackendRef(tlsroute)
+		if err != nil {
+			d.recordSyncError("TLSRoute", client.ObjectKeyFromObject(tlsroute), err, "no usable backend for tlsroute")
+			continue
+		}
 
-			// If any rule for an ingress matches, then it applies to this ingress
-			for _, httpIngressPath := range rule.HTTP.Paths {
-				matchType := "path_prefix"
-				if httpIngressPath.PathType != nil {
-					switch *httpIngressPath.PathType {
-					case netv1.PathTypePrefix:
-						matchType = "path_prefix"
-					case netv1.PathTypeExact:
-						matchType = "exact_path"
-					case netv1.PathTypeImplementationSpecific:
-						matchType = "path_prefix" // Path Prefix seems like a sane default for most cases
-					default:
-						d.log.Error(fmt.Errorf("unknown path type"), "unknown path type", "pathType", *httpIngressPath.PathType)
-						continue
-					}
-				}
+		if _, err := d.checkBackendRef(*backendRef, "TLSRoute", tlsroute.Namespace); err != nil {
+			d.recordSyncError("TLSRoute", client.ObjectKeyFromObject(tlsroute), err, "could not resolve backend for tlsroute")
+			continue
+		}
 
-				// We only support service backends right now. TODO: support resource backends
-				if httpIngressPath.Backend.Service == nil {
-					continue
-				}
+		refName := string(backendRef.Name)
+		serviceUID, servicePort, err := d.getEdgeBackendRef(*backendRef, "TLSRoute", tlsroute.Namespace)
+		if err != nil {
+			d.recordSyncError("TLSRoute", client.ObjectKeyFromObject(tlsroute), err, "could not find port for service", "service", refName)
+			continue
+		}
 
-				serviceName := httpIngressPath.Backend.Service.Name
-				serviceUID, servicePort, err := d.getEdgeBackend(*httpIngressPath.Backend.Service, ingress.Namespace)
-				if err != nil {
-					d.log.Error(err, "could not find port for service", "namespace", ingress.Namespace, "service", serviceName)
-					continue
-				}
+		slices.Sort(hostnames)
+		hostnames = slices.Compact(hostnames)
+		hostports := make([]string, 0, len(hostnames))
+		for _, hostname := range hostnames {
+			hostports = append(hostports, hostname+":443")
+		}
 
-				route := ingressv1alpha1.HTTPSEdgeRouteSpec{
-					Match:     httpIngressPath.Path,
-					MatchType: matchType,
-					Backend: ingressv1alpha1.TunnelGroupBackend{
-						Labels: d.ngrokLabels(ingress.Namespace, serviceUID, serviceName, servicePort),
+		edge := ingressv1alpha1.TLSEdge{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: tlsroute.Name + "-",
+				Namespace:    tlsroute.Namespace,
+				Labels:       d.edgeLabels(),
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: gatewayv1alpha2.GroupVersion.String(),
+						Kind:       "TLSRoute",
+						Name:       tlsroute.Name,
+						UID:        tlsroute.UID,
 					},
-					CircuitBreaker:      modSet.Modules.CircuitBreaker,
-					Compression:         modSet.Modules.Compression,
-					IPRestriction:       modSet.Modules.IPRestriction,
-					Headers:             modSet.Modules.Headers,
-					OAuth:               modSet.Modules.OAuth,
-					Policy:              policyJSON,
-					OIDC:                modSet.Modules.OIDC,
-					SAML:                modSet.Modules.SAML,
-					WebhookVerification: modSet.Modules.WebhookVerification,
-				}
-				route.Metadata = d.ingressNgrokMetadata
+				},
+			},
+			Spec: ingressv1alpha1.TLSEdgeSpec{
+				Backend: ingressv1alpha1.TunnelGroupBackend{
+					Labels: d.ngrokLabels(tlsroute.Namespace, serviceUID, refName, servicePort),
+				},
+				Hostports: hostports,
+				TLSTermination: &ingressv1alpha1.EndpointTLSTermination{
+					TerminateAt: terminateAt,
+				},
+			},
+		}
+		edge.Spec.Metadata = d.gatewayNgrokMetadata
+		edgeMap[getKey(tlsroute.Name, tlsroute.Namespace)] = edge
+	}
 
-				// Loop through existing routes and check if any match the path and match type
-				// If they do, warn about it and continue replacing it
-				for _, existingRoute := range edge.Spec.Routes {
-					if existingRoute.Match == route.Match && existingRoute.MatchType == route.MatchType {
-						d.log.Info("replacing existing route", "route", existingRoute.Match, "newRoute", route.Match)
-						continue
-					}
-				}
+	return edgeMap
+}
 
-				edge.Spec.Routes = append(edge.Spec.Routes, route)
-			}
+// tlsEdgeKeyFromEdge returns the key of the TLSRoute owning the edge, matching the keys of calculateTLSEdges
+func tlsEdgeKeyFromEdge(edge ingressv1alpha1.TLSEdge) (string, bool) {
+	for _, ref := range edge.OwnerReferences {
+		if ref.Kind == "TLSRoute" {
+			return getKey(ref.Name, edge.Namespace), true
+		}
+	}
+	return "", false
+}
 
-			edgeMap[rule.Host] = edge
+// tlsRouteBackendRef returns the backendRef a TLSEdge will forward to. A TLSEdge has a single
+// tunnel group backend, so routes with more than one backendRef aren't supported.
+func tlsRouteBackendRef(tlsroute *gatewayv1alpha2.TLSRoute) (*gatewayv1.BackendRef, error) {
+	var backendRefs []gatewayv1.BackendRef
+	for _, rule := range tlsroute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	return singleRouteBackendRef(backendRefs)
+}
+
+// tlsRouteAccepted checks if one of the parents of the TLSRoute is a TLS listener of our Gateways accepting it
+func (d *Driver) tlsRouteAccepted(tlsroute *gatewayv1alpha2.TLSRoute) bool {
+	for _, parent := range tlsroute.Spec.ParentRefs {
+		if listeners, _, _ := d.tlsRouteParentListeners(tlsroute, parent); len(listeners) > 0 {
+			return true
 		}
 	}
+	return false
 }
 
-// getTrafficPolicyJSON retrieves the traffic policy for an ingress and falls back to the modSet policy if it doesn't exist.
-func (d *Driver) getTrafficPolicyJSON(ingress *netv1.Ingress, modSet *ingressv1alpha1.NgrokModuleSet) (json.RawMessage, error) {
-	var err error
-	var policyJSON json.RawMessage
+// tlsRouteParentListeners returns the TLS listeners of the Gateway selected by parent that the TLSRoute is attached to.
+// ngrok TLS edges are only served on port 443, and the listener must share at least one hostname with the route.
+// The reason is empty when the parent isn't one of our Gateways.
+func (d *Driver) tlsRouteParentListeners(tlsroute *gatewayv1alpha2.TLSRoute, parent gatewayv1.ParentReference) ([]gatewayv1.Listener, gatewayv1.RouteConditionReason, string) {
+	_, listeners, reason, message := d.routeParentListeners(parent, "TLSRoute", tlsroute.Namespace, gatewayv1.TLSProtocolType)
+	if len(listeners) == 0 {
+		return nil, reason, message
+	}
 
-	trafficPolicy, err := d.getNgrokTrafficPolicyForIngress(ingress)
+	listeners = slices.DeleteFunc(listeners, func(listener gatewayv1.Listener) bool {
+		return listener.Port != 443
+	})
+	if len(listeners) == 0 {
+		return nil, gatewayv1.RouteReasonNotAllowedByListeners, "ngrok TLS edges are only served on port 443"
+	}
+
+	listeners = slices.DeleteFunc(listeners, func(listener gatewayv1.Listener) bool {
+		return len(routeHostnamesForListener(listener, tlsroute.Spec.Hostnames)) == 0
+	})
+	if len(listeners) == 0 {
+		return nil, gatewayv1.RouteReasonNoMatchingListenerHostname, "No hostname of the route matches a listener hostname"
+	}
+
+	return listeners, gatewayv1.RouteReasonAccepted, "Route is accepted"
+}
+
+// calculateTLSRouteStatus computes the status of a TLSRoute for the parents handled by this controller,
+// leaving the status reported by other controllers untouched.
+func (d *Driver) calculateTLSRouteStatus(tlsroute *gatewayv1alpha2.TLSRoute) gatewayv1.RouteStatus {
+	var backendRefs []gatewayv1.BackendRef
+	for _, rule := range tlsroute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	resolvedReason, resolvedErr := d.checkRouteBackendRefs(backendRefs, "TLSRoute", tlsroute.Namespace)
+
+	return calculateRouteStatus(tlsroute.Status.RouteStatus, tlsroute.Spec.ParentRefs, tlsroute.Generation, resolvedReason, resolvedErr,
+		func(parent gatewayv1.ParentReference) (bool, gatewayv1.RouteConditionReason, string) {
+			listeners, reason, message := d.tlsRouteParentListeners(tlsroute, parent)
+			return singleBackendRefAccepted(backendRefs, len(listeners) > 0, reason, message)
+		},
+	)
+}
+
+// listenerIsPassthrough checks if a TLS listener passes the TLS connection through to the backend.
+// TLS listeners terminate TLS unless configured otherwise.
+func listenerIsPassthrough(listener gatewayv1.Listener) bool {
+	return listener.TLS != nil && listener.TLS.Mode != nil && *listener.TLS.Mode == gatewayv1.TLSModePassthrough
+}
+
+// routeHostnamesForListener returns the hostnames of a route that are served by the listener.
+// A listener without a hostname serves all the route hostnames, and a route without hostnames
+// takes the listener hostname. Wildcard hostnames are allowed on either side.
+func routeHostnamesForListener(listener gatewayv1.Listener, routeHostnames []gatewayv1.Hostname) []string {
+	if listener.Hostname == nil {
+		hostnames := make([]string, 0, len(routeHostnames))
+		for _, hostname := range routeHostnames {
+			hostnames = append(hostnames, string(hostname))
+		}
+		return hostnames
+	}
+
+	listenerHostname := string(*listener.Hostname)
+	if len(routeHostnames) == 0 {
+		return []string{listenerHostname}
+	}
+
+	var hostnames []string
+	for _, hostname := range routeHostnames {
+		routeHostname := string(hostname)
+		var served string
+		switch {
+		case routeHostname == listenerHostname:
+			served = routeHostname
+		case wildcardHostnameMatches(listenerHostname, routeHostname):
+			served = routeHostname
+		case wildcardHostnameMatches(routeHostname, listenerHostname):
+			served = listenerHostname
+		default:
+			continue
+		}
+		if !slices.Contains(hostnames, served) {
+			hostnames = append(hostnames, served)
+		}
+	}
+	return hostnames
+}
+
+// wildcardHostnameMatches checks if a wildcard hostname such as *.example.com matches hostname, which may itself be
+// a more specific wildcard such as *.a.example.com
+func wildcardHostnameMatches(wildcard, hostname string) bool {
+	suffix, ok := strings.CutPrefix(wildcard, "*")
+	if !ok {
+		return false
+	}
+	return strings.HasSuffix(hostname, suffix) && len(hostname) > len(suffix)
+}
+
+// routeHostnamesExpression returns the CEL expression restricting the requests of a route to its hostnames on the
+// listener, empty when the route serves the whole listener hostname
+func routeHostnamesExpression(listener gatewayv1.Listener, routeHostnames []gatewayv1.Hostname) string {
+	hostnames := routeHostnamesForListener(listener, routeHostnames)
+	if listener.Hostname != nil && slices.Contains(hostnames, string(*listener.Hostname)) {
+		return ""
+	}
+
+	conditions := make([]string, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		if suffix, ok := strings.CutPrefix(hostname, "*"); ok {
+			conditions = append(conditions, fmt.Sprintf("req.host.endsWith(%q)", suffix))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("req.host == %q", hostname))
+		}
+	}
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return "(" + strings.Join(conditions, " || ") + ")"
+}
+
+// findGatewayForParentRef returns the Gateway referenced by a route's parentRef, or nil if it isn't in the store or
+// its GatewayClass isn't handled by the ngrok gateway controller
+func (d *Driver) findGatewayForParentRef(parent gatewayv1.ParentReference, routeNamespace string) *gatewayv1.Gateway {
+	if parent.Group != nil && *parent.Group != gatewayv1.GroupName {
+		return nil
+	}
+	if parent.Kind != nil && *parent.Kind != "Gateway" {
+		return nil
+	}
+
+	namespace := routeNamespace
+	if parent.Namespace != nil {
+		namespace = string(*parent.Namespace)
+	}
 
+	gtw, err := d.store.GetNgrokGateway(string(parent.Name), namespace)
 	if err != nil {
-		d.log.Error(err, "error getting ngrok traffic policy for ingress", "ingress", ingress)
-		return nil, err
+		return nil
 	}
+	return gtw
+}
 
-	if modSet.Modules.Policy != nil && trafficPolicy != nil {
-		return nil, fmt.Errorf("cannot have both a traffic policy and a moduleset policy on ingress: %s", ingress.Name)
+// hasNgrokParent checks if one of the parentRefs of a route resolves to one of our Gateways
+func (d *Driver) hasNgrokParent(parents []gatewayv1.ParentReference, routeNamespace string) bool {
+	for _, parent := range parents {
+		if d.findGatewayForParentRef(parent, routeNamespace) != nil {
+			return true
+		}
 	}
+	return false
+}
 
-	if trafficPolicy != nil {
-		return trafficPolicy.Spec.Policy, nil
+// parentRefMatchesListener checks the optional sectionName and port of a parentRef against a listener
+func parentRefMatchesListener(parent gatewayv1.ParentReference, listener gatewayv1.Listener) bool {
+	if parent.SectionName != nil && *parent.SectionName != listener.Name {
+		return false
+	}
+	if parent.Port != nil && *parent.Port != listener.Port {
+		return false
 	}
+	return true
+}
 
-	if modSet == nil {
-		return policyJSON, nil
+// listenerAllowsRoute checks the listener's allowedRoutes against a route of the given kind living in routeNamespace
+func (d *Driver) listenerAllowsRoute(gtw *gatewayv1.Gateway, listener gatewayv1.Listener, routeKind gatewayv1.Kind, routeNamespace string) bool {
+	if listener.AllowedRoutes == nil {
+		// defaults to routes of the listener's protocol from the same namespace
+		return routeNamespace == gtw.Namespace
 	}
 
-	if policyJSON, err = json.Marshal(modSet.Modules.Policy); err != nil {
-		d.log.Error(err, "cannot convert module-set policy json", "ingress", ingress, "Policy", modSet.Modules.Policy)
-		return nil, err
+	if len(listener.AllowedRoutes.Kinds) > 0 {
+		kindAllowed := false
+		for _, kind := range listener.AllowedRoutes.Kinds {
+			if kind.Kind == routeKind && (kind.Group == nil || *kind.Group == gatewayv1.GroupName) {
+				kindAllowed = true
+				break
+			}
+		}
+		if !kindAllowed {
+			return false
+		}
+	}
+
+	from := gatewayv1.NamespacesFromSame
+	if listener.AllowedRoutes.Namespaces != nil && listener.AllowedRoutes.Namespaces.From != nil {
+		from = *listener.AllowedRoutes.Namespaces.From
+	}
+
+	switch from {
+	case gatewayv1.NamespacesFromAll:
+		return true
+	case gatewayv1.NamespacesFromSame:
+		return routeNamespace == gtw.Namespace
+	case gatewayv1.NamespacesFromSelector:
+		return d.namespaceMatchesSelector(gtw, routeNamespace, listener.AllowedRoutes.Namespaces.Selector)
+	default:
+		d.recordSyncError("Gateway", types.NamespacedName{Namespace: gtw.Namespace, Name: gtw.Name}, nil, fmt.Sprintf("unsupported allowedRoutes namespaces %q on listener %q", from, listener.Name))
+		return false
+	}
+}
+
+// namespaceMatchesSelector evaluates a listener's namespace label selector against the labels of the cached Namespace.
+// Invalid or missing selectors and unknown namespaces don't match.
+func (d *Driver) namespaceMatchesSelector(gtw *gatewayv1.Gateway, namespace string, labelSelector *metav1.LabelSelector) bool {
+	if labelSelector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		d.recordSyncError("Gateway", types.NamespacedName{Namespace: gtw.Namespace, Name: gtw.Name}, err, "invalid allowedRoutes namespace selector")
+		return false
+	}
+	ns, err := d.store.GetNamespaceV1(namespace)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(ns.Labels))
+}
+
+// checkBackendRef verifies a backendRef of a route of routeKind points to a Service port we can route to.
+// The returned reason is suitable for a ResolvedRefs route condition.
+func (d *Driver) checkBackendRef(backendRef gatewayv1.BackendRef, routeKind gatewayv1.Kind, namespace string) (gatewayv1.RouteConditionReason, error) {
+	if (backendRef.Group != nil && *backendRef.Group != "") || (backendRef.Kind != nil && *backendRef.Kind != "Service") {
+		return gatewayv1.RouteReasonInvalidKind, fmt.Errorf("backendRef %s is not a Service", backendRef.Name)
+	}
+	if !d.referenceGrantAllowsBackendRef(backendRef, routeKind, namespace) {
+		return gatewayv1.RouteReasonRefNotPermitted, fmt.Errorf("backendRef %s/%s is in a different namespace and no ReferenceGrant allows it", *backendRef.Namespace, backendRef.Name)
+	}
+	if backendRef.Port == nil {
+		return gatewayv1.RouteReasonBackendNotFound, fmt.Errorf("backendRef %s has no port", backendRef.Name)
+	}
+	if _, _, err := d.findBackendRefServicePort(backendRef, routeKind, namespace); err != nil {
+		return gatewayv1.RouteReasonBackendNotFound, err
+	}
+	return gatewayv1.RouteReasonResolvedRefs, nil
+}
+
+// backendRefNamespace returns the namespace of the backend referenced by a route in routeNamespace
+func backendRefNamespace(backendRef gatewayv1.BackendRef, routeNamespace string) string {
+	if backendRef.Namespace != nil && *backendRef.Namespace != "" {
+		return string(*backendRef.Namespace)
+	}
+	return routeNamespace
+}
+
+// referenceGrantAllowsBackendRef checks that a route of routeKind in routeNamespace may reference the Service of
+// backendRef. References within the route's namespace are always allowed, others need a ReferenceGrant in the
+// namespace of the Service.
+func (d *Driver) referenceGrantAllowsBackendRef(backendRef gatewayv1.BackendRef, routeKind gatewayv1.Kind, routeNamespace string) bool {
+	toNamespace := backendRefNamespace(backendRef, routeNamespace)
+	return d.referenceGrantAllows(routeKind, routeNamespace, "Service", toNamespace, backendRef.Name)
+}
+
+// referenceGrantAllows returns true when an object of the Gateway API kind in fromNamespace may reference the core
+// object of kind toKind named toName in toNamespace. References within a namespace are always allowed.
+func (d *Driver) referenceGrantAllows(fromKind gatewayv1.Kind, fromNamespace string, toKind gatewayv1.Kind, toNamespace string, toName gatewayv1.ObjectName) bool {
+	if toNamespace == fromNamespace {
+		return true
+	}
+
+	for _, grant := range d.store.ListReferenceGrants() {
+		if grant.Namespace != toNamespace {
+			continue
+		}
+		from := slices.ContainsFunc(grant.Spec.From, func(from gatewayv1beta1.ReferenceGrantFrom) bool {
+			return from.Group == gatewayv1.GroupName && from.Kind == fromKind && string(from.Namespace) == fromNamespace
+		})
+		to := slices.ContainsFunc(grant.Spec.To, func(to gatewayv1beta1.ReferenceGrantTo) bool {
+			return to.Group == "" && to.Kind == toKind && (to.Name == nil || *to.Name == "" || *to.Name == toName)
+		})
+		if from && to {
+			return true
+		}
+	}
+	return false
+}
+
+// listenerCertificateRef returns the Secret the certificate of a listener's hostname is read from, or nil when the
+// listener doesn't terminate TLS with its own certificate. ngrok serves a single certificate per domain, so only the
+// first certificateRef is used. When the reference can't be used, the listener condition reason and message explaining
+// why are returned.
+func (d *Driver) listenerCertificateRef(gtw *gatewayv1.Gateway, listener gatewayv1.Listener) (*ingressv1alpha1.DomainCertificateRef, gatewayv1.ListenerConditionReason, string) {
+	if listener.TLS == nil || len(listener.TLS.CertificateRefs) == 0 {
+		return nil, "", ""
+	}
+	if listener.TLS.Mode != nil && *listener.TLS.Mode != gatewayv1.TLSModeTerminate {
+		return nil, "", ""
+	}
+
+	certRef := listener.TLS.CertificateRefs[0]
+	if (certRef.Group != nil && *certRef.Group != "") || (certRef.Kind != nil && *certRef.Kind != "Secret") {
+		return nil, gatewayv1.ListenerReasonInvalidCertificateRef, fmt.Sprintf("Certificate reference %q must be a core Secret", certRef.Name)
+	}
+
+	namespace := gtw.Namespace
+	if certRef.Namespace != nil && *certRef.Namespace != "" {
+		namespace = string(*certRef.Namespace)
+	}
+	if !d.referenceGrantAllows("Gateway", gtw.Namespace, "Secret", namespace, certRef.Name) {
+		return nil, gatewayv1.ListenerReasonRefNotPermitted, fmt.Sprintf("Certificate reference to Secret %s/%s is not permitted by any ReferenceGrant", namespace, certRef.Name)
 	}
 
-	return policyJSON, nil
+	return &ingressv1alpha1.DomainCertificateRef{
+		Name:      string(certRef.Name),
+		Namespace: namespace,
+	}, "", ""
 }
 
-func (d *Driver) calculateHTTPSEdgesFromGateway(edgeMap map[string]ingressv1alpha1.HTTPSEdge) {
-	gateways := d.store.ListGateways()
-
-	for _, gtw := range gateways {
-		for _, listener := range gtw.Spec.Listeners {
-			if listener.Hostname == nil {
-				continue
-			}
-			allowedRoutes := listener.AllowedRoutes.Kinds
-			if len(allowedRoutes) > 0 {
-				createHttpsedge := false
-				for _, routeKind := range allowedRoutes {
-					if routeKind.Kind == "HTTPRoute" {
-						createHttpsedge = true
-					}
-				}
-				if !createHttpsedge {
-					continue
-				}
-			}
-			domainName := string(*listener.Hostname)
-			edge, ok := edgeMap[domainName]
-			if !ok {
-				continue
-			}
-			// TODO: Calculate routes from httpRoutes
-			// TODO: skip if no backend services
-			httproutes := d.store.ListHTTPRoutes()
-			for _, httproute := range httproutes {
-				for _, parent := range httproute.Spec.ParentRefs {
-					if string(parent.Name) != gtw.Name {
-						// not our gateway so skip
-						continue
-					}
-
-					if listener.AllowedRoutes != nil && listener.AllowedRoutes.Namespaces.From != nil {
-						switch *listener.AllowedRoutes.Namespaces.From {
-						case gatewayv1.NamespacesFromAll:
-						case gatewayv1.NamespacesFromSame:
-							if httproute.Namespace != gtw.Namespace {
-								continue
-							}
-						case gatewayv1.NamespacesFromSelector:
-							if httproute.Namespace != listener.AllowedRoutes.Namespaces.Selector.String() {
-								continue
-							}
-						}
-					}
+func newRouteCondition(conditionType gatewayv1.RouteConditionType, status bool, reason gatewayv1.RouteConditionReason, message string) metav1.Condition {
+	conditionStatus := metav1.ConditionFalse
+	if status {
+		conditionStatus = metav1.ConditionTrue
+	}
+	return metav1.Condition{
+		Type:    string(conditionType),
+		Status:  conditionStatus,
+		Reason:  string(reason),
+		Message: message,
+	}
+}
 
-					// matches our gateway
-					for _, hostname := range httproute.Spec.Hostnames {
-						if string(hostname) != string(*listener.Hostname) {
-							// doesn't match this listener
-							continue
-						}
-						// matches gateway and listener
-						for _, rule := range httproute.Spec.Rules {
-							// TODO: resolve rule.Matches
-							// TODO: resolve rule.Filters
-							// for v0 we will only resolve the first backendRef
-							pathMatch := "/"
-							pathMatchType := "path_prefix"
-							// first match with a path will be accepted as the route's path
-							for _, match := range rule.Matches {
-								if match.Path != nil {
-									pathMatch = *match.Path.Value
-									if *match.Path.Type == gatewayv1.PathMatchExact {
-										pathMatchType = "exact_path"
-									}
-									break
-								}
-							}
-							route := ingressv1alpha1.HTTPSEdgeRouteSpec{
-								Match:     pathMatch,     // change based on the rule.match
-								MatchType: pathMatchType, // change based on rule.Matches
-							}
-
-							// TODO: set with values from rules.Filters + rules.Matches
-							// this HTTPRouteRule comes direct from gateway api yaml, and func returns the policy,
-							// which goes directly into the edge route in ngrok.
-							policy, err := d.createEndpointPolicyForGateway(&rule, httproute.Namespace)
-							if err != nil {
-								d.log.Error(err, "error creating policy from HTTPRouteRule", "rule", rule)
-								continue
-							}
-
-							route.Policy = policy
-
-							for idx, backendref := range rule.BackendRefs {
-								// currently the ingress controller doesn't support weighted backends
-								// so we'll only support one backendref per rule
-								// TODO: remove when tested with multiple backends
-								if idx > 0 {
-									break
-								}
-								// handle backendref
-								refKind := string(*backendref.Kind)
-								if refKind != "Service" {
-									// only support services currently
-									continue
-								}
-
-								refName := string(backendref.Name)
-								serviceUID, servicePort, err := d.getEdgeBackendRef(backendref.BackendRef, httproute.Namespace)
-								if err != nil {
-									d.log.Error(err, "could not find port for service", "namespace", httproute.Namespace, "service", refName)
-									continue
-								}
-
-								route.Backend = ingressv1alpha1.TunnelGroupBackend{
-									Labels: d.ngrokLabels(httproute.Namespace, serviceUID, refName, servicePort),
-								}
-
-							}
-							route.Metadata = d.gatewayNgrokMetadata
-
-							edge.Spec.Routes = append(edge.Spec.Routes, route)
-						}
-					}
-				}
-			}
+// pruneRouteParentStatuses removes this controller's status entries for parents the route no longer references
+func pruneRouteParentStatuses(status *gatewayv1.RouteStatus, parents []gatewayv1.ParentReference) {
+	status.Parents = slices.DeleteFunc(status.Parents, func(parentStatus gatewayv1.RouteParentStatus) bool {
+		if parentStatus.ControllerName != GatewayControllerName {
+			return false
+		}
+		return !slices.ContainsFunc(parents, func(parent gatewayv1.ParentReference) bool {
+			return reflect.DeepEqual(parent, parentStatus.ParentRef)
+		})
+	})
+}
 
-			edgeMap[domainName] = edge
+// setRouteParentConditions sets the conditions on this controller's status entry for parent, adding the entry if needed.
+// The transition time of a condition is only changed when its status changes.
+func setRouteParentConditions(status *gatewayv1.RouteStatus, parent gatewayv1.ParentReference, generation int64, conditions ...metav1.Condition) {
+	idx := -1
+	for i, parentStatus := range status.Parents {
+		if parentStatus.ControllerName == GatewayControllerName && reflect.DeepEqual(parentStatus.ParentRef, parent) {
+			idx = i
+			break
 		}
 	}
+	if idx == -1 {
+		status.Parents = append(status.Parents, gatewayv1.RouteParentStatus{
+			ParentRef:      parent,
+			ControllerName: GatewayControllerName,
+		})
+		idx = len(status.Parents) - 1
+	}
+
+	for _, condition := range conditions {
+		condition.ObservedGeneration = generation
+		meta.SetStatusCondition(&status.Parents[idx].Conditions, condition)
+	}
 }
 
 func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule, namespace string) (json.RawMessage, error) {
@@ -1140,20 +3306,31 @@ func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule, n
 				}
 			}
 		}
+	}
 
-		if match.Method != nil {
-			d.log.Error(fmt.Errorf("unsupported match type"), "Unsupported match type", "HTTPMethod", *match.Method)
-		}
-
-		if len(match.Headers) > 0 {
-			d.log.Error(fmt.Errorf("unsupported match type"), "Unsupported match type", "HTTPHeaderMatch", match.Headers)
-		}
+	return d.createEndpointPolicy(pathPrefixMatches, rule.Filters, namespace)
+}
 
-		if len(match.QueryParams) > 0 {
-			d.log.Error(fmt.Errorf("unsupported match type"), "Unsupported match type", "HTTPQueryParamMatch", match.QueryParams)
-		}
+// createEndpointPolicyForGRPCRoute returns the traffic policy of the edge route for a GRPCRoute rule. GRPCRoute
+// filters are a subset of the HTTPRoute ones, they're translated the same way.
+func (d *Driver) createEndpointPolicyForGRPCRoute(rule *gatewayv1alpha2.GRPCRouteRule, namespace string) (json.RawMessage, error) {
+	filters := make([]gatewayv1.HTTPRouteFilter, 0, len(rule.Filters))
+	for _, filter := range rule.Filters {
+		filters = append(filters, gatewayv1.HTTPRouteFilter{
+			Type:                   gatewayv1.HTTPRouteFilterType(filter.Type),
+			RequestHeaderModifier:  filter.RequestHeaderModifier,
+			ResponseHeaderModifier: filter.ResponseHeaderModifier,
+			RequestMirror:          filter.RequestMirror,
+			ExtensionRef:           filter.ExtensionRef,
+		})
 	}
 
+	return d.createEndpointPolicy(nil, filters, namespace)
+}
+
+// createEndpointPolicy builds the traffic policy of a route rule from its filters. The matches of the rule are
+// enforced by the edge route the policy is merged into, see mergeRouteBranches.
+func (d *Driver) createEndpointPolicy(pathPrefixMatches []string, filters []gatewayv1.HTTPRouteFilter, namespace string) (json.RawMessage, error) {
 	fullTrafficPolicy := util.NewTrafficPolicy()
 
 	// "hard-coded" phases. Since Filters are translated to rules in particular phases, the operator has to be aware of these.
@@ -1200,7 +3377,7 @@ func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule, n
 	}
 
 	responseHeaders := make(map[string]string)
-	for _, filter := range rule.Filters {
+	for _, filter := range filters {
 		switch filter.Type {
 		case gatewayv1.HTTPRouteFilterRequestRedirect:
 			// NOTE: request redirect is a special case, and is subject to change
@@ -1254,6 +3431,168 @@ func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule, n
 	return policy, nil
 }
 
+// httpRouteMatchPath returns the edge route path match of an HTTPRoute match. Matches without a path match every
+// path, the same as the "/" prefix.
+func httpRouteMatchPath(match gatewayv1.HTTPRouteMatch) (string, string, error) {
+	if match.Path == nil {
+		return "/", "path_prefix", nil
+	}
+
+	path := "/"
+	if match.Path.Value != nil {
+		path = *match.Path.Value
+	}
+	matchType := gatewayv1.PathMatchPathPrefix
+	if match.Path.Type != nil {
+		matchType = *match.Path.Type
+	}
+
+	switch matchType {
+	case gatewayv1.PathMatchExact:
+		return path, "exact_path", nil
+	case gatewayv1.PathMatchPathPrefix:
+		return path, "path_prefix", nil
+	default:
+		return "", "", fmt.Errorf("unsupported path match type %s", matchType)
+	}
+}
+
+// httpRouteMatchExpression compiles the method, header and query param conditions of an HTTPRoute match into a CEL
+// expression that is true when a request satisfies all of them. The expression is empty when the match doesn't
+// restrict requests beyond their path, which is matched by the edge route.
+func httpRouteMatchExpression(match gatewayv1.HTTPRouteMatch) (string, error) {
+	conditions := []string{}
+	if match.Method != nil {
+		conditions = append(conditions, fmt.Sprintf("req.method == %q", string(*match.Method)))
+	}
+	for _, header := range match.Headers {
+		matchType := gatewayv1.HeaderMatchExact
+		if header.Type != nil {
+			matchType = *header.Type
+		}
+		// header names are case insensitive and ngrok exposes them lowercased
+		condition, err := valuesMatchExpression("req.headers", strings.ToLower(string(header.Name)), string(matchType), header.Value)
+		if err != nil {
+			return "", fmt.Errorf("header match %s: %w", header.Name, err)
+		}
+		conditions = append(conditions, condition)
+	}
+	for _, queryParam := range match.QueryParams {
+		matchType := gatewayv1.QueryParamMatchExact
+		if queryParam.Type != nil {
+			matchType = *queryParam.Type
+		}
+		condition, err := valuesMatchExpression("req.url.query_params", string(queryParam.Name), string(matchType), queryParam.Value)
+		if err != nil {
+			return "", fmt.Errorf("query param match %s: %w", queryParam.Name, err)
+		}
+		conditions = append(conditions, condition)
+	}
+	return strings.Join(conditions, " && "), nil
+}
+
+// grpcRouteMatchPath returns the edge route path match of a GRPCRoute match. gRPC requests are sent to
+// /<service>/<method>, so exact service and method matches are path matches. The other matches can't be told apart by
+// the path and are reported as unsupported.
+func grpcRouteMatchPath(match gatewayv1alpha2.GRPCRouteMatch) (string, string, error) {
+	if len(match.Headers) > 0 {
+		return "", "", fmt.Errorf("header matches are not supported, gRPC requests are only routed on their service and method")
+	}
+
+	method := match.Method
+	if method == nil {
+		return "/", "path_prefix", nil
+	}
+	if method.Type != nil && *method.Type != gatewayv1alpha2.GRPCMethodMatchExact {
+		return "", "", fmt.Errorf("unsupported method match type %s, gRPC requests are only routed on their exact service and method", *method.Type)
+	}
+
+	switch {
+	case method.Service != nil && method.Method != nil:
+		return fmt.Sprintf("/%s/%s", *method.Service, *method.Method), "exact_path", nil
+	case method.Service != nil:
+		return fmt.Sprintf("/%s/", *method.Service), "path_prefix", nil
+	case method.Method != nil:
+		return "", "", fmt.Errorf("method match %q without a service is not supported, gRPC requests are only routed on their service and method", *method.Method)
+	default:
+		return "/", "path_prefix", nil
+	}
+}
+
+// valuesMatchExpression returns a CEL expression checking that one of the values of key in the multi valued map
+// field is either equal to value or matches it as a regular expression
+func valuesMatchExpression(field string, key string, matchType string, value string) (string, error) {
+	var valueCheck string
+	switch matchType {
+	case string(gatewayv1.HeaderMatchExact):
+		valueCheck = fmt.Sprintf("v == %q", value)
+	case string(gatewayv1.HeaderMatchRegularExpression):
+		// ngrok evaluates regular expressions with RE2, same as Go
+		if _, err := regexp.Compile(value); err != nil {
+			return "", fmt.Errorf("invalid regular expression %q: %w", value, err)
+		}
+		valueCheck = fmt.Sprintf("v.matches(%q)", value)
+	default:
+		return "", fmt.Errorf("unsupported match type %s", matchType)
+	}
+	return fmt.Sprintf("(%q in %s && %s[%q].exists(v, %s))", key, field, field, key, valueCheck), nil
+}
+
+type CustomResponseConfig struct {
+	StatusCode int               `json:"status_code"`
+	Content    string            `json:"content,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+// grpcRequestExpression is true for gRPC requests, whose content type is application/grpc or one of its variants
+const grpcRequestExpression = `("content-type" in req.headers && req.headers["content-type"].exists(v, v.startsWith("application/grpc")))`
+
+// mergeRejectUnmatchedRequestsRule adds a rule responding with a 404 to the requests that don't satisfy matchesExpression
+func mergeRejectUnmatchedRequestsRule(matchesExpression string, trafficPolicy util.TrafficPolicy) error {
+	return mergeCustomResponseRule("Reject requests not matching the route rules", []string{fmt.Sprintf("!(%s)", matchesExpression)}, CustomResponseConfig{
+		StatusCode: 404,
+		Content:    "Not Found",
+	}, trafficPolicy)
+}
+
+// mergeRejectUnmatchedGRPCRequestsRule adds a rule responding to the gRPC requests that don't satisfy matchesExpression
+// with the UNIMPLEMENTED status, gRPC clients expect a gRPC status rather than an HTTP error
+func mergeRejectUnmatchedGRPCRequestsRule(matchesExpression string, trafficPolicy util.TrafficPolicy) error {
+	return mergeCustomResponseRule("Reject gRPC requests not matching the route rules", []string{fmt.Sprintf("!(%s)", matchesExpression), grpcRequestExpression}, CustomResponseConfig{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"content-type": "application/grpc",
+			"grpc-status":  "12",
+			"grpc-message": "unknown service or method",
+		},
+	}, trafficPolicy)
+}
+
+func mergeCustomResponseRule(name string, expressions []string, customResponse CustomResponseConfig, trafficPolicy util.TrafficPolicy) error {
+	config, err := json.Marshal(customResponse)
+	if err != nil {
+		return err
+	}
+
+	rawAction, err := json.Marshal(&util.EndpointAction{
+		Type:   "custom-response",
+		Config: config,
+	})
+	if err != nil {
+		return err
+	}
+
+	return trafficPolicy.MergeEndpointRule(util.EndpointRule{
+		Name:        name,
+		Expressions: expressions,
+		Actions:     []util.RawAction{rawAction},
+	}, util.PhaseOnHttpRequest)
+}
+
+type ForwardInternalConfig struct {
+	URL string `json:"url"`
+}
+
 type RemoveHeadersConfig struct {
 	Headers []string `json:"headers"`
 }
@@ -1576,6 +3915,15 @@ type tunnelKey struct {
 	namespace string
 	service   string
 	port      string
+	// serviceNamespace is only set when the service isn't in the namespace of the tunnel
+	serviceNamespace string
+	// group and replica are only set for the members of a weighted tunnel group
+	group   string
+	replica string
+	// url is only set for the tunnels started as internal agent endpoints
+	url string
+	// appProtocol is only set for the tunnels of GRPCRoute backends, which are carried over HTTP/2
+	appProtocol string
 }
 
 func (d *Driver) tunnelKeyFromTunnel(tunnel ingressv1alpha1.Tunnel) tunnelKey {
@@ -1583,6 +3931,13 @@ func (d *Driver) tunnelKeyFromTunnel(tunnel ingressv1alpha1.Tunnel) tunnelKey {
 		namespace: tunnel.Namespace,
 		service:   tunnel.Labels[labelService],
 		port:      tunnel.Labels[labelPort],
+		group:     tunnel.Labels[labelWeightedGroup],
+		replica:   tunnel.Labels[labelWeightedReplica],
+
+		serviceNamespace: tunnel.Labels[labelServiceNamespace],
+
+		url:         tunnel.Spec.URL,
+		appProtocol: tunnel.Labels[labelAppProtocol],
 	}
 }
 
@@ -1595,149 +3950,430 @@ func (d *Driver) calculateTunnels() map[tunnelKey]ingressv1alpha1.Tunnel {
 
 func (d *Driver) calculateTunnelsFromIngress(tunnels map[tunnelKey]ingressv1alpha1.Tunnel) {
 	for _, ingress := range d.store.ListNgrokIngressesV1() {
+		strategy := d.ingressMappingStrategy(ingress)
 		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
 			for _, path := range rule.HTTP.Paths {
-				// We only support service backends right now.
-				// TODO: support resource backends
+				// Resource backends forward to other ngrok endpoints, so only services need tunnels
 				if path.Backend.Service == nil {
 					continue
 				}
+				d.calculateTunnelForIngressBackend(tunnels, ingress, *path.Backend.Service, strategy)
+			}
+		}
 
-				serviceName := path.Backend.Service.Name
-				serviceUID, servicePort, protocol, appProtocol, err := d.getTunnelBackend(*path.Backend.Service, ingress.Namespace)
-				if err != nil {
-					d.log.Error(err, "could not find port for service", "namespace", ingress.Namespace, "service", serviceName)
-				}
+		if ingress.Spec.DefaultBackend != nil && ingress.Spec.DefaultBackend.Service != nil {
+			d.calculateTunnelForIngressBackend(tunnels, ingress, *ingress.Spec.DefaultBackend.Service, strategy)
+		}
+	}
+}
 
-				key := tunnelKey{ingress.Namespace, serviceName, strconv.Itoa(int(servicePort))}
-				tunnel, found := tunnels[key]
-				if !found {
-					targetAddr := fmt.Sprintf("%s.%s.%s:%d", serviceName, key.namespace, d.clusterDomain, servicePort)
-					tunnel = ingressv1alpha1.Tunnel{
-						ObjectMeta: metav1.ObjectMeta{
-							GenerateName:    fmt.Sprintf("%s-%d-", serviceName, servicePort),
-							Namespace:       ingress.Namespace,
-							OwnerReferences: nil, // fill owner references below
-							Labels:          d.tunnelLabels(serviceName, servicePort),
-						},
-						Spec: ingressv1alpha1.TunnelSpec{
-							ForwardsTo: targetAddr,
-							Labels:     d.ngrokLabels(ingress.Namespace, serviceUID, serviceName, servicePort),
-							BackendConfig: &ingressv1alpha1.BackendConfig{
-								Protocol: protocol,
-							},
-							AppProtocol: appProtocol,
-						},
-					}
-				}
+// calculateTunnelForIngressBackend adds or updates the tunnel for an ingress service backend. The ingresses using the
+// endpoints mapping strategy get tunnels started as internal agent endpoints rather than labeled tunnels.
+func (d *Driver) calculateTunnelForIngressBackend(tunnels map[tunnelKey]ingressv1alpha1.Tunnel, ingress *netv1.Ingress, backend netv1.IngressServiceBackend, strategy IngressMappingStrategy) {
+	serviceName := backend.Name
+	serviceUID, servicePort, protocol, appProtocol, err := d.getTunnelBackend(backend, ingress.Namespace)
+	if err != nil {
+		d.recordSyncError("Ingress", types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, err, fmt.Sprintf("could not find port for service %q", serviceName))
+	}
 
-				hasIngressReference := false
-				for _, ref := range tunnel.OwnerReferences {
-					if ref.UID == ingress.UID {
-						hasIngressReference = true
-						break
-					}
-				}
-				if !hasIngressReference {
-					tunnel.OwnerReferences = append(tunnel.OwnerReferences, metav1.OwnerReference{
-						APIVersion: ingress.APIVersion,
-						Kind:       ingress.Kind,
-						Name:       ingress.Name,
-						UID:        ingress.UID,
-					})
-					slices.SortStableFunc(tunnel.OwnerReferences, func(i, j metav1.OwnerReference) int {
-						return cmp.Compare(string(i.UID), string(j.UID))
-					})
-				}
+	key := tunnelKey{namespace: ingress.Namespace, service: serviceName, port: strconv.Itoa(int(servicePort))}
+	if strategy == IngressMappingStrategyEndpoints {
+		key.url = internalEndpointURL(ingress.Namespace, serviceName, serviceUID, servicePort)
+	}
+	tunnel, found := tunnels[key]
+	if !found {
+		targetAddr := fmt.Sprintf("%s.%s.%s:%d", serviceName, key.namespace, d.clusterDomain, servicePort)
+		tunnel = ingressv1alpha1.Tunnel{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName:    fmt.Sprintf("%s-%d-", serviceName, servicePort),
+				Namespace:       ingress.Namespace,
+				OwnerReferences: nil, // fill owner references below
+				Labels:          d.tunnelLabels(serviceName, servicePort),
+			},
+			Spec: ingressv1alpha1.TunnelSpec{
+				ForwardsTo: targetAddr,
+				Labels:     d.ngrokLabels(ingress.Namespace, serviceUID, serviceName, servicePort),
+				BackendConfig: &ingressv1alpha1.BackendConfig{
+					Protocol: protocol,
+				},
+				AppProtocol: appProtocol,
+			},
+		}
+		if key.url != "" {
+			// The CloudEndpoints forward to the internal agent endpoints by URL rather than by labels
+			tunnel.Spec.Labels = nil
+			tunnel.Spec.URL = key.url
+		}
+	}
 
-				tunnels[key] = tunnel
-			}
+	hasIngressReference := false
+	for _, ref := range tunnel.OwnerReferences {
+		if ref.UID == ingress.UID {
+			hasIngressReference = true
+			break
 		}
 	}
+	if !hasIngressReference {
+		tunnel.OwnerReferences = append(tunnel.OwnerReferences, metav1.OwnerReference{
+			APIVersion: ingress.APIVersion,
+			Kind:       ingress.Kind,
+			Name:       ingress.Name,
+			UID:        ingress.UID,
+		})
+		slices.SortStableFunc(tunnel.OwnerReferences, func(i, j metav1.OwnerReference) int {
+			return cmp.Compare(string(i.UID), string(j.UID))
+		})
+	}
+
+	tunnels[key] = tunnel
 }
 
 func (d *Driver) calculateTunnelsFromGateway(tunnels map[tunnelKey]ingressv1alpha1.Tunnel) {
 	httproutes := d.store.ListHTTPRoutes()
 
 	for _, httproute := range httproutes {
-		for _, rule := range httproute.Spec.Rules {
-			for _, backendRef := range rule.BackendRefs {
-				// We only support service backends right now.
-				// TODO: support resource backends
+		if !d.routeAllowedByListeners(httproute.Spec.ParentRefs, "HTTPRoute", httproute.Namespace) {
+			continue
+		}
+		owner := metav1.OwnerReference{
+			APIVersion: httproute.APIVersion,
+			Kind:       httproute.Kind,
+			Name:       httproute.Name,
+			UID:        httproute.UID,
+		}
+		for ruleIdx, rule := range httproute.Spec.Rules {
+			d.calculateTunnelsForRule(tunnels, rule.BackendRefs, "HTTPRoute", httproute.Namespace, httproute.Name, ruleIdx, owner)
+		}
+	}
 
-				//if path.Backend.Service == nil {
-				//	continue
-				//}
+	grpcroutes := d.store.ListGRPCRoutes()
 
-				serviceName := string(backendRef.Name)
-				serviceUID, servicePort, protocol, appProtocol, err := d.getTunnelBackendFromGateway(backendRef.BackendRef, httproute.Namespace)
-				if err != nil {
-					d.log.Error(err, "could not find port for service", "namespace", httproute.Namespace, "service", serviceName)
-				}
+	for _, grpcroute := range grpcroutes {
+		if !d.routeAllowedByListeners(grpcroute.Spec.ParentRefs, "GRPCRoute", grpcroute.Namespace) {
+			continue
+		}
+		owner := metav1.OwnerReference{
+			APIVersion: gatewayv1alpha2.GroupVersion.String(),
+			Kind:       "GRPCRoute",
+			Name:       grpcroute.Name,
+			UID:        grpcroute.UID,
+		}
+		for ruleIdx, rule := range grpcroute.Spec.Rules {
+			d.calculateTunnelsForRule(tunnels, grpcHTTPBackendRefs(rule.BackendRefs), "GRPCRoute", grpcroute.Namespace, grpcroute.Name, ruleIdx, owner)
+		}
+	}
 
-				key := tunnelKey{httproute.Namespace, serviceName, strconv.Itoa(int(servicePort))}
-				tunnel, found := tunnels[key]
-				if !found {
-					targetAddr := fmt.Sprintf("%s.%s.%s:%d", serviceName, key.namespace, d.clusterDomain, servicePort)
-					tunnel = ingressv1alpha1.Tunnel{
-						ObjectMeta: metav1.ObjectMeta{
-							GenerateName:    fmt.Sprintf("%s-%d-", serviceName, servicePort),
-							Namespace:       httproute.Namespace,
-							OwnerReferences: nil, // fill owner references below
-							Labels:          d.tunnelLabels(serviceName, servicePort),
-						},
-						Spec: ingressv1alpha1.TunnelSpec{
-							ForwardsTo: targetAddr,
-							Labels:     d.ngrokLabels(httproute.Namespace, serviceUID, serviceName, servicePort),
-							BackendConfig: &ingressv1alpha1.BackendConfig{
-								Protocol: protocol,
-							},
-							AppProtocol: appProtocol,
-						},
-					}
-				}
+	tcproutes := d.store.ListTCPRoutes()
 
-				hasReference := false
-				for _, ref := range tunnel.OwnerReferences {
-					if ref.UID == httproute.UID {
-						hasReference = true
-						break
-					}
-				}
-				if !hasReference {
-					tunnel.OwnerReferences = append(tunnel.OwnerReferences, metav1.OwnerReference{
-						APIVersion: httproute.APIVersion,
-						Kind:       httproute.Kind,
-						Name:       httproute.Name,
-						UID:        httproute.UID,
-					})
-					slices.SortStableFunc(tunnel.OwnerReferences, func(i, j metav1.OwnerReference) int {
-						return cmp.Compare(string(i.UID), string(j.UID))
-					})
-				}
+	for _, tcproute := range tcproutes {
+		if !d.tcpRouteAccepted(tcproute) {
+			continue
+		}
+		// TCPEdges only support a single backend, so that's the only one that needs a tunnel
+		backendRef, err := tcpRouteBackendRef(tcproute)
+		if err != nil {
+			continue
+		}
+		owner := metav1.OwnerReference{
+			APIVersion: gatewayv1alpha2.GroupVersion.String(),
+			Kind:       "TCPRoute",
+			Name:       tcproute.Name,
+			UID:        tcproute.UID,
+		}
+		d.calculateTunnelForBackendRef(tunnels, *backendRef, "TCPRoute", tcproute.Namespace, owner, "", 0)
+	}
+
+	tlsroutes := d.store.ListTLSRoutes()
+
+	for _, tlsroute := range tlsroutes {
+		if !d.tlsRouteAccepted(tlsroute) {
+			continue
+		}
+		// TLSEdges only support a single backend, so that's the only one that needs a tunnel
+		backendRef, err := tlsRouteBackendRef(tlsroute)
+		if err != nil {
+			continue
+		}
+		owner := metav1.OwnerReference{
+			APIVersion: gatewayv1alpha2.GroupVersion.String(),
+			Kind:       "TLSRoute",
+			Name:       tlsroute.Name,
+			UID:        tlsroute.UID,
+		}
+		d.calculateTunnelForBackendRef(tunnels, *backendRef, "TLSRoute", tlsroute.Namespace, owner, "", 0)
+	}
+}
+
+// routeAllowedByListeners checks if an HTTPS listener of one of the route's parent Gateways allows an HTTPRoute
+// or GRPCRoute, taking the listener's allowedRoutes namespaces into account
+func (d *Driver) routeAllowedByListeners(parents []gatewayv1.ParentReference, routeKind gatewayv1.Kind, namespace string) bool {
+	for _, parent := range parents {
+		if _, listeners, _, _ := d.routeParentListeners(parent, routeKind, namespace, gatewayv1.HTTPSProtocolType); len(listeners) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// calculateTunnelsForRule adds or updates the tunnels for the backends of an HTTPRoute or GRPCRoute rule
+func (d *Driver) calculateTunnelsForRule(tunnels map[tunnelKey]ingressv1alpha1.Tunnel, backendRefs []gatewayv1.HTTPBackendRef, routeKind gatewayv1.Kind, namespace, routeName string, ruleIdx int, owner metav1.OwnerReference) {
+	backends, _, _ := weightedBackends(d.resolvedHTTPBackendRefs(backendRefs, routeKind, namespace))
+	if len(backends) == 1 {
+		d.calculateTunnelForBackendRef(tunnels, backends[0].backendRef, routeKind, namespace, owner, "", 0)
+		return
+	}
+
+	// ngrok balances requests evenly across the tunnels of a tunnel group, so each backend gets
+	// a number of tunnels in the rule's group proportional to its weight
+	group := weightedGroupName(routeKind, namespace, routeName, ruleIdx)
+	for _, backend := range backends {
+		for replica := 0; replica < backend.tunnels; replica++ {
+			d.calculateTunnelForBackendRef(tunnels, backend.backendRef, routeKind, namespace, owner, group, replica)
+		}
+	}
+}
+
+// calculateTunnelForBackendRef adds or updates the tunnel for a Gateway API route backendRef, adding the route as an owner.
+// When group is set, the tunnel is the replica-th tunnel of the backend in that weighted tunnel group instead of the
+// tunnel shared by every route using the service port.
+func (d *Driver) calculateTunnelForBackendRef(tunnels map[tunnelKey]ingressv1alpha1.Tunnel, backendRef gatewayv1.BackendRef, routeKind gatewayv1.Kind, namespace string, owner metav1.OwnerReference, group string, replica int) {
+	// We only support service backends right now.
+	// TODO: support resource backends
+
+	serviceName := string(backendRef.Name)
+	serviceNamespace := backendRefNamespace(backendRef, namespace)
+	serviceUID, servicePort, protocol, appProtocol, err := d.getTunnelBackendFromGateway(backendRef, routeKind, namespace)
+	if err != nil {
+		d.recordSyncError(string(routeKind), types.NamespacedName{Namespace: namespace, Name: owner.Name}, err,
+			fmt.Sprintf("could not find port for service %s/%s", serviceNamespace, serviceName))
+		return
+	}
+
+	key := tunnelKey{namespace: namespace, service: serviceName, port: strconv.Itoa(int(servicePort))}
+	labels := d.tunnelLabels(serviceName, servicePort)
+	if serviceNamespace != namespace {
+		// the tunnel lives with the route that owns it, and forwards to the service in the namespace that granted the reference
+		key.serviceNamespace = serviceNamespace
+		labels[labelServiceNamespace] = serviceNamespace
+	}
+	ngrokLabels := d.ngrokLabels(namespace, serviceUID, serviceName, servicePort)
+	if routeKind == "GRPCRoute" {
+		// gRPC is carried over HTTP/2 whatever the appProtocol of the service port, so GRPCRoute backends get
+		// tunnels of their own instead of changing the protocol of the tunnel shared with Ingresses and HTTPRoutes
+		appProtocol = "http2"
+		key.appProtocol = appProtocol
+		labels[labelAppProtocol] = appProtocol
+		ngrokLabels[labelAppProtocol] = appProtocol
+	}
+	if group != "" {
+		key.group = group
+		key.replica = strconv.Itoa(replica)
+		labels[labelWeightedGroup] = key.group
+		labels[labelWeightedReplica] = key.replica
+		ngrokLabels = d.weightedGroupLabels(namespace, group)
+	}
+
+	tunnel, found := tunnels[key]
+	if !found {
+		targetAddr := fmt.Sprintf("%s.%s.%s:%d", serviceName, serviceNamespace, d.clusterDomain, servicePort)
+		tunnel = ingressv1alpha1.Tunnel{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName:    fmt.Sprintf("%s-%d-", serviceName, servicePort),
+				Namespace:       namespace,
+				OwnerReferences: nil, // fill owner references below
+				Labels:          labels,
+			},
+			Spec: ingressv1alpha1.TunnelSpec{
+				ForwardsTo: targetAddr,
+				Labels:     ngrokLabels,
+				BackendConfig: &ingressv1alpha1.BackendConfig{
+					Protocol: protocol,
+				},
+				AppProtocol: appProtocol,
+			},
+		}
+	}
+
+	hasReference := false
+	for _, ref := range tunnel.OwnerReferences {
+		if ref.UID == owner.UID {
+			hasReference = true
+			break
+		}
+	}
+	if !hasReference {
+		tunnel.OwnerReferences = append(tunnel.OwnerReferences, owner)
+		slices.SortStableFunc(tunnel.OwnerReferences, func(i, j metav1.OwnerReference) int {
+			return cmp.Compare(string(i.UID), string(j.UID))
+		})
+	}
+
+	tunnels[key] = tunnel
+}
 
-				tunnels[key] = tunnel
+const (
+	// RouteConditionBackendWeightsExact is set on HTTPRoutes splitting traffic between several backends. It's False
+	// when the backend weights can only be approximated by the number of tunnels in the weighted tunnel groups, or
+	// when a rule has more weighted backends than tunnels in a group and its traffic can't be split.
+	RouteConditionBackendWeightsExact gatewayv1.RouteConditionType = "ngrok.com/BackendWeightsExact"
+
+	RouteReasonBackendWeightsExact        gatewayv1.RouteConditionReason = "Exact"
+	RouteReasonBackendWeightsApproximated gatewayv1.RouteConditionReason = "Approximated"
+	RouteReasonTooManyWeightedBackends    gatewayv1.RouteConditionReason = "TooManyBackends"
+)
+
+// maxWeightedTunnels is the maximum number of tunnels in the weighted tunnel group of an HTTPRoute rule. Weights that
+// don't reduce to at most this many tunnels are approximated, and rules with more backends than this are rejected.
+const maxWeightedTunnels = 10
+
+// weightedBackend is a backendRef of a weighted HTTPRoute rule along with its number of tunnels in the rule's group
+type weightedBackend struct {
+	backendRef gatewayv1.BackendRef
+	weight     int32
+	tunnels    int
+}
+
+// weightedBackends returns the backendRefs that should receive traffic along with the number of tunnels each of them
+// gets in a weighted tunnel group. Backends with a weight of 0 are dropped. The returned bool is false when the
+// weights could not be represented exactly within maxWeightedTunnels tunnels. An error is returned when there are
+// more backends with a weight than maxWeightedTunnels, since each of them needs at least one tunnel.
+func weightedBackends(backendRefs []gatewayv1.HTTPBackendRef) ([]weightedBackend, bool, error) {
+	backends := []weightedBackend{}
+	for _, backendRef := range backendRefs {
+		weight := int32(1)
+		if backendRef.Weight != nil {
+			weight = *backendRef.Weight
+		}
+		if weight <= 0 {
+			continue
+		}
+		backends = append(backends, weightedBackend{backendRef: backendRef.BackendRef, weight: weight})
+	}
+	if len(backends) == 0 {
+		return backends, true, nil
+	}
+	if len(backends) > maxWeightedTunnels {
+		return nil, false, fmt.Errorf("%d backends have a weight, traffic can only be split between at most %d backends", len(backends), maxWeightedTunnels)
+	}
+
+	divisor := backends[0].weight
+	var totalWeight int64
+	for _, backend := range backends {
+		divisor = gcd(divisor, backend.weight)
+		totalWeight += int64(backend.weight)
+	}
+
+	totalTunnels := 0
+	for i := range backends {
+		backends[i].tunnels = int(backends[i].weight / divisor)
+		totalTunnels += backends[i].tunnels
+	}
+	if totalTunnels <= maxWeightedTunnels {
+		return backends, true, nil
+	}
+
+	// largest remainder allocation of the maxWeightedTunnels tunnels, where every backend with a
+	// weight gets at least one tunnel so that it keeps some of the traffic
+	quotas := make([]float64, len(backends))
+	totalTunnels = 0
+	for i := range backends {
+		quotas[i] = float64(backends[i].weight) * maxWeightedTunnels / float64(totalWeight)
+		backends[i].tunnels = max(int(math.Floor(quotas[i])), 1)
+		totalTunnels += backends[i].tunnels
+	}
+	for ; totalTunnels < maxWeightedTunnels; totalTunnels++ {
+		idx := 0
+		for i := range backends {
+			if quotas[i]-float64(backends[i].tunnels) > quotas[idx]-float64(backends[idx].tunnels) {
+				idx = i
+			}
+		}
+		backends[idx].tunnels++
+	}
+	for ; totalTunnels > maxWeightedTunnels; totalTunnels-- {
+		// the backends bumped to one tunnel are taken from the most overallocated ones
+		idx := -1
+		for i := range backends {
+			if backends[i].tunnels > 1 && (idx < 0 || float64(backends[i].tunnels)-quotas[i] > float64(backends[idx].tunnels)-quotas[idx]) {
+				idx = i
 			}
 		}
+		backends[idx].tunnels--
+	}
+	return backends, false, nil
+}
+
+func gcd(a, b int32) int32 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// weightedGroupName returns the name of the weighted tunnel group for the rule at ruleIdx of an HTTPRoute or GRPCRoute.
+// It's hashed to fit in a label value whatever the length of the route's name.
+func weightedGroupName(routeKind gatewayv1.Kind, namespace, routeName string, ruleIdx int) string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s/%s/%s/%d", routeKind, namespace, routeName, ruleIdx)
+	return fmt.Sprintf("rule-%x", h.Sum32())
+}
+
+// grpcHTTPBackendRefs returns the backendRefs of a GRPCRoute rule as HTTPRoute backendRefs, GRPCRoute backends are
+// served the same way. Their filters aren't supported.
+func grpcHTTPBackendRefs(backendRefs []gatewayv1alpha2.GRPCBackendRef) []gatewayv1.HTTPBackendRef {
+	httpBackendRefs := make([]gatewayv1.HTTPBackendRef, 0, len(backendRefs))
+	for _, backendRef := range backendRefs {
+		httpBackendRefs = append(httpBackendRefs, gatewayv1.HTTPBackendRef{BackendRef: backendRef.BackendRef})
+	}
+	return httpBackendRefs
+}
+
+// resolvedHTTPBackendRefs filters out the backendRefs that don't point to a known Service port
+func (d *Driver) resolvedHTTPBackendRefs(backendRefs []gatewayv1.HTTPBackendRef, routeKind gatewayv1.Kind, namespace string) []gatewayv1.HTTPBackendRef {
+	resolved := []gatewayv1.HTTPBackendRef{}
+	for _, backendRef := range backendRefs {
+		if reason, err := d.checkBackendRef(backendRef.BackendRef, routeKind, namespace); reason != gatewayv1.RouteReasonResolvedRefs {
+			// the ResolvedRefs condition of the route reports it
+			d.log.V(3).Info("skipping unresolved backendRef", "namespace", namespace, "backendRef", backendRef.Name, "reason", reason, "error", err)
+			continue
+		}
+		resolved = append(resolved, backendRef)
 	}
+	return resolved
 }
 
 func (d *Driver) calculateIngressLoadBalancerIPStatus(ing *netv1.Ingress, c client.Reader) []netv1.IngressLoadBalancerIngress {
-	ingressHosts := map[string]bool{}
-	for _, rule := range ing.Spec.Rules {
-		ingressHosts[rule.Host] = true
+	domainsByDomain, err := d.listDomainsByDomain(context.Background(), c)
+	if err != nil {
+		return []netv1.IngressLoadBalancerIngress{}
 	}
+	return ingressLoadBalancerIPStatus(ing, domainsByDomain)
+}
 
+// listDomainsByDomain returns the domains of the cluster keyed by their domain name
+func (d *Driver) listDomainsByDomain(ctx context.Context, c client.Reader) (map[string]ingressv1alpha1.Domain, error) {
 	domains := &ingressv1alpha1.DomainList{}
-	if err := c.List(context.Background(), domains); err != nil {
+	if err := c.List(ctx, domains); err != nil {
 		d.log.Error(err, "failed to list domains")
-		return []netv1.IngressLoadBalancerIngress{}
+		return nil, err
 	}
 
-	domainsByDomain := map[string]ingressv1alpha1.Domain{}
+	domainsByDomain := make(map[string]ingressv1alpha1.Domain, len(domains.Items))
 	for _, domain := range domains.Items {
 		domainsByDomain[domain.Spec.Domain] = domain
 	}
+	return domainsByDomain, nil
+}
+
+// ingressLoadBalancerIPStatus returns the load balancer status of an ingress from the domains of its hosts
+func ingressLoadBalancerIPStatus(ing *netv1.Ingress, domainsByDomain map[string]ingressv1alpha1.Domain) []netv1.IngressLoadBalancerIngress {
+	ingressHosts := map[string]bool{}
+	for _, rule := range ing.Spec.Rules {
+		ingressHosts[rule.Host] = true
+	}
 
 	status := []netv1.IngressLoadBalancerIngress{}
 
@@ -1747,19 +4383,7 @@ func (d *Driver) calculateIngressLoadBalancerIPStatus(ing *netv1.Ingress, c clie
 			continue
 		}
 
-		var hostname string
-
-		switch {
-		// Custom domain
-		case d.Status.CNAMETarget != nil:
-			hostname = *d.Status.CNAMETarget
-		// ngrok managed domain
-		default:
-			// Trim the wildcard prefix if it exists for ngrok managed domains
-			hostname = strings.TrimPrefix(d.Status.Domain, "*.")
-		}
-
-		if hostname != "" {
+		if hostname := domainAddress(d); hostname != "" {
 			status = append(status, netv1.IngressLoadBalancerIngress{
 				Hostname: hostname,
 			})
@@ -1769,6 +4393,19 @@ func (d *Driver) calculateIngressLoadBalancerIPStatus(ing *netv1.Ingress, c clie
 	return status
 }
 
+// domainAddress returns the hostname traffic for a Domain should be sent to, empty until the domain is reserved
+func domainAddress(domain ingressv1alpha1.Domain) string {
+	switch {
+	// Custom domain
+	case domain.Status.CNAMETarget != nil:
+		return *domain.Status.CNAMETarget
+	// ngrok managed domain
+	default:
+		// Trim the wildcard prefix if it exists for ngrok managed domains
+		return strings.TrimPrefix(domain.Status.Domain, "*.")
+	}
+}
+
 func (d *Driver) getEdgeBackend(backendSvc netv1.IngressServiceBackend, namespace string) (string, int32, error) {
 	service, servicePort, err := d.findBackendServicePort(backendSvc, namespace)
 	if err != nil {
@@ -1778,11 +4415,8 @@ func (d *Driver) getEdgeBackend(backendSvc netv1.IngressServiceBackend, namespac
 	return string(service.UID), servicePort.Port, nil
 }
 
-func (d *Driver) getEdgeBackendRef(backendRef gatewayv1.BackendRef, namespace string) (string, int32, error) {
-	if backendRef.Namespace != nil && string(*backendRef.Namespace) != namespace {
-		return "", 0, fmt.Errorf("namespace %s not supported", string(*backendRef.Namespace))
-	}
-	service, servicePort, err := d.findBackendRefServicePort(backendRef, namespace)
+func (d *Driver) getEdgeBackendRef(backendRef gatewayv1.BackendRef, routeKind gatewayv1.Kind, namespace string) (string, int32, error) {
+	service, servicePort, err := d.findBackendRefServicePort(backendRef, routeKind, namespace)
 	if err != nil {
 		return "", 0, err
 	}
@@ -1790,8 +4424,13 @@ func (d *Driver) getEdgeBackendRef(backendRef gatewayv1.BackendRef, namespace st
 	return string(service.UID), servicePort.Port, nil
 }
 
-func (d *Driver) findBackendRefServicePort(backendRef gatewayv1.BackendRef, namespace string) (*corev1.Service, *corev1.ServicePort, error) {
-	service, err := d.store.GetServiceV1(string(backendRef.Name), namespace)
+// findBackendRefServicePort finds the Service port referenced by a backendRef of a route of routeKind in namespace.
+// Services in other namespaces are only resolved when a ReferenceGrant allows it.
+func (d *Driver) findBackendRefServicePort(backendRef gatewayv1.BackendRef, routeKind gatewayv1.Kind, namespace string) (*corev1.Service, *corev1.ServicePort, error) {
+	if !d.referenceGrantAllowsBackendRef(backendRef, routeKind, namespace) {
+		return nil, nil, fmt.Errorf("backendRef %s/%s is not allowed by any ReferenceGrant", backendRefNamespace(backendRef, namespace), backendRef.Name)
+	}
+	service, err := d.store.GetServiceV1(string(backendRef.Name), backendRefNamespace(backendRef, namespace))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1832,8 +4471,8 @@ func (d *Driver) getTunnelBackend(backendSvc netv1.IngressServiceBackend, namesp
 	return string(service.UID), servicePort.Port, protocol, appProtocol, nil
 }
 
-func (d *Driver) getTunnelBackendFromGateway(backendRef gatewayv1.BackendRef, namespace string) (string, int32, string, string, error) {
-	service, servicePort, err := d.findBackendRefServicePort(backendRef, namespace)
+func (d *Driver) getTunnelBackendFromGateway(backendRef gatewayv1.BackendRef, routeKind gatewayv1.Kind, namespace string) (string, int32, string, string, error) {
+	service, servicePort, err := d.findBackendRefServicePort(backendRef, routeKind, namespace)
 	if err != nil {
 		return "", 0, "", "", err
 	}
@@ -1932,6 +4571,14 @@ func (d *Driver) tunnelLabels(serviceName string, port int32) map[string]string
 	}
 }
 
+// Generates a labels map for matching ngrok Routes to the Agent Tunnels of a weighted tunnel group
+func (d *Driver) weightedGroupLabels(namespace, group string) map[string]string {
+	return map[string]string{
+		labelNamespace:     namespace,
+		labelWeightedGroup: group,
+	}
+}
+
 // Generates a labels map for matching ngrok Routes to Agent Tunnels
 func (d *Driver) ngrokLabels(namespace, serviceUID, serviceName string, port int32) map[string]string {
 	return map[string]string{
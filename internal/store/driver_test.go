@@ -4,23 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
 	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
@@ -37,6 +46,8 @@ var _ = Describe("Driver", func() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(ingressv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(gatewayv1.AddToScheme(scheme))
+	utilruntime.Must(gatewayv1alpha2.AddToScheme(scheme))
+	utilruntime.Must(gatewayv1beta1.AddToScheme(scheme))
 	utilruntime.Must(ngrokv1alpha1.AddToScheme(scheme))
 	BeforeEach(func() {
 		// create a fake logger to pass into the cachestore
@@ -175,6 +186,1873 @@ var _ = Describe("Driver", func() {
 		})
 	})
 
+	Describe("Ingress backends", func() {
+		var c client.WithWatch
+		var ic netv1.IngressClass
+		var ing netv1.Ingress
+		var api, fallback corev1.Service
+
+		BeforeEach(func() {
+			ic = NewTestIngressClass("ngrok", true, true)
+			ing = NewTestIngressV1("test-ingress", "test-namespace")
+			ing.Spec.Rules[0].HTTP.Paths[0].Path = "/api"
+			ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "api"
+			api = NewTestServiceV1("api", "test-namespace")
+			fallback = NewTestServiceV1("fallback", "test-namespace")
+		})
+
+		sync := func(obs ...client.Object) {
+			c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		}
+
+		edgeRoutes := func() []ingressv1alpha1.HTTPSEdgeRouteSpec {
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			return edges.Items[0].Spec.Routes
+		}
+
+		It("routes everything the paths don't match to the default backend", func() {
+			ing.Spec.DefaultBackend = &netv1.IngressBackend{
+				Service: &netv1.IngressServiceBackend{Name: "fallback", Port: netv1.ServiceBackendPort{Number: 80}},
+			}
+			sync(&ic, &ing, &api, &fallback)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(2))
+			Expect(routes[0].Match).To(Equal("/api"))
+			Expect(routes[1].Match).To(Equal("/"))
+			Expect(routes[1].MatchType).To(Equal("path_prefix"))
+			Expect(routes[1].Backend.Labels).To(HaveKeyWithValue("k8s.ngrok.com/service", "fallback"))
+
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(HaveLen(2))
+		})
+
+		It("keeps a catch-all path over the default backend", func() {
+			ing.Spec.Rules[0].HTTP.Paths[0].Path = "/"
+			ing.Spec.DefaultBackend = &netv1.IngressBackend{
+				Service: &netv1.IngressServiceBackend{Name: "fallback", Port: netv1.ServiceBackendPort{Number: 80}},
+			}
+			sync(&ic, &ing, &api, &fallback)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Backend.Labels).To(HaveKeyWithValue("k8s.ngrok.com/service", "api"))
+		})
+
+		It("routes hosts without paths to the default backend", func() {
+			ing.Spec.Rules[0].HTTP = nil
+			ing.Spec.DefaultBackend = &netv1.IngressBackend{
+				Service: &netv1.IngressServiceBackend{Name: "fallback", Port: netv1.ServiceBackendPort{Number: 80}},
+			}
+			sync(&ic, &ing, &fallback)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Match).To(Equal("/"))
+			Expect(routes[0].Backend.Labels).To(HaveKeyWithValue("k8s.ngrok.com/service", "fallback"))
+		})
+
+		It("forwards resource backends to the CloudEndpoint URL", func() {
+			endpoint := &ngrokv1alpha1.CloudEndpoint{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "test-namespace"},
+				Spec:       ngrokv1alpha1.CloudEndpointSpec{URL: "https://legacy.internal"},
+			}
+			ing.Spec.DefaultBackend = &netv1.IngressBackend{
+				Resource: &corev1.TypedLocalObjectReference{
+					APIGroup: ptr.To(ngrokv1alpha1.GroupVersion.Group),
+					Kind:     "CloudEndpoint",
+					Name:     "legacy",
+				},
+			}
+			sync(&ic, &ing, &api, endpoint)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(2))
+			Expect(routes[1].Match).To(Equal("/"))
+			Expect(routes[1].Backend.Labels).To(BeEmpty())
+			Expect(string(routes[1].Policy)).To(MatchJSON(`{
+				"on_http_request": [{
+					"name": "Forward to https://legacy.internal",
+					"actions": [{"type": "forward-internal", "config": {"url": "https://legacy.internal"}}]
+				}]
+			}`))
+		})
+
+		It("skips resource backends whose CloudEndpoint isn't internal", func() {
+			endpoint := &ngrokv1alpha1.CloudEndpoint{
+				ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "test-namespace"},
+				Spec:       ngrokv1alpha1.CloudEndpointSpec{URL: "https://public.example.com"},
+			}
+			ing.Spec.Rules[0].HTTP.Paths[0].Backend = netv1.IngressBackend{
+				Resource: &corev1.TypedLocalObjectReference{
+					APIGroup: ptr.To(ngrokv1alpha1.GroupVersion.Group),
+					Kind:     "CloudEndpoint",
+					Name:     "public",
+				},
+			}
+			sync(&ic, &ing, endpoint)
+
+			Expect(edgeRoutes()).To(BeEmpty())
+		})
+
+		It("skips resource backends whose resource doesn't exist", func() {
+			ing.Spec.Rules[0].HTTP.Paths[0].Backend = netv1.IngressBackend{
+				Resource: &corev1.TypedLocalObjectReference{
+					APIGroup: ptr.To(ngrokv1alpha1.GroupVersion.Group),
+					Kind:     "CloudEndpoint",
+					Name:     "missing",
+				},
+			}
+			sync(&ic, &ing)
+
+			Expect(edgeRoutes()).To(BeEmpty())
+		})
+	})
+
+	Describe("Endpoints mapping strategy", func() {
+		var c client.WithWatch
+		var ic netv1.IngressClass
+		var ing netv1.Ingress
+		var api, fallback corev1.Service
+
+		BeforeEach(func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				WithGatewayEnabled(false),
+				WithSyncAllowConcurrent(true),
+				WithIngressMappingStrategy(IngressMappingStrategyEndpoints),
+			)
+			ic = NewTestIngressClass("ngrok", true, true)
+			ing = NewTestIngressV1("test-ingress", "test-namespace")
+			ing.Spec.Rules[0].HTTP.Paths[0].Path = "/api"
+			ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "api"
+			api = NewTestServiceV1("api", "test-namespace")
+			api.UID = "api-uid"
+			fallback = NewTestServiceV1("fallback", "test-namespace")
+			fallback.UID = "fallback-uid"
+		})
+
+		sync := func(obs ...client.Object) {
+			c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		}
+
+		cloudEndpoints := func() []ngrokv1alpha1.CloudEndpoint {
+			endpoints := &ngrokv1alpha1.CloudEndpointList{}
+			Expect(c.List(context.Background(), endpoints)).To(Succeed())
+			return endpoints.Items
+		}
+
+		apiURL := internalEndpointURL("test-namespace", "api", "api-uid", 80)
+		fallbackURL := internalEndpointURL("test-namespace", "fallback", "fallback-uid", 80)
+
+		It("translates the hosts to CloudEndpoints instead of HTTPSEdges", func() {
+			ing.Spec.DefaultBackend = &netv1.IngressBackend{
+				Service: &netv1.IngressServiceBackend{Name: "fallback", Port: netv1.ServiceBackendPort{Number: 80}},
+			}
+			sync(&ic, &ing, &api, &fallback)
+
+			endpoints := cloudEndpoints()
+			Expect(endpoints).To(HaveLen(1))
+			Expect(endpoints[0].Namespace).To(Equal("test-namespace"))
+			Expect(endpoints[0].Name).To(HavePrefix("example-com-"))
+			Expect(endpoints[0].Labels["k8s.ngrok.com/controller-name"]).To(Equal(defaultManagerName))
+			Expect(endpoints[0].Spec.URL).To(Equal("https://example.com"))
+			Expect(string(endpoints[0].Spec.TrafficPolicy.Policy)).To(MatchJSON(fmt.Sprintf(`{
+				"on_http_request": [{
+					"name": "Forward /api to %[1]s",
+					"expressions": ["req.url.path.startsWith(\"/api\")"],
+					"actions": [{"type": "forward-internal", "config": {"url": "%[1]s"}}]
+				}, {
+					"name": "Forward / to %[2]s",
+					"actions": [{"type": "forward-internal", "config": {"url": "%[2]s"}}]
+				}]
+			}`, apiURL, fallbackURL)))
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(BeEmpty())
+
+			domain := &ingressv1alpha1.Domain{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "example-com"}, domain)).To(Succeed())
+
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(HaveLen(2))
+			for _, tunnel := range tunnels.Items {
+				Expect(tunnel.Spec.Labels).To(BeEmpty())
+				Expect(tunnel.Spec.URL).To(BeElementOf(apiURL, fallbackURL))
+			}
+		})
+
+		It("restricts the traffic policy of an ingress to its paths and rejects the other requests", func() {
+			policy := &ngrokv1alpha1.NgrokTrafficPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "deny", Namespace: "test-namespace"},
+				Spec: ngrokv1alpha1.NgrokTrafficPolicySpec{
+					Policy: []byte(`{"on_http_request": [{"name": "deny", "expressions": ["req.method == 'POST'"], "actions": [{"type": "deny"}]}]}`),
+				},
+			}
+			ing.Annotations = map[string]string{"k8s.ngrok.com/traffic-policy": "deny"}
+			exact := netv1.PathTypeExact
+			ing.Spec.Rules[0].HTTP.Paths = append(ing.Spec.Rules[0].HTTP.Paths, netv1.HTTPIngressPath{
+				Path:     "/health",
+				PathType: &exact,
+				Backend:  ing.Spec.Rules[0].HTTP.Paths[0].Backend,
+			})
+			sync(&ic, &ing, &api, policy)
+
+			endpoints := cloudEndpoints()
+			Expect(endpoints).To(HaveLen(1))
+			Expect(string(endpoints[0].Spec.TrafficPolicy.Policy)).To(MatchJSON(fmt.Sprintf(`{
+				"on_http_request": [{
+					"name": "deny",
+					"expressions": ["(req.url.path.startsWith(\"/api\") || req.url.path == \"/health\")", "req.method == 'POST'"],
+					"actions": [{"type": "deny"}]
+				}, {
+					"name": "Forward /health to %[1]s",
+					"expressions": ["req.url.path == \"/health\""],
+					"actions": [{"type": "forward-internal", "config": {"url": "%[1]s"}}]
+				}, {
+					"name": "Forward /api to %[1]s",
+					"expressions": ["req.url.path.startsWith(\"/api\")"],
+					"actions": [{"type": "forward-internal", "config": {"url": "%[1]s"}}]
+				}, {
+					"name": "Reject requests not matching any ingress path",
+					"actions": [{"type": "custom-response", "config": {"status_code": 404, "content": "Not Found"}}]
+				}]
+			}`, apiURL)))
+		})
+
+		It("uses the mapping strategy of the ingress class annotation", func() {
+			driver.mappingStrategy = IngressMappingStrategyEdges
+			ic.Annotations[annotationMappingStrategy] = string(IngressMappingStrategyEndpoints)
+			sync(&ic, &ing, &api)
+
+			Expect(cloudEndpoints()).To(HaveLen(1))
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(BeEmpty())
+		})
+
+		It("reports an invalid mapping strategy annotation once on each ingress of the class", func() {
+			ic.Annotations[annotationMappingStrategy] = "invalid"
+			other := NewTestIngressV1("other-ingress", "test-namespace")
+			other.Spec.Rules[0].Host = "other.example.com"
+			other.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "api"
+			sync(&ic, &ing, &other, &api)
+
+			for _, key := range []types.NamespacedName{client.ObjectKeyFromObject(&ing), client.ObjectKeyFromObject(&other)} {
+				found := &netv1.Ingress{}
+				Expect(c.Get(context.Background(), key, found)).To(Succeed())
+				status := found.Annotations[annotationSyncStatus]
+				Expect(status).To(HavePrefix(syncStatusErrorPrefix))
+				Expect(strings.Count(status, "invalid "+annotationMappingStrategy+" annotation")).To(Equal(1))
+			}
+		})
+
+		It("deletes the CloudEndpoints of the hosts that aren't used anymore", func() {
+			sync(&ic, &ing, &api)
+			Expect(cloudEndpoints()).To(HaveLen(1))
+
+			Expect(driver.DeleteIngress(&ing)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+			Expect(cloudEndpoints()).To(BeEmpty())
+		})
+	})
+
+	Describe("Ingress route conflicts", func() {
+		var c client.WithWatch
+		var recorder *record.FakeRecorder
+		var ic netv1.IngressClass
+		var older, newer netv1.Ingress
+		var olderSvc, newerSvc corev1.Service
+
+		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(10)
+			WithEventRecorder(recorder)(driver)
+
+			ic = NewTestIngressClass("ngrok", true, true)
+			now := metav1.Now()
+			// the newer ingress sorts first by name so that the creationTimestamp decides which one wins
+			older = NewTestIngressV1("older", "test-namespace")
+			older.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+			older.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "older"
+			newer = NewTestIngressV1("newer", "test-namespace")
+			newer.CreationTimestamp = now
+			newer.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "newer"
+			olderSvc = NewTestServiceV1("older", "test-namespace")
+			newerSvc = NewTestServiceV1("newer", "test-namespace")
+		})
+
+		sync := func(obs ...client.Object) {
+			c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		}
+
+		getIngress := func(name string) *netv1.Ingress {
+			ing := &netv1.Ingress{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: name}, ing)).To(Succeed())
+			return ing
+		}
+
+		It("routes to the oldest ingress and reports the conflict on the newer one", func() {
+			sync(&ic, &older, &newer, &olderSvc, &newerSvc)
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes[0].Backend.Labels).To(HaveKeyWithValue("k8s.ngrok.com/service", "older"))
+
+			Expect(getIngress("older").Annotations).ToNot(HaveKey(annotationRouteConflicts))
+			Expect(getIngress("newer").Annotations).To(HaveKeyWithValue(annotationRouteConflicts, "example.com/ (path_prefix) shadowed by test-namespace/older"))
+
+			Expect(recorder.Events).To(HaveLen(1))
+			Expect(<-recorder.Events).To(ContainSubstring("RouteConflict"))
+		})
+
+		It("does not report the conflict again when nothing changed", func() {
+			sync(&ic, &older, &newer, &olderSvc, &newerSvc)
+			Expect(recorder.Events).To(HaveLen(1))
+
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+			Expect(recorder.Events).To(HaveLen(1))
+		})
+
+		It("clears the annotation once the conflict is resolved", func() {
+			newer.Annotations = map[string]string{annotationRouteConflicts: "example.com/ (path_prefix) shadowed by test-namespace/older"}
+			newer.Spec.Rules[0].HTTP.Paths[0].Path = "/newer"
+			sync(&ic, &older, &newer, &olderSvc, &newerSvc)
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(2))
+
+			Expect(getIngress("newer").Annotations).ToNot(HaveKey(annotationRouteConflicts))
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("reports a default backend shadowed by an older catch-all path", func() {
+			newer.Spec.Rules[0].HTTP.Paths[0].Path = "/newer"
+			newer.Spec.DefaultBackend = &netv1.IngressBackend{
+				Service: &netv1.IngressServiceBackend{Name: "newer", Port: netv1.ServiceBackendPort{Number: 80}},
+			}
+			sync(&ic, &older, &newer, &olderSvc, &newerSvc)
+
+			Expect(getIngress("newer").Annotations).To(HaveKeyWithValue(annotationRouteConflicts, "example.com/ (path_prefix) shadowed by test-namespace/older"))
+		})
+	})
+
+	Describe("Sync status", func() {
+		var c client.WithWatch
+		var recorder *record.FakeRecorder
+		var ic netv1.IngressClass
+		var ing netv1.Ingress
+		var svc corev1.Service
+
+		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(10)
+			WithEventRecorder(recorder)(driver)
+
+			ic = NewTestIngressClass("ngrok", true, true)
+			ing = NewTestIngressV1("test-ingress", "test-namespace")
+			svc = NewTestServiceV1("example", "test-namespace")
+		})
+
+		sync := func(obs ...client.Object) {
+			c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		}
+
+		getIngress := func() *netv1.Ingress {
+			found := &netv1.Ingress{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&ing), found)).To(Succeed())
+			return found
+		}
+
+		It("marks ingresses translated without errors as synced", func() {
+			sync(&ic, &ing, &svc)
+
+			Expect(getIngress().Annotations).To(HaveKeyWithValue(annotationSyncStatus, syncStatusSynced))
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("reports translation errors on the ingress", func() {
+			ing.SetAnnotations(map[string]string{"k8s.ngrok.com/modules": "missing"})
+			sync(&ic, &ing, &svc)
+
+			status := getIngress().Annotations[annotationSyncStatus]
+			Expect(status).To(HavePrefix(syncStatusErrorPrefix))
+			Expect(status).To(ContainSubstring("error getting ngrok moduleset for ingress"))
+
+			Expect(recorder.Events).To(HaveLen(1))
+			Expect(<-recorder.Events).To(HavePrefix("Warning SyncError"))
+
+			// the same errors aren't reported again
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("reports when the errors are fixed", func() {
+			ing.SetAnnotations(map[string]string{annotationSyncStatus: syncStatusErrorPrefix + "previous error"})
+			sync(&ic, &ing, &svc)
+
+			Expect(getIngress().Annotations).To(HaveKeyWithValue(annotationSyncStatus, syncStatusSynced))
+			Expect(recorder.Events).To(HaveLen(1))
+			Expect(<-recorder.Events).To(HavePrefix("Normal Synced"))
+		})
+
+		It("reports gateway listeners whose hostname is used by an ingress", func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				WithGatewayEnabled(true),
+				WithSyncAllowConcurrent(true),
+				WithEventRecorder(recorder),
+			)
+
+			gwClass := gatewayv1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "ngrok"},
+				Spec:       gatewayv1.GatewayClassSpec{ControllerName: GatewayControllerName},
+			}
+			gw := gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Spec: gatewayv1.GatewaySpec{
+					GatewayClassName: "ngrok",
+					Listeners: []gatewayv1.Listener{{
+						Name:     "https",
+						Hostname: ptr.To(gatewayv1.Hostname("example.com")),
+						Port:     443,
+						Protocol: gatewayv1.HTTPSProtocolType,
+					}},
+				},
+			}
+			c = fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(&ic, &ing, &svc, &gwClass, &gw).
+				WithStatusSubresource(&gatewayv1.Gateway{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			found := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&gw), found)).To(Succeed())
+			Expect(found.Annotations[annotationSyncStatus]).To(ContainSubstring(`hostname "example.com" of listener "https" is already used by an ingress`))
+		})
+	})
+
+	Describe("Metrics", func() {
+		It("records the syncs and the desired and actual resources", func() {
+			ic := NewTestIngressClass("ngrok", true, true)
+			ing := NewTestIngressV1("test-ingress", "test-namespace")
+			svc := NewTestServiceV1("example", "test-namespace")
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&ic, &ing, &svc).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+
+			syncs := testutil.ToFloat64(syncsTotal.WithLabelValues(syncModeFull, "success"))
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+			Expect(testutil.ToFloat64(syncsTotal.WithLabelValues(syncModeFull, "success"))).To(Equal(syncs + 1))
+			Expect(testutil.ToFloat64(syncObjects.WithLabelValues("Domain", "desired"))).To(Equal(1.0))
+			Expect(testutil.ToFloat64(syncObjects.WithLabelValues("Domain", "actual"))).To(Equal(0.0))
+
+			// the second sync finds what the first one created
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+			Expect(testutil.ToFloat64(syncObjects.WithLabelValues("Domain", "actual"))).To(Equal(1.0))
+			Expect(testutil.ToFloat64(syncObjects.WithLabelValues("Tunnel", "actual"))).To(Equal(1.0))
+		})
+	})
+
+	Describe("OwnerMetadata", func() {
+		It("defaults to the metadata of the CRDs", func() {
+			Expect(driver.OwnerMetadata()).To(Equal([]map[string]string{{"owned-by": "kubernetes-ingress-controller"}}))
+		})
+
+		It("includes the custom metadata", func() {
+			driver.WithNgrokMetadata(map[string]string{"cluster": "prod"})
+			Expect(driver.OwnerMetadata()).To(Equal([]map[string]string{
+				{"owned-by": "kubernetes-ingress-controller", "cluster": "prod"},
+			}))
+		})
+	})
+
+	Describe("Incremental sync", func() {
+		var c client.WithWatch
+		var ic netv1.IngressClass
+		var ingA, ingB netv1.Ingress
+		var svcA, svcB corev1.Service
+
+		BeforeEach(func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				WithSyncAllowConcurrent(true),
+				WithIncrementalSync(true),
+			)
+
+			ic = NewTestIngressClass("ngrok", true, true)
+			ingA = NewTestIngressV1("ingress-a", "test-namespace")
+			ingA.Spec.Rules[0].Host = "a.example.com"
+			ingA.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "service-a"
+			ingB = NewTestIngressV1("ingress-b", "test-namespace")
+			ingB.Spec.Rules[0].Host = "b.example.com"
+			ingB.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "service-b"
+			svcA = NewTestServiceV1("service-a", "test-namespace")
+			svcB = NewTestServiceV1("service-b", "test-namespace")
+
+			c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(&ic, &ingA, &ingB, &svcA, &svcB).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			// the first sync is a full one
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		})
+
+		// tamperDomain changes the domain of a host in the cluster only, so it's only reverted if the host is synced
+		tamperDomain := func(host string) {
+			domain := &ingressv1alpha1.Domain{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: ingressv1alpha1.HyphenatedDomainNameFromURL(host)}, domain)).To(Succeed())
+			domain.Spec.Metadata = "tampered"
+			Expect(c.Update(context.Background(), domain)).To(Succeed())
+		}
+
+		domainMetadata := func(host string) string {
+			domain := &ingressv1alpha1.Domain{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: ingressv1alpha1.HyphenatedDomainNameFromURL(host)}, domain)).To(Succeed())
+			return domain.Spec.Metadata
+		}
+
+		edgeRoutes := func(host string) []ingressv1alpha1.HTTPSEdgeRouteSpec {
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			for _, edge := range edges.Items {
+				if edge.Spec.Hostports[0] == host+":443" {
+					return edge.Spec.Routes
+				}
+			}
+			return nil
+		}
+
+		It("only syncs the hosts of the changed ingresses", func() {
+			tamperDomain("a.example.com")
+			tamperDomain("b.example.com")
+
+			ingress := &netv1.Ingress{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&ingA), ingress)).To(Succeed())
+			ingress.Spec.Rules[0].HTTP.Paths[0].Path = "/changed"
+			Expect(c.Update(context.Background(), ingress)).To(Succeed())
+			_, err := driver.UpdateIngress(ingress)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			Expect(edgeRoutes("a.example.com")).To(HaveLen(1))
+			Expect(edgeRoutes("a.example.com")[0].Match).To(Equal("/changed"))
+			Expect(domainMetadata("a.example.com")).ToNot(Equal("tampered"))
+			Expect(domainMetadata("b.example.com")).To(Equal("tampered"))
+		})
+
+		It("syncs the hosts of the ingresses of a changed service", func() {
+			tamperDomain("a.example.com")
+			tamperDomain("b.example.com")
+
+			svcB.Labels = map[string]string{"changed": "true"}
+			Expect(driver.store.Update(&svcB)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			Expect(domainMetadata("a.example.com")).To(Equal("tampered"))
+			Expect(domainMetadata("b.example.com")).ToNot(Equal("tampered"))
+		})
+
+		It("deletes the edge of a host no ingress defines anymore", func() {
+			Expect(driver.DeleteNamedIngress(client.ObjectKeyFromObject(&ingB))).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			Expect(edgeRoutes("a.example.com")).To(HaveLen(1))
+			Expect(edgeRoutes("b.example.com")).To(BeNil())
+		})
+
+		It("falls back to a full sync for changes it can't map to hosts", func() {
+			tamperDomain("a.example.com")
+			tamperDomain("b.example.com")
+
+			Expect(driver.store.Update(&ic)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			Expect(domainMetadata("a.example.com")).ToNot(Equal("tampered"))
+			Expect(domainMetadata("b.example.com")).ToNot(Equal("tampered"))
+		})
+	})
+
+	Describe("Dry-run", func() {
+		var c client.WithWatch
+		var ic netv1.IngressClass
+		var ing netv1.Ingress
+		var svc corev1.Service
+
+		BeforeEach(func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				WithSyncAllowConcurrent(true),
+				WithDryRun(true),
+			)
+
+			ic = NewTestIngressClass("ngrok", true, true)
+			ing = NewTestIngressV1("test-ingress", "test-namespace")
+			svc = NewTestServiceV1("example", "test-namespace")
+		})
+
+		sync := func(obs ...client.Object) {
+			c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		}
+
+		It("reports the resources it would create without creating them", func() {
+			sync(&ic, &ing, &svc)
+
+			domains := &ingressv1alpha1.DomainList{}
+			Expect(c.List(context.Background(), domains)).To(Succeed())
+			Expect(domains.Items).To(BeEmpty())
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(BeEmpty())
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(BeEmpty())
+
+			diff := driver.LastSyncDiff()
+			Expect(diff.Count(ChangeActionCreate)).To(Equal(3))
+			Expect(diff.Changes).To(ContainElement(Change{Action: ChangeActionCreate, Kind: "Domain", Namespace: "test-namespace", Name: "example-com"}))
+		})
+
+		It("reports updates as a patch without applying them", func() {
+			domain := NewDomainV1("example.com", "test-namespace")
+			domain.Name = "example-com"
+			domain.Spec.Domain = "example.com"
+			domain.Spec.Metadata = "outdated"
+			sync(&ic, &ing, &svc, &domain)
+
+			found := &ingressv1alpha1.Domain{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&domain), found)).To(Succeed())
+			Expect(found.Spec.Metadata).To(Equal("outdated"))
+
+			var update *Change
+			for _, change := range driver.LastSyncDiff().Changes {
+				if change.Action == ChangeActionUpdate && change.Kind == "Domain" {
+					update = &change
+				}
+			}
+			Expect(update).ToNot(BeNil())
+			Expect(string(update.Patch)).To(ContainSubstring(`"metadata"`))
+			Expect(string(update.Patch)).ToNot(ContainSubstring("outdated"))
+		})
+
+		It("reports the same changes on every sync", func() {
+			sync(&ic, &ing, &svc)
+			first := driver.LastSyncDiff()
+
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+			Expect(driver.LastSyncDiff()).To(Equal(first))
+
+			found := &netv1.Ingress{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&ing), found)).To(Succeed())
+			Expect(found.Annotations).ToNot(HaveKey(annotationSyncStatus))
+		})
+
+		It("reports the changes of an edges sync", func() {
+			sync(&ic, &ing, &svc)
+
+			Expect(driver.SyncEdges(context.Background(), c)).To(Succeed())
+			diff := driver.LastSyncDiff()
+			Expect(diff.Changes).To(HaveLen(1))
+			Expect(diff.Changes[0].Action).To(Equal(ChangeActionCreate))
+			Expect(diff.Changes[0].Kind).To(Equal("HTTPSEdge"))
+		})
+	})
+
+	Describe("Render", func() {
+		It("renders the resources a sync would create, sorted by kind", func() {
+			ic := NewTestIngressClass("ngrok", true, true)
+			ing := NewTestIngressV1("test-ingress", "test-namespace")
+			svc := NewTestServiceV1("example", "test-namespace")
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&ic, &ing, &svc).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+
+			result, err := driver.Render()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Errors).To(BeEmpty())
+
+			kinds := []string{}
+			for _, obj := range result.Objects {
+				kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+			}
+			Expect(kinds).To(Equal([]string{"Domain", "HTTPSEdge", "Tunnel"}))
+
+			// nothing is written to the cluster
+			domains := &ingressv1alpha1.DomainList{}
+			Expect(c.List(context.Background(), domains)).To(Succeed())
+			Expect(domains.Items).To(BeEmpty())
+		})
+
+		It("returns the translation errors", func() {
+			ic := NewTestIngressClass("ngrok", true, true)
+			ing := NewTestIngressV1("test-ingress", "test-namespace")
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&ic, &ing).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+
+			result, err := driver.Render()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Errors).ToNot(BeEmpty())
+			for _, renderErr := range result.Errors {
+				Expect(renderErr).To(HavePrefix("Ingress test-namespace/test-ingress: "))
+			}
+		})
+	})
+
+	Describe("TCPRoutes", func() {
+		var c client.WithWatch
+		var gtw gatewayv1.Gateway
+		var gwClass gatewayv1.GatewayClass
+		var svc corev1.Service
+
+		BeforeEach(func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				WithGatewayEnabled(true),
+				WithSyncAllowConcurrent(true),
+			)
+			gwClass = NewTestGatewayClass("ngrok", true)
+			gtw = NewTestGateway("test-gateway", "test-namespace", gatewayv1.Listener{
+				Name:     "tcp",
+				Protocol: gatewayv1.TCPProtocolType,
+				Port:     5432,
+			})
+			svc = NewTestServiceV1("postgres", "test-namespace")
+			svc.Spec.Ports[0].Port = 5432
+		})
+
+		sync := func(obs ...client.Object) {
+			c = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append(obs, &gwClass)...).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1alpha2.TCPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		}
+
+		routeStatus := func(name string) gatewayv1alpha2.TCPRouteStatus {
+			route := &gatewayv1alpha2.TCPRoute{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: name}, route)).To(Succeed())
+			return route.Status
+		}
+
+		It("creates a TCPEdge and a tunnel for an accepted route", func() {
+			route := NewTestTCPRoute("test-route", "test-namespace", "test-gateway", "postgres", 5432)
+			sync(&gtw, &svc, &route)
+
+			edges := &ingressv1alpha1.TCPEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			edge := edges.Items[0]
+			Expect(edge.Name).To(HavePrefix("test-route-"))
+			Expect(edge.Labels[labelControllerName]).To(Equal(defaultManagerName))
+			Expect(edge.Spec.Backend.Labels).To(HaveKeyWithValue(labelService, "postgres"))
+			Expect(edge.Spec.Backend.Labels).To(HaveKeyWithValue(labelPort, "5432"))
+			Expect(edge.OwnerReferences).To(HaveLen(1))
+			Expect(edge.OwnerReferences[0].Kind).To(Equal("TCPRoute"))
+
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(HaveLen(1))
+			Expect(tunnels.Items[0].Spec.ForwardsTo).To(Equal("postgres.test-namespace.svc.cluster.local:5432"))
+
+			status := routeStatus("test-route")
+			Expect(status.Parents).To(HaveLen(1))
+			Expect(status.Parents[0].ControllerName).To(Equal(GatewayControllerName))
+			accepted := meta.FindStatusCondition(status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionTrue))
+			resolved := meta.FindStatusCondition(status.Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+			Expect(resolved).ToNot(BeNil())
+			Expect(resolved.Status).To(Equal(metav1.ConditionTrue))
+
+			gateway := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "test-gateway"}, gateway)).To(Succeed())
+			Expect(gateway.Status.Listeners).To(HaveLen(1))
+			Expect(gateway.Status.Listeners[0].AttachedRoutes).To(Equal(int32(1)))
+			Expect(gateway.Status.Addresses).To(BeEmpty())
+			programmed := meta.FindStatusCondition(gateway.Status.Listeners[0].Conditions, string(gatewayv1.ListenerConditionProgrammed))
+			Expect(programmed).ToNot(BeNil())
+			Expect(programmed.Reason).To(Equal(string(gatewayv1.ListenerReasonPending)))
+			Expect(meta.IsStatusConditionTrue(gateway.Status.Conditions, string(gatewayv1.GatewayConditionProgrammed))).To(BeFalse())
+		})
+
+		It("reports the TCP address reserved for the route on the gateway", func() {
+			route := NewTestTCPRoute("test-route", "test-namespace", "test-gateway", "postgres", 5432)
+			sync(&gtw, &svc, &route)
+
+			edges := &ingressv1alpha1.TCPEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			edge := edges.Items[0]
+			edge.Status.Hostports = []string{"1.tcp.ngrok.io:12345"}
+			Expect(c.Update(context.Background(), &edge)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			gateway := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "test-gateway"}, gateway)).To(Succeed())
+			Expect(gateway.Status.Addresses).To(Equal([]gatewayv1.GatewayStatusAddress{
+				{Type: ptr.To(gatewayv1.HostnameAddressType), Value: "1.tcp.ngrok.io"},
+			}))
+			programmed := meta.FindStatusCondition(gateway.Status.Listeners[0].Conditions, string(gatewayv1.ListenerConditionProgrammed))
+			Expect(programmed).ToNot(BeNil())
+			Expect(programmed.Status).To(Equal(metav1.ConditionTrue))
+			Expect(programmed.Message).To(ContainSubstring("1.tcp.ngrok.io:12345"))
+			Expect(meta.IsStatusConditionTrue(gateway.Status.Conditions, string(gatewayv1.GatewayConditionProgrammed))).To(BeTrue())
+		})
+
+		It("keeps the existing edge when the route is synced again", func() {
+			route := NewTestTCPRoute("test-route", "test-namespace", "test-gateway", "postgres", 5432)
+			sync(&gtw, &svc, &route)
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.TCPEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+		})
+
+		It("does not accept routes without a TCP listener", func() {
+			gtw.Spec.Listeners[0].Protocol = gatewayv1.HTTPProtocolType
+			route := NewTestTCPRoute("test-route", "test-namespace", "test-gateway", "postgres", 5432)
+			sync(&gtw, &svc, &route)
+
+			edges := &ingressv1alpha1.TCPEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(BeEmpty())
+
+			status := routeStatus("test-route")
+			Expect(status.Parents).To(HaveLen(1))
+			accepted := meta.FindStatusCondition(status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonNotAllowedByListeners)))
+		})
+
+		It("reports unresolved backends", func() {
+			route := NewTestTCPRoute("test-route", "test-namespace", "test-gateway", "missing", 5432)
+			sync(&gtw, &svc, &route)
+
+			edges := &ingressv1alpha1.TCPEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(BeEmpty())
+
+			status := routeStatus("test-route")
+			Expect(status.Parents).To(HaveLen(1))
+			resolved := meta.FindStatusCondition(status.Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+			Expect(resolved).ToNot(BeNil())
+			Expect(resolved.Status).To(Equal(metav1.ConditionFalse))
+			Expect(resolved.Reason).To(Equal(string(gatewayv1.RouteReasonBackendNotFound)))
+
+			found := &gatewayv1alpha2.TCPRoute{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "test-route"}, found)).To(Succeed())
+			Expect(found.Annotations[annotationSyncStatus]).To(HavePrefix(syncStatusErrorPrefix))
+			Expect(found.Annotations[annotationSyncStatus]).To(ContainSubstring("could not resolve backend for tcproute"))
+			Expect(found.Annotations[annotationSyncStatus]).To(ContainSubstring("could not find port for service test-namespace/missing"))
+
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(BeEmpty())
+		})
+
+		It("does not accept routes with more than one backendRef", func() {
+			route := NewTestTCPRoute("test-route", "test-namespace", "test-gateway", "postgres", 5432)
+			route.Spec.Rules = append(route.Spec.Rules, route.Spec.Rules[0])
+			sync(&gtw, &svc, &route)
+
+			edges := &ingressv1alpha1.TCPEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(BeEmpty())
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(BeEmpty())
+
+			status := routeStatus("test-route")
+			Expect(status.Parents).To(HaveLen(1))
+			accepted := meta.FindStatusCondition(status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonUnsupportedValue)))
+			Expect(accepted.Message).To(ContainSubstring("2 backendRefs"))
+		})
+
+		It("ignores routes for gateways it doesn't handle", func() {
+			route := NewTestTCPRoute("test-route", "test-namespace", "other-gateway", "postgres", 5432)
+			sync(&gtw, &svc, &route)
+
+			edges := &ingressv1alpha1.TCPEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(BeEmpty())
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(BeEmpty())
+			Expect(routeStatus("test-route").Parents).To(BeEmpty())
+		})
+	})
+
+	Describe("TLSRoutes", func() {
+		var c client.WithWatch
+		var gtw gatewayv1.Gateway
+		var gwClass gatewayv1.GatewayClass
+		var svc corev1.Service
+
+		BeforeEach(func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				WithGatewayEnabled(true),
+				WithSyncAllowConcurrent(true),
+			)
+			gwClass = NewTestGatewayClass("ngrok", true)
+			gtw = NewTestGateway("test-gateway", "test-namespace", gatewayv1.Listener{
+				Name:     "tls",
+				Hostname: ptr.To(gatewayv1.Hostname("*.example.com")),
+				Protocol: gatewayv1.TLSProtocolType,
+				Port:     443,
+				TLS: &gatewayv1.GatewayTLSConfig{
+					Mode: ptr.To(gatewayv1.TLSModePassthrough),
+				},
+			})
+			svc = NewTestServiceV1("backend", "test-namespace")
+			svc.Spec.Ports[0].Port = 8443
+		})
+
+		sync := func(obs ...client.Object) {
+			c = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append(obs, &gwClass)...).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1alpha2.TLSRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		}
+
+		routeAccepted := func(name string) *metav1.Condition {
+			route := &gatewayv1alpha2.TLSRoute{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: name}, route)).To(Succeed())
+			Expect(route.Status.Parents).To(HaveLen(1))
+			return meta.FindStatusCondition(route.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))
+		}
+
+		It("creates a passthrough TLSEdge for the route hostnames", func() {
+			route := NewTestTLSRoute("test-route", "test-namespace", "test-gateway", "app.example.com", "backend", 8443)
+			sync(&gtw, &svc, &route)
+
+			edges := &ingressv1alpha1.TLSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			edge := edges.Items[0]
+			Expect(edge.Name).To(HavePrefix("test-route-"))
+			Expect(edge.Spec.Hostports).To(Equal([]string{"app.example.com:443"}))
+			Expect(edge.Spec.TLSTermination).ToNot(BeNil())
+			Expect(edge.Spec.TLSTermination.TerminateAt).To(Equal("upstream"))
+			Expect(edge.Spec.Backend.Labels).To(HaveKeyWithValue(labelService, "backend"))
+			Expect(edge.Spec.Backend.Labels).To(HaveKeyWithValue(labelPort, "8443"))
+
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(HaveLen(1))
+			Expect(tunnels.Items[0].Spec.ForwardsTo).To(Equal("backend.test-namespace.svc.cluster.local:8443"))
+
+			accepted := routeAccepted("test-route")
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("terminates at the edge for listeners in terminate mode", func() {
+			gtw.Spec.Listeners[0].TLS.Mode = ptr.To(gatewayv1.TLSModeTerminate)
+			route := NewTestTLSRoute("test-route", "test-namespace", "test-gateway", "app.example.com", "backend", 8443)
+			sync(&gtw, &svc, &route)
+
+			edges := &ingressv1alpha1.TLSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.TLSTermination.TerminateAt).To(Equal("edge"))
+		})
+
+		It("uses the listener certificate for the route hostnames when terminating at the edge", func() {
+			gtw.Spec.Listeners[0].TLS = &gatewayv1.GatewayTLSConfig{
+				Mode:            ptr.To(gatewayv1.TLSModeTerminate),
+				CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "wildcard-tls"}},
+			}
+			route := NewTestTLSRoute("test-route", "test-namespace", "test-gateway", "app.example.com", "backend", 8443)
+			sync(&gtw, &svc, &route)
+
+			domains := &ingressv1alpha1.DomainList{}
+			Expect(c.List(context.Background(), domains)).To(Succeed())
+			certificateRefs := map[string]*ingressv1alpha1.DomainCertificateRef{}
+			for _, domain := range domains.Items {
+				certificateRefs[domain.Spec.Domain] = domain.Spec.CertificateRef
+			}
+			expected := &ingressv1alpha1.DomainCertificateRef{Name: "wildcard-tls", Namespace: "test-namespace"}
+			Expect(certificateRefs).To(HaveKeyWithValue("*.example.com", expected))
+			Expect(certificateRefs).To(HaveKeyWithValue("app.example.com", expected))
+		})
+
+		It("reserves a Domain for the route hostnames on a listener without a hostname", func() {
+			gtw.Spec.Listeners[0].Hostname = nil
+			route := NewTestTLSRoute("test-route", "test-namespace", "test-gateway", "app.other.com", "backend", 8443)
+			sync(&gtw, &svc, &route)
+
+			domains := &ingressv1alpha1.DomainList{}
+			Expect(c.List(context.Background(), domains)).To(Succeed())
+			Expect(domains.Items).To(HaveLen(1))
+			Expect(domains.Items[0].Spec.Domain).To(Equal("app.other.com"))
+			Expect(domains.Items[0].Spec.CertificateRef).To(BeNil())
+
+			edges := &ingressv1alpha1.TLSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Hostports).To(Equal([]string{"app.other.com:443"}))
+		})
+
+		It("does not accept routes whose hostnames don't match the listener", func() {
+			route := NewTestTLSRoute("test-route", "test-namespace", "test-gateway", "app.other.com", "backend", 8443)
+			sync(&gtw, &svc, &route)
+
+			edges := &ingressv1alpha1.TLSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(BeEmpty())
+
+			accepted := routeAccepted("test-route")
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonNoMatchingListenerHostname)))
+		})
+
+		It("does not accept routes on listeners other than port 443", func() {
+			gtw.Spec.Listeners[0].Port = 8443
+			route := NewTestTLSRoute("test-route", "test-namespace", "test-gateway", "app.example.com", "backend", 8443)
+			sync(&gtw, &svc, &route)
+
+			accepted := routeAccepted("test-route")
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonNotAllowedByListeners)))
+		})
+	})
+
+	Describe("HTTPRoutes", func() {
+		var c client.WithWatch
+		var gtw gatewayv1.Gateway
+		var gwClass gatewayv1.GatewayClass
+		var stable, canary, next corev1.Service
+
+		BeforeEach(func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				WithGatewayEnabled(true),
+				WithSyncAllowConcurrent(true),
+			)
+			gwClass = NewTestGatewayClass("ngrok", true)
+			gtw = NewTestGateway("test-gateway", "test-namespace", gatewayv1.Listener{
+				Name:     "https",
+				Protocol: gatewayv1.HTTPSProtocolType,
+				Port:     443,
+				Hostname: ptr.To(gatewayv1.Hostname("app.example.com")),
+				AllowedRoutes: &gatewayv1.AllowedRoutes{
+					Namespaces: &gatewayv1.RouteNamespaces{From: ptr.To(gatewayv1.NamespacesFromSame)},
+				},
+			})
+			stable = NewTestServiceV1("stable", "test-namespace")
+			canary = NewTestServiceV1("canary", "test-namespace")
+			next = NewTestServiceV1("next", "test-namespace")
+		})
+
+		sync := func(obs ...client.Object) {
+			c = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append(obs, &gwClass)...).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		}
+
+		tunnelsByService := func() map[string][]ingressv1alpha1.Tunnel {
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			byService := map[string][]ingressv1alpha1.Tunnel{}
+			for _, tunnel := range tunnels.Items {
+				byService[tunnel.Labels[labelService]] = append(byService[tunnel.Labels[labelService]], tunnel)
+			}
+			return byService
+		}
+
+		routeCondition := func(name string, conditionType gatewayv1.RouteConditionType) *metav1.Condition {
+			route := &gatewayv1.HTTPRoute{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: name}, route)).To(Succeed())
+			if len(route.Status.Parents) == 0 {
+				return nil
+			}
+			return meta.FindStatusCondition(route.Status.Parents[0].Conditions, string(conditionType))
+		}
+
+		weightsCondition := func(name string) *metav1.Condition {
+			return routeCondition(name, RouteConditionBackendWeightsExact)
+		}
+
+		It("splits traffic with a weighted tunnel group", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+				NewTestHTTPBackendRef("stable", 80, 90),
+				NewTestHTTPBackendRef("canary", 80, 10),
+			)
+			sync(&gtw, &stable, &canary, &route)
+
+			group := weightedGroupName("HTTPRoute", "test-namespace", "test-route", 0)
+			tunnels := tunnelsByService()
+			Expect(tunnels["stable"]).To(HaveLen(9))
+			Expect(tunnels["canary"]).To(HaveLen(1))
+			for _, tunnel := range append(tunnels["stable"], tunnels["canary"]...) {
+				Expect(tunnel.Spec.Labels).To(Equal(driver.weightedGroupLabels("test-namespace", group)))
+			}
+			Expect(tunnels["canary"][0].Spec.ForwardsTo).To(Equal("canary.test-namespace.svc.cluster.local:80"))
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes[0].Backend.Labels).To(Equal(driver.weightedGroupLabels("test-namespace", group)))
+
+			condition := weightsCondition("test-route")
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(string(RouteReasonBackendWeightsExact)))
+		})
+
+		It("reports weights that can only be approximated", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+				NewTestHTTPBackendRef("stable", 80, 33),
+				NewTestHTTPBackendRef("canary", 80, 33),
+				NewTestHTTPBackendRef("next", 80, 34),
+			)
+			sync(&gtw, &stable, &canary, &next, &route)
+
+			tunnels := tunnelsByService()
+			Expect(tunnels["stable"]).To(HaveLen(3))
+			Expect(tunnels["canary"]).To(HaveLen(3))
+			Expect(tunnels["next"]).To(HaveLen(4))
+
+			condition := weightsCondition("test-route")
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(string(RouteReasonBackendWeightsApproximated)))
+			Expect(condition.Message).To(ContainSubstring("33/33/34 are approximated as 3/3/4"))
+		})
+
+		It("does not split traffic between more backends than tunnels in a group", func() {
+			backendRefs := []gatewayv1.HTTPBackendRef{}
+			for i := 0; i <= maxWeightedTunnels; i++ {
+				backendRefs = append(backendRefs, NewTestHTTPBackendRef([]string{"stable", "canary"}[i%2], 80, 1))
+			}
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com", backendRefs...)
+			sync(&gtw, &stable, &canary, &route)
+
+			Expect(tunnelsByService()).To(BeEmpty())
+
+			condition := weightsCondition("test-route")
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(string(RouteReasonTooManyWeightedBackends)))
+			Expect(condition.Message).To(ContainSubstring("rule 0: 11 backends have a weight"))
+		})
+
+		It("routes to the service tunnel when only one backend has a weight", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+				NewTestHTTPBackendRef("stable", 80, 1),
+				NewTestHTTPBackendRef("canary", 80, 0),
+			)
+			sync(&gtw, &stable, &canary, &route)
+
+			tunnels := tunnelsByService()
+			Expect(tunnels["stable"]).To(HaveLen(1))
+			Expect(tunnels["stable"][0].Spec.Labels).To(HaveKeyWithValue(labelService, "stable"))
+			Expect(tunnels["canary"]).To(BeEmpty())
+			Expect(weightsCondition("test-route")).To(BeNil())
+		})
+
+		It("reports the route as accepted with resolved refs", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+				NewTestHTTPBackendRef("stable", 80, 1),
+			)
+			sync(&gtw, &stable, &route)
+
+			accepted := routeCondition("test-route", gatewayv1.RouteConditionAccepted)
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionTrue))
+			resolved := routeCondition("test-route", gatewayv1.RouteConditionResolvedRefs)
+			Expect(resolved).ToNot(BeNil())
+			Expect(resolved.Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		edgeRoutes := func() []ingressv1alpha1.HTTPSEdgeRouteSpec {
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			return edges.Items[0].Spec.Routes
+		}
+
+		onHTTPRequestExpressions := func(policy json.RawMessage) [][]string {
+			trafficPolicy, err := util.NewTrafficPolicyFromJson(policy)
+			Expect(err).To(BeNil())
+			expressions := [][]string{}
+			for _, rule := range trafficPolicy.Deconstruct()[util.PhaseOnHttpRequest] {
+				var endpointRule util.EndpointRule
+				Expect(json.Unmarshal(rule, &endpointRule)).To(Succeed())
+				expressions = append(expressions, endpointRule.Expressions)
+			}
+			return expressions
+		}
+
+		It("creates a route for each match of a rule", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+				NewTestHTTPBackendRef("stable", 80, 1),
+			)
+			route.Spec.Rules[0].Matches = []gatewayv1.HTTPRouteMatch{
+				{Path: &gatewayv1.HTTPPathMatch{Type: ptr.To(gatewayv1.PathMatchPathPrefix), Value: ptr.To("/a")}, Method: ptr.To(gatewayv1.HTTPMethodGet)},
+				{Path: &gatewayv1.HTTPPathMatch{Type: ptr.To(gatewayv1.PathMatchExact), Value: ptr.To("/b")}, Method: ptr.To(gatewayv1.HTTPMethodPost)},
+			}
+			sync(&gtw, &stable, &route)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(2))
+			Expect(routes[0].Match).To(Equal("/a"))
+			Expect(routes[0].MatchType).To(Equal("path_prefix"))
+			Expect(onHTTPRequestExpressions(routes[0].Policy)).To(Equal([][]string{{`!(req.method == "GET")`}}))
+			Expect(routes[1].Match).To(Equal("/b"))
+			Expect(routes[1].MatchType).To(Equal("exact_path"))
+			Expect(onHTTPRequestExpressions(routes[1].Policy)).To(Equal([][]string{{`!(req.method == "POST")`}}))
+		})
+
+		It("merges the rules sharing a path into a single route with ordered branches", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com")
+			route.Spec.Rules = []gatewayv1.HTTPRouteRule{
+				{
+					Matches: []gatewayv1.HTTPRouteMatch{{}},
+					Filters: []gatewayv1.HTTPRouteFilter{{
+						Type:                  gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+						RequestHeaderModifier: &gatewayv1.HTTPHeaderFilter{Add: []gatewayv1.HTTPHeader{{Name: "x-branch", Value: "default"}}},
+					}},
+					BackendRefs: []gatewayv1.HTTPBackendRef{NewTestHTTPBackendRef("stable", 80, 1)},
+				},
+				{
+					Matches: []gatewayv1.HTTPRouteMatch{{Headers: []gatewayv1.HTTPHeaderMatch{{Name: "X-Tenant", Value: "acme"}}}},
+					Filters: []gatewayv1.HTTPRouteFilter{{
+						Type:                  gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+						RequestHeaderModifier: &gatewayv1.HTTPHeaderFilter{Add: []gatewayv1.HTTPHeader{{Name: "x-branch", Value: "acme"}}},
+					}},
+					BackendRefs: []gatewayv1.HTTPBackendRef{NewTestHTTPBackendRef("stable", 80, 1)},
+				},
+			}
+			sync(&gtw, &stable, &route)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Match).To(Equal("/"))
+			tenant := `("x-tenant" in req.headers && req.headers["x-tenant"].exists(v, v == "acme"))`
+			// the header match takes precedence, requests not satisfying it fall through to the first rule
+			Expect(onHTTPRequestExpressions(routes[0].Policy)).To(Equal([][]string{
+				{tenant},
+				{fmt.Sprintf("!(%s)", tenant)},
+			}))
+			Expect(routeCondition("test-route", gatewayv1.RouteConditionAccepted).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("does not accept rules forwarding a path to another backend", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com")
+			route.Spec.Rules = []gatewayv1.HTTPRouteRule{
+				{
+					BackendRefs: []gatewayv1.HTTPBackendRef{NewTestHTTPBackendRef("stable", 80, 1)},
+				},
+				{
+					Matches:     []gatewayv1.HTTPRouteMatch{{Headers: []gatewayv1.HTTPHeaderMatch{{Name: "X-Canary", Value: "true"}}}},
+					BackendRefs: []gatewayv1.HTTPBackendRef{NewTestHTTPBackendRef("canary", 80, 1)},
+				},
+			}
+			sync(&gtw, &stable, &canary, &route)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Backend.Labels).To(HaveKeyWithValue(labelService, "canary"))
+
+			accepted := routeCondition("test-route", gatewayv1.RouteConditionAccepted)
+			Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonUnsupportedValue)))
+			Expect(accepted.Message).To(ContainSubstring("single backend"))
+		})
+
+		It("ignores gateways of GatewayClasses handled by other controllers", func() {
+			gwClass = NewTestGatewayClass("ngrok", false)
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+				NewTestHTTPBackendRef("stable", 80, 1),
+			)
+			sync(&gtw, &stable, &route)
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(BeEmpty())
+			Expect(tunnelsByService()).To(BeEmpty())
+
+			gateway := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "test-gateway"}, gateway)).To(Succeed())
+			Expect(gateway.Status.Listeners).To(BeEmpty())
+			Expect(gateway.Annotations).ToNot(HaveKey(annotationSyncStatus))
+			Expect(routeCondition("test-route", gatewayv1.RouteConditionAccepted)).To(BeNil())
+
+			found := &gatewayv1.HTTPRoute{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "test-route"}, found)).To(Succeed())
+			Expect(found.Annotations).ToNot(HaveKey(annotationSyncStatus))
+		})
+
+		It("attaches routes without hostnames with the listener hostname", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "",
+				NewTestHTTPBackendRef("stable", 80, 1),
+			)
+			route.Spec.Hostnames = nil
+			sync(&gtw, &stable, &route)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(1))
+			Expect(onHTTPRequestExpressions(routes[0].Policy)).To(BeEmpty())
+			Expect(routeCondition("test-route", gatewayv1.RouteConditionAccepted).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("restricts routes to their hostnames on wildcard listeners", func() {
+			gtw.Spec.Listeners[0].Hostname = ptr.To(gatewayv1.Hostname("*.example.com"))
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+				NewTestHTTPBackendRef("stable", 80, 1),
+			)
+			sync(&gtw, &stable, &route)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(1))
+			Expect(onHTTPRequestExpressions(routes[0].Policy)).To(Equal([][]string{{`!(req.host == "app.example.com")`}}))
+			Expect(routeCondition("test-route", gatewayv1.RouteConditionAccepted).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("reports missing backends and unknown hostnames", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "other.example.com",
+				NewTestHTTPBackendRef("missing", 80, 1),
+			)
+			sync(&gtw, &stable, &route)
+
+			accepted := routeCondition("test-route", gatewayv1.RouteConditionAccepted)
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonNoMatchingListenerHostname)))
+			resolved := routeCondition("test-route", gatewayv1.RouteConditionResolvedRefs)
+			Expect(resolved).ToNot(BeNil())
+			Expect(resolved.Status).To(Equal(metav1.ConditionFalse))
+			Expect(resolved.Reason).To(Equal(string(gatewayv1.RouteReasonBackendNotFound)))
+		})
+
+		Context("with a backend in another namespace", func() {
+			var route gatewayv1.HTTPRoute
+			var other corev1.Service
+
+			BeforeEach(func() {
+				backendRef := NewTestHTTPBackendRef("other", 80, 1)
+				backendRef.Namespace = ptr.To(gatewayv1.Namespace("other-namespace"))
+				route = NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com", backendRef)
+				other = NewTestServiceV1("other", "other-namespace")
+			})
+
+			It("does not resolve the backend without a ReferenceGrant", func() {
+				sync(&gtw, &other, &route)
+
+				Expect(tunnelsByService()["other"]).To(BeEmpty())
+				resolved := routeCondition("test-route", gatewayv1.RouteConditionResolvedRefs)
+				Expect(resolved).ToNot(BeNil())
+				Expect(resolved.Status).To(Equal(metav1.ConditionFalse))
+				Expect(resolved.Reason).To(Equal(string(gatewayv1.RouteReasonRefNotPermitted)))
+			})
+
+			It("forwards to the backend when a ReferenceGrant allows it", func() {
+				grant := &gatewayv1beta1.ReferenceGrant{
+					ObjectMeta: metav1.ObjectMeta{Name: "allow-test-namespace", Namespace: "other-namespace"},
+					Spec: gatewayv1beta1.ReferenceGrantSpec{
+						From: []gatewayv1beta1.ReferenceGrantFrom{{
+							Group:     gatewayv1.GroupName,
+							Kind:      "HTTPRoute",
+							Namespace: "test-namespace",
+						}},
+						To: []gatewayv1beta1.ReferenceGrantTo{{Kind: "Service"}},
+					},
+				}
+				sync(&gtw, &other, &route, grant)
+
+				tunnels := tunnelsByService()["other"]
+				Expect(tunnels).To(HaveLen(1))
+				Expect(tunnels[0].Namespace).To(Equal("test-namespace"))
+				Expect(tunnels[0].Spec.ForwardsTo).To(Equal("other.other-namespace.svc.cluster.local:80"))
+				Expect(tunnels[0].Labels).To(HaveKeyWithValue(labelServiceNamespace, "other-namespace"))
+				resolved := routeCondition("test-route", gatewayv1.RouteConditionResolvedRefs)
+				Expect(resolved).ToNot(BeNil())
+				Expect(resolved.Status).To(Equal(metav1.ConditionTrue))
+			})
+		})
+
+		Context("with a listener selecting namespaces by label", func() {
+			var route gatewayv1.HTTPRoute
+			var payments corev1.Service
+
+			BeforeEach(func() {
+				gtw.Spec.Listeners[0].AllowedRoutes.Namespaces = &gatewayv1.RouteNamespaces{
+					From:     ptr.To(gatewayv1.NamespacesFromSelector),
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+				}
+				route = NewTestHTTPRoute("test-route", "payments", "test-gateway", "app.example.com",
+					NewTestHTTPBackendRef("payments", 80, 1),
+				)
+				route.Spec.ParentRefs[0].Namespace = ptr.To(gatewayv1.Namespace("test-namespace"))
+				payments = NewTestServiceV1("payments", "payments")
+			})
+
+			acceptedCondition := func() *metav1.Condition {
+				route := &gatewayv1.HTTPRoute{}
+				Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "payments", Name: "test-route"}, route)).To(Succeed())
+				Expect(route.Status.Parents).To(HaveLen(1))
+				return meta.FindStatusCondition(route.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))
+			}
+
+			It("accepts routes from namespaces matching the selector", func() {
+				namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"team": "payments"}}}
+				sync(&gtw, &payments, &route, namespace)
+
+				accepted := acceptedCondition()
+				Expect(accepted).ToNot(BeNil())
+				Expect(accepted.Status).To(Equal(metav1.ConditionTrue))
+				Expect(tunnelsByService()["payments"]).To(HaveLen(1))
+			})
+
+			It("does not accept routes from namespaces that don't match the selector", func() {
+				namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"team": "billing"}}}
+				sync(&gtw, &payments, &route, namespace)
+
+				accepted := acceptedCondition()
+				Expect(accepted).ToNot(BeNil())
+				Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+				Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonNotAllowedByListeners)))
+				Expect(tunnelsByService()["payments"]).To(BeEmpty())
+			})
+
+			It("accepts routes once the namespace is labeled", func() {
+				namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments"}}
+				sync(&gtw, &payments, &route, namespace)
+				Expect(acceptedCondition().Status).To(Equal(metav1.ConditionFalse))
+
+				namespace.Labels = map[string]string{"team": "payments"}
+				Expect(c.Update(context.Background(), namespace)).To(Succeed())
+				Expect(driver.UpdateNamespace(namespace)).To(Succeed())
+				Expect(driver.Sync(context.Background(), c)).To(Succeed())
+				Expect(acceptedCondition().Status).To(Equal(metav1.ConditionTrue))
+			})
+		})
+
+		Context("with a listener certificateRef", func() {
+			var route gatewayv1.HTTPRoute
+
+			BeforeEach(func() {
+				route = NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+					NewTestHTTPBackendRef("stable", 80, 1),
+				)
+			})
+
+			domainCertificateRef := func() *ingressv1alpha1.DomainCertificateRef {
+				domains := &ingressv1alpha1.DomainList{}
+				Expect(c.List(context.Background(), domains)).To(Succeed())
+				Expect(domains.Items).To(HaveLen(1))
+				return domains.Items[0].Spec.CertificateRef
+			}
+
+			listenerResolvedRefs := func() *metav1.Condition {
+				gateway := &gatewayv1.Gateway{}
+				Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "test-gateway"}, gateway)).To(Succeed())
+				Expect(gateway.Status.Listeners).To(HaveLen(1))
+				return meta.FindStatusCondition(gateway.Status.Listeners[0].Conditions, string(gatewayv1.ListenerConditionResolvedRefs))
+			}
+
+			It("uses the Secret for the domain certificate", func() {
+				gtw.Spec.Listeners[0].TLS = &gatewayv1.GatewayTLSConfig{
+					CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "app-tls"}},
+				}
+				sync(&gtw, &stable, &route)
+
+				Expect(domainCertificateRef()).To(Equal(&ingressv1alpha1.DomainCertificateRef{Name: "app-tls", Namespace: "test-namespace"}))
+				resolved := listenerResolvedRefs()
+				Expect(resolved).ToNot(BeNil())
+				Expect(resolved.Status).To(Equal(metav1.ConditionTrue))
+			})
+
+			It("does not use a Secret in another namespace without a ReferenceGrant", func() {
+				gtw.Spec.Listeners[0].TLS = &gatewayv1.GatewayTLSConfig{
+					CertificateRefs: []gatewayv1.SecretObjectReference{{
+						Name:      "app-tls",
+						Namespace: ptr.To(gatewayv1.Namespace("certs")),
+					}},
+				}
+				sync(&gtw, &stable, &route)
+
+				Expect(domainCertificateRef()).To(BeNil())
+				resolved := listenerResolvedRefs()
+				Expect(resolved).ToNot(BeNil())
+				Expect(resolved.Status).To(Equal(metav1.ConditionFalse))
+				Expect(resolved.Reason).To(Equal(string(gatewayv1.ListenerReasonRefNotPermitted)))
+			})
+
+			It("uses a Secret in another namespace when a ReferenceGrant allows it", func() {
+				gtw.Spec.Listeners[0].TLS = &gatewayv1.GatewayTLSConfig{
+					CertificateRefs: []gatewayv1.SecretObjectReference{{
+						Name:      "app-tls",
+						Namespace: ptr.To(gatewayv1.Namespace("certs")),
+					}},
+				}
+				grant := &gatewayv1beta1.ReferenceGrant{
+					ObjectMeta: metav1.ObjectMeta{Name: "allow-gateways", Namespace: "certs"},
+					Spec: gatewayv1beta1.ReferenceGrantSpec{
+						From: []gatewayv1beta1.ReferenceGrantFrom{{
+							Group:     gatewayv1.GroupName,
+							Kind:      "Gateway",
+							Namespace: "test-namespace",
+						}},
+						To: []gatewayv1beta1.ReferenceGrantTo{{Kind: "Secret", Name: ptr.To(gatewayv1.ObjectName("app-tls"))}},
+					},
+				}
+				sync(&gtw, &stable, &route, grant)
+
+				Expect(domainCertificateRef()).To(Equal(&ingressv1alpha1.DomainCertificateRef{Name: "app-tls", Namespace: "certs"}))
+			})
+
+			It("reports certificate references that aren't Secrets", func() {
+				gtw.Spec.Listeners[0].TLS = &gatewayv1.GatewayTLSConfig{
+					CertificateRefs: []gatewayv1.SecretObjectReference{{
+						Name:  "app-tls",
+						Group: ptr.To(gatewayv1.Group("cert-manager.io")),
+						Kind:  ptr.To(gatewayv1.Kind("Certificate")),
+					}},
+				}
+				sync(&gtw, &stable, &route)
+
+				Expect(domainCertificateRef()).To(BeNil())
+				resolved := listenerResolvedRefs()
+				Expect(resolved).ToNot(BeNil())
+				Expect(resolved.Reason).To(Equal(string(gatewayv1.ListenerReasonInvalidCertificateRef)))
+			})
+		})
+
+		It("reports the gateway listeners, attached routes and addresses", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+				NewTestHTTPBackendRef("stable", 80, 1),
+			)
+			sync(&gtw, &stable, &route)
+
+			gateway := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "test-gateway"}, gateway)).To(Succeed())
+			Expect(gateway.Status.Listeners).To(HaveLen(1))
+			listener := gateway.Status.Listeners[0]
+			Expect(listener.AttachedRoutes).To(Equal(int32(1)))
+			Expect(listener.SupportedKinds).To(HaveLen(2))
+			Expect(listener.SupportedKinds[0].Kind).To(Equal(gatewayv1.Kind("HTTPRoute")))
+			Expect(listener.SupportedKinds[1].Kind).To(Equal(gatewayv1.Kind("GRPCRoute")))
+			Expect(meta.IsStatusConditionTrue(listener.Conditions, string(gatewayv1.ListenerConditionAccepted))).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(gateway.Status.Conditions, string(gatewayv1.GatewayConditionAccepted))).To(BeTrue())
+			// the domain isn't reserved yet
+			Expect(gateway.Status.Addresses).To(BeEmpty())
+			Expect(meta.IsStatusConditionFalse(gateway.Status.Conditions, string(gatewayv1.GatewayConditionProgrammed))).To(BeTrue())
+
+			domains := &ingressv1alpha1.DomainList{}
+			Expect(c.List(context.Background(), domains)).To(Succeed())
+			Expect(domains.Items).To(HaveLen(1))
+			domain := domains.Items[0]
+			domain.Status.CNAMETarget = ptr.To("app.cname.ngrok.io")
+			Expect(c.Update(context.Background(), &domain)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "test-gateway"}, gateway)).To(Succeed())
+			Expect(gateway.Status.Addresses).To(Equal([]gatewayv1.GatewayStatusAddress{
+				{Type: ptr.To(gatewayv1.HostnameAddressType), Value: "app.cname.ngrok.io"},
+			}))
+			Expect(meta.IsStatusConditionTrue(gateway.Status.Conditions, string(gatewayv1.GatewayConditionProgrammed))).To(BeTrue())
+		})
+
+		It("does not accept rules with matches that can't be represented", func() {
+			route := NewTestHTTPRoute("test-route", "test-namespace", "test-gateway", "app.example.com",
+				NewTestHTTPBackendRef("stable", 80, 1),
+			)
+			route.Spec.Rules[0].Matches = []gatewayv1.HTTPRouteMatch{
+				{
+					Headers: []gatewayv1.HTTPHeaderMatch{
+						{Type: ptr.To(gatewayv1.HeaderMatchRegularExpression), Name: "x-tenant", Value: "("},
+					},
+				},
+			}
+			sync(&gtw, &stable, &route)
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			for _, edge := range edges.Items {
+				Expect(edge.Spec.Routes).To(BeEmpty())
+			}
+
+			accepted := routeCondition("test-route", gatewayv1.RouteConditionAccepted)
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonUnsupportedValue)))
+		})
+	})
+
+	Describe("GRPCRoutes", func() {
+		var c client.WithWatch
+		var gtw gatewayv1.Gateway
+		var gwClass gatewayv1.GatewayClass
+		var greeter corev1.Service
+
+		BeforeEach(func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				WithGatewayEnabled(true),
+				WithSyncAllowConcurrent(true),
+			)
+			gwClass = NewTestGatewayClass("ngrok", true)
+			gtw = NewTestGateway("test-gateway", "test-namespace", gatewayv1.Listener{
+				Name:     "https",
+				Protocol: gatewayv1.HTTPSProtocolType,
+				Port:     443,
+				Hostname: ptr.To(gatewayv1.Hostname("grpc.example.com")),
+				AllowedRoutes: &gatewayv1.AllowedRoutes{
+					Namespaces: &gatewayv1.RouteNamespaces{From: ptr.To(gatewayv1.NamespacesFromSame)},
+				},
+			})
+			greeter = NewTestServiceV1("greeter", "test-namespace")
+		})
+
+		sync := func(obs ...client.Object) {
+			c = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append(obs, &gwClass)...).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}, &gatewayv1alpha2.GRPCRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+		}
+
+		edgeRoutes := func() []ingressv1alpha1.HTTPSEdgeRouteSpec {
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Hostports).To(Equal([]string{"grpc.example.com:443"}))
+			return edges.Items[0].Spec.Routes
+		}
+
+		routeAccepted := func(name string) *metav1.Condition {
+			route := &gatewayv1alpha2.GRPCRoute{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: name}, route)).To(Succeed())
+			Expect(route.Status.Parents).To(HaveLen(1))
+			return meta.FindStatusCondition(route.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))
+		}
+
+		It("uses a tunnel of its own for a service port shared with an ingress", func() {
+			ic := NewTestIngressClass("ngrok", true, true)
+			ing := NewTestIngressV1WithClass("test-ingress", "test-namespace", "ngrok")
+			ing.Spec.Rules[0].Host = "app.example.com"
+			ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "greeter"
+			route := NewTestGRPCRoute("test-route", "test-namespace", "test-gateway", "grpc.example.com",
+				NewTestGRPCBackendRef("greeter", 80, 1),
+			)
+			sync(&gtw, &greeter, &route, &ic, &ing)
+
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(HaveLen(2))
+			var ingressTunnel, grpcTunnel ingressv1alpha1.Tunnel
+			for _, tunnel := range tunnels.Items {
+				if tunnel.Spec.AppProtocol == "http2" {
+					grpcTunnel = tunnel
+				} else {
+					ingressTunnel = tunnel
+				}
+			}
+			Expect(grpcTunnel.OwnerReferences).To(HaveLen(1))
+			Expect(grpcTunnel.OwnerReferences[0].Kind).To(Equal("GRPCRoute"))
+			Expect(grpcTunnel.Spec.Labels).To(HaveKeyWithValue(labelAppProtocol, "http2"))
+			Expect(ingressTunnel.OwnerReferences).To(HaveLen(1))
+			Expect(ingressTunnel.OwnerReferences[0].Name).To(Equal("test-ingress"))
+			Expect(ingressTunnel.Spec.Labels).ToNot(HaveKey(labelAppProtocol))
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(2))
+			for _, edge := range edges.Items {
+				Expect(edge.Spec.Routes).To(HaveLen(1))
+				switch edge.Spec.Hostports[0] {
+				case "grpc.example.com:443":
+					Expect(edge.Spec.Routes[0].Backend.Labels).To(Equal(grpcTunnel.Spec.Labels))
+				case "app.example.com:443":
+					Expect(edge.Spec.Routes[0].Backend.Labels).To(Equal(ingressTunnel.Spec.Labels))
+				}
+			}
+		})
+
+		It("routes service and method matches by path to an http2 tunnel", func() {
+			route := NewTestGRPCRoute("test-route", "test-namespace", "test-gateway", "grpc.example.com",
+				NewTestGRPCBackendRef("greeter", 80, 1),
+			)
+			route.Spec.Rules[0].Matches = []gatewayv1alpha2.GRPCRouteMatch{
+				{Method: &gatewayv1alpha2.GRPCMethodMatch{Service: ptr.To("helloworld.Greeter"), Method: ptr.To("SayHello")}},
+			}
+			sync(&gtw, &greeter, &route)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Match).To(Equal("/helloworld.Greeter/SayHello"))
+			Expect(routes[0].MatchType).To(Equal("exact_path"))
+			Expect(routes[0].Backend.Labels).To(HaveKeyWithValue(labelService, "greeter"))
+
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(HaveLen(1))
+			Expect(tunnels.Items[0].Spec.AppProtocol).To(Equal("http2"))
+			Expect(tunnels.Items[0].OwnerReferences[0].Kind).To(Equal("GRPCRoute"))
+
+			accepted := routeAccepted("test-route")
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("creates a route for each service of a rule", func() {
+			route := NewTestGRPCRoute("test-route", "test-namespace", "test-gateway", "grpc.example.com",
+				NewTestGRPCBackendRef("greeter", 80, 1),
+			)
+			route.Spec.Rules[0].Matches = []gatewayv1alpha2.GRPCRouteMatch{
+				{Method: &gatewayv1alpha2.GRPCMethodMatch{Service: ptr.To("a.A")}},
+				{Method: &gatewayv1alpha2.GRPCMethodMatch{Service: ptr.To("b.B")}},
+			}
+			sync(&gtw, &greeter, &route)
+
+			routes := edgeRoutes()
+			Expect(routes).To(HaveLen(2))
+			Expect(routes[0].Match).To(Equal("/a.A/"))
+			Expect(routes[1].Match).To(Equal("/b.B/"))
+			for _, route := range routes {
+				Expect(route.MatchType).To(Equal("path_prefix"))
+				Expect(string(route.Policy)).ToNot(ContainSubstring("custom-response"))
+			}
+		})
+
+		It("does not accept matches that aren't a service and method", func() {
+			route := NewTestGRPCRoute("test-route", "test-namespace", "test-gateway", "grpc.example.com",
+				NewTestGRPCBackendRef("greeter", 80, 1),
+			)
+			route.Spec.Rules[0].Matches = []gatewayv1alpha2.GRPCRouteMatch{
+				{Method: &gatewayv1alpha2.GRPCMethodMatch{Method: ptr.To("SayHello")}},
+			}
+			sync(&gtw, &greeter, &route)
+
+			Expect(edgeRoutes()).To(BeEmpty())
+
+			accepted := routeAccepted("test-route")
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonUnsupportedValue)))
+			Expect(accepted.Message).To(ContainSubstring("without a service is not supported"))
+		})
+
+		It("answers gRPC requests for other hostnames of a wildcard listener with a gRPC status", func() {
+			gtw.Spec.Listeners[0].Hostname = ptr.To(gatewayv1.Hostname("*.example.com"))
+			route := NewTestGRPCRoute("test-route", "test-namespace", "test-gateway", "grpc.example.com",
+				NewTestGRPCBackendRef("greeter", 80, 1),
+			)
+			sync(&gtw, &greeter, &route)
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			routes := edges.Items[0].Spec.Routes
+			Expect(routes).To(HaveLen(1))
+
+			trafficPolicy, err := util.NewTrafficPolicyFromJson(routes[0].Policy)
+			Expect(err).To(BeNil())
+			rules := trafficPolicy.Deconstruct()[util.PhaseOnHttpRequest]
+			Expect(rules).To(HaveLen(2))
+			var grpcReject util.EndpointRule
+			Expect(json.Unmarshal(rules[0], &grpcReject)).To(Succeed())
+			Expect(grpcReject.Expressions).To(Equal([]string{`!(req.host == "grpc.example.com")`, grpcRequestExpression}))
+			Expect(string(grpcReject.Actions[0])).To(ContainSubstring(`"grpc-status":"12"`))
+		})
+
+		It("shares the edge with HTTPRoutes on the same hostname", func() {
+			grpcroute := NewTestGRPCRoute("grpc-route", "test-namespace", "test-gateway", "grpc.example.com",
+				NewTestGRPCBackendRef("greeter", 80, 1),
+			)
+			httproute := NewTestHTTPRoute("http-route", "test-namespace", "test-gateway", "grpc.example.com",
+				NewTestHTTPBackendRef("greeter", 80, 1),
+			)
+			sync(&gtw, &greeter, &grpcroute, &httproute)
+
+			// both routes match every path, their rules are merged into a single edge route
+			Expect(edgeRoutes()).To(HaveLen(1))
+
+			gateway := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Namespace: "test-namespace", Name: "test-gateway"}, gateway)).To(Succeed())
+			Expect(gateway.Status.Listeners).To(HaveLen(1))
+			Expect(gateway.Status.Listeners[0].AttachedRoutes).To(Equal(int32(2)))
+		})
+
+		It("does not accept rules with invalid method matches", func() {
+			route := NewTestGRPCRoute("test-route", "test-namespace", "test-gateway", "grpc.example.com",
+				NewTestGRPCBackendRef("greeter", 80, 1),
+			)
+			route.Spec.Rules[0].Matches = []gatewayv1alpha2.GRPCRouteMatch{
+				{Method: &gatewayv1alpha2.GRPCMethodMatch{
+					Type:    ptr.To(gatewayv1alpha2.GRPCMethodMatchRegularExpression),
+					Service: ptr.To("("),
+				}},
+			}
+			sync(&gtw, &greeter, &route)
+
+			accepted := routeAccepted("test-route")
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.RouteReasonUnsupportedValue)))
+		})
+	})
+
 	Describe("calculateIngressLoadBalancerIPStatus", func() {
 		var domains []ingressv1alpha1.Domain
 		var ingress netv1.Ingress
@@ -503,6 +2381,24 @@ var _ = Describe("Driver", func() {
 			Expect(policy).ToNot(BeNil())
 		})
 
+		It("Should leave the rule matches to the edge route", func() {
+			rule.Matches = []gatewayv1.HTTPRouteMatch{
+				{
+					Method: ptr.To(gatewayv1.HTTPMethodPost),
+					Headers: []gatewayv1.HTTPHeaderMatch{
+						{Name: "X-Tenant", Value: "foo"},
+					},
+				},
+			}
+
+			policy, err := driver.createEndpointPolicyForGateway(rule, namespace)
+			Expect(err).To(BeNil())
+
+			trafficPolicy, err := util.NewTrafficPolicyFromJson(policy)
+			Expect(err).To(BeNil())
+			Expect(trafficPolicy.Deconstruct()[util.PhaseOnHttpRequest]).To(BeEmpty())
+		})
+
 		It("Should return a merged policy if there rules with extensionRef", func() {
 			hostname := gatewayv1.PreciseHostname("test-hostname.com")
 			replacePrefixMatch := "/paprika"
@@ -603,7 +2499,7 @@ var _ = Describe("Driver", func() {
 			proceed, wait := driver.syncStart(false)
 			Expect(proceed).To(BeTrue())
 			Expect(wait).To(BeNil())
-			driver.syncDone()
+			driver.syncDone(nil)
 		})
 
 		It("second waits, then returns error", func() {
@@ -614,7 +2510,7 @@ var _ = Describe("Driver", func() {
 			Expect(secondProceed).To(BeFalse())
 			Expect(secondWait).To(Not(BeNil()))
 
-			driver.syncDone()
+			driver.syncDone(nil)
 
 			err := secondWait(context.Background())
 			Expect(err).To(Equal(errSyncDone))
@@ -635,7 +2531,7 @@ var _ = Describe("Driver", func() {
 			secondErr := secondWait(context.Background())
 			Expect(secondErr).To(BeNil())
 
-			driver.syncDone()
+			driver.syncDone(nil)
 
 			err := thirdWait(context.Background())
 			Expect(err).To(Equal(errSyncDone))
@@ -656,11 +2552,69 @@ var _ = Describe("Driver", func() {
 			thirdErr := thirdWait(context.Background())
 			Expect(thirdErr).To(BeNil())
 
-			driver.syncDone()
+			driver.syncDone(nil)
 
 			err := secondWait(context.Background())
 			Expect(err).To(Equal(errSyncDone))
 		})
+
+		It("callers arriving during the debounce window share its result", func() {
+			firstProceed, _ := driver.syncStart(false)
+			Expect(firstProceed).To(BeTrue())
+			driver.setSyncDebouncing(true)
+
+			secondProceed, secondWait := driver.syncStart(false)
+			Expect(secondProceed).To(BeFalse())
+			partialProceed, partialWait := driver.syncStart(true)
+			Expect(partialProceed).To(BeFalse())
+
+			driver.setSyncDebouncing(false)
+			syncErr := fmt.Errorf("sync failed")
+			driver.syncDone(syncErr)
+
+			Expect(secondWait(context.Background())).To(Equal(syncErr))
+			Expect(partialWait(context.Background())).To(Equal(syncErr))
+		})
+
+		It("full callers arriving during the debounce window of a partial sync wait for the next one", func() {
+			firstProceed, _ := driver.syncStart(true)
+			Expect(firstProceed).To(BeTrue())
+			driver.setSyncDebouncing(true)
+
+			secondProceed, secondWait := driver.syncStart(false)
+			Expect(secondProceed).To(BeFalse())
+
+			driver.setSyncDebouncing(false)
+			driver.syncDone(nil)
+
+			Expect(secondWait(context.Background())).To(Equal(errSyncDone))
+		})
+
+		It("coalesces the syncs requested during the debounce window", func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				WithSyncDebounce(50*time.Millisecond),
+			)
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+			errs := make(chan error, 5)
+			go func() { errs <- driver.Sync(context.Background(), c) }()
+			Eventually(func() bool {
+				driver.syncMu.Lock()
+				defer driver.syncMu.Unlock()
+				return driver.syncDebouncing
+			}).Should(BeTrue())
+			for i := 0; i < 4; i++ {
+				go func() { errs <- driver.Sync(context.Background(), c) }()
+			}
+
+			for i := 0; i < 5; i++ {
+				Expect(<-errs).ToNot(HaveOccurred())
+			}
+		})
 	})
 })
 
@@ -735,3 +2689,385 @@ func TestExtractPolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteHostnamesForListener(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		listenerHostname *gatewayv1.Hostname
+		routeHostnames   []gatewayv1.Hostname
+		expected         []string
+	}{
+		{
+			name:           "listener without hostname serves all route hostnames",
+			routeHostnames: []gatewayv1.Hostname{"a.example.com", "b.example.com"},
+			expected:       []string{"a.example.com", "b.example.com"},
+		},
+		{
+			name:             "route without hostnames takes the listener hostname",
+			listenerHostname: ptr.To(gatewayv1.Hostname("a.example.com")),
+			expected:         []string{"a.example.com"},
+		},
+		{
+			name:             "exact match",
+			listenerHostname: ptr.To(gatewayv1.Hostname("a.example.com")),
+			routeHostnames:   []gatewayv1.Hostname{"a.example.com", "b.example.com"},
+			expected:         []string{"a.example.com"},
+		},
+		{
+			name:             "wildcard listener",
+			listenerHostname: ptr.To(gatewayv1.Hostname("*.example.com")),
+			routeHostnames:   []gatewayv1.Hostname{"a.example.com", "example.com", "a.other.com"},
+			expected:         []string{"a.example.com"},
+		},
+		{
+			name:             "wildcard route",
+			listenerHostname: ptr.To(gatewayv1.Hostname("a.example.com")),
+			routeHostnames:   []gatewayv1.Hostname{"*.example.com"},
+			expected:         []string{"a.example.com"},
+		},
+		{
+			name:             "more specific wildcard route",
+			listenerHostname: ptr.To(gatewayv1.Hostname("*.example.com")),
+			routeHostnames:   []gatewayv1.Hostname{"*.a.example.com"},
+			expected:         []string{"*.a.example.com"},
+		},
+		{
+			name:             "less specific wildcard route",
+			listenerHostname: ptr.To(gatewayv1.Hostname("*.a.example.com")),
+			routeHostnames:   []gatewayv1.Hostname{"*.example.com", "b.a.example.com"},
+			expected:         []string{"*.a.example.com", "b.a.example.com"},
+		},
+		{
+			name:             "hostnames served once",
+			listenerHostname: ptr.To(gatewayv1.Hostname("a.example.com")),
+			routeHostnames:   []gatewayv1.Hostname{"*.example.com", "a.example.com"},
+			expected:         []string{"a.example.com"},
+		},
+		{
+			name:             "no match",
+			listenerHostname: ptr.To(gatewayv1.Hostname("a.example.com")),
+			routeHostnames:   []gatewayv1.Hostname{"a.other.com"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			listener := gatewayv1.Listener{Hostname: tc.listenerHostname}
+			assert.Equal(t, tc.expected, routeHostnamesForListener(listener, tc.routeHostnames))
+		})
+	}
+}
+
+func TestRouteHostnamesExpression(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		listenerHostname gatewayv1.Hostname
+		routeHostnames   []gatewayv1.Hostname
+		expected         string
+	}{
+		{
+			name:             "route without hostnames",
+			listenerHostname: "*.example.com",
+			expected:         "",
+		},
+		{
+			name:             "route serving the whole listener hostname",
+			listenerHostname: "a.example.com",
+			routeHostnames:   []gatewayv1.Hostname{"*.example.com"},
+			expected:         "",
+		},
+		{
+			name:             "route restricted to a hostname of a wildcard listener",
+			listenerHostname: "*.example.com",
+			routeHostnames:   []gatewayv1.Hostname{"a.example.com"},
+			expected:         `req.host == "a.example.com"`,
+		},
+		{
+			name:             "route restricted to several hostnames of a wildcard listener",
+			listenerHostname: "*.example.com",
+			routeHostnames:   []gatewayv1.Hostname{"a.example.com", "*.b.example.com"},
+			expected:         `(req.host == "a.example.com" || req.host.endsWith(".b.example.com"))`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			listener := gatewayv1.Listener{Hostname: ptr.To(tc.listenerHostname)}
+			assert.Equal(t, tc.expected, routeHostnamesExpression(listener, tc.routeHostnames))
+		})
+	}
+}
+
+func TestWeightedBackends(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		weights         []int32
+		expectedTunnels []int
+		expectedExact   bool
+		expectedErr     bool
+	}{
+		{
+			name:            "no backends",
+			weights:         []int32{},
+			expectedTunnels: []int{},
+			expectedExact:   true,
+		},
+		{
+			name:            "equal weights",
+			weights:         []int32{50, 50},
+			expectedTunnels: []int{1, 1},
+			expectedExact:   true,
+		},
+		{
+			name:            "canary",
+			weights:         []int32{90, 10},
+			expectedTunnels: []int{9, 1},
+			expectedExact:   true,
+		},
+		{
+			name:            "zero weights are dropped",
+			weights:         []int32{3, 0, 1},
+			expectedTunnels: []int{3, 1},
+			expectedExact:   true,
+		},
+		{
+			name:            "approximated weights",
+			weights:         []int32{95, 5},
+			expectedTunnels: []int{9, 1},
+			expectedExact:   false,
+		},
+		{
+			name:            "approximated weights within the tunnels",
+			weights:         []int32{1, 1, 1, 1, 1, 1, 1, 1, 1, 100},
+			expectedTunnels: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+			expectedExact:   false,
+		},
+		{
+			name:            "largest remainders",
+			weights:         []int32{33, 33, 34},
+			expectedTunnels: []int{3, 3, 4},
+			expectedExact:   false,
+		},
+		{
+			name:            "too many backends",
+			weights:         []int32{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2},
+			expectedTunnels: []int{},
+			expectedExact:   false,
+			expectedErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			backendRefs := []gatewayv1.HTTPBackendRef{}
+			for i, weight := range tc.weights {
+				backendRefs = append(backendRefs, NewTestHTTPBackendRef(fmt.Sprintf("svc-%d", i), 80, weight))
+			}
+
+			backends, exact, err := weightedBackends(backendRefs)
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			tunnels := []int{}
+			for _, backend := range backends {
+				tunnels = append(tunnels, backend.tunnels)
+			}
+			assert.Equal(t, tc.expectedTunnels, tunnels)
+			assert.Equal(t, tc.expectedExact, exact)
+		})
+	}
+}
+
+func TestHTTPRouteMatchExpression(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		match       gatewayv1.HTTPRouteMatch
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "empty match",
+			expected: "",
+		},
+		{
+			name:     "path only",
+			match:    gatewayv1.HTTPRouteMatch{Path: &gatewayv1.HTTPPathMatch{Type: ptr.To(gatewayv1.PathMatchPathPrefix), Value: ptr.To("/api")}},
+			expected: "",
+		},
+		{
+			name:     "method",
+			match:    gatewayv1.HTTPRouteMatch{Method: ptr.To(gatewayv1.HTTPMethodGet)},
+			expected: `req.method == "GET"`,
+		},
+		{
+			name: "query param regular expression",
+			match: gatewayv1.HTTPRouteMatch{QueryParams: []gatewayv1.HTTPQueryParamMatch{
+				{Type: ptr.To(gatewayv1.QueryParamMatchRegularExpression), Name: "version", Value: `^v\d+$`},
+			}},
+			expected: `("version" in req.url.query_params && req.url.query_params["version"].exists(v, v.matches("^v\\d+$")))`,
+		},
+		{
+			name: "all the conditions of a match",
+			match: gatewayv1.HTTPRouteMatch{
+				Method: ptr.To(gatewayv1.HTTPMethodPost),
+				Headers: []gatewayv1.HTTPHeaderMatch{
+					{Name: "X-Tenant", Value: "acme"},
+				},
+			},
+			expected: `req.method == "POST" && ("x-tenant" in req.headers && req.headers["x-tenant"].exists(v, v == "acme"))`,
+		},
+		{
+			name: "invalid regular expression",
+			match: gatewayv1.HTTPRouteMatch{Headers: []gatewayv1.HTTPHeaderMatch{
+				{Type: ptr.To(gatewayv1.HeaderMatchRegularExpression), Name: "x-tenant", Value: "("},
+			}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			expression, err := httpRouteMatchExpression(tc.match)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, expression)
+		})
+	}
+}
+
+func TestHTTPRouteMatchPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		match            gatewayv1.HTTPRouteMatch
+		expectedPath     string
+		expectedPathType string
+		expectedErr      bool
+	}{
+		{
+			name:             "no path",
+			expectedPath:     "/",
+			expectedPathType: "path_prefix",
+		},
+		{
+			name:             "prefix",
+			match:            gatewayv1.HTTPRouteMatch{Path: &gatewayv1.HTTPPathMatch{Type: ptr.To(gatewayv1.PathMatchPathPrefix), Value: ptr.To("/api")}},
+			expectedPath:     "/api",
+			expectedPathType: "path_prefix",
+		},
+		{
+			name:             "exact",
+			match:            gatewayv1.HTTPRouteMatch{Path: &gatewayv1.HTTPPathMatch{Type: ptr.To(gatewayv1.PathMatchExact), Value: ptr.To("/healthz")}},
+			expectedPath:     "/healthz",
+			expectedPathType: "exact_path",
+		},
+		{
+			name:        "regular expression",
+			match:       gatewayv1.HTTPRouteMatch{Path: &gatewayv1.HTTPPathMatch{Type: ptr.To(gatewayv1.PathMatchRegularExpression), Value: ptr.To("/api/.*")}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path, pathType, err := httpRouteMatchPath(tc.match)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPath, path)
+			assert.Equal(t, tc.expectedPathType, pathType)
+		})
+	}
+}
+
+func TestGRPCRouteMatchPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		match            gatewayv1alpha2.GRPCRouteMatch
+		expectedPath     string
+		expectedPathType string
+		expectedErr      bool
+	}{
+		{
+			name:             "empty match",
+			expectedPath:     "/",
+			expectedPathType: "path_prefix",
+		},
+		{
+			name:             "service and method",
+			match:            gatewayv1alpha2.GRPCRouteMatch{Method: &gatewayv1alpha2.GRPCMethodMatch{Service: ptr.To("helloworld.Greeter"), Method: ptr.To("SayHello")}},
+			expectedPath:     "/helloworld.Greeter/SayHello",
+			expectedPathType: "exact_path",
+		},
+		{
+			name:             "service only",
+			match:            gatewayv1alpha2.GRPCRouteMatch{Method: &gatewayv1alpha2.GRPCMethodMatch{Service: ptr.To("helloworld.Greeter")}},
+			expectedPath:     "/helloworld.Greeter/",
+			expectedPathType: "path_prefix",
+		},
+		{
+			name:        "method only",
+			match:       gatewayv1alpha2.GRPCRouteMatch{Method: &gatewayv1alpha2.GRPCMethodMatch{Method: ptr.To("SayHello")}},
+			expectedErr: true,
+		},
+		{
+			name: "regular expression method",
+			match: gatewayv1alpha2.GRPCRouteMatch{Method: &gatewayv1alpha2.GRPCMethodMatch{
+				Type:    ptr.To(gatewayv1alpha2.GRPCMethodMatchRegularExpression),
+				Service: ptr.To(`helloworld\..*`),
+			}},
+			expectedErr: true,
+		},
+		{
+			name: "service with header",
+			match: gatewayv1alpha2.GRPCRouteMatch{
+				Method:  &gatewayv1alpha2.GRPCMethodMatch{Service: ptr.To("helloworld.Greeter")},
+				Headers: []gatewayv1alpha2.GRPCHeaderMatch{{Name: "X-Tenant", Value: "acme"}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path, pathType, err := grpcRouteMatchPath(tc.match)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPath, path)
+			assert.Equal(t, tc.expectedPathType, pathType)
+		})
+	}
+}
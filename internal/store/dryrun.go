@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// ChangeAction is the kind of write a sync would make to a resource
+type ChangeAction string
+
+const (
+	ChangeActionCreate ChangeAction = "create"
+	ChangeActionUpdate ChangeAction = "update"
+	ChangeActionDelete ChangeAction = "delete"
+)
+
+// Change is a write a sync would make to a resource of the cluster
+type Change struct {
+	Action ChangeAction `json:"action"`
+	Kind   string       `json:"kind"`
+	// Subresource is set for writes to a subresource of the resource, such as its status
+	Subresource string `json:"subresource,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	// Name is the name of the resource, or the prefix of its generated name for resources created with one
+	Name string `json:"name"`
+	// Patch is the JSON merge patch from the current resource to the resource the sync would write, for updates
+	Patch json.RawMessage `json:"patch,omitempty"`
+}
+
+// SyncDiff is the list of writes a sync would make to the cluster, in the order it would make them
+type SyncDiff struct {
+	Changes []Change `json:"changes"`
+}
+
+// Count returns the number of changes of the given action
+func (s SyncDiff) Count(action ChangeAction) int {
+	count := 0
+	for _, change := range s.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// WithDryRun makes the driver compute the changes a sync would make instead of writing them to the cluster. The
+// changes of the last sync are logged and returned by LastSyncDiff.
+func WithDryRun(enabled bool) DriverOpt {
+	return func(d *Driver) {
+		d.dryRun = enabled
+	}
+}
+
+// LastSyncDiff returns the changes the last sync would have made to the cluster in dry-run mode
+func (d *Driver) LastSyncDiff() SyncDiff {
+	d.lastSyncDiffMu.Lock()
+	defer d.lastSyncDiffMu.Unlock()
+	return d.lastSyncDiff
+}
+
+func (d *Driver) setLastSyncDiff(diff SyncDiff) {
+	d.lastSyncDiffMu.Lock()
+	defer d.lastSyncDiffMu.Unlock()
+	d.lastSyncDiff = diff
+}
+
+// logSyncDiff logs the changes a dry-run sync would have made
+func (d *Driver) logSyncDiff(diff SyncDiff) {
+	d.log.Info("dry-run sync computed changes",
+		"creates", diff.Count(ChangeActionCreate),
+		"updates", diff.Count(ChangeActionUpdate),
+		"deletes", diff.Count(ChangeActionDelete),
+	)
+	for _, change := range diff.Changes {
+		d.log.Info("dry-run change",
+			"action", change.Action,
+			"kind", change.Kind,
+			"subresource", change.Subresource,
+			"namespace", change.Namespace,
+			"name", change.Name,
+			"patch", string(change.Patch),
+		)
+	}
+}
+
+// updateStoreAfterWrite keeps the store in sync with an object the driver wrote to the cluster. Nothing is written
+// in dry-run mode, so the store keeps the cluster's version of the object.
+func (d *Driver) updateStoreAfterWrite(obj client.Object) error {
+	if d.dryRun {
+		return nil
+	}
+	return d.store.Update(obj)
+}
+
+// dryRunClient reads from the cluster but records the writes made through it instead of sending them
+type dryRunClient struct {
+	client.Client
+
+	mu      sync.Mutex
+	changes []Change
+}
+
+func newDryRunClient(c client.Client) *dryRunClient {
+	return &dryRunClient{Client: c}
+}
+
+// diff returns the writes recorded so far
+func (c *dryRunClient) diff() SyncDiff {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return SyncDiff{Changes: append([]Change{}, c.changes...)}
+}
+
+func (c *dryRunClient) record(ctx context.Context, action ChangeAction, obj client.Object, subresource string) error {
+	change := Change{
+		Action:      action,
+		Subresource: subresource,
+		Namespace:   obj.GetNamespace(),
+		Name:        obj.GetName(),
+	}
+	if change.Name == "" {
+		change.Name = obj.GetGenerateName()
+	}
+	if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+		change.Kind = gvk.Kind
+	}
+
+	if action == ChangeActionUpdate {
+		current, ok := obj.DeepCopyObject().(client.Object)
+		if !ok {
+			return nil
+		}
+		if err := c.Client.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, current); err != nil {
+			return err
+		}
+		patch, err := client.MergeFrom(current).Data(obj)
+		if err != nil {
+			return err
+		}
+		change.Patch = patch
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changes = append(c.changes, change)
+	return nil
+}
+
+func (c *dryRunClient) Create(ctx context.Context, obj client.Object, _ ...client.CreateOption) error {
+	return c.record(ctx, ChangeActionCreate, obj, "")
+}
+
+func (c *dryRunClient) Update(ctx context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	return c.record(ctx, ChangeActionUpdate, obj, "")
+}
+
+func (c *dryRunClient) Patch(ctx context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+	return c.record(ctx, ChangeActionUpdate, obj, "")
+}
+
+func (c *dryRunClient) Delete(ctx context.Context, obj client.Object, _ ...client.DeleteOption) error {
+	return c.record(ctx, ChangeActionDelete, obj, "")
+}
+
+func (c *dryRunClient) DeleteAllOf(_ context.Context, _ client.Object, _ ...client.DeleteAllOfOption) error {
+	return fmt.Errorf("delete all of is not supported in dry-run mode")
+}
+
+func (c *dryRunClient) Status() client.SubResourceWriter {
+	return c.SubResource("status")
+}
+
+func (c *dryRunClient) SubResource(subResource string) client.SubResourceClient {
+	return &dryRunSubResourceClient{SubResourceClient: c.Client.SubResource(subResource), parent: c, subResource: subResource}
+}
+
+// dryRunSubResourceClient records the writes made to a subresource instead of sending them
+type dryRunSubResourceClient struct {
+	client.SubResourceClient
+
+	parent      *dryRunClient
+	subResource string
+}
+
+func (c *dryRunSubResourceClient) Create(ctx context.Context, obj client.Object, _ client.Object, _ ...client.SubResourceCreateOption) error {
+	return c.parent.record(ctx, ChangeActionCreate, obj, c.subResource)
+}
+
+func (c *dryRunSubResourceClient) Update(ctx context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+	return c.parent.record(ctx, ChangeActionUpdate, obj, c.subResource)
+}
+
+func (c *dryRunSubResourceClient) Patch(ctx context.Context, obj client.Object, _ client.Patch, _ ...client.SubResourcePatchOption) error {
+	return c.parent.record(ctx, ChangeActionUpdate, obj, c.subResource)
+}
@@ -0,0 +1,398 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/util"
+)
+
+// gatewayRouteBranch is the translation of a single match of an HTTPRoute or GRPCRoute rule. The routes of an HTTPS
+// edge only match on paths, so the branches sharing a path are merged into a single edge route whose traffic policy
+// picks the branch of each request with the CEL condition of its match.
+type gatewayRouteBranch struct {
+	source  syncSource
+	created metav1.Time
+	ruleIdx int
+	// matchIdx orders the branches of the same rule
+	matchIdx int
+
+	path     string
+	pathType string
+	// condition is the CEL expression of the route hostnames and of the method, header and query param matches,
+	// empty when the match doesn't restrict requests beyond their path
+	condition string
+	// precedence holds whether the route is restricted to some hostnames of the listener and the number of method,
+	// header and query param matches. Hostnames are matched first, then the Gateway API gives precedence to the
+	// matches with the most of each in that order.
+	precedence [4]int
+
+	// grpc is true for the branches of GRPCRoutes
+	grpc    bool
+	policy  json.RawMessage
+	backend ingressv1alpha1.TunnelGroupBackend
+	// err is set when the branch can't be translated, errMsg describes what failed
+	err    error
+	errMsg string
+}
+
+// gatewayEdgeRoute is an HTTPS edge route with the branches merged into it, in the order they're evaluated
+type gatewayEdgeRoute struct {
+	path     string
+	pathType string
+	backend  ingressv1alpha1.TunnelGroupBackend
+	branches []gatewayRouteBranch
+	// conditional is true when every branch has a condition, requests satisfying none of them don't match the route
+	conditional bool
+}
+
+// listenerRouteBranches returns the branches of the HTTPRoutes and GRPCRoutes attached to an HTTPS listener
+func (d *Driver) listenerRouteBranches(gtw *gatewayv1.Gateway, listener gatewayv1.Listener, gatewayDomainMap map[string]ingressv1alpha1.Domain) []gatewayRouteBranch {
+	var branches []gatewayRouteBranch
+
+	for _, httproute := range d.store.ListHTTPRoutes() {
+		if !d.httpRouteAttachedToListener(httproute, gtw, listener, gatewayDomainMap) {
+			continue
+		}
+		source := syncSource{Kind: "HTTPRoute", NamespacedName: types.NamespacedName{Namespace: httproute.Namespace, Name: httproute.Name}}
+		hostCondition := routeHostnamesExpression(listener, httproute.Spec.Hostnames)
+
+		for ruleIdx, rule := range httproute.Spec.Rules {
+			backend, backendErr := d.edgeRouteBackend(rule.BackendRefs, "HTTPRoute", httproute.Namespace, httproute.Name, ruleIdx)
+
+			matches := rule.Matches
+			if len(matches) == 0 {
+				// a rule without matches matches every request
+				matches = []gatewayv1.HTTPRouteMatch{{}}
+			}
+			for matchIdx, match := range matches {
+				path, pathType, err := httpRouteMatchPath(match)
+				if err != nil {
+					// reported by the route's matches condition
+					continue
+				}
+				condition, err := httpRouteMatchExpression(match)
+				if err != nil {
+					continue
+				}
+				condition = joinConditions(hostCondition, condition)
+
+				// each branch only rewrites or redirects the path prefix of its own match
+				matchRule := rule
+				matchRule.Matches = []gatewayv1.HTTPRouteMatch{match}
+				policy, err := d.createEndpointPolicyForGateway(&matchRule, httproute.Namespace)
+				errMsg := fmt.Sprintf("error creating policy from rule %d", ruleIdx)
+				if err == nil && backendErr != nil {
+					err, errMsg = backendErr, fmt.Sprintf("could not find the backend of rule %d", ruleIdx)
+				}
+
+				branches = append(branches, gatewayRouteBranch{
+					source:     source,
+					created:    httproute.CreationTimestamp,
+					ruleIdx:    ruleIdx,
+					matchIdx:   matchIdx,
+					path:       path,
+					pathType:   pathType,
+					condition:  condition,
+					precedence: [4]int{boolToInt(hostCondition != ""), boolToInt(match.Method != nil), len(match.Headers), len(match.QueryParams)},
+					policy:     policy,
+					backend:    backend,
+					err:        err,
+					errMsg:     errMsg,
+				})
+			}
+		}
+	}
+
+	for _, grpcroute := range d.store.ListGRPCRoutes() {
+		if !d.grpcRouteAttachedToListener(grpcroute, gtw, listener, gatewayDomainMap) {
+			continue
+		}
+		source := syncSource{Kind: "GRPCRoute", NamespacedName: types.NamespacedName{Namespace: grpcroute.Namespace, Name: grpcroute.Name}}
+		hostCondition := routeHostnamesExpression(listener, grpcroute.Spec.Hostnames)
+
+		for ruleIdx, rule := range grpcroute.Spec.Rules {
+			backend, backendErr := d.edgeRouteBackend(grpcHTTPBackendRefs(rule.BackendRefs), "GRPCRoute", grpcroute.Namespace, grpcroute.Name, ruleIdx)
+			policy, err := d.createEndpointPolicyForGRPCRoute(&rule, grpcroute.Namespace)
+			errMsg := fmt.Sprintf("error creating policy from rule %d", ruleIdx)
+			if err == nil && backendErr != nil {
+				err, errMsg = backendErr, fmt.Sprintf("could not find the backend of rule %d", ruleIdx)
+			}
+
+			matches := rule.Matches
+			if len(matches) == 0 {
+				// a rule without matches matches every request
+				matches = []gatewayv1alpha2.GRPCRouteMatch{{}}
+			}
+			for matchIdx, match := range matches {
+				path, pathType, matchErr := grpcRouteMatchPath(match)
+				if matchErr != nil {
+					// reported by the route's matches condition
+					continue
+				}
+
+				branches = append(branches, gatewayRouteBranch{
+					source:     source,
+					created:    grpcroute.CreationTimestamp,
+					ruleIdx:    ruleIdx,
+					matchIdx:   matchIdx,
+					path:       path,
+					pathType:   pathType,
+					condition:  hostCondition,
+					precedence: [4]int{boolToInt(hostCondition != ""), 0, 0, 0},
+					grpc:       true,
+					policy:     policy,
+					backend:    backend,
+					err:        err,
+					errMsg:     errMsg,
+				})
+			}
+		}
+	}
+
+	return branches
+}
+
+// mergeRouteBranches merges the branches sharing a path into edge routes, keeping the order in which the paths first
+// appear. The branches of a path are ordered by the Gateway API precedence: the most specific matches first, then
+// the oldest route and the order of the rules. A branch following one without condition can never be picked and is
+// dropped.
+//
+// Requests satisfying none of the conditions of a path fall through to the branches of the less specific path
+// prefixes, as long as they forward to the same backend, since an edge route only has one.
+//
+// The errors returned are the ones of the rules that can't be represented, keyed by their route.
+func mergeRouteBranches(branches []gatewayRouteBranch) ([]gatewayEdgeRoute, map[syncSource][]string) {
+	type pathKey struct{ path, pathType string }
+	order := []pathKey{}
+	byPath := map[pathKey][]gatewayRouteBranch{}
+	for _, branch := range branches {
+		if branch.err != nil {
+			continue
+		}
+		key := pathKey{branch.path, branch.pathType}
+		if _, ok := byPath[key]; !ok {
+			order = append(order, key)
+		}
+		byPath[key] = append(byPath[key], branch)
+	}
+
+	for _, key := range order {
+		slices.SortStableFunc(byPath[key], compareRouteBranches)
+	}
+
+	conflicts := map[syncSource][]string{}
+	addConflict := func(branch gatewayRouteBranch, msg string) {
+		conflicts[branch.source] = append(conflicts[branch.source], fmt.Sprintf("rule %d: %s", branch.ruleIdx, msg))
+	}
+
+	// reachableBranches returns the branches of a path up to the first one without condition, dropping the ones
+	// forwarding to another backend than the first one
+	reachableBranches := func(key pathKey) []gatewayRouteBranch {
+		var reachable []gatewayRouteBranch
+		for _, branch := range byPath[key] {
+			if len(reachable) > 0 && !reflect.DeepEqual(branch.backend, reachable[0].backend) {
+				addConflict(branch, fmt.Sprintf("requests to %s %s are already forwarded to another backend by rule %d of %s %s, ngrok edge routes forward a path to a single backend",
+					key.pathType, key.path, reachable[0].ruleIdx, reachable[0].source.Kind, reachable[0].source.NamespacedName))
+				continue
+			}
+			reachable = append(reachable, branch)
+			if branch.condition == "" {
+				break
+			}
+		}
+		return reachable
+	}
+
+	reachable := map[pathKey][]gatewayRouteBranch{}
+	for _, key := range order {
+		reachable[key] = reachableBranches(key)
+	}
+
+	routes := make([]gatewayEdgeRoute, 0, len(order))
+	for _, key := range order {
+		own := reachable[key]
+		route := gatewayEdgeRoute{
+			path:     key.path,
+			pathType: key.pathType,
+			backend:  own[0].backend,
+			branches: own,
+		}
+
+		if own[len(own)-1].condition != "" {
+			// fall through to the less specific prefixes, longest first
+			var prefixes []pathKey
+			for _, other := range order {
+				if other != key && other.pathType == "path_prefix" && strings.HasPrefix(key.path, other.path) {
+					prefixes = append(prefixes, other)
+				}
+			}
+			slices.SortStableFunc(prefixes, func(a, b pathKey) int { return len(b.path) - len(a.path) })
+
+		fallthroughs:
+			for _, prefix := range prefixes {
+				for _, branch := range reachable[prefix] {
+					if !reflect.DeepEqual(branch.backend, route.backend) {
+						// requests matching this branch can't reach its backend, stop before picking a later one
+						addConflict(own[0], fmt.Sprintf("requests to %s %s not satisfying its matches can't fall through to rule %d of %s %s, ngrok edge routes forward a path to a single backend",
+							key.pathType, key.path, branch.ruleIdx, branch.source.Kind, branch.source.NamespacedName))
+						break fallthroughs
+					}
+					route.branches = append(route.branches, branch)
+					if branch.condition == "" {
+						break fallthroughs
+					}
+				}
+			}
+		}
+
+		route.conditional = route.branches[len(route.branches)-1].condition != ""
+		routes = append(routes, route)
+	}
+
+	return routes, conflicts
+}
+
+// compareRouteBranches orders the branches of a path by the Gateway API precedence
+func compareRouteBranches(a, b gatewayRouteBranch) int {
+	for i := range a.precedence {
+		if a.precedence[i] != b.precedence[i] {
+			return b.precedence[i] - a.precedence[i]
+		}
+	}
+	if !a.created.Equal(&b.created) {
+		if a.created.Before(&b.created) {
+			return -1
+		}
+		return 1
+	}
+	if a.source != b.source {
+		if a.source.Namespace != b.source.Namespace {
+			return strings.Compare(a.source.Namespace, b.source.Namespace)
+		}
+		if a.source.Name != b.source.Name {
+			return strings.Compare(a.source.Name, b.source.Name)
+		}
+		return strings.Compare(a.source.Kind, b.source.Kind)
+	}
+	if a.ruleIdx != b.ruleIdx {
+		return a.ruleIdx - b.ruleIdx
+	}
+	return a.matchIdx - b.matchIdx
+}
+
+// trafficPolicy builds the traffic policy of the edge route. Requests satisfying none of the branch conditions are
+// rejected first, with a gRPC status for gRPC requests when a GRPCRoute has a branch on the route, then the rules of each branch only apply to the requests it picks: the ones satisfying its
+// condition and none of the conditions of the branches before it.
+func (r gatewayEdgeRoute) trafficPolicy() (json.RawMessage, error) {
+	if len(r.branches) == 1 && !r.conditional {
+		return r.branches[0].policy, nil
+	}
+
+	policy := util.NewTrafficPolicy()
+	if r.conditional {
+		conditions := make([]string, 0, len(r.branches))
+		for _, branch := range r.branches {
+			conditions = append(conditions, branch.condition)
+		}
+		matchesExpression := conditions[0]
+		if len(conditions) > 1 {
+			matchesExpression = "(" + strings.Join(conditions, ") || (") + ")"
+		}
+		if slices.ContainsFunc(r.branches, func(branch gatewayRouteBranch) bool { return branch.grpc }) {
+			if err := mergeRejectUnmatchedGRPCRequestsRule(matchesExpression, policy); err != nil {
+				return nil, err
+			}
+		}
+		if err := mergeRejectUnmatchedRequestsRule(matchesExpression, policy); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, branch := range r.branches {
+		branchPolicy, err := util.NewTrafficPolicyFromJson(branch.policy)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.branches) > 1 {
+			if branchPolicy, err = scopeBranchPolicy(branchPolicy, branchSelector(r.branches[:i], branch)); err != nil {
+				return nil, err
+			}
+		}
+		policy.Merge(branchPolicy)
+	}
+
+	return policy.ToCRDJson()
+}
+
+// branchSelector returns the CEL expression picking the requests of a branch given the branches evaluated before it
+func branchSelector(previous []gatewayRouteBranch, branch gatewayRouteBranch) string {
+	expressions := make([]string, 0, len(previous)+1)
+	for _, p := range previous {
+		expressions = append(expressions, fmt.Sprintf("!(%s)", p.condition))
+	}
+	if branch.condition != "" {
+		expressions = append(expressions, branch.condition)
+	}
+	return strings.Join(expressions, " && ")
+}
+
+// scopeBranchPolicy restricts the rules of the traffic policy of a branch to the requests the branch picks
+func scopeBranchPolicy(policy util.TrafficPolicy, selector string) (util.TrafficPolicy, error) {
+	scoped := map[string][]util.RawRule{}
+	for phase, rules := range policy.Deconstruct() {
+		for _, rule := range rules {
+			scopedRule, err := scopeRule(rule, selector)
+			if err != nil {
+				return nil, err
+			}
+			scoped[phase] = append(scoped[phase], scopedRule)
+		}
+	}
+
+	msg, err := json.Marshal(scoped)
+	if err != nil {
+		return nil, err
+	}
+	return util.NewTrafficPolicyFromJson(msg)
+}
+
+// listenerBranchConflicts returns the errors of the rules of a route that can't be represented on the HTTPS edges of
+// the given listeners of gtw
+func (d *Driver) listenerBranchConflicts(source syncSource, gtw *gatewayv1.Gateway, listeners []gatewayv1.Listener, gatewayDomainMap map[string]ingressv1alpha1.Domain) []string {
+	var errs []string
+	for _, listener := range listeners {
+		_, conflicts := mergeRouteBranches(d.listenerRouteBranches(gtw, listener, gatewayDomainMap))
+		for _, err := range conflicts[source] {
+			if !slices.Contains(errs, err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// joinConditions returns the CEL expression satisfied when all the non empty conditions are
+func joinConditions(conditions ...string) string {
+	conditions = slices.DeleteFunc(conditions, func(condition string) bool { return condition == "" })
+	if len(conditions) < 2 {
+		return strings.Join(conditions, "")
+	}
+	return "(" + strings.Join(conditions, ") && (") + ")"
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
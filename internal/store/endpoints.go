@@ -0,0 +1,494 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/util"
+)
+
+// IngressMappingStrategy is how the driver translates ingresses to ngrok resources
+type IngressMappingStrategy string
+
+const (
+	// IngressMappingStrategyEdges translates each ingress host to a Domain and an HTTPSEdge, whose routes forward to
+	// the labeled tunnels the agent starts for the backend services
+	IngressMappingStrategyEdges IngressMappingStrategy = "edges"
+	// IngressMappingStrategyEndpoints translates each ingress host to a Domain and a CloudEndpoint, whose traffic policy
+	// forwards the paths to the internal agent endpoints the agent starts for the backend services
+	IngressMappingStrategyEndpoints IngressMappingStrategy = "endpoints"
+)
+
+// IngressMappingStrategies are the mapping strategies the driver supports
+var IngressMappingStrategies = []IngressMappingStrategy{IngressMappingStrategyEdges, IngressMappingStrategyEndpoints}
+
+// annotationMappingStrategy is set on IngressClasses to choose the mapping strategy of their ingresses
+const annotationMappingStrategy = "k8s.ngrok.com/mapping-strategy"
+
+// cloudEndpointDescription is the description of the CloudEndpoints created for the ingresses, the default of the CRD
+const cloudEndpointDescription = "Created by the ngrok-operator"
+
+// ParseIngressMappingStrategy returns the mapping strategy named s
+func ParseIngressMappingStrategy(s string) (IngressMappingStrategy, error) {
+	strategy := IngressMappingStrategy(s)
+	if !slices.Contains(IngressMappingStrategies, strategy) {
+		return "", fmt.Errorf("invalid mapping strategy %q, must be %s or %s", s, IngressMappingStrategyEdges, IngressMappingStrategyEndpoints)
+	}
+	return strategy, nil
+}
+
+// WithIngressMappingStrategy sets the mapping strategy of the ingresses whose IngressClass doesn't choose one with the
+// k8s.ngrok.com/mapping-strategy annotation. It defaults to IngressMappingStrategyEdges.
+func WithIngressMappingStrategy(strategy IngressMappingStrategy) DriverOpt {
+	return func(d *Driver) {
+		d.mappingStrategy = strategy
+	}
+}
+
+// ingressMappingStrategy returns the mapping strategy the annotation of the ingress' IngressClass chooses, or the
+// driver's one. An invalid annotation is reported on the ingress, which keeps the driver's mapping strategy.
+func (d *Driver) ingressMappingStrategy(ingress *netv1.Ingress) IngressMappingStrategy {
+	class := d.ingressClassOf(ingress)
+	if class == nil {
+		return d.mappingStrategy
+	}
+	value, ok := class.Annotations[annotationMappingStrategy]
+	if !ok {
+		return d.mappingStrategy
+	}
+
+	strategy, err := ParseIngressMappingStrategy(value)
+	if err != nil {
+		d.recordSyncError("Ingress", types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, err, fmt.Sprintf("invalid %s annotation on IngressClass %q", annotationMappingStrategy, class.Name))
+		return d.mappingStrategy
+	}
+	return strategy
+}
+
+// ingressClassOf returns the ngrok IngressClass of the ingress, which is the default one for ingresses without a class
+func (d *Driver) ingressClassOf(ingress *netv1.Ingress) *netv1.IngressClass {
+	for _, class := range d.store.ListNgrokIngressClassesV1() {
+		if ingress.Spec.IngressClassName != nil {
+			if class.Name == *ingress.Spec.IngressClassName {
+				return class
+			}
+		} else if class.Annotations["ingressclass.kubernetes.io/is-default-class"] == "true" {
+			return class
+		}
+	}
+	return nil
+}
+
+// ingressesByMappingStrategy splits the ingresses between the ones using the edges mapping strategy and the ones using
+// the endpoints mapping strategy, keeping their order
+func (d *Driver) ingressesByMappingStrategy(ingresses []*netv1.Ingress) ([]*netv1.Ingress, []*netv1.Ingress) {
+	var edges, endpoints []*netv1.Ingress
+	for _, ingress := range ingresses {
+		if d.ingressMappingStrategy(ingress) == IngressMappingStrategyEndpoints {
+			endpoints = append(endpoints, ingress)
+		} else {
+			edges = append(edges, ingress)
+		}
+	}
+	return edges, endpoints
+}
+
+// usesEndpointsMapping tells whether some ingresses may use the endpoints mapping strategy
+func (d *Driver) usesEndpointsMapping() bool {
+	if d.mappingStrategy == IngressMappingStrategyEndpoints {
+		return true
+	}
+	return slices.ContainsFunc(d.store.ListNgrokIngressClassesV1(), func(class *netv1.IngressClass) bool {
+		return class.Annotations[annotationMappingStrategy] == string(IngressMappingStrategyEndpoints)
+	})
+}
+
+// ingressesHosts returns the set of the hosts of the rules of the ingresses
+func ingressesHosts(ingresses []*netv1.Ingress) map[string]bool {
+	hosts := map[string]bool{}
+	for _, ingress := range ingresses {
+		for _, host := range ingressHosts(ingress) {
+			hosts[host] = true
+		}
+	}
+	return hosts
+}
+
+// internalEndpointURL returns the URL of the internal agent endpoint the agent starts for a service port. Internal
+// endpoints are shared by every cluster of the ngrok account, the hash of the service UID keeps their URLs apart.
+func internalEndpointURL(namespace, serviceName, serviceUID string, port int32) string {
+	h := fnv.New32a()
+	fmt.Fprint(h, serviceUID)
+	return fmt.Sprintf("https://%s.%s.%d.%08x.internal", serviceName, namespace, port, h.Sum32())
+}
+
+// cloudEndpointRoute is a path of a host translated to a CloudEndpoint, forwarded to an internal URL
+type cloudEndpointRoute struct {
+	ingressRouteKey
+	URL string
+}
+
+// expression returns the CEL expression matching the requests of the route, empty for the routes matching everything
+func (r cloudEndpointRoute) expression() string {
+	switch {
+	case r.MatchType == "exact_path":
+		return fmt.Sprintf("req.url.path == %q", r.Match)
+	case r.Match == "/":
+		return ""
+	default:
+		return fmt.Sprintf("req.url.path.startsWith(%q)", r.Match)
+	}
+}
+
+// cloudEndpointHost accumulates the traffic policy of a host translated to a CloudEndpoint
+type cloudEndpointHost struct {
+	domain ingressv1alpha1.Domain
+	policy util.TrafficPolicy
+	routes []cloudEndpointRoute
+}
+
+func (d *Driver) calculateCloudEndpoints(ingressDomains []ingressv1alpha1.Domain) (map[string]ngrokv1alpha1.CloudEndpoint, map[types.NamespacedName][]ingressRouteConflict) {
+	return d.calculateCloudEndpointsFromIngresses(ingressDomains, d.store.ListNgrokIngressesV1())
+}
+
+// calculateCloudEndpointsFromIngresses returns the CloudEndpoints of the hosts of the ingresses using the endpoints
+// mapping strategy, keyed by URL, and the routes of each ingress shadowed by the same routes of older ingresses.
+//
+// The traffic policy of a CloudEndpoint starts with the rules of the traffic policies of its ingresses, each restricted
+// to the paths of its ingress, followed by a rule per path forwarding the requests to the internal agent endpoint of
+// the path's service. The exact paths come first, then the prefixes from the longest to the shortest, so that the most
+// specific path matches first as it does for the routes of the HTTPSEdges.
+func (d *Driver) calculateCloudEndpointsFromIngresses(ingressDomains []ingressv1alpha1.Domain, ingresses []*netv1.Ingress) (map[string]ngrokv1alpha1.CloudEndpoint, map[types.NamespacedName][]ingressRouteConflict) {
+	edgeIngresses, endpointIngresses := d.ingressesByMappingStrategy(ingresses)
+	edgeHosts := ingressesHosts(edgeIngresses)
+	slices.SortStableFunc(endpointIngresses, compareIngressAge)
+
+	domains := make(map[string]ingressv1alpha1.Domain, len(ingressDomains))
+	for _, domain := range ingressDomains {
+		domains[domain.Spec.Domain] = domain
+	}
+
+	routes := newIngressRouteOwners(d.log)
+	hosts := map[string]*cloudEndpointHost{}
+	var hostOrder []string
+	for _, ingress := range endpointIngresses {
+		ingressName := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+		policy, err := d.cloudEndpointIngressPolicy(ingress)
+		if err != nil {
+			d.recordSyncError("Ingress", ingressName, err, "error getting traffic policy for ingress")
+			continue
+		}
+
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host == "" {
+				continue
+			}
+			if edgeHosts[rule.Host] {
+				d.recordSyncError("Ingress", ingressName, nil, fmt.Sprintf("host %q is already translated to an HTTPSEdge for the ingresses using the edges mapping strategy", rule.Host))
+				continue
+			}
+			domain, ok := domains[rule.Host]
+			if !ok {
+				continue
+			}
+
+			host, ok := hosts[rule.Host]
+			if !ok {
+				host = &cloudEndpointHost{domain: domain, policy: util.NewTrafficPolicy()}
+				hosts[rule.Host] = host
+				hostOrder = append(hostOrder, rule.Host)
+			}
+
+			var claimed []cloudEndpointRoute
+			addRoute := func(backend netv1.IngressBackend, match, matchType string) {
+				url, err := d.cloudEndpointBackendURL(ingress, backend)
+				if err != nil {
+					d.recordSyncError("Ingress", ingressName, err, fmt.Sprintf("could not resolve backend for ingress path %q", match))
+					return
+				}
+				route := cloudEndpointRoute{ingressRouteKey: ingressRouteKey{Host: rule.Host, Match: match, MatchType: matchType}, URL: url}
+				if routes.claim(ingress, route.ingressRouteKey) {
+					claimed = append(claimed, route)
+				}
+			}
+
+			if rule.HTTP != nil {
+				for _, path := range rule.HTTP.Paths {
+					matchType, err := ingressPathMatchType(path)
+					if err != nil {
+						d.recordSyncError("Ingress", ingressName, err, "unsupported ingress path", "path", path.Path)
+						continue
+					}
+					addRoute(path.Backend, path.Path, matchType)
+				}
+			}
+			// The default backend catches everything the paths of the host don't, unless a path already does
+			if ingress.Spec.DefaultBackend != nil {
+				addRoute(*ingress.Spec.DefaultBackend, "/", "path_prefix")
+			}
+
+			if len(claimed) == 0 {
+				continue
+			}
+			host.routes = append(host.routes, claimed...)
+			if policy != nil {
+				scoped, err := scopeTrafficPolicy(policy, claimed)
+				if err != nil {
+					d.recordSyncError("Ingress", ingressName, err, "error restricting the traffic policy of the ingress to its paths")
+					continue
+				}
+				host.policy.Merge(scoped)
+			}
+		}
+	}
+
+	endpoints := make(map[string]ngrokv1alpha1.CloudEndpoint, len(hosts))
+	for _, hostname := range hostOrder {
+		host := hosts[hostname]
+		policyJSON, err := host.trafficPolicy()
+		if err != nil {
+			d.log.Error(err, "error building the traffic policy of the cloud endpoint", "host", hostname)
+			continue
+		}
+
+		endpoint := ngrokv1alpha1.CloudEndpoint{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: host.domain.Name + "-",
+				Namespace:    host.domain.Namespace,
+				Labels:       d.edgeLabels(),
+			},
+			Spec: ngrokv1alpha1.CloudEndpointSpec{
+				URL:           "https://" + hostname,
+				TrafficPolicy: &ngrokv1alpha1.NgrokTrafficPolicySpec{Policy: policyJSON},
+				Description:   cloudEndpointDescription,
+				Metadata:      d.ingressNgrokMetadata,
+			},
+		}
+		endpoints[endpoint.Spec.URL] = endpoint
+	}
+	return endpoints, routes.conflicts
+}
+
+// trafficPolicy returns the traffic policy of the host's CloudEndpoint, ending with the rules forwarding its paths
+func (h *cloudEndpointHost) trafficPolicy() (json.RawMessage, error) {
+	routes := slices.Clone(h.routes)
+	slices.SortStableFunc(routes, func(a, b cloudEndpointRoute) int {
+		if (a.MatchType == "exact_path") != (b.MatchType == "exact_path") {
+			if a.MatchType == "exact_path" {
+				return -1
+			}
+			return 1
+		}
+		return len(b.Match) - len(a.Match)
+	})
+
+	catchAll := false
+	for _, route := range routes {
+		rule, err := forwardInternalRule(fmt.Sprintf("Forward %s to %s", route.Match, route.URL), route.URL)
+		if err != nil {
+			return nil, err
+		}
+		if expression := route.expression(); expression != "" {
+			rule.Expressions = []string{expression}
+		} else {
+			catchAll = true
+		}
+		if err := h.policy.MergeEndpointRule(rule, util.PhaseOnHttpRequest); err != nil {
+			return nil, err
+		}
+	}
+
+	if !catchAll {
+		// The HTTPSEdges respond with a 404 to the requests no route matches
+		config, err := json.Marshal(CustomResponseConfig{StatusCode: 404, Content: "Not Found"})
+		if err != nil {
+			return nil, err
+		}
+		rawAction, err := json.Marshal(&util.EndpointAction{Type: "custom-response", Config: config})
+		if err != nil {
+			return nil, err
+		}
+		if err := h.policy.MergeEndpointRule(util.EndpointRule{
+			Name:    "Reject requests not matching any ingress path",
+			Actions: []util.RawAction{rawAction},
+		}, util.PhaseOnHttpRequest); err != nil {
+			return nil, err
+		}
+	}
+	return h.policy.ToAPIJson()
+}
+
+// forwardInternalRule returns a rule forwarding every request to the internal URL
+func forwardInternalRule(name, url string) (util.EndpointRule, error) {
+	config, err := json.Marshal(ForwardInternalConfig{URL: url})
+	if err != nil {
+		return util.EndpointRule{}, err
+	}
+	rawAction, err := json.Marshal(&util.EndpointAction{
+		Type:   "forward-internal",
+		Config: config,
+	})
+	if err != nil {
+		return util.EndpointRule{}, err
+	}
+	return util.EndpointRule{Name: name, Actions: []util.RawAction{rawAction}}, nil
+}
+
+// cloudEndpointIngressPolicy returns the traffic policy of an ingress using the endpoints mapping strategy, nil when it
+// has none. Only the policy module of the NgrokModuleSets can be used, the other modules need to be replaced with
+// their traffic policy, which the NgrokModuleSet controller reports in the status of the module sets.
+func (d *Driver) cloudEndpointIngressPolicy(ingress *netv1.Ingress) (util.TrafficPolicy, error) {
+	modSet, err := d.getNgrokModuleSetForIngress(ingress)
+	if err != nil {
+		return nil, err
+	}
+	modules := modSet.Modules
+	modules.Policy = nil
+	if !reflect.DeepEqual(modules, ingressv1alpha1.NgrokModuleSetModules{}) {
+		return nil, fmt.Errorf("the endpoints mapping strategy only supports the policy module of NgrokModuleSets, use the traffic policy in the status of the NgrokModuleSets instead")
+	}
+
+	policyJSON, err := d.getTrafficPolicyJSON(ingress, modSet)
+	if err != nil {
+		return nil, err
+	}
+	if len(policyJSON) == 0 || string(policyJSON) == "null" {
+		return nil, nil
+	}
+
+	policy, err := extractPolicy(policyJSON)
+	if err != nil {
+		return nil, err
+	}
+	if enabled := policy.Enabled(); enabled != nil && !*enabled {
+		return nil, nil
+	}
+	return policy, nil
+}
+
+// cloudEndpointBackendURL returns the internal URL the CloudEndpoint forwards the requests for an ingress backend to
+func (d *Driver) cloudEndpointBackendURL(ingress *netv1.Ingress, backend netv1.IngressBackend) (string, error) {
+	switch {
+	case backend.Service != nil:
+		service, servicePort, err := d.findBackendServicePort(*backend.Service, ingress.Namespace)
+		if err != nil {
+			return "", err
+		}
+		return internalEndpointURL(ingress.Namespace, service.Name, string(service.UID), servicePort.Port), nil
+	case backend.Resource != nil:
+		return d.ingressResourceBackendURL(backend.Resource, ingress.Namespace)
+	default:
+		return "", fmt.Errorf("ingress backend has neither a service nor a resource")
+	}
+}
+
+// scopeTrafficPolicy restricts the HTTP rules of the traffic policy of an ingress to the requests of its routes, by
+// adding the expression matching them to the expressions of each rule, which must all be true for a rule to apply.
+// The on_tcp_connect rules apply before the path of the requests is known, so they apply to every request of the host.
+func scopeTrafficPolicy(policy util.TrafficPolicy, routes []cloudEndpointRoute) (util.TrafficPolicy, error) {
+	var expressions []string
+	for _, route := range routes {
+		expression := route.expression()
+		if expression == "" {
+			// the ingress routes every request of the host
+			return policy, nil
+		}
+		expressions = append(expressions, expression)
+	}
+	scope := strings.Join(expressions, " || ")
+	if len(expressions) > 1 {
+		scope = "(" + scope + ")"
+	}
+
+	scoped := map[string][]util.RawRule{}
+	for phase, rules := range policy.Deconstruct() {
+		if phase == util.PhaseOnTcpConnect {
+			scoped[phase] = rules
+			continue
+		}
+		for _, rule := range rules {
+			scopedRule, err := scopeRule(rule, scope)
+			if err != nil {
+				return nil, err
+			}
+			scoped[phase] = append(scoped[phase], scopedRule)
+		}
+	}
+
+	msg, err := json.Marshal(scoped)
+	if err != nil {
+		return nil, err
+	}
+	return util.NewTrafficPolicyFromJson(msg)
+}
+
+// scopeRule adds the expression in front of the expressions of the traffic policy rule
+func scopeRule(raw util.RawRule, expression string) (util.RawRule, error) {
+	var rule map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &rule); err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("traffic policy rule must be an object")
+	}
+
+	var expressions []string
+	if existing, ok := rule["expressions"]; ok {
+		if err := json.Unmarshal(existing, &expressions); err != nil {
+			return nil, err
+		}
+	}
+	scoped, err := json.Marshal(append([]string{expression}, expressions...))
+	if err != nil {
+		return nil, err
+	}
+	rule["expressions"] = scoped
+	return json.Marshal(rule)
+}
+
+func (d *Driver) applyCloudEndpoints(ctx context.Context, c client.Client, desiredEndpoints map[string]ngrokv1alpha1.CloudEndpoint, currentEndpoints []ngrokv1alpha1.CloudEndpoint) error {
+	// update or delete the endpoints we don't need anymore
+	for _, currEndpoint := range currentEndpoints {
+		desiredEndpoint, ok := desiredEndpoints[currEndpoint.Spec.URL]
+		if !ok {
+			if err := c.Delete(ctx, &currEndpoint); client.IgnoreNotFound(err) != nil {
+				d.log.Error(err, "error deleting cloud endpoint", "endpoint", currEndpoint)
+				return err
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(desiredEndpoint.Spec, currEndpoint.Spec) {
+			currEndpoint.Spec = desiredEndpoint.Spec
+			if err := c.Update(ctx, &currEndpoint); err != nil {
+				d.log.Error(err, "error updating cloud endpoint", "desiredEndpoint", desiredEndpoint, "currEndpoint", currEndpoint)
+				return err
+			}
+		}
+
+		// matched and updated the endpoint, no longer desired
+		delete(desiredEndpoints, currEndpoint.Spec.URL)
+	}
+
+	// the set of desired endpoints now only contains new endpoints, create them
+	for _, endpoint := range desiredEndpoints {
+		if err := c.Create(ctx, &endpoint); err != nil {
+			d.log.Error(err, "error creating cloud endpoint", "endpoint", endpoint)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,474 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/annotations"
+)
+
+// WithIncrementalSync makes the syncs only recompute the Domains, HTTPSEdges and ingress statuses of the hosts affected
+// by the changes made to the store since the previous sync. Changes the driver can't map to hosts, such as changes to
+// IngressClasses, and every sync when the Gateway API or the endpoints mapping strategy are used, still fall back to a
+// full sync.
+func WithIncrementalSync(enabled bool) DriverOpt {
+	return func(d *Driver) {
+		d.incrementalSync = enabled
+	}
+}
+
+// WithSyncDebounce makes each sync wait for the given window before reading the store. Syncs requested during the
+// window are coalesced with it, so a burst of changes, such as a rollout updating many Services, ends in a single sync.
+func WithSyncDebounce(window time.Duration) DriverOpt {
+	return func(d *Driver) {
+		d.syncDebounce = window
+	}
+}
+
+// syncDebounceWait waits for the debounce window of the sync, during which the syncs requested are coalesced with it
+func (d *Driver) syncDebounceWait(ctx context.Context) error {
+	if d.syncDebounce <= 0 {
+		return nil
+	}
+
+	d.setSyncDebouncing(true)
+	defer d.setSyncDebouncing(false)
+
+	timer := time.NewTimer(d.syncDebounce)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Driver) setSyncDebouncing(debouncing bool) {
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+	d.syncDebouncing = debouncing
+}
+
+// syncResult is the outcome of a sync, shared with the callers coalesced with it during its debounce window
+type syncResult struct {
+	done chan struct{}
+	err  error
+}
+
+// syncDependency identifies a resource ingresses reference, such as the Service of a backend
+type syncDependency struct {
+	Kind string
+	types.NamespacedName
+}
+
+// syncChanges are the changes made to the store since the last sync
+type syncChanges struct {
+	// full is set when a change can't be mapped to the hosts it affects
+	full      bool
+	ingresses map[types.NamespacedName]bool
+	hosts     map[string]bool
+	deps      map[syncDependency]bool
+}
+
+func newSyncChanges(full bool) syncChanges {
+	return syncChanges{
+		full:      full,
+		ingresses: map[types.NamespacedName]bool{},
+		hosts:     map[string]bool{},
+		deps:      map[syncDependency]bool{},
+	}
+}
+
+// merge adds the other changes to these ones
+func (c syncChanges) merge(other syncChanges) syncChanges {
+	c.full = c.full || other.full
+	for key := range other.ingresses {
+		c.ingresses[key] = true
+	}
+	for host := range other.hosts {
+		c.hosts[host] = true
+	}
+	for dep := range other.deps {
+		c.deps[dep] = true
+	}
+	return c
+}
+
+// syncTracker records the changes made to the store between syncs. It also indexes the hosts and dependencies of the
+// ingresses in the store, so that a sync can tell which ingresses and hosts a change affects without reading them all.
+type syncTracker struct {
+	mu      sync.Mutex
+	changes syncChanges
+
+	ingressHosts  map[types.NamespacedName][]string
+	ingressDeps   map[types.NamespacedName][]syncDependency
+	hostIngresses map[string]map[types.NamespacedName]bool
+	depIngresses  map[syncDependency]map[types.NamespacedName]bool
+}
+
+// newSyncTracker returns a tracker whose first sync is a full one, since nothing was synced yet
+func newSyncTracker() *syncTracker {
+	return &syncTracker{
+		changes:       newSyncChanges(true),
+		ingressHosts:  map[types.NamespacedName][]string{},
+		ingressDeps:   map[types.NamespacedName][]syncDependency{},
+		hostIngresses: map[string]map[types.NamespacedName]bool{},
+		depIngresses:  map[syncDependency]map[types.NamespacedName]bool{},
+	}
+}
+
+// observe records the change of an object of the store, from its previous version to its new one. Either is nil when
+// the object is created or deleted.
+func (t *syncTracker) observe(old, obj runtime.Object) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if oldIngress, ok := old.(*netv1.Ingress); ok {
+		if ingress, ok := obj.(*netv1.Ingress); ok && !ingressTranslationChanged(oldIngress, ingress) {
+			// the driver's own status and annotation updates don't need another sync
+			return
+		}
+	}
+
+	for _, o := range []runtime.Object{old, obj} {
+		if o == nil {
+			continue
+		}
+		switch o := o.(type) {
+		case *netv1.Ingress:
+			t.changes.ingresses[client.ObjectKeyFromObject(o)] = true
+			for _, host := range ingressHosts(o) {
+				t.changes.hosts[host] = true
+			}
+		case *corev1.Service:
+			t.changes.deps[syncDependency{Kind: "Service", NamespacedName: client.ObjectKeyFromObject(o)}] = true
+		case *ingressv1alpha1.NgrokModuleSet:
+			t.changes.deps[syncDependency{Kind: "NgrokModuleSet", NamespacedName: client.ObjectKeyFromObject(o)}] = true
+		case *ngrokv1alpha1.NgrokTrafficPolicy:
+			t.changes.deps[syncDependency{Kind: "NgrokTrafficPolicy", NamespacedName: client.ObjectKeyFromObject(o)}] = true
+		case *ngrokv1alpha1.CloudEndpoint:
+			t.changes.deps[syncDependency{Kind: "CloudEndpoint", NamespacedName: client.ObjectKeyFromObject(o)}] = true
+		case *ingressv1alpha1.Domain:
+			// the domain of a host changing changes the status of its ingresses, and the domain is recreated if deleted
+			t.changes.hosts[o.Spec.Domain] = true
+		case *ingressv1alpha1.HTTPSEdge:
+			// edges changed by someone else are reverted
+			for _, hostport := range o.Spec.Hostports {
+				t.changes.hosts[strings.TrimSuffix(hostport, ":443")] = true
+			}
+		case *ingressv1alpha1.Tunnel:
+			// tunnels are shared by the hosts of their services, they're always synced entirely
+		default:
+			t.changes.full = true
+		}
+	}
+
+	if ingress, ok := obj.(*netv1.Ingress); ok {
+		t.indexIngress(ingress)
+	} else if ingress, ok := old.(*netv1.Ingress); ok && obj == nil {
+		t.unindexIngress(client.ObjectKeyFromObject(ingress))
+	}
+}
+
+func (t *syncTracker) indexIngress(ingress *netv1.Ingress) {
+	key := client.ObjectKeyFromObject(ingress)
+	t.unindexIngress(key)
+
+	hosts := ingressHosts(ingress)
+	deps := ingressDependencies(ingress)
+	t.ingressHosts[key] = hosts
+	t.ingressDeps[key] = deps
+	for _, host := range hosts {
+		if t.hostIngresses[host] == nil {
+			t.hostIngresses[host] = map[types.NamespacedName]bool{}
+		}
+		t.hostIngresses[host][key] = true
+	}
+	for _, dep := range deps {
+		if t.depIngresses[dep] == nil {
+			t.depIngresses[dep] = map[types.NamespacedName]bool{}
+		}
+		t.depIngresses[dep][key] = true
+	}
+}
+
+func (t *syncTracker) unindexIngress(key types.NamespacedName) {
+	for _, host := range t.ingressHosts[key] {
+		delete(t.hostIngresses[host], key)
+		if len(t.hostIngresses[host]) == 0 {
+			delete(t.hostIngresses, host)
+		}
+	}
+	for _, dep := range t.ingressDeps[key] {
+		delete(t.depIngresses[dep], key)
+		if len(t.depIngresses[dep]) == 0 {
+			delete(t.depIngresses, dep)
+		}
+	}
+	delete(t.ingressHosts, key)
+	delete(t.ingressDeps, key)
+}
+
+// take returns the changes made since the last call and forgets them
+func (t *syncTracker) take() syncChanges {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	changes := t.changes
+	t.changes = newSyncChanges(false)
+	return changes
+}
+
+// restore records changes again after the sync that took them failed, so that the next sync retries them
+func (t *syncTracker) restore(changes syncChanges) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.changes = t.changes.merge(changes)
+}
+
+// affected returns the hosts and ingresses affected by the changes: the changed ingresses and hosts, the ingresses
+// depending on changed resources, and the hosts of these ingresses. Ingresses sharing a host share its edge and can
+// shadow each other's routes, so they're always recomputed together.
+func (t *syncTracker) affected(changes syncChanges) (map[string]bool, map[types.NamespacedName]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hosts := map[string]bool{}
+	ingresses := map[types.NamespacedName]bool{}
+	var pendingHosts []string
+	var pendingIngresses []types.NamespacedName
+	addHost := func(host string) {
+		if !hosts[host] {
+			hosts[host] = true
+			pendingHosts = append(pendingHosts, host)
+		}
+	}
+	addIngress := func(key types.NamespacedName) {
+		if !ingresses[key] {
+			ingresses[key] = true
+			pendingIngresses = append(pendingIngresses, key)
+		}
+	}
+
+	for host := range changes.hosts {
+		addHost(host)
+	}
+	for key := range changes.ingresses {
+		addIngress(key)
+	}
+	for dep := range changes.deps {
+		for key := range t.depIngresses[dep] {
+			addIngress(key)
+		}
+	}
+
+	for len(pendingHosts) > 0 || len(pendingIngresses) > 0 {
+		for len(pendingIngresses) > 0 {
+			key := pendingIngresses[len(pendingIngresses)-1]
+			pendingIngresses = pendingIngresses[:len(pendingIngresses)-1]
+			for _, host := range t.ingressHosts[key] {
+				addHost(host)
+			}
+		}
+		for len(pendingHosts) > 0 {
+			host := pendingHosts[len(pendingHosts)-1]
+			pendingHosts = pendingHosts[:len(pendingHosts)-1]
+			for key := range t.hostIngresses[host] {
+				addIngress(key)
+			}
+		}
+	}
+
+	return hosts, ingresses
+}
+
+// ingressTranslationChanged tells whether an ingress changed in a way that changes what it's translated to
+func ingressTranslationChanged(old, ingress *netv1.Ingress) bool {
+	if !reflect.DeepEqual(old.Spec, ingress.Spec) || !old.DeletionTimestamp.Equal(ingress.DeletionTimestamp) {
+		return true
+	}
+
+	// the annotations set by the driver are ignored
+	translated := func(annotations map[string]string) map[string]string {
+		filtered := make(map[string]string, len(annotations))
+		for k, v := range annotations {
+			if k != annotationSyncStatus && k != annotationRouteConflicts {
+				filtered[k] = v
+			}
+		}
+		return filtered
+	}
+	return !reflect.DeepEqual(translated(old.Annotations), translated(ingress.Annotations))
+}
+
+// ingressHosts returns the hosts of the rules of an ingress
+func ingressHosts(ingress *netv1.Ingress) []string {
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	return hosts
+}
+
+// ingressDependencies returns the resources the edges and tunnels of an ingress are computed from
+func ingressDependencies(ingress *netv1.Ingress) []syncDependency {
+	var deps []syncDependency
+	addBackend := func(backend netv1.IngressBackend) {
+		switch {
+		case backend.Service != nil:
+			deps = append(deps, syncDependency{Kind: "Service", NamespacedName: types.NamespacedName{Namespace: ingress.Namespace, Name: backend.Service.Name}})
+		case backend.Resource != nil:
+			deps = append(deps, syncDependency{Kind: backend.Resource.Kind, NamespacedName: types.NamespacedName{Namespace: ingress.Namespace, Name: backend.Resource.Name}})
+		}
+	}
+
+	if ingress.Spec.DefaultBackend != nil {
+		addBackend(*ingress.Spec.DefaultBackend)
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			addBackend(path.Backend)
+		}
+	}
+
+	if modules, err := annotations.ExtractNgrokModuleSetsFromAnnotations(ingress); err == nil {
+		for _, module := range modules {
+			deps = append(deps, syncDependency{Kind: "NgrokModuleSet", NamespacedName: types.NamespacedName{Namespace: ingress.Namespace, Name: module}})
+		}
+	}
+	if policy, err := annotations.ExtractNgrokTrafficPolicyFromAnnotations(ingress); err == nil {
+		deps = append(deps, syncDependency{Kind: "NgrokTrafficPolicy", NamespacedName: types.NamespacedName{Namespace: ingress.Namespace, Name: policy}})
+	}
+	return deps
+}
+
+// canSyncIncrementally tells whether the changes can be synced by only recomputing the hosts they affect
+func (d *Driver) canSyncIncrementally(changes syncChanges) bool {
+	// The gateway resources aren't indexed, and a dry-run sync doesn't write the changes it takes
+	if !d.incrementalSync || changes.full || d.gatewayEnabled || d.dryRun {
+		return false
+	}
+	// The CloudEndpoints of the endpoints mapping strategy are only synced by the full syncs
+	if d.usesEndpointsMapping() {
+		d.log.Info("incremental sync disabled, the endpoints mapping strategy is in use")
+		return false
+	}
+	return true
+}
+
+// syncIncremental syncs the Domains, HTTPSEdges and ingress statuses of the hosts affected by the changes. The
+// Tunnels are still synced entirely, since they're shared by the hosts routing to the same services.
+func (d *Driver) syncIncremental(ctx context.Context, c client.Client, changes syncChanges) error {
+	hosts, ingressKeys := d.syncTracker.affected(changes)
+	d.log.Info("syncing affected hosts", "hosts", len(hosts), "ingresses", len(ingressKeys))
+
+	ingresses := make([]*netv1.Ingress, 0, len(ingressKeys))
+	for key := range ingressKeys {
+		ingress, err := d.store.GetNgrokIngressV1(key.Name, key.Namespace)
+		if err != nil || ingress == nil {
+			// deleted, or not handled by the operator
+			continue
+		}
+		ingresses = append(ingresses, ingress)
+	}
+
+	domainMap := d.calculateDomainsFromIngresses(ingresses)
+	desiredDomains := make([]ingressv1alpha1.Domain, 0, len(domainMap))
+	desiredEdges := make(map[string]ingressv1alpha1.HTTPSEdge, len(domainMap))
+	for host, domain := range domainMap {
+		desiredDomains = append(desiredDomains, domain)
+		desiredEdges[host] = d.newIngressHTTPSEdge(domain)
+	}
+	conflicts := d.calculateHTTPSEdgesFromIngresses(desiredEdges, ingresses)
+	d.updateIngressRouteConflictsOf(conflicts, ingressKeys)
+	desiredTunnels := d.calculateTunnels()
+
+	currDomains := &ingressv1alpha1.DomainList{}
+	currEdges := &ingressv1alpha1.HTTPSEdgeList{}
+	currTunnels := &ingressv1alpha1.TunnelList{}
+
+	if err := c.List(ctx, currDomains); err != nil {
+		d.log.Error(err, "error listing domains")
+		return err
+	}
+	if err := c.List(ctx, currEdges, client.MatchingLabels{
+		labelControllerNamespace: d.managerName.Namespace,
+		labelControllerName:      d.managerName.Name,
+	}); err != nil {
+		d.log.Error(err, "error listing edges")
+		return err
+	}
+	if err := c.List(ctx, currTunnels, client.MatchingLabels{
+		labelControllerNamespace: d.managerName.Namespace,
+		labelControllerName:      d.managerName.Name,
+	}); err != nil {
+		d.log.Error(err, "error listing tunnels")
+		return err
+	}
+
+	// The domains and edges of the other hosts are left as they are
+	affectedDomains := make([]ingressv1alpha1.Domain, 0, len(hosts))
+	for _, domain := range currDomains.Items {
+		if hosts[domain.Spec.Domain] {
+			affectedDomains = append(affectedDomains, domain)
+		}
+	}
+	affectedEdges := make([]ingressv1alpha1.HTTPSEdge, 0, len(hosts))
+	for _, edge := range currEdges.Items {
+		for _, hostport := range edge.Spec.Hostports {
+			if hosts[strings.TrimSuffix(hostport, ":443")] {
+				affectedEdges = append(affectedEdges, edge)
+				break
+			}
+		}
+	}
+
+	// The resources of the other hosts are assumed to be in sync
+	observeSyncObjects("Domain", len(currDomains.Items)-len(affectedDomains)+len(desiredDomains), len(currDomains.Items))
+	observeSyncObjects("HTTPSEdge", len(currEdges.Items)-len(affectedEdges)+len(desiredEdges), len(currEdges.Items))
+	observeSyncObjects("Tunnel", len(desiredTunnels), len(currTunnels.Items))
+
+	if err := d.applyDomains(ctx, c, desiredDomains, affectedDomains); err != nil {
+		return err
+	}
+
+	if err := d.applyHTTPSEdges(ctx, c, desiredEdges, affectedEdges); err != nil {
+		return err
+	}
+
+	if err := d.applyTunnels(ctx, c, desiredTunnels, currTunnels.Items); err != nil {
+		return err
+	}
+
+	if err := d.updateStatusesOfIngresses(ctx, c, ingresses, conflicts); err != nil {
+		return err
+	}
+
+	for _, ingress := range ingresses {
+		// the statuses were updated, the store holds their latest version
+		ingress, err := d.store.GetNgrokIngressV1(ingress.Name, ingress.Namespace)
+		if err != nil || ingress == nil {
+			continue
+		}
+		if err := d.updateSyncStatus(ctx, c, "Ingress", ingress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	syncModeFull        = "full"
+	syncModeIncremental = "incremental"
+	syncModeEdges       = "edges"
+)
+
+var (
+	syncsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "driver",
+		Name:      "syncs_total",
+		Help:      "Number of syncs of the driver, by mode (full, incremental or edges) and result (success or error).",
+	}, []string{"mode", "result"})
+
+	syncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "driver",
+		Name:      "sync_duration_seconds",
+		Help:      "Duration of the syncs of the driver, by mode (full, incremental or edges) and result (success or error).",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"mode", "result"})
+
+	syncObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "driver",
+		Name:      "objects",
+		Help:      "Number of ngrok resources the last sync derived from the store (desired) and found in the cluster (actual), by kind.",
+	}, []string{"kind", "state"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(syncsTotal, syncDuration, syncObjects)
+}
+
+// observeSync records a sync of the given mode that started at start
+func observeSync(mode string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	syncsTotal.WithLabelValues(mode, result).Inc()
+	syncDuration.WithLabelValues(mode, result).Observe(time.Since(start).Seconds())
+}
+
+// observeSyncObjects records the number of resources of a kind the sync wants and the number it found in the cluster
+func observeSyncObjects(kind string, desired, actual int) {
+	syncObjects.WithLabelValues(kind, "desired").Set(float64(desired))
+	syncObjects.WithLabelValues(kind, "actual").Set(float64(actual))
+}
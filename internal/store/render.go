@@ -0,0 +1,80 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+
+	"golang.org/x/exp/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// RenderResult holds the ngrok resources the driver derives from the resources in its store
+type RenderResult struct {
+	// Objects are the Domains, HTTPSEdges, CloudEndpoints and Tunnels, followed by the TCPEdges and TLSEdges when the Gateway API is enabled.
+	// Each kind is sorted by namespace and name, or by the prefix of the generated name for resources created with one.
+	Objects []client.Object
+	// Errors are the errors found while translating the resources, prefixed by the resource they belong to
+	Errors []string
+}
+
+// Render computes the ngrok resources a sync would create from the resources in the store, without reading or writing
+// the cluster. The resources are returned as they would be created, so they aren't named yet when the sync relies on
+// generated names.
+func (d *Driver) Render() (RenderResult, error) {
+	d.syncErrors.reset()
+
+	domains, ingressDomains, gatewayDomainMap := d.calculateDomains()
+	edges, _ := d.calculateHTTPSEdges(&ingressDomains, gatewayDomainMap)
+	endpoints, _ := d.calculateCloudEndpoints(ingressDomains)
+	tunnels := d.calculateTunnels()
+
+	kinds := make([][]client.Object, 4, 6)
+	for i := range domains {
+		kinds[0] = append(kinds[0], &domains[i])
+	}
+	for _, edge := range edges {
+		kinds[1] = append(kinds[1], edge.DeepCopy())
+	}
+	for _, endpoint := range endpoints {
+		kinds[2] = append(kinds[2], endpoint.DeepCopy())
+	}
+	for _, tunnel := range tunnels {
+		kinds[3] = append(kinds[3], tunnel.DeepCopy())
+	}
+	if d.gatewayEnabled {
+		var tcpEdges, tlsEdges []client.Object
+		for _, edge := range d.calculateTCPEdges() {
+			tcpEdges = append(tcpEdges, edge.DeepCopy())
+		}
+		for _, edge := range d.calculateTLSEdges() {
+			tlsEdges = append(tlsEdges, edge.DeepCopy())
+		}
+		kinds = append(kinds, tcpEdges, tlsEdges)
+	}
+
+	result := RenderResult{}
+	for _, objs := range kinds {
+		slices.SortStableFunc(objs, func(a, b client.Object) int {
+			return cmp.Or(
+				cmp.Compare(a.GetNamespace(), b.GetNamespace()),
+				cmp.Compare(a.GetName(), b.GetName()),
+				cmp.Compare(a.GetGenerateName(), b.GetGenerateName()),
+				// fmt prints maps sorted by key, which orders the tunnels of weighted groups
+				cmp.Compare(fmt.Sprint(a.GetLabels()), fmt.Sprint(b.GetLabels())),
+			)
+		})
+		for _, obj := range objs {
+			// The type meta tells the kind of the resources once they're printed
+			gvk, err := apiutil.GVKForObject(obj, d.scheme)
+			if err != nil {
+				return result, err
+			}
+			obj.GetObjectKind().SetGroupVersionKind(gvk)
+			result.Objects = append(result.Objects, obj)
+		}
+	}
+	result.Errors = d.syncErrors.all()
+
+	return result, nil
+}
@@ -15,6 +15,7 @@ package store
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -27,6 +28,8 @@ import (
 	netv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"github.com/go-logr/logr"
 )
@@ -43,11 +46,17 @@ type Storer interface {
 	GetIngressClassV1(name string) (*netv1.IngressClass, error)
 	GetIngressV1(name, namespace string) (*netv1.Ingress, error)
 	GetServiceV1(name, namespace string) (*corev1.Service, error)
+	GetNamespaceV1(name string) (*corev1.Namespace, error)
 	GetNgrokIngressV1(name, namespace string) (*netv1.Ingress, error)
 	GetNgrokModuleSetV1(name, namespace string) (*ingressv1alpha1.NgrokModuleSet, error)
 	GetNgrokTrafficPolicyV1(name, namespace string) (*ngrokv1alpha1.NgrokTrafficPolicy, error)
+	GetCloudEndpointV1(name, namespace string) (*ngrokv1alpha1.CloudEndpoint, error)
 	GetGateway(name string, namespace string) (*gatewayv1.Gateway, error)
+	GetNgrokGateway(name string, namespace string) (*gatewayv1.Gateway, error)
 	GetHTTPRoute(name string, namespace string) (*gatewayv1.HTTPRoute, error)
+	GetTCPRoute(name string, namespace string) (*gatewayv1alpha2.TCPRoute, error)
+	GetTLSRoute(name string, namespace string) (*gatewayv1alpha2.TLSRoute, error)
+	GetGRPCRoute(name string, namespace string) (*gatewayv1alpha2.GRPCRoute, error)
 
 	ListIngressClassesV1() []*netv1.IngressClass
 	ListNgrokIngressClassesV1() []*netv1.IngressClass
@@ -56,7 +65,12 @@ type Storer interface {
 	ListNgrokIngressesV1() []*netv1.Ingress
 
 	ListGateways() []*gatewayv1.Gateway
+	ListNgrokGateways() []*gatewayv1.Gateway
 	ListHTTPRoutes() []*gatewayv1.HTTPRoute
+	ListTCPRoutes() []*gatewayv1alpha2.TCPRoute
+	ListTLSRoutes() []*gatewayv1alpha2.TLSRoute
+	ListGRPCRoutes() []*gatewayv1alpha2.GRPCRoute
+	ListReferenceGrants() []*gatewayv1beta1.ReferenceGrant
 
 	ListDomainsV1() []*ingressv1alpha1.Domain
 	ListTunnelsV1() []*ingressv1alpha1.Tunnel
@@ -141,6 +155,18 @@ func (s Store) GetServiceV1(name, namespace string) (*corev1.Service, error) {
 	return p.(*corev1.Service), nil
 }
 
+// GetNamespaceV1 returns the 'name' Namespace resource.
+func (s Store) GetNamespaceV1(name string) (*corev1.Namespace, error) {
+	p, exists, err := s.stores.NamespaceV1.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewErrorNotFound(fmt.Sprintf("Namespace %v not found", name))
+	}
+	return p.(*corev1.Namespace), nil
+}
+
 // GetNgrokIngressV1 looks up the Ingress resource by name and namespace and returns it if it's found
 func (s Store) GetNgrokIngressV1(name, namespace string) (*netv1.Ingress, error) {
 	ing, err := s.GetIngressV1(name, namespace)
@@ -177,6 +203,17 @@ func (s Store) GetNgrokTrafficPolicyV1(name, namespace string) (*ngrokv1alpha1.N
 	return p.(*ngrokv1alpha1.NgrokTrafficPolicy), nil
 }
 
+func (s Store) GetCloudEndpointV1(name, namespace string) (*ngrokv1alpha1.CloudEndpoint, error) {
+	p, exists, err := s.stores.CloudEndpointV1.GetByKey(getKey(name, namespace))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewErrorNotFound(fmt.Sprintf("CloudEndpoint %v not found", name))
+	}
+	return p.(*ngrokv1alpha1.CloudEndpoint), nil
+}
+
 func (s Store) GetGateway(name string, namespace string) (*gatewayv1.Gateway, error) {
 	gtw, exists, err := s.stores.Gateway.GetByKey(getKey(name, namespace))
 	if err != nil {
@@ -188,6 +225,18 @@ func (s Store) GetGateway(name string, namespace string) (*gatewayv1.Gateway, er
 	return gtw.(*gatewayv1.Gateway), nil
 }
 
+// GetNgrokGateway returns the 'name' Gateway if its GatewayClass is handled by the ngrok gateway controller
+func (s Store) GetNgrokGateway(name string, namespace string) (*gatewayv1.Gateway, error) {
+	gtw, err := s.GetGateway(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !s.shouldHandleGateway(gtw) {
+		return nil, errors.NewErrorNotFound(fmt.Sprintf("Gateway %v of an ngrok GatewayClass not found", name))
+	}
+	return gtw, nil
+}
+
 func (s Store) GetHTTPRoute(name string, namespace string) (*gatewayv1.HTTPRoute, error) {
 	obj, exists, err := s.stores.HTTPRoute.GetByKey(getKey(name, namespace))
 	if err != nil {
@@ -199,6 +248,39 @@ func (s Store) GetHTTPRoute(name string, namespace string) (*gatewayv1.HTTPRoute
 	return obj.(*gatewayv1.HTTPRoute), nil
 }
 
+func (s Store) GetTCPRoute(name string, namespace string) (*gatewayv1alpha2.TCPRoute, error) {
+	obj, exists, err := s.stores.TCPRoute.GetByKey(getKey(name, namespace))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewErrorNotFound(fmt.Sprintf("TCPRoute %v not found", name))
+	}
+	return obj.(*gatewayv1alpha2.TCPRoute), nil
+}
+
+func (s Store) GetTLSRoute(name string, namespace string) (*gatewayv1alpha2.TLSRoute, error) {
+	obj, exists, err := s.stores.TLSRoute.GetByKey(getKey(name, namespace))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewErrorNotFound(fmt.Sprintf("TLSRoute %v not found", name))
+	}
+	return obj.(*gatewayv1alpha2.TLSRoute), nil
+}
+
+func (s Store) GetGRPCRoute(name string, namespace string) (*gatewayv1alpha2.GRPCRoute, error) {
+	obj, exists, err := s.stores.GRPCRoute.GetByKey(getKey(name, namespace))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewErrorNotFound(fmt.Sprintf("GRPCRoute %v not found", name))
+	}
+	return obj.(*gatewayv1alpha2.GRPCRoute), nil
+}
+
 // ListIngressClassesV1 returns the list of Ingresses in the Ingress v1 store.
 func (s Store) ListIngressClassesV1() []*netv1.IngressClass {
 	// filter ingress rules
@@ -275,6 +357,17 @@ func (s Store) ListGateways() []*gatewayv1.Gateway {
 	return gateways
 }
 
+// ListNgrokGateways returns the Gateways whose GatewayClass is handled by the ngrok gateway controller
+func (s Store) ListNgrokGateways() []*gatewayv1.Gateway {
+	var gateways []*gatewayv1.Gateway
+	for _, gtw := range s.ListGateways() {
+		if s.shouldHandleGateway(gtw) {
+			gateways = append(gateways, gtw)
+		}
+	}
+	return gateways
+}
+
 func (s Store) ListHTTPRoutes() []*gatewayv1.HTTPRoute {
 	var httproutes []*gatewayv1.HTTPRoute
 
@@ -290,6 +383,86 @@ func (s Store) ListHTTPRoutes() []*gatewayv1.HTTPRoute {
 	return httproutes
 }
 
+func (s Store) ListTCPRoutes() []*gatewayv1alpha2.TCPRoute {
+	var tcproutes []*gatewayv1alpha2.TCPRoute
+
+	for _, item := range s.stores.TCPRoute.List() {
+		tcproute, ok := item.(*gatewayv1alpha2.TCPRoute)
+		if !ok {
+			s.log.Error(nil, "TCPRoute: dropping object of unexpected type", "type", fmt.Sprintf("%#v", item))
+			continue
+		}
+		tcproutes = append(tcproutes, tcproute)
+	}
+
+	sort.SliceStable(tcproutes, func(i, j int) bool {
+		return strings.Compare(fmt.Sprintf("%s/%s", tcproutes[i].Namespace, tcproutes[i].Name),
+			fmt.Sprintf("%s/%s", tcproutes[j].Namespace, tcproutes[j].Name)) < 0
+	})
+
+	return tcproutes
+}
+
+func (s Store) ListTLSRoutes() []*gatewayv1alpha2.TLSRoute {
+	var tlsroutes []*gatewayv1alpha2.TLSRoute
+
+	for _, item := range s.stores.TLSRoute.List() {
+		tlsroute, ok := item.(*gatewayv1alpha2.TLSRoute)
+		if !ok {
+			s.log.Error(nil, "TLSRoute: dropping object of unexpected type", "type", fmt.Sprintf("%#v", item))
+			continue
+		}
+		tlsroutes = append(tlsroutes, tlsroute)
+	}
+
+	sort.SliceStable(tlsroutes, func(i, j int) bool {
+		return strings.Compare(fmt.Sprintf("%s/%s", tlsroutes[i].Namespace, tlsroutes[i].Name),
+			fmt.Sprintf("%s/%s", tlsroutes[j].Namespace, tlsroutes[j].Name)) < 0
+	})
+
+	return tlsroutes
+}
+
+func (s Store) ListGRPCRoutes() []*gatewayv1alpha2.GRPCRoute {
+	var grpcroutes []*gatewayv1alpha2.GRPCRoute
+
+	for _, item := range s.stores.GRPCRoute.List() {
+		grpcroute, ok := item.(*gatewayv1alpha2.GRPCRoute)
+		if !ok {
+			s.log.Error(nil, "GRPCRoute: dropping object of unexpected type", "type", fmt.Sprintf("%#v", item))
+			continue
+		}
+		grpcroutes = append(grpcroutes, grpcroute)
+	}
+
+	sort.SliceStable(grpcroutes, func(i, j int) bool {
+		return strings.Compare(fmt.Sprintf("%s/%s", grpcroutes[i].Namespace, grpcroutes[i].Name),
+			fmt.Sprintf("%s/%s", grpcroutes[j].Namespace, grpcroutes[j].Name)) < 0
+	})
+
+	return grpcroutes
+}
+
+func (s Store) ListReferenceGrants() []*gatewayv1beta1.ReferenceGrant {
+	var grants []*gatewayv1beta1.ReferenceGrant
+
+	for _, item := range s.stores.ReferenceGrant.List() {
+		grant, ok := item.(*gatewayv1beta1.ReferenceGrant)
+		if !ok {
+			s.log.Error(nil, "ReferenceGrant: dropping object of unexpected type", "type", fmt.Sprintf("%#v", item))
+			continue
+		}
+		grants = append(grants, grant)
+	}
+
+	sort.SliceStable(grants, func(i, j int) bool {
+		return strings.Compare(fmt.Sprintf("%s/%s", grants[i].Namespace, grants[i].Name),
+			fmt.Sprintf("%s/%s", grants[j].Namespace, grants[j].Name)) < 0
+	})
+
+	return grants
+}
+
 func (s Store) ListNgrokIngressesV1() []*netv1.Ingress {
 	ings := s.ListIngressesV1()
 
@@ -428,6 +601,39 @@ func (s Store) shouldHandleIngressCheckClass(ing *netv1.Ingress) (bool, error) {
 	return false, errors.NewErrDifferentIngressClass(ngrokClasses, ing.Spec.IngressClassName)
 }
 
+// shouldHandleGateway checks if the GatewayClass of the gateway is handled by the ngrok gateway controller
+func (s Store) shouldHandleGateway(gtw *gatewayv1.Gateway) bool {
+	item, exists, err := s.stores.GatewayClass.GetByKey(string(gtw.Spec.GatewayClassName))
+	if err != nil || !exists {
+		return false
+	}
+	gatewayClass, ok := item.(*gatewayv1.GatewayClass)
+	return ok && gatewayClass.Spec.ControllerName == GatewayControllerName
+}
+
+// supportedIngressResourceBackendKinds are the kinds of ngrok resources an ingress resource backend may reference
+var supportedIngressResourceBackendKinds = []string{"CloudEndpoint"}
+
+// isSupportedIngressResourceBackend returns true when the resource backend references an ngrok resource the
+// ingress can forward traffic to
+func isSupportedIngressResourceBackend(ref *corev1.TypedLocalObjectReference) bool {
+	return ref.APIGroup != nil && *ref.APIGroup == ngrokv1alpha1.GroupVersion.Group && slices.Contains(supportedIngressResourceBackendKinds, ref.Kind)
+}
+
+// validateIngressBackend checks that an ingress backend is either a service or a resource backend referencing
+// a supported ngrok resource.
+func validateIngressBackend(backend netv1.IngressBackend, errs *errors.ErrInvalidIngressSpec) {
+	if backend.Resource != nil {
+		if !isSupportedIngressResourceBackend(backend.Resource) {
+			errs.AddError(fmt.Sprintf("Resource backends must reference a %s, got %s", strings.Join(supportedIngressResourceBackendKinds, " or "), backend.Resource.Kind))
+		}
+		return
+	}
+	if backend.Service == nil {
+		errs.AddError("Service backends are required for this ingress")
+	}
+}
+
 // shouldHandleIngressIsValid checks if the ingress spec meets controller requirements.
 func (s Store) shouldHandleIngressIsValid(ing *netv1.Ingress) (bool, error) {
 	errs := errors.NewErrInvalidIngressSpec()
@@ -440,21 +646,17 @@ func (s Store) shouldHandleIngressIsValid(ing *netv1.Ingress) (bool, error) {
 			}
 			if rule.HTTP != nil {
 				for _, path := range rule.HTTP.Paths {
-					if path.Backend.Resource != nil {
-						errs.AddError("Resource backends are not supported")
-					}
-					if path.Backend.Service == nil {
-						errs.AddError("Service backends are required for this ingress")
-					}
+					validateIngressBackend(path.Backend, &errs)
 				}
-			} else {
+			} else if ing.Spec.DefaultBackend == nil {
+				// Rules without paths only make sense when everything goes to the default backend
 				errs.AddError("HTTP rules are required for ingress")
 			}
 		}
 	}
 
 	if ing.Spec.DefaultBackend != nil {
-		errs.AddError("Default backends are not supported")
+		validateIngressBackend(*ing.Spec.DefaultBackend, &errs)
 	}
 
 	if errs.HasErrors() {
@@ -8,7 +8,9 @@ import (
 	"github.com/ngrok/ngrok-operator/internal/errors"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/utils/ptr"
 )
 
 const ngrokIngressClass = "ngrok"
@@ -368,8 +370,8 @@ var _ = Describe("Store", func() {
 			})
 		})
 
-		Context("when ingress has unsupported default backend", func() {
-			It("ignores the ingress with default backend and returns an error", func() {
+		Context("when ingress has a default backend", func() {
+			It("accepts a service default backend", func() {
 				ing := NewTestIngressV1("ingress-default-backend", "test-namespace")
 				ing.Spec.DefaultBackend = &netv1.IngressBackend{
 					Service: &netv1.IngressServiceBackend{
@@ -378,9 +380,53 @@ var _ = Describe("Store", func() {
 					},
 				}
 				ok, err := store.shouldHandleIngressIsValid(&ing)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+			})
+
+			It("accepts rules without HTTP paths", func() {
+				ing := NewTestIngressV1("ingress-default-backend", "test-namespace")
+				ing.Spec.Rules[0].HTTP = nil
+				ing.Spec.DefaultBackend = &netv1.IngressBackend{
+					Service: &netv1.IngressServiceBackend{
+						Name: "default-service",
+						Port: netv1.ServiceBackendPort{Number: 80},
+					},
+				}
+				ok, err := store.shouldHandleIngressIsValid(&ing)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+			})
+		})
+
+		Context("when ingress has resource backends", func() {
+			It("accepts CloudEndpoint resource backends", func() {
+				ing := NewTestIngressV1("ingress-resource-backend", "test-namespace")
+				ing.Spec.Rules[0].HTTP.Paths[0].Backend = netv1.IngressBackend{
+					Resource: &corev1.TypedLocalObjectReference{
+						APIGroup: ptr.To("ngrok.k8s.ngrok.com"),
+						Kind:     "CloudEndpoint",
+						Name:     "internal-api",
+					},
+				}
+				ok, err := store.shouldHandleIngressIsValid(&ing)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+			})
+
+			It("rejects resource backends of other kinds", func() {
+				ing := NewTestIngressV1("ingress-resource-backend", "test-namespace")
+				ing.Spec.DefaultBackend = &netv1.IngressBackend{
+					Resource: &corev1.TypedLocalObjectReference{
+						APIGroup: ptr.To("k8s.example.com"),
+						Kind:     "StorageBucket",
+						Name:     "static-assets",
+					},
+				}
+				ok, err := store.shouldHandleIngressIsValid(&ing)
 				Expect(ok).To(BeFalse())
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("Default backends are not supported"))
+				Expect(err.Error()).To(ContainSubstring("Resource backends must reference a CloudEndpoint"))
 			})
 		})
 
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
+)
+
+func benchmarkScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(ingressv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(ngrokv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+// benchmarkObjects returns an ngrok IngressClass and count ingresses, each on its own host and service
+func benchmarkObjects(count int) []client.Object {
+	ic := NewTestIngressClass("ngrok", true, true)
+	objs := []client.Object{&ic}
+	for i := 0; i < count; i++ {
+		ing := NewTestIngressV1(fmt.Sprintf("ingress-%d", i), "bench")
+		ing.Spec.Rules[0].Host = fmt.Sprintf("host-%d.example.com", i)
+		ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = fmt.Sprintf("service-%d", i)
+		svc := NewTestServiceV1(fmt.Sprintf("service-%d", i), "bench")
+		objs = append(objs, &ing, &svc)
+	}
+	return objs
+}
+
+// benchmarkSyncOneIngressChange measures the sync following the change of a single ingress among count ingresses
+func benchmarkSyncOneIngressChange(b *testing.B, count int, opts ...DriverOpt) {
+	ctx := context.Background()
+	scheme := benchmarkScheme()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(benchmarkObjects(count)...).Build()
+
+	opts = append(opts, WithSyncAllowConcurrent(true))
+	driver := NewDriver(logr.Discard(), scheme, defaultControllerName, types.NamespacedName{Name: defaultManagerName}, opts...)
+	if err := driver.Seed(ctx, c); err != nil {
+		b.Fatal(err)
+	}
+	// settle the cluster so that the benchmark only measures the syncs of the changes
+	for i := 0; i < 2; i++ {
+		if err := driver.Sync(ctx, c); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		key := types.NamespacedName{Namespace: "bench", Name: fmt.Sprintf("ingress-%d", i%count)}
+		ingress := &netv1.Ingress{}
+		if err := c.Get(ctx, key, ingress); err != nil {
+			b.Fatal(err)
+		}
+		ingress.Spec.Rules[0].HTTP.Paths[0].Path = fmt.Sprintf("/%d", i)
+		if err := c.Update(ctx, ingress); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := driver.UpdateIngress(ingress); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err := driver.Sync(ctx, c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSyncFull(b *testing.B) {
+	for _, count := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("ingresses=%d", count), func(b *testing.B) {
+			benchmarkSyncOneIngressChange(b, count)
+		})
+	}
+}
+
+func BenchmarkSyncIncremental(b *testing.B) {
+	for _, count := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("ingresses=%d", count), func(b *testing.B) {
+			benchmarkSyncOneIngressChange(b, count, WithIncrementalSync(true))
+		})
+	}
+}
+
+// BenchmarkSyncDebounce measures bursts of concurrent sync requests, such as the reconciles of a rollout, and reports
+// the number of syncs each burst ends in. Requests returning errSyncDone are retried, as they're requeued in the
+// operator.
+func BenchmarkSyncDebounce(b *testing.B) {
+	const burst = 20
+
+	for _, window := range []time.Duration{0, time.Millisecond, 10 * time.Millisecond} {
+		b.Run(fmt.Sprintf("window=%s", window), func(b *testing.B) {
+			ctx := context.Background()
+			scheme := benchmarkScheme()
+
+			var syncs atomic.Int64
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(benchmarkObjects(10)...).WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					// every sync lists the tunnels once
+					if _, ok := list.(*ingressv1alpha1.TunnelList); ok {
+						syncs.Add(1)
+					}
+					return c.List(ctx, list, opts...)
+				},
+			}).Build()
+
+			driver := NewDriver(logr.Discard(), scheme, defaultControllerName, types.NamespacedName{Name: defaultManagerName}, WithSyncDebounce(window))
+			if err := driver.Seed(ctx, c); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			syncs.Store(0)
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				for j := 0; j < burst; j++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for {
+							err := driver.Sync(ctx, c)
+							if errors.Is(err, errSyncDone) {
+								continue
+							}
+							if err != nil {
+								b.Error(err)
+							}
+							return
+						}
+					}()
+				}
+				wg.Wait()
+			}
+			b.ReportMetric(float64(syncs.Load())/float64(b.N), "syncs/burst")
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// annotationSyncStatus is set on the Ingresses, Gateways and HTTPRoutes the driver translates. It's either
+// syncStatusSynced or the errors found while translating the resource, so they can be debugged without the operator logs.
+const annotationSyncStatus = "ngrok.com/sync-status"
+
+const (
+	syncStatusSynced      = "synced"
+	syncStatusErrorPrefix = "error: "
+)
+
+// syncSource identifies the resource a sync error belongs to
+type syncSource struct {
+	Kind string
+	types.NamespacedName
+}
+
+// syncErrors collects the errors found while translating resources during a sync, keyed by the resource they
+// belong to. The same error found several times during a sync is only recorded once.
+type syncErrors struct {
+	mu   sync.Mutex
+	errs map[syncSource][]string
+}
+
+func newSyncErrors() *syncErrors {
+	return &syncErrors{errs: map[syncSource][]string{}}
+}
+
+// reset forgets the errors of the previous sync
+func (e *syncErrors) reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errs = map[syncSource][]string{}
+}
+
+// add records an error for the resource of the given kind, it returns false when the error was already recorded
+func (e *syncErrors) add(kind string, key types.NamespacedName, err error) bool {
+	source := syncSource{Kind: kind, NamespacedName: key}
+	msg := err.Error()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, existing := range e.errs[source] {
+		if existing == msg {
+			return false
+		}
+	}
+	e.errs[source] = append(e.errs[source], msg)
+	return true
+}
+
+// get returns the sorted errors recorded for the resource of the given kind
+func (e *syncErrors) get(kind string, obj client.Object) []string {
+	source := syncSource{Kind: kind, NamespacedName: client.ObjectKeyFromObject(obj)}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	errs := append([]string(nil), e.errs[source]...)
+	sort.Strings(errs)
+	return errs
+}
+
+// all returns every recorded error, prefixed by the resource it belongs to, sorted
+func (e *syncErrors) all() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var all []string
+	for source, errs := range e.errs {
+		for _, err := range errs {
+			all = append(all, fmt.Sprintf("%s %s: %s", source.Kind, source.NamespacedName, err))
+		}
+	}
+	sort.Strings(all)
+	return all
+}
+
+// syncStatus returns the value of the sync status annotation for the given errors
+func syncStatus(errs []string) string {
+	if len(errs) == 0 {
+		return syncStatusSynced
+	}
+	return syncStatusErrorPrefix + strings.Join(errs, "; ")
+}
+
+// recordSyncError records an error found while translating a resource so it's reported on the resource, and logs it
+// the first time it's found during a sync
+func (d *Driver) recordSyncError(kind string, key types.NamespacedName, err error, msg string, keysAndValues ...interface{}) {
+	wrapped := fmt.Errorf("%s", msg)
+	if err != nil {
+		wrapped = fmt.Errorf("%s: %w", msg, err)
+	}
+	if d.syncErrors.add(kind, key, wrapped) {
+		d.log.Error(err, msg, append([]interface{}{strings.ToLower(kind), key}, keysAndValues...)...)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 func NewTestIngressClass(name string, isDefault bool, isNgrok bool) netv1.IngressClass {
@@ -140,3 +143,155 @@ func NewTestNgrokTrafficPolicy(name string, namespace string, policyStr string)
 		},
 	}
 }
+
+func NewTestGatewayClass(name string, isNgrok bool) gatewayv1.GatewayClass {
+	gwc := gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: GatewayControllerName,
+		},
+	}
+
+	if !isNgrok {
+		gwc.Spec.ControllerName = "example.com/other-gateway-controller"
+	}
+
+	return gwc
+}
+
+func NewTestGateway(name string, namespace string, listeners ...gatewayv1.Listener) gatewayv1.Gateway {
+	return gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: "ngrok",
+			Listeners:        listeners,
+		},
+	}
+}
+
+func NewTestTCPRoute(name string, namespace string, gatewayName string, serviceName string, port int32) gatewayv1alpha2.TCPRoute {
+	return gatewayv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: gatewayv1alpha2.TCPRouteSpec{
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{
+					{Name: gatewayv1.ObjectName(gatewayName)},
+				},
+			},
+			Rules: []gatewayv1alpha2.TCPRouteRule{
+				{
+					BackendRefs: []gatewayv1.BackendRef{
+						{
+							BackendObjectReference: gatewayv1.BackendObjectReference{
+								Kind: ptr.To(gatewayv1.Kind("Service")),
+								Name: gatewayv1.ObjectName(serviceName),
+								Port: ptr.To(gatewayv1.PortNumber(port)),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func NewTestTLSRoute(name string, namespace string, gatewayName string, hostname string, serviceName string, port int32) gatewayv1alpha2.TLSRoute {
+	return gatewayv1alpha2.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: gatewayv1alpha2.TLSRouteSpec{
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{
+					{Name: gatewayv1.ObjectName(gatewayName)},
+				},
+			},
+			Hostnames: []gatewayv1.Hostname{gatewayv1.Hostname(hostname)},
+			Rules: []gatewayv1alpha2.TLSRouteRule{
+				{
+					BackendRefs: []gatewayv1.BackendRef{
+						{
+							BackendObjectReference: gatewayv1.BackendObjectReference{
+								Kind: ptr.To(gatewayv1.Kind("Service")),
+								Name: gatewayv1.ObjectName(serviceName),
+								Port: ptr.To(gatewayv1.PortNumber(port)),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func NewTestHTTPRoute(name string, namespace string, gatewayName string, hostname string, backendRefs ...gatewayv1.HTTPBackendRef) gatewayv1.HTTPRoute {
+	return gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{
+					{Name: gatewayv1.ObjectName(gatewayName)},
+				},
+			},
+			Hostnames: []gatewayv1.Hostname{gatewayv1.Hostname(hostname)},
+			Rules: []gatewayv1.HTTPRouteRule{
+				{
+					BackendRefs: backendRefs,
+				},
+			},
+		},
+	}
+}
+
+func NewTestHTTPBackendRef(serviceName string, port int32, weight int32) gatewayv1.HTTPBackendRef {
+	return gatewayv1.HTTPBackendRef{
+		BackendRef: gatewayv1.BackendRef{
+			BackendObjectReference: gatewayv1.BackendObjectReference{
+				Kind: ptr.To(gatewayv1.Kind("Service")),
+				Name: gatewayv1.ObjectName(serviceName),
+				Port: ptr.To(gatewayv1.PortNumber(port)),
+			},
+			Weight: ptr.To(weight),
+		},
+	}
+}
+
+func NewTestGRPCRoute(name string, namespace string, gatewayName string, hostname string, backendRefs ...gatewayv1alpha2.GRPCBackendRef) gatewayv1alpha2.GRPCRoute {
+	return gatewayv1alpha2.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: gatewayv1alpha2.GRPCRouteSpec{
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{
+					{Name: gatewayv1.ObjectName(gatewayName)},
+				},
+			},
+			Hostnames: []gatewayv1.Hostname{gatewayv1.Hostname(hostname)},
+			Rules: []gatewayv1alpha2.GRPCRouteRule{
+				{
+					BackendRefs: backendRefs,
+				},
+			},
+		},
+	}
+}
+
+func NewTestGRPCBackendRef(serviceName string, port int32, weight int32) gatewayv1alpha2.GRPCBackendRef {
+	return gatewayv1alpha2.GRPCBackendRef{
+		BackendRef: NewTestHTTPBackendRef(serviceName, port, weight).BackendRef,
+	}
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/parser"
+)
+
+// maxCELDepth bounds the nesting of the expressions ValidateCELSyntax parses, as the CEL parser of the ngrok API does
+const maxCELDepth = 250
+
+// celParser parses the expressions with the standard macros, such as has() and all(), as the ngrok API does
+var celParser = func() *parser.Parser {
+	p, err := parser.NewParser(
+		parser.Macros(parser.AllMacros...),
+		parser.MaxRecursionDepth(maxCELDepth),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}()
+
+// ValidateCELSyntax checks that expr is a syntactically valid CEL expression. It doesn't check that the variables and
+// functions it uses exist, as those depend on the phase the expression is evaluated in.
+func ValidateCELSyntax(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return fmt.Errorf("expression is empty")
+	}
+
+	_, errs := celParser.Parse(common.NewTextSource(expr))
+	if len(errs.GetErrors()) == 0 {
+		return nil
+	}
+	// The first error is enough to fix the expression, the parser often reports the same mistake several times
+	err := errs.GetErrors()[0]
+	msg := strings.TrimPrefix(err.Message, "Syntax error: ")
+	if err.Location.Line() < 1 {
+		// errors such as the recursion limit being exceeded aren't tied to a position
+		return fmt.Errorf("syntax error: %s", msg)
+	}
+	return fmt.Errorf("syntax error at line %d, column %d: %s", err.Location.Line(), err.Location.Column()+1, msg)
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCELSyntax(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{
+		`req.method == "GET"`,
+		`!(req.url.path.startsWith("/api/") && req.headers["x-foo"][0] == 'bar')`,
+		`req.url.path.matches("^/v[0-9]+/")`,
+		`conn.client_ip in ["10.0.0.1", "10.0.0.2",]`,
+		`size(req.headers) > 0u ? true : false`,
+		`{"a": 1, "b": -2.5e3}.a + 0x1F >= 3`,
+		`google.protobuf.Duration{seconds: 10} != null`,
+		`r"\d+" + '''multi
+line''' + b"bytes"`,
+		`.req.url // a comment`,
+		"req.url.path == \"/\\x00\\u1234\\\"\"",
+	}
+	for _, expr := range valid {
+		assert.NoError(t, ValidateCELSyntax(expr), expr)
+	}
+
+	invalid := map[string]string{
+		``:                         "expression is empty",
+		`req.method = "GET"`:       `syntax error at line 1, column 12: token recognition error at: '= '`,
+		`req.method == "GET`:       `syntax error at line 1, column 15: token recognition error at: '"GET'`,
+		`(req.method == "GET"`:     "syntax error at line 1, column 21: missing ')' at '<EOF>'",
+		`req.method == "GET")`:     "syntax error at line 1, column 20: extraneous input ')' expecting <EOF>",
+		`req.url. == "a"`:          "syntax error at line 1, column 10: no viable alternative at input '. =='",
+		`true ? 1`:                 "syntax error at line 1, column 9: mismatched input '<EOF>' expecting ':'",
+		`1abc > 0`:                 "syntax error at line 1, column 2: mismatched input 'abc' expecting <EOF>",
+		`{"a" 1}`:                  "syntax error at line 1, column 6: missing ':' at '1'",
+		`req.headers["a"]{foo: 1}`: "syntax error at line 1, column 17: mismatched input '{' expecting <EOF>",
+		"req.method == 'GET' &&\n  req.url.path = '/'": "syntax error at line 2, column 16: token recognition error at: '= '",
+	}
+	for _, expr := range []string{`req.headers[]`, `a && in`, `f(1,,2)`} {
+		assert.ErrorContains(t, ValidateCELSyntax(expr), "syntax error at line 1", expr)
+	}
+	for expr, msg := range invalid {
+		err := ValidateCELSyntax(expr)
+		if assert.Error(t, err, expr) {
+			assert.Equal(t, msg, err.Error(), expr)
+		}
+	}
+
+	deep := strings.Repeat("(", 300) + "1" + strings.Repeat(")", 300)
+	assert.EqualError(t, ValidateCELSyntax(deep), "syntax error: expression recursion limit exceeded: 250")
+}
@@ -1,5 +1,7 @@
 package util
 
+import "slices"
+
 // MergeMaps merges multiple maps into a single map giving precedence to the last map in the list
 func MergeMaps[K comparable, V any](maps ...map[K]V) map[K]V {
 	result := make(map[K]V)
@@ -10,3 +12,13 @@ func MergeMaps[K comparable, V any](maps ...map[K]V) map[K]V {
 	}
 	return result
 }
+
+// sortedKeys returns the keys of the map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
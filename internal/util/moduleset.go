@@ -0,0 +1,352 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ModuleSetResolver resolves the references of a module set to the values its traffic policy needs
+type ModuleSetResolver interface {
+	// ResolveSecret returns the value of the key of a secret in the namespace of the module set
+	ResolveSecret(ctx context.Context, ref ingressv1alpha1.SecretKeyRef) (string, error)
+	// ResolveIPPolicies returns the IDs of the IP policies, referenced by the name of their IPPolicy or by their ID
+	ResolveIPPolicies(ctx context.Context, namesOrIDs []string) ([]string, error)
+}
+
+// UnsupportedModuleError is returned for the module settings that have no traffic policy equivalent. These are
+// settings that restrict access, so dropping them would expose endpoints that the module set protects.
+type UnsupportedModuleError struct {
+	Module string
+	Reason string
+}
+
+func (e *UnsupportedModuleError) Error() string {
+	return fmt.Sprintf("the %s module can't be converted to traffic policy: %s", e.Module, e.Reason)
+}
+
+type restrictIPsConfig struct {
+	IPPolicies []string `json:"ip_policies"`
+}
+
+type oauthConfig struct {
+	Provider                string   `json:"provider"`
+	ClientID                *string  `json:"client_id,omitempty"`
+	ClientSecret            *string  `json:"client_secret,omitempty"`
+	Scopes                  []string `json:"scopes,omitempty"`
+	MaxSessionDuration      string   `json:"max_session_duration,omitempty"`
+	IdleSessionTimeout      string   `json:"idle_session_timeout,omitempty"`
+	UserinfoRefreshInterval string   `json:"userinfo_refresh_interval,omitempty"`
+	AllowCORSPreflight      bool     `json:"allow_cors_preflight,omitempty"`
+}
+
+type openIDConnectConfig struct {
+	IssuerURL          string   `json:"issuer_url"`
+	ClientID           string   `json:"client_id"`
+	ClientSecret       string   `json:"client_secret"`
+	Scopes             []string `json:"scopes,omitempty"`
+	MaxSessionDuration string   `json:"max_session_duration,omitempty"`
+	IdleSessionTimeout string   `json:"idle_session_timeout,omitempty"`
+	AllowCORSPreflight bool     `json:"allow_cors_preflight,omitempty"`
+}
+
+type verifyWebhookConfig struct {
+	Provider string `json:"provider"`
+	Secret   string `json:"secret,omitempty"`
+}
+
+type circuitBreakerConfig struct {
+	ErrorThreshold  float64 `json:"error_threshold,omitempty"`
+	VolumeThreshold uint32  `json:"volume_threshold,omitempty"`
+	WindowDuration  string  `json:"window_duration,omitempty"`
+	TrippedDuration string  `json:"tripped_duration,omitempty"`
+	NumBuckets      uint32  `json:"num_buckets,omitempty"`
+}
+
+type headersConfig struct {
+	Headers any `json:"headers"`
+}
+
+type terminateTLSConfig struct {
+	MinVersion *string `json:"min_version,omitempty"`
+}
+
+// ModuleSetToTrafficPolicy converts the modules of a module set to the equivalent traffic policy, so that module sets
+// can be used by the resources that only accept traffic policy, such as CloudEndpoints.
+//
+// The rules of the policy enforce the modules in the order the edge routes do: IP restriction, authentication,
+// webhook verification, circuit breaking, then the headers, the rules of the policy module and compression. The
+// cookie prefix of the authentication modules has no equivalent and is ignored. An UnsupportedModuleError is returned
+// for SAML, mutual TLS, TLS termination at the upstream and GitHub teams and organizations.
+func ModuleSetToTrafficPolicy(ctx context.Context, modules ingressv1alpha1.NgrokModuleSetModules, resolver ModuleSetResolver) (TrafficPolicy, error) {
+	if modules.SAML != nil {
+		return nil, &UnsupportedModuleError{Module: "saml", Reason: "traffic policy has no SAML action"}
+	}
+	if modules.MutualTLS != nil && len(modules.MutualTLS.CertificateAuthorities) > 0 {
+		return nil, &UnsupportedModuleError{Module: "mutualTLS", Reason: "the certificate authorities are referenced by ID, while traffic policy needs their certificates"}
+	}
+
+	c := &moduleSetConverter{policy: NewTrafficPolicy(), resolver: resolver}
+	for _, convert := range []func(context.Context, ingressv1alpha1.NgrokModuleSetModules) error{
+		c.convertTLSTermination,
+		c.convertIPRestriction,
+		c.convertOAuth,
+		c.convertOIDC,
+		c.convertWebhookVerification,
+		c.convertCircuitBreaker,
+		c.convertRequestHeaders,
+		c.convertPolicy,
+		c.convertResponseHeaders,
+		c.convertCompression,
+	} {
+		if err := convert(ctx, modules); err != nil {
+			return nil, err
+		}
+	}
+	return c.policy, nil
+}
+
+type moduleSetConverter struct {
+	policy   TrafficPolicy
+	resolver ModuleSetResolver
+}
+
+// addRule adds a rule with a single action to the phase of the policy
+func (c *moduleSetConverter) addRule(phase, name string, expressions []string, actionType string, config any) error {
+	action := EndpointAction{Type: actionType, Config: RawConfig(`{}`)}
+	if config != nil {
+		rawConfig, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+		action.Config = rawConfig
+	}
+
+	rawAction, err := json.Marshal(&action)
+	if err != nil {
+		return err
+	}
+	return c.policy.MergeEndpointRule(EndpointRule{
+		Name:        name,
+		Expressions: expressions,
+		Actions:     []RawAction{rawAction},
+	}, phase)
+}
+
+func (c *moduleSetConverter) convertTLSTermination(_ context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	tls := modules.TLSTermination
+	if tls == nil {
+		return nil
+	}
+	if tls.TerminateAt == "upstream" {
+		return &UnsupportedModuleError{Module: "tlsTermination", Reason: "traffic policy can't pass TLS through to the upstream"}
+	}
+	if tls.MinVersion == nil {
+		return nil
+	}
+	return c.addRule(PhaseOnTcpConnect, "TLS Termination", nil, "terminate-tls", terminateTLSConfig{MinVersion: tls.MinVersion})
+}
+
+func (c *moduleSetConverter) convertIPRestriction(ctx context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	if modules.IPRestriction == nil || len(modules.IPRestriction.IPPolicies) == 0 {
+		return nil
+	}
+	ids, err := c.resolver.ResolveIPPolicies(ctx, modules.IPRestriction.IPPolicies)
+	if err != nil {
+		return err
+	}
+	return c.addRule(PhaseOnHttpRequest, "IP Restriction", nil, "restrict-ips", restrictIPsConfig{IPPolicies: ids})
+}
+
+func (c *moduleSetConverter) convertOAuth(ctx context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	if modules.OAuth == nil {
+		return nil
+	}
+
+	oauth := modules.OAuth
+	var provider string
+	var common *ingressv1alpha1.OAuthProviderCommon
+	switch {
+	case oauth.Github != nil:
+		if len(oauth.Github.Teams) > 0 || len(oauth.Github.Organizations) > 0 {
+			return &UnsupportedModuleError{Module: "oauth", Reason: "traffic policy can't check GitHub teams and organizations"}
+		}
+		provider, common = "github", &oauth.Github.OAuthProviderCommon
+	case oauth.Facebook != nil:
+		provider, common = "facebook", &oauth.Facebook.OAuthProviderCommon
+	case oauth.Microsoft != nil:
+		provider, common = "microsoft", &oauth.Microsoft.OAuthProviderCommon
+	case oauth.Google != nil:
+		provider, common = "google", &oauth.Google.OAuthProviderCommon
+	case oauth.Linkedin != nil:
+		provider, common = "linkedin", &oauth.Linkedin.OAuthProviderCommon
+	case oauth.Gitlab != nil:
+		provider, common = "gitlab", &oauth.Gitlab.OAuthProviderCommon
+	case oauth.Twitch != nil:
+		provider, common = "twitch", &oauth.Twitch.OAuthProviderCommon
+	case oauth.Amazon != nil:
+		provider, common = "amazon", &oauth.Amazon.OAuthProviderCommon
+	default:
+		return nil
+	}
+
+	config := oauthConfig{
+		Provider:                provider,
+		ClientID:                common.ClientID,
+		Scopes:                  common.Scopes,
+		MaxSessionDuration:      durationString(common.MaximumDuration),
+		IdleSessionTimeout:      durationString(common.InactivityTimeout),
+		UserinfoRefreshInterval: durationString(common.AuthCheckInterval),
+		AllowCORSPreflight:      common.OptionsPassthrough,
+	}
+	if common.ClientSecret != nil {
+		secret, err := c.resolver.ResolveSecret(ctx, *common.ClientSecret)
+		if err != nil {
+			return err
+		}
+		config.ClientSecret = &secret
+	}
+	if err := c.addRule(PhaseOnHttpRequest, "OAuth", nil, "oauth", config); err != nil {
+		return err
+	}
+
+	// The edges only let the users with the listed email addresses or domains through, when there are some
+	if len(common.EmailAddresses) == 0 && len(common.EmailDomains) == 0 {
+		return nil
+	}
+	var allowed []string
+	if len(common.EmailAddresses) > 0 {
+		quoted := make([]string, 0, len(common.EmailAddresses))
+		for _, address := range common.EmailAddresses {
+			quoted = append(quoted, strconv.Quote(address))
+		}
+		allowed = append(allowed, fmt.Sprintf("actions.ngrok.oauth.identity.email in [%s]", strings.Join(quoted, ", ")))
+	}
+	for _, domain := range common.EmailDomains {
+		allowed = append(allowed, fmt.Sprintf("actions.ngrok.oauth.identity.email.endsWith(%s)", strconv.Quote("@"+domain)))
+	}
+	return c.addRule(PhaseOnHttpRequest, "OAuth Authorization", []string{fmt.Sprintf("!(%s)", strings.Join(allowed, " || "))}, "deny", nil)
+}
+
+func (c *moduleSetConverter) convertOIDC(ctx context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	oidc := modules.OIDC
+	if oidc == nil {
+		return nil
+	}
+
+	secret, err := c.resolver.ResolveSecret(ctx, oidc.ClientSecret)
+	if err != nil {
+		return err
+	}
+	return c.addRule(PhaseOnHttpRequest, "OIDC", nil, "openid-connect", openIDConnectConfig{
+		IssuerURL:          oidc.Issuer,
+		ClientID:           oidc.ClientID,
+		ClientSecret:       secret,
+		Scopes:             oidc.Scopes,
+		MaxSessionDuration: durationString(oidc.MaximumDuration),
+		IdleSessionTimeout: durationString(oidc.InactivityTimeout),
+		AllowCORSPreflight: oidc.OptionsPassthrough,
+	})
+}
+
+func (c *moduleSetConverter) convertWebhookVerification(ctx context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	verification := modules.WebhookVerification
+	if verification == nil {
+		return nil
+	}
+
+	config := verifyWebhookConfig{Provider: verification.Provider}
+	// AWS SNS doesn't use a secret
+	if verification.SecretRef != nil {
+		secret, err := c.resolver.ResolveSecret(ctx, *verification.SecretRef)
+		if err != nil {
+			return err
+		}
+		config.Secret = secret
+	}
+	return c.addRule(PhaseOnHttpRequest, "Webhook Verification", nil, "verify-webhook", config)
+}
+
+func (c *moduleSetConverter) convertCircuitBreaker(_ context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	cb := modules.CircuitBreaker
+	if cb == nil {
+		return nil
+	}
+	return c.addRule(PhaseOnHttpRequest, "Circuit Breaker", nil, "circuit-breaker", circuitBreakerConfig{
+		ErrorThreshold:  cb.ErrorThresholdPercentage.AsApproximateFloat64(),
+		VolumeThreshold: cb.VolumeThreshold,
+		WindowDuration:  durationString(cb.RollingWindow),
+		TrippedDuration: durationString(cb.TrippedDuration),
+		NumBuckets:      cb.NumBuckets,
+	})
+}
+
+func (c *moduleSetConverter) convertRequestHeaders(_ context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	if modules.Headers == nil || modules.Headers.Request == nil {
+		return nil
+	}
+	return c.convertHeaders(PhaseOnHttpRequest, "Request Headers", modules.Headers.Request.Remove, modules.Headers.Request.Add)
+}
+
+func (c *moduleSetConverter) convertResponseHeaders(_ context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	if modules.Headers == nil || modules.Headers.Response == nil {
+		return nil
+	}
+	return c.convertHeaders(PhaseOnHttpResponse, "Response Headers", modules.Headers.Response.Remove, modules.Headers.Response.Add)
+}
+
+// convertHeaders removes the headers before adding the others, so that the added headers replace the removed ones
+func (c *moduleSetConverter) convertHeaders(phase, name string, remove []string, add map[string]string) error {
+	if len(remove) > 0 {
+		if err := c.addRule(phase, "Remove "+name, nil, "remove-headers", headersConfig{Headers: remove}); err != nil {
+			return err
+		}
+	}
+	if len(add) > 0 {
+		if err := c.addRule(phase, "Add "+name, nil, "add-headers", headersConfig{Headers: add}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertPolicy merges the rules of the deprecated policy module, converting its directions to phases
+func (c *moduleSetConverter) convertPolicy(_ context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	policy := modules.Policy
+	if policy == nil || (policy.Enabled != nil && !*policy.Enabled) {
+		return nil
+	}
+
+	rules := &ingressv1alpha1.EndpointPolicy{Inbound: policy.Inbound, Outbound: policy.Outbound}
+	rawPolicy, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	modulePolicy, err := NewTrafficPolicyFromJson(rawPolicy)
+	if err != nil {
+		return err
+	}
+	modulePolicy.ConvertLegacyDirectionsToPhases()
+	c.policy.Merge(modulePolicy)
+	return nil
+}
+
+func (c *moduleSetConverter) convertCompression(_ context.Context, modules ingressv1alpha1.NgrokModuleSetModules) error {
+	if modules.Compression == nil || !modules.Compression.Enabled {
+		return nil
+	}
+	return c.addRule(PhaseOnHttpResponse, "Compression", nil, "compress-response", nil)
+}
+
+// durationString formats the duration for traffic policy, or returns an empty string for the unset durations
+func durationString(d metav1.Duration) string {
+	if d.Duration == 0 {
+		return ""
+	}
+	return d.Duration.String()
+}
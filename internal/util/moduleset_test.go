@@ -0,0 +1,158 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+type fakeModuleSetResolver struct{}
+
+func (fakeModuleSetResolver) ResolveSecret(_ context.Context, ref ingressv1alpha1.SecretKeyRef) (string, error) {
+	return ref.Name + "-" + ref.Key, nil
+}
+
+func (fakeModuleSetResolver) ResolveIPPolicies(_ context.Context, namesOrIDs []string) ([]string, error) {
+	ids := make([]string, 0, len(namesOrIDs))
+	for _, name := range namesOrIDs {
+		ids = append(ids, "ipp_"+name)
+	}
+	return ids, nil
+}
+
+func TestModuleSetToTrafficPolicy(t *testing.T) {
+	t.Parallel()
+
+	modules := ingressv1alpha1.NgrokModuleSetModules{
+		CircuitBreaker: &ingressv1alpha1.EndpointCircuitBreaker{
+			TrippedDuration:          metav1.Duration{Duration: time.Minute},
+			RollingWindow:            metav1.Duration{Duration: 10 * time.Second},
+			NumBuckets:               10,
+			VolumeThreshold:          20,
+			ErrorThresholdPercentage: resource.MustParse("0.5"),
+		},
+		Compression: &ingressv1alpha1.EndpointCompression{Enabled: true},
+		Headers: &ingressv1alpha1.EndpointHeaders{
+			Request:  &ingressv1alpha1.EndpointRequestHeaders{Add: map[string]string{"X-Foo": "bar"}, Remove: []string{"X-Bar"}},
+			Response: &ingressv1alpha1.EndpointResponseHeaders{Remove: []string{"Server"}},
+		},
+		IPRestriction: &ingressv1alpha1.EndpointIPPolicy{IPPolicies: []string{"office"}},
+		OAuth: &ingressv1alpha1.EndpointOAuth{
+			Google: &ingressv1alpha1.EndpointOAuthGoogle{OAuthProviderCommon: ingressv1alpha1.OAuthProviderCommon{
+				ClientID:        ptr.To("client"),
+				ClientSecret:    &ingressv1alpha1.SecretKeyRef{Name: "google", Key: "secret"},
+				MaximumDuration: metav1.Duration{Duration: 24 * time.Hour},
+				EmailAddresses:  []string{"alice@example.com"},
+				EmailDomains:    []string{"ngrok.com"},
+			}},
+		},
+		Policy: &ingressv1alpha1.EndpointPolicy{
+			Inbound: []ingressv1alpha1.EndpointRule{{
+				Name:        "Deny admin",
+				Expressions: []string{`req.url.path.startsWith("/admin")`},
+				Actions:     []ingressv1alpha1.EndpointAction{{Type: "deny"}},
+			}},
+		},
+		TLSTermination:      &ingressv1alpha1.EndpointTLSTermination{MinVersion: ptr.To("1.2")},
+		WebhookVerification: &ingressv1alpha1.EndpointWebhookVerification{Provider: "github", SecretRef: &ingressv1alpha1.SecretKeyRef{Name: "github", Key: "token"}},
+	}
+
+	policy, err := ModuleSetToTrafficPolicy(context.Background(), modules, fakeModuleSetResolver{})
+	require.NoError(t, err)
+	actual, err := policy.ToAPIJson()
+	require.NoError(t, err)
+
+	expected := `{
+		"on_tcp_connect": [
+			{"name": "TLS Termination", "actions": [{"type": "terminate-tls", "config": {"min_version": "1.2"}}]}
+		],
+		"on_http_request": [
+			{"name": "IP Restriction", "actions": [{"type": "restrict-ips", "config": {"ip_policies": ["ipp_office"]}}]},
+			{"name": "OAuth", "actions": [{"type": "oauth", "config": {"provider": "google", "client_id": "client", "client_secret": "google-secret", "max_session_duration": "24h0m0s"}}]},
+			{"name": "OAuth Authorization", "expressions": ["!(actions.ngrok.oauth.identity.email in [\"alice@example.com\"] || actions.ngrok.oauth.identity.email.endsWith(\"@ngrok.com\"))"], "actions": [{"type": "deny", "config": {}}]},
+			{"name": "Webhook Verification", "actions": [{"type": "verify-webhook", "config": {"provider": "github", "secret": "github-token"}}]},
+			{"name": "Circuit Breaker", "actions": [{"type": "circuit-breaker", "config": {"error_threshold": 0.5, "volume_threshold": 20, "window_duration": "10s", "tripped_duration": "1m0s", "num_buckets": 10}}]},
+			{"name": "Remove Request Headers", "actions": [{"type": "remove-headers", "config": {"headers": ["X-Bar"]}}]},
+			{"name": "Add Request Headers", "actions": [{"type": "add-headers", "config": {"headers": {"X-Foo": "bar"}}}]},
+			{"name": "Deny admin", "expressions": ["req.url.path.startsWith(\"/admin\")"], "actions": [{"type": "deny"}]}
+		],
+		"on_http_response": [
+			{"name": "Remove Response Headers", "actions": [{"type": "remove-headers", "config": {"headers": ["Server"]}}]},
+			{"name": "Compression", "actions": [{"type": "compress-response", "config": {}}]}
+		]
+	}`
+	assert.JSONEq(t, expected, string(actual))
+
+	errs, _ := ValidateTrafficPolicy(actual, field.NewPath("policy"))
+	assert.Empty(t, errs, "the generated policy is valid")
+}
+
+func TestModuleSetToTrafficPolicyUnsupported(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		modules ingressv1alpha1.NgrokModuleSetModules
+		module  string
+	}{
+		{
+			name:    "SAML",
+			modules: ingressv1alpha1.NgrokModuleSetModules{SAML: &ingressv1alpha1.EndpointSAML{IdPMetadata: "<xml/>"}},
+			module:  "saml",
+		},
+		{
+			name:    "mutual TLS",
+			modules: ingressv1alpha1.NgrokModuleSetModules{MutualTLS: &ingressv1alpha1.EndpointMutualTLS{CertificateAuthorities: []string{"ca_123"}}},
+			module:  "mutualTLS",
+		},
+		{
+			name:    "TLS termination at the upstream",
+			modules: ingressv1alpha1.NgrokModuleSetModules{TLSTermination: &ingressv1alpha1.EndpointTLSTermination{TerminateAt: "upstream"}},
+			module:  "tlsTermination",
+		},
+		{
+			name: "GitHub teams",
+			modules: ingressv1alpha1.NgrokModuleSetModules{OAuth: &ingressv1alpha1.EndpointOAuth{
+				Github: &ingressv1alpha1.EndpointOAuthGitHub{Teams: []string{"ngrok/eng"}},
+			}},
+			module: "oauth",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ModuleSetToTrafficPolicy(context.Background(), tc.modules, fakeModuleSetResolver{})
+			var unsupported *UnsupportedModuleError
+			require.True(t, errors.As(err, &unsupported), "unexpected error %v", err)
+			assert.Equal(t, tc.module, unsupported.Module)
+		})
+	}
+}
+
+func TestModuleSetToTrafficPolicyDisabledPolicy(t *testing.T) {
+	t.Parallel()
+
+	modules := ingressv1alpha1.NgrokModuleSetModules{
+		Policy: &ingressv1alpha1.EndpointPolicy{
+			Enabled: ptr.To(false),
+			Inbound: []ingressv1alpha1.EndpointRule{{Actions: []ingressv1alpha1.EndpointAction{{Type: "deny"}}}},
+		},
+	}
+	policy, err := ModuleSetToTrafficPolicy(context.Background(), modules, fakeModuleSetResolver{})
+	require.NoError(t, err)
+	actual, err := policy.ToAPIJson()
+	require.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`{}`), actual)
+}
@@ -5,6 +5,7 @@ import (
 )
 
 const (
+	PhaseOnTcpConnect   = "on_tcp_connect"
 	PhaseOnHttpRequest  = "on_http_request"
 	PhaseOnHttpResponse = "on_http_response"
 
@@ -30,8 +31,9 @@ type EndpointAction struct {
 }
 
 type EndpointRule struct {
-	Name    string      `json:"name"`
-	Actions []RawAction `json:"actions"`
+	Name        string      `json:"name"`
+	Expressions []string    `json:"expressions,omitempty"`
+	Actions     []RawAction `json:"actions"`
 }
 
 // RawRule exists to make generic raw json/map manipulation more legible. It adds no additional functionality on top of RawMessage.
@@ -473,6 +473,25 @@ func TestMergeEndpointRule(t *testing.T) {
 					},
 				}},
 		},
+		{
+			name: "rule with expressions",
+			addedRule: EndpointRule{
+				Name:        "test-rule",
+				Expressions: []string{"req.method == 'POST'"},
+				Actions: []RawAction{
+					[]byte(`{"c":"d"}`),
+				},
+			},
+			addedPhase: PhaseOnHttpRequest,
+			expectedMergedTrafficPolicy: trafficPolicyImpl{
+				trafficPolicy: map[string][]RawRule{
+					PhaseOnHttpRequest: {
+						[]byte(`a`),
+						[]byte(`{"name":"test-rule","expressions":["req.method == 'POST'"],"actions":[{"c":"d"}]}`),
+					},
+				},
+			},
+		},
 		{
 			name: "malformed json",
 			addedRule: EndpointRule{
@@ -0,0 +1,346 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// TrafficPolicyPhases are the phases of a traffic policy document
+var TrafficPolicyPhases = []string{PhaseOnTcpConnect, PhaseOnHttpRequest, PhaseOnHttpResponse}
+
+// configField is the type of a field in the config of a traffic policy action
+type configField int
+
+const (
+	stringField configField = iota
+	boolField
+	intField
+	stringListField
+	stringMapField
+	objectField
+)
+
+func (f configField) String() string {
+	switch f {
+	case stringField:
+		return "a string"
+	case boolField:
+		return "a boolean"
+	case intField:
+		return "an integer"
+	case stringListField:
+		return "a list of strings"
+	case stringMapField:
+		return "an object of strings"
+	default:
+		return "an object"
+	}
+}
+
+// matches checks value, as decoded by encoding/json, against the type of the field
+func (f configField) matches(value any) bool {
+	switch f {
+	case stringField:
+		_, ok := value.(string)
+		return ok
+	case boolField:
+		_, ok := value.(bool)
+		return ok
+	case intField:
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case stringListField:
+		l, ok := value.([]any)
+		return ok && !slices.ContainsFunc(l, func(v any) bool { return !stringField.matches(v) })
+	case stringMapField:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return false
+		}
+		for _, v := range m {
+			if !stringField.matches(v) {
+				return false
+			}
+		}
+		return true
+	default:
+		_, ok := value.(map[string]any)
+		return ok
+	}
+}
+
+// actionSchema describes what the ngrok API accepts for a traffic policy action
+type actionSchema struct {
+	// phases are the phases the action can be used in
+	phases []string
+	// config are the fields of the action's config. The config of the actions whose config is nil isn't checked.
+	config map[string]configField
+	// required are the config fields the action can't do without
+	required []string
+}
+
+var (
+	requestPhases = []string{PhaseOnHttpRequest}
+	httpPhases    = []string{PhaseOnHttpRequest, PhaseOnHttpResponse}
+)
+
+// trafficPolicyActions are the traffic policy actions the operator knows about
+var trafficPolicyActions = map[string]actionSchema{
+	"add-headers": {
+		phases:   httpPhases,
+		config:   map[string]configField{"headers": stringMapField},
+		required: []string{"headers"},
+	},
+	"basic-auth": {
+		phases:   requestPhases,
+		config:   map[string]configField{"credentials": stringListField, "realm": stringField, "enforce": boolField},
+		required: []string{"credentials"},
+	},
+	"circuit-breaker":  {phases: requestPhases},
+	"close-connection": {phases: TrafficPolicyPhases},
+	"compress-response": {
+		phases: []string{PhaseOnHttpResponse},
+		config: map[string]configField{"algorithms": stringListField},
+	},
+	"custom-response": {
+		phases:   httpPhases,
+		config:   map[string]configField{"status_code": intField, "content": stringField, "body": stringField, "headers": stringMapField},
+		required: []string{"status_code"},
+	},
+	"deny": {
+		phases: TrafficPolicyPhases,
+		config: map[string]configField{"status_code": intField},
+	},
+	"forward-internal": {
+		phases:   []string{PhaseOnTcpConnect, PhaseOnHttpRequest},
+		config:   map[string]configField{"url": stringField, "binding": stringField, "on_error": stringField},
+		required: []string{"url"},
+	},
+	"http-request":   {phases: httpPhases},
+	"jwt-validation": {phases: requestPhases},
+	"log": {
+		phases: TrafficPolicyPhases,
+		config: map[string]configField{"metadata": objectField},
+	},
+	"oauth":          {phases: requestPhases},
+	"openid-connect": {phases: requestPhases},
+	"rate-limit": {
+		phases:   requestPhases,
+		config:   map[string]configField{"name": stringField, "algorithm": stringField, "capacity": intField, "rate": stringField, "bucket_key": stringListField},
+		required: []string{"algorithm", "capacity", "rate", "bucket_key"},
+	},
+	"redirect": {
+		phases:   httpPhases,
+		config:   map[string]configField{"from": stringField, "to": stringField, "status_code": intField, "headers": stringMapField},
+		required: []string{"to"},
+	},
+	"remove-headers": {
+		phases:   httpPhases,
+		config:   map[string]configField{"headers": stringListField},
+		required: []string{"headers"},
+	},
+	"restrict-ips": {
+		phases: []string{PhaseOnTcpConnect, PhaseOnHttpRequest},
+		config: map[string]configField{"enforce": boolField, "allow": stringListField, "deny": stringListField, "ip_policies": stringListField},
+	},
+	"set-vars":      {phases: TrafficPolicyPhases},
+	"terminate-tls": {phases: []string{PhaseOnTcpConnect}},
+	"url-rewrite": {
+		phases:   requestPhases,
+		config:   map[string]configField{"from": stringField, "to": stringField, "format": stringField},
+		required: []string{"to"},
+	},
+	"verify-webhook": {
+		phases:   requestPhases,
+		config:   map[string]configField{"provider": stringField, "secret": stringField, "enforce": boolField},
+		required: []string{"provider", "secret"},
+	},
+}
+
+// ValidateTrafficPolicy checks the traffic policy document msg beyond it parsing: its phase names, the types and
+// configs of its actions, and the syntax of the CEL expressions of its rules. The errors are what the ngrok API would
+// reject the policy for. The warnings are about the legacy options, and the actions and config fields the operator
+// doesn't know about, which newer versions of the ngrok API may support.
+func ValidateTrafficPolicy(msg json.RawMessage, path *field.Path) (field.ErrorList, []string) {
+	v := validateTrafficPolicy(msg, path)
+	return v.errs, v.warnings
+}
+
+// ValidateTrafficPolicySyntax is ValidateTrafficPolicy for callers that only reject the policies that can't be
+// parsed: the policy not being valid JSON or YAML, and the CEL expressions with syntax errors. The other errors are
+// returned as warnings along with the warnings of ValidateTrafficPolicy, as the operator's knowledge of the actions
+// and their configs may lag behind the ngrok API.
+func ValidateTrafficPolicySyntax(msg json.RawMessage, path *field.Path) (field.ErrorList, []string) {
+	v := validateTrafficPolicy(msg, path)
+	warnings := v.warnings
+	for _, err := range v.errs {
+		if !slices.Contains(v.syntaxErrs, err) {
+			warnings = append(warnings, err.Error())
+		}
+	}
+	return v.syntaxErrs, warnings
+}
+
+func validateTrafficPolicy(msg json.RawMessage, path *field.Path) *policyValidator {
+	v := &policyValidator{}
+	if len(msg) == 0 {
+		return v
+	}
+
+	policy, err := NewTrafficPolicyFromJson(msg)
+	if err != nil {
+		v.syntaxError(field.Invalid(path, string(msg), err.Error()))
+		return v
+	}
+
+	if policy.IsLegacyPolicy() {
+		v.warn(path, "the legacy directions 'inbound' and 'outbound' are deprecated, use the phases 'on_tcp_connect', 'on_http_request' and 'on_http_response'")
+	}
+	if policy.Enabled() != nil {
+		v.warn(path, "'enabled' is a legacy option that will stop being supported soon")
+	}
+
+	phases := policy.Deconstruct()
+	for _, phase := range sortedKeys(phases) {
+		// The legacy directions are converted to the HTTP phases before the policy is sent to the ngrok API
+		effectivePhase := phase
+		switch phase {
+		case LegacyPhaseInbound:
+			effectivePhase = PhaseOnHttpRequest
+		case LegacyPhaseOutbound:
+			effectivePhase = PhaseOnHttpResponse
+		}
+		if !slices.Contains(TrafficPolicyPhases, effectivePhase) {
+			v.errs = append(v.errs, field.NotSupported(path.Child(phase), phase, TrafficPolicyPhases))
+			continue
+		}
+
+		for i, rule := range phases[phase] {
+			v.validateRule(path.Child(phase).Index(i), effectivePhase, rule)
+		}
+	}
+	return v
+}
+
+type policyValidator struct {
+	errs     field.ErrorList
+	warnings []string
+	// syntaxErrs are the errors of errs that keep the policy from being parsed at all
+	syntaxErrs field.ErrorList
+}
+
+func (v *policyValidator) syntaxError(err *field.Error) {
+	v.errs = append(v.errs, err)
+	v.syntaxErrs = append(v.syntaxErrs, err)
+}
+
+func (v *policyValidator) warn(path *field.Path, format string, args ...any) {
+	v.warnings = append(v.warnings, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *policyValidator) validateRule(path *field.Path, phase string, raw RawRule) {
+	var rule map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &rule); err != nil || rule == nil {
+		v.errs = append(v.errs, field.Invalid(path, string(raw), "must be an object"))
+		return
+	}
+
+	for _, key := range sortedKeys(rule) {
+		switch key {
+		case "name":
+			var name string
+			if err := json.Unmarshal(rule[key], &name); err != nil {
+				v.errs = append(v.errs, field.Invalid(path.Child(key), string(rule[key]), "must be a string"))
+			}
+		case "expressions":
+			var expressions []string
+			if err := json.Unmarshal(rule[key], &expressions); err != nil {
+				v.errs = append(v.errs, field.Invalid(path.Child(key), string(rule[key]), "must be a list of strings"))
+				continue
+			}
+			for i, expression := range expressions {
+				if err := ValidateCELSyntax(expression); err != nil {
+					v.syntaxError(field.Invalid(path.Child(key).Index(i), expression, err.Error()))
+				}
+			}
+		case "actions":
+			var actions []json.RawMessage
+			if err := json.Unmarshal(rule[key], &actions); err != nil {
+				v.errs = append(v.errs, field.Invalid(path.Child(key), string(rule[key]), "must be a list"))
+				continue
+			}
+			if len(actions) == 0 {
+				v.errs = append(v.errs, field.Required(path.Child(key), "a rule must have at least one action"))
+			}
+			for i, action := range actions {
+				v.validateAction(path.Child(key).Index(i), phase, action)
+			}
+		default:
+			v.warn(path.Child(key), "unknown field")
+		}
+	}
+
+	if _, ok := rule["actions"]; !ok {
+		v.errs = append(v.errs, field.Required(path.Child("actions"), "a rule must have at least one action"))
+	}
+}
+
+func (v *policyValidator) validateAction(path *field.Path, phase string, raw json.RawMessage) {
+	var action map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &action); err != nil || action == nil {
+		v.errs = append(v.errs, field.Invalid(path, string(raw), "must be an object"))
+		return
+	}
+
+	for _, key := range sortedKeys(action) {
+		if key != "type" && key != "config" {
+			v.warn(path.Child(key), "unknown field")
+		}
+	}
+
+	var actionType string
+	if err := json.Unmarshal(action["type"], &actionType); err != nil || actionType == "" {
+		v.errs = append(v.errs, field.Required(path.Child("type"), "must be the type of the action"))
+		return
+	}
+
+	schema, ok := trafficPolicyActions[actionType]
+	if !ok {
+		v.warn(path.Child("type"), "unknown action type %q", actionType)
+		return
+	}
+	if !slices.Contains(schema.phases, phase) {
+		v.errs = append(v.errs, field.Invalid(path.Child("type"), actionType, fmt.Sprintf("the action is not supported in the %s phase", phase)))
+	}
+
+	var config map[string]any
+	if raw, ok := action["config"]; ok {
+		if err := json.Unmarshal(raw, &config); err != nil {
+			v.errs = append(v.errs, field.Invalid(path.Child("config"), string(raw), "must be an object"))
+			return
+		}
+	}
+	if schema.config == nil {
+		return
+	}
+
+	configPath := path.Child("config")
+	for _, key := range sortedKeys(config) {
+		kind, known := schema.config[key]
+		switch {
+		case !known:
+			v.warn(configPath.Child(key), "unknown field of the %s action", actionType)
+		case config[key] != nil && !kind.matches(config[key]):
+			v.errs = append(v.errs, field.Invalid(configPath.Child(key), config[key], "must be "+kind.String()))
+		}
+	}
+	for _, key := range schema.required {
+		if config[key] == nil {
+			v.errs = append(v.errs, field.Required(configPath.Child(key), fmt.Sprintf("required by the %s action", actionType)))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateTrafficPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		policy   string
+		errors   []string
+		warnings []string
+	}{
+		{
+			name: "valid",
+			policy: `{
+				"on_http_request": [{
+					"name": "Reject non GET requests",
+					"expressions": ["req.method != 'GET'"],
+					"actions": [{"type": "custom-response", "config": {"status_code": 405, "content": "Method Not Allowed"}}]
+				}, {
+					"actions": [{"type": "redirect", "config": {"to": "https://example.com", "status_code": null}}]
+				}],
+				"on_http_response": [{"actions": [{"type": "add-headers", "config": {"headers": {"x-foo": "bar"}}}]}],
+				"on_tcp_connect": [{"actions": [{"type": "restrict-ips", "config": {"allow": ["10.0.0.0/8"]}}]}]
+			}`,
+		},
+		{
+			name:   "empty",
+			policy: ``,
+		},
+		{
+			name:   "not a policy",
+			policy: `["deny"]`,
+			errors: []string{`spec.policy: Invalid value: "[\"deny\"]": json: cannot unmarshal array into Go value of type map[string]interface {}`},
+		},
+		{
+			name:   "unknown phase",
+			policy: `{"on_http_requests": []}`,
+			errors: []string{`spec.policy.on_http_requests: Unsupported value: "on_http_requests": supported values: "on_tcp_connect", "on_http_request", "on_http_response"`},
+		},
+		{
+			name:     "legacy policy",
+			policy:   `{"enabled": true, "inbound": [{"actions": [{"type": "deny"}]}], "outbound": [{"actions": [{"type": "compress-response"}]}]}`,
+			warnings: []string{"spec.policy: the legacy directions 'inbound' and 'outbound' are deprecated, use the phases 'on_tcp_connect', 'on_http_request' and 'on_http_response'", "spec.policy: 'enabled' is a legacy option that will stop being supported soon"},
+		},
+		{
+			name:   "invalid rules",
+			policy: `{"on_http_request": ["deny", {"name": 1, "expressions": ["req.method = 'GET'"], "actions": []}, {"expressions": "true"}]}`,
+			errors: []string{
+				`spec.policy.on_http_request[0]: Invalid value: "\"deny\"": must be an object`,
+				`spec.policy.on_http_request[1].actions: Required value: a rule must have at least one action`,
+				`spec.policy.on_http_request[1].expressions[0]: Invalid value: "req.method = 'GET'": syntax error at line 1, column 12: token recognition error at: '= '`,
+				`spec.policy.on_http_request[1].name: Invalid value: "1": must be a string`,
+				`spec.policy.on_http_request[2].expressions: Invalid value: "\"true\"": must be a list of strings`,
+				`spec.policy.on_http_request[2].actions: Required value: a rule must have at least one action`,
+			},
+		},
+		{
+			name: "invalid actions",
+			policy: `{"on_http_request": [{"actions": [
+				{"config": {}},
+				{"type": "add-headers", "config": {"headers": ["x-foo"]}},
+				{"type": "rate-limit", "config": {"name": "limit", "capacity": 1.5, "rate": "60s", "bucket_key": ["conn.client_ip"]}},
+				{"type": "compress-response"}
+			]}]}`,
+			errors: []string{
+				`spec.policy.on_http_request[0].actions[0].type: Required value: must be the type of the action`,
+				`spec.policy.on_http_request[0].actions[1].config.headers: Invalid value: []interface {}{"x-foo"}: must be an object of strings`,
+				`spec.policy.on_http_request[0].actions[2].config.capacity: Invalid value: 1.5: must be an integer`,
+				`spec.policy.on_http_request[0].actions[2].config.algorithm: Required value: required by the rate-limit action`,
+				`spec.policy.on_http_request[0].actions[3].type: Invalid value: "compress-response": the action is not supported in the on_http_request phase`,
+			},
+		},
+		{
+			name:   "unknown actions and fields",
+			policy: `{"on_http_request": [{"id": "rule", "actions": [{"type": "teleport"}, {"type": "deny", "config": {"status_code": 404, "reason": "gone"}}]}]}`,
+			warnings: []string{
+				`spec.policy.on_http_request[0].actions[0].type: unknown action type "teleport"`,
+				`spec.policy.on_http_request[0].actions[1].config.reason: unknown field of the deny action`,
+				`spec.policy.on_http_request[0].id: unknown field`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			errs, warnings := ValidateTrafficPolicy(json.RawMessage(tc.policy), field.NewPath("spec", "policy"))
+
+			var errStrings []string
+			for _, err := range errs {
+				errStrings = append(errStrings, err.Error())
+			}
+			assert.Equal(t, tc.errors, errStrings)
+			assert.Equal(t, tc.warnings, warnings)
+		})
+	}
+}
+
+func TestValidateTrafficPolicySyntax(t *testing.T) {
+	t.Parallel()
+
+	path := field.NewPath("spec", "policy")
+
+	errs, warnings := ValidateTrafficPolicySyntax(json.RawMessage(`{
+		"on_http_request": [{"expressions": ["req.method == 'GET'"], "actions": [{"type": "new-action"}]}],
+		"on_http_connect": []
+	}`), path)
+	assert.Empty(t, errs)
+	assert.Equal(t, []string{
+		`spec.policy.on_http_request[0].actions[0].type: unknown action type "new-action"`,
+		`spec.policy.on_http_connect: Unsupported value: "on_http_connect": supported values: "on_tcp_connect", "on_http_request", "on_http_response"`,
+	}, warnings)
+
+	errs, warnings = ValidateTrafficPolicySyntax(json.RawMessage(`{
+		"on_http_request": [{"expressions": ["req.method = 'GET'"]}]
+	}`), path)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, `spec.policy.on_http_request[0].expressions[0]: Invalid value: "req.method = 'GET'": syntax error at line 1, column 12: token recognition error at: '= '`, errs[0].Error())
+	}
+	assert.Equal(t, []string{"spec.policy.on_http_request[0].actions: Required value: a rule must have at least one action"}, warnings)
+
+	errs, _ = ValidateTrafficPolicySyntax(json.RawMessage(`{"on_http_request": {}}`), path)
+	assert.Len(t, errs, 1)
+}
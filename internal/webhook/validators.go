@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
+	"github.com/ngrok/ngrok-operator/internal/annotations"
+	"github.com/ngrok/ngrok-operator/internal/util"
+)
+
+func validateCloudEndpoint(obj client.Object) (field.ErrorList, admission.Warnings) {
+	clep, ok := obj.(*ngrokv1alpha1.CloudEndpoint)
+	if !ok {
+		return nil, nil
+	}
+
+	specPath := field.NewPath("spec")
+	if clep.Spec.TrafficPolicyName != "" && clep.Spec.TrafficPolicy != nil {
+		return field.ErrorList{
+			field.Forbidden(specPath.Child("trafficPolicy"), "trafficPolicy and trafficPolicyName are mutually exclusive"),
+		}, nil
+	}
+	if clep.Spec.TrafficPolicy != nil {
+		return validatePolicy(specPath.Child("trafficPolicy", "policy"), clep.Spec.TrafficPolicy.Policy)
+	}
+	return nil, nil
+}
+
+func validateTrafficPolicy(obj client.Object) (field.ErrorList, admission.Warnings) {
+	policy, ok := obj.(*ngrokv1alpha1.NgrokTrafficPolicy)
+	if !ok {
+		return nil, nil
+	}
+	return validatePolicy(field.NewPath("spec", "policy"), policy.Spec.Policy)
+}
+
+// validatePolicy rejects the traffic policies that don't parse, and returns the other findings of the
+// NgrokTrafficPolicy controller as warnings, so that the policies using actions newer than the operator still apply
+func validatePolicy(path *field.Path, policy json.RawMessage) (field.ErrorList, admission.Warnings) {
+	return util.ValidateTrafficPolicySyntax(policy, path)
+}
+
+func validateAnnotations(obj client.Object) (field.ErrorList, admission.Warnings) {
+	return annotations.Validate(obj), nil
+}
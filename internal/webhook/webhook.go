@@ -0,0 +1,126 @@
+// Package webhook implements the validating admission webhooks that reject invalid ngrok CRs and ngrok annotations
+// when they're applied, rather than when the controllers reconcile them.
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
+)
+
+// validateFunc returns the errors of an object, and warnings about the parts of it that are deprecated
+type validateFunc func(obj client.Object) (field.ErrorList, admission.Warnings)
+
+// SetupWithManager registers the validating webhooks of the CloudEndpoints, NgrokTrafficPolicies, Ingresses and
+// Services with the webhook server of the manager
+func SetupWithManager(mgr ctrl.Manager) error {
+	webhooks := []struct {
+		obj      client.Object
+		validate validateFunc
+	}{
+		{&ngrokv1alpha1.CloudEndpoint{}, validateCloudEndpoint},
+		{&ngrokv1alpha1.NgrokTrafficPolicy{}, validateTrafficPolicy},
+		{&netv1.Ingress{}, validateAnnotations},
+		{&corev1.Service{}, validateAnnotations},
+	}
+
+	for _, wh := range webhooks {
+		gvk, err := apiutil.GVKForObject(wh.obj, mgr.GetScheme())
+		if err != nil {
+			return err
+		}
+		v := &validator{scheme: mgr.GetScheme(), validate: wh.validate}
+		if err := ctrl.NewWebhookManagedBy(mgr).For(wh.obj).WithValidator(v).Complete(); err != nil {
+			return fmt.Errorf("unable to create the %s webhook: %w", gvk.Kind, err)
+		}
+	}
+	return nil
+}
+
+// validator adapts a validateFunc to the admission.CustomValidator interface
+type validator struct {
+	scheme   *runtime.Scheme
+	validate validateFunc
+}
+
+func (v *validator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	o, ok := obj.(client.Object)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T", obj)
+	}
+
+	errs, warnings := v.validate(o)
+	return warnings, v.invalid(o, errs)
+}
+
+func (v *validator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	o, ok := newObj.(client.Object)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T", newObj)
+	}
+	old, ok := oldObj.(client.Object)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T", oldObj)
+	}
+
+	// Objects being deleted must be let through, for their finalizers to be removed
+	if o.GetDeletionTimestamp() != nil {
+		return nil, nil
+	}
+
+	errs, warnings := v.validate(o)
+	if len(errs) == 0 {
+		return warnings, nil
+	}
+
+	// The objects created before the webhook was enabled are only rejected once an update adds errors, so that the
+	// controllers can still update them, such as to add their finalizers
+	oldErrs, _ := v.validate(old)
+	if sameErrors(errs, oldErrs) {
+		for _, err := range errs {
+			warnings = append(warnings, err.Error())
+		}
+		return warnings, nil
+	}
+
+	return warnings, v.invalid(o, errs)
+}
+
+func (v *validator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *validator) invalid(obj client.Object, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	gvk, err := apiutil.GVKForObject(obj, v.scheme)
+	if err != nil {
+		return err
+	}
+	return apierrors.NewInvalid(gvk.GroupKind(), obj.GetName(), errs)
+}
+
+func sameErrors(a, b field.ErrorList) bool {
+	return slices.Equal(errorStrings(a), errorStrings(b))
+}
+
+func errorStrings(errs field.ErrorList) []string {
+	s := make([]string, 0, len(errs))
+	for _, err := range errs {
+		s = append(s, err.Error())
+	}
+	return s
+}
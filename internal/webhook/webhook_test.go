@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	ngrokv1alpha1 "github.com/ngrok/ngrok-operator/api/ngrok/v1alpha1"
+)
+
+func newValidator(t *testing.T, validate validateFunc) *validator {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, ngrokv1alpha1.AddToScheme(scheme))
+	return &validator{scheme: scheme, validate: validate}
+}
+
+func TestCloudEndpointWebhook(t *testing.T) {
+	ctx := context.Background()
+	v := newValidator(t, validateCloudEndpoint)
+
+	clep := &ngrokv1alpha1.CloudEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "clep", Namespace: "default"},
+		Spec: ngrokv1alpha1.CloudEndpointSpec{
+			URL:               "https://example.ngrok.app",
+			TrafficPolicyName: "policy",
+			TrafficPolicy:     &ngrokv1alpha1.NgrokTrafficPolicySpec{Policy: json.RawMessage(`{"on_http_request":[]}`)},
+		},
+	}
+	_, err := v.ValidateCreate(ctx, clep)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Equal(t, `CloudEndpoint.ngrok.k8s.ngrok.com "clep" is invalid: spec.trafficPolicy: Forbidden: trafficPolicy and trafficPolicyName are mutually exclusive`, err.Error())
+
+	clep.Spec.TrafficPolicyName = ""
+	warnings, err := v.ValidateCreate(ctx, clep)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	clep.Spec.TrafficPolicy.Policy = json.RawMessage(`{"inbound":[]}`)
+	warnings, err = v.ValidateCreate(ctx, clep)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+}
+
+func TestTrafficPolicyWebhook(t *testing.T) {
+	ctx := context.Background()
+	v := newValidator(t, validateTrafficPolicy)
+
+	policy := &ngrokv1alpha1.NgrokTrafficPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "default"},
+		Spec:       ngrokv1alpha1.NgrokTrafficPolicySpec{Policy: json.RawMessage(`{"on_http_request":"not a list"}`)},
+	}
+	_, err := v.ValidateCreate(ctx, policy)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsInvalid(err))
+
+	policy.Spec.Policy = json.RawMessage(`{"enabled":true,"on_http_request":[]}`)
+	warnings, err := v.ValidateCreate(ctx, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"spec.policy: 'enabled' is a legacy option that will stop being supported soon"}, []string(warnings))
+
+	policy.Spec.Policy = json.RawMessage(`{"on_http_request":[{"actions":[{"type":"new-action"}]}]}`)
+	warnings, err = v.ValidateCreate(ctx, policy)
+	assert.NoError(t, err, "policies the operator doesn't know enough about are let through")
+	assert.Len(t, warnings, 1)
+
+	policy.Spec.Policy = json.RawMessage(`{"on_http_request":[{"expressions":["req.method = 'GET'"],"actions":[{"type":"deny"}]}]}`)
+	_, err = v.ValidateCreate(ctx, policy)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsInvalid(err))
+}
+
+func TestAnnotationsWebhook(t *testing.T) {
+	ctx := context.Background()
+	v := newValidator(t, validateAnnotations)
+
+	ing := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ing",
+			Namespace:   "default",
+			Annotations: map[string]string{"k8s.ngrok.com/traffic-policy": "a,b"},
+		},
+	}
+	_, err := v.ValidateCreate(ctx, ing)
+	require.Error(t, err)
+	assert.Equal(t, `Ingress.networking.k8s.io "ing" is invalid: metadata.annotations[k8s.ngrok.com/traffic-policy]: Invalid value: "a,b": multiple traffic policies are not supported: [a b]`, err.Error())
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "svc",
+			Namespace:   "default",
+			Annotations: map[string]string{"k8s.ngrok.com/app-protocols": "{"},
+		},
+	}
+	_, err = v.ValidateCreate(ctx, svc)
+	require.Error(t, err)
+	assert.Equal(t, `Service "svc" is invalid: metadata.annotations[k8s.ngrok.com/app-protocols]: Invalid value: "{": must be a JSON object of port names to protocols`, err.Error())
+}
+
+func TestWebhookUpdates(t *testing.T) {
+	ctx := context.Background()
+	v := newValidator(t, validateAnnotations)
+
+	valid := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "default"}}
+	invalid := valid.DeepCopy()
+	invalid.Annotations = map[string]string{"k8s.ngrok.com/app-protocols": "{"}
+
+	_, err := v.ValidateUpdate(ctx, valid, invalid)
+	assert.Error(t, err, "updates adding errors are rejected")
+
+	withFinalizer := invalid.DeepCopy()
+	withFinalizer.Finalizers = []string{"k8s.ngrok.com/finalizer"}
+	warnings, err := v.ValidateUpdate(ctx, invalid, withFinalizer)
+	assert.NoError(t, err, "updates of objects that were already invalid are let through")
+	assert.Len(t, warnings, 1)
+
+	deleting := invalid.DeepCopy()
+	deleting.DeletionTimestamp = &metav1.Time{}
+	_, err = v.ValidateUpdate(ctx, valid, deleting)
+	assert.NoError(t, err, "objects being deleted are let through")
+}
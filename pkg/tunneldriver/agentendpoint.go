@@ -0,0 +1,85 @@
+package tunneldriver
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"reflect"
+
+	"golang.ngrok.com/ngrok"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// AgentEndpointOpts are the options of an agent endpoint
+type AgentEndpointOpts struct {
+	// URL is the URL the endpoint listens on, public or internal
+	URL string
+	// ForwardsTo is the host and port of the upstream service
+	ForwardsTo string
+	// Protocol is how the agent connects to the upstream service, HTTP or HTTPS
+	Protocol string
+	// AppProtocol is the application protocol of the upstream service. Currently only supports `http2`
+	AppProtocol string
+	// ClientCertificates are presented to the upstream service when the agent connects to it over TLS
+	ClientCertificates []tls.Certificate
+}
+
+type agentEndpoint struct {
+	tun  ngrok.Tunnel
+	opts AgentEndpointOpts
+}
+
+// CreateAgentEndpoint starts listening on the URL of an agent endpoint and forwarding its connections in a goroutine,
+// and returns the URL the ngrok service assigned to it. If an agent endpoint with the same name already exists, it
+// will be stopped and replaced with a new one unless its options match.
+func (td *TunnelDriver) CreateAgentEndpoint(ctx context.Context, name string, opts AgentEndpointOpts) (string, error) {
+	session, err := td.getSession()
+	if err != nil {
+		return "", err
+	}
+
+	log := log.FromContext(ctx)
+
+	if existing, ok := td.agentEndpoints[name]; ok {
+		if reflect.DeepEqual(existing.opts, opts) {
+			log.Info("Agent endpoint options match existing agent endpoint, doing nothing")
+			return tunnelURL(existing.tun), nil
+		}
+		// There is already an agent endpoint with this name, start the new one and defer closing the old one
+		//nolint:errcheck
+		defer td.stopTunnel(context.Background(), existing.tun)
+	}
+
+	tunnelConfig, err := td.buildEndpointConfig(opts.URL, opts.ForwardsTo, opts.AppProtocol)
+	if err != nil {
+		return "", err
+	}
+	tun, err := session.Listen(ctx, tunnelConfig)
+	if err != nil {
+		return "", err
+	}
+	td.agentEndpoints[name] = &agentEndpoint{tun: tun, opts: opts}
+	agentEndpointsGauge.Set(float64(len(td.agentEndpoints)))
+
+	go handleConnections(ctx, &net.Dialer{}, tun, opts.ForwardsTo, opts.Protocol, opts.AppProtocol, opts.ClientCertificates)
+	return tunnelURL(tun), nil
+}
+
+// DeleteAgentEndpoint stops and deletes an agent endpoint
+func (td *TunnelDriver) DeleteAgentEndpoint(ctx context.Context, name string) error {
+	log := log.FromContext(ctx).WithValues("name", name)
+
+	existing, ok := td.agentEndpoints[name]
+	if !ok {
+		log.Info("Agent endpoint not found while trying to delete agent endpoint")
+		return nil
+	}
+
+	if err := td.stopTunnel(ctx, existing.tun); err != nil {
+		return err
+	}
+	delete(td.agentEndpoints, name)
+	agentEndpointsGauge.Set(float64(len(td.agentEndpoints)))
+	log.Info("Agent endpoint deleted successfully")
+	return nil
+}
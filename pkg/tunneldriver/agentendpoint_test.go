@@ -0,0 +1,154 @@
+package tunneldriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ngrok/ngrok-operator/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.ngrok.com/ngrok"
+	"golang.ngrok.com/ngrok/config"
+)
+
+func testAgentEndpointOpts() AgentEndpointOpts {
+	return AgentEndpointOpts{
+		URL:         "https://api.internal",
+		ForwardsTo:  "api.default.svc.cluster.local:8443",
+		Protocol:    "HTTPS",
+		AppProtocol: "http2",
+	}
+}
+
+func TestCreateAgentEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSession := mocks.NewMockSession(ctrl)
+	tun := mocks.NewMockTunnel(ctrl)
+	tun.EXPECT().URL().Return("https://api.internal").AnyTimes()
+
+	mockSession.EXPECT().Listen(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tunnel config.Tunnel) (ngrok.Tunnel, error) {
+		cfg, ok := tunnel.(tunnelConfig)
+		require.True(t, ok)
+		assert.Equal(t, "https", cfg.Proto())
+		assert.Equal(t, "api.default.svc.cluster.local:8443", cfg.ForwardsTo())
+		assert.Equal(t, "http2", cfg.ForwardsProto())
+		return tun, nil
+	})
+	handled := expectHandled(tun)
+
+	td := newTestTunnelDriver(mockSession)
+	url, err := td.CreateAgentEndpoint(context.Background(), "default/api", testAgentEndpointOpts())
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.internal", url)
+	require.Contains(t, td.agentEndpoints, "default/api")
+	assert.Same(t, tun, td.agentEndpoints["default/api"].tun)
+	<-handled
+	ctrl.Finish()
+}
+
+func TestCreateAgentEndpointKeepsTheEndpointOfTheSameOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSession := mocks.NewMockSession(ctrl)
+	existing := mocks.NewMockTunnel(ctrl)
+	existing.EXPECT().URL().Return("https://api.internal").AnyTimes()
+
+	td := newTestTunnelDriver(mockSession)
+	td.agentEndpoints["default/api"] = &agentEndpoint{tun: existing, opts: testAgentEndpointOpts()}
+
+	url, err := td.CreateAgentEndpoint(context.Background(), "default/api", testAgentEndpointOpts())
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.internal", url)
+	assert.Same(t, existing, td.agentEndpoints["default/api"].tun)
+	ctrl.Finish()
+}
+
+func TestCreateAgentEndpointReplacesTheEndpointOfOtherOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSession := mocks.NewMockSession(ctrl)
+	existing := mocks.NewMockTunnel(ctrl)
+	replacement := mocks.NewMockTunnel(ctrl)
+	replacement.EXPECT().URL().Return("https://api.internal").AnyTimes()
+
+	gomock.InOrder(
+		mockSession.EXPECT().Listen(gomock.Any(), gomock.Any()).Return(replacement, nil),
+		existing.EXPECT().CloseWithContext(gomock.Any()).Return(nil),
+	)
+	handled := expectHandled(replacement)
+
+	previous := testAgentEndpointOpts()
+	previous.ForwardsTo = "api.default.svc.cluster.local:80"
+	td := newTestTunnelDriver(mockSession)
+	td.agentEndpoints["default/api"] = &agentEndpoint{tun: existing, opts: previous}
+
+	_, err := td.CreateAgentEndpoint(context.Background(), "default/api", testAgentEndpointOpts())
+	require.NoError(t, err)
+	assert.Same(t, replacement, td.agentEndpoints["default/api"].tun)
+	assert.Equal(t, testAgentEndpointOpts(), td.agentEndpoints["default/api"].opts)
+	<-handled
+	ctrl.Finish()
+}
+
+func TestCreateAgentEndpointErrors(t *testing.T) {
+	t.Run("invalid URL", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		td := newTestTunnelDriver(mocks.NewMockSession(ctrl))
+
+		opts := testAgentEndpointOpts()
+		opts.URL = "tcp://api.internal:443"
+		_, err := td.CreateAgentEndpoint(context.Background(), "default/api", opts)
+		assert.Error(t, err)
+		assert.Empty(t, td.agentEndpoints)
+		ctrl.Finish()
+	})
+
+	t.Run("listen error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockSession := mocks.NewMockSession(ctrl)
+		mockSession.EXPECT().Listen(gomock.Any(), gomock.Any()).Return(nil, errors.New("endpoint already online"))
+		td := newTestTunnelDriver(mockSession)
+
+		_, err := td.CreateAgentEndpoint(context.Background(), "default/api", testAgentEndpointOpts())
+		assert.EqualError(t, err, "endpoint already online")
+		assert.Empty(t, td.agentEndpoints)
+		ctrl.Finish()
+	})
+
+	t.Run("no session", func(t *testing.T) {
+		td := &TunnelDriver{agentEndpoints: map[string]*agentEndpoint{}}
+		td.session.Store(&sessionState{readyErr: errors.New("attempting to connect")})
+
+		_, err := td.CreateAgentEndpoint(context.Background(), "default/api", testAgentEndpointOpts())
+		assert.EqualError(t, err, "attempting to connect")
+	})
+}
+
+func TestDeleteAgentEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	existing := mocks.NewMockTunnel(ctrl)
+	existing.EXPECT().CloseWithContext(gomock.Any()).Return(nil)
+
+	td := newTestTunnelDriver(mocks.NewMockSession(ctrl))
+	td.agentEndpoints["default/api"] = &agentEndpoint{tun: existing, opts: testAgentEndpointOpts()}
+
+	require.NoError(t, td.DeleteAgentEndpoint(context.Background(), "default/api"))
+	assert.Empty(t, td.agentEndpoints)
+
+	// deleting an agent endpoint that doesn't exist is a no-op
+	require.NoError(t, td.DeleteAgentEndpoint(context.Background(), "default/api"))
+	ctrl.Finish()
+}
+
+func TestDeleteAgentEndpointKeepsTheEndpointItCantClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	existing := mocks.NewMockTunnel(ctrl)
+	existing.EXPECT().CloseWithContext(gomock.Any()).Return(errors.New("session closed"))
+
+	td := newTestTunnelDriver(mocks.NewMockSession(ctrl))
+	td.agentEndpoints["default/api"] = &agentEndpoint{tun: existing, opts: testAgentEndpointOpts()}
+
+	assert.EqualError(t, td.DeleteAgentEndpoint(context.Background(), "default/api"), "session closed")
+	assert.Contains(t, td.agentEndpoints, "default/api")
+	ctrl.Finish()
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -47,8 +48,10 @@ const (
 
 // TunnelDriver is a driver for creating and deleting ngrok tunnels
 type TunnelDriver struct {
-	session atomic.Pointer[sessionState]
-	tunnels map[string]ngrok.Tunnel
+	session        atomic.Pointer[sessionState]
+	sessionChanges chan struct{}
+	tunnels        map[string]ngrok.Tunnel
+	agentEndpoints map[string]*agentEndpoint
 }
 
 // TunnelDriverOpts are options for creating a new TunnelDriver
@@ -67,6 +70,8 @@ type sessionState struct {
 	session   ngrok.Session
 	readyErr  error
 	healthErr error
+	// disconnectErr is the error the established session disconnected with, until it reconnects
+	disconnectErr error
 }
 
 // New creates and initializes a new TunnelDriver
@@ -124,7 +129,9 @@ func New(ctx context.Context, logger logr.Logger, opts TunnelDriverOpts) (*Tunne
 	}
 
 	td := &TunnelDriver{
-		tunnels: make(map[string]ngrok.Tunnel),
+		sessionChanges: make(chan struct{}, 1),
+		tunnels:        make(map[string]ngrok.Tunnel),
+		agentEndpoints: make(map[string]*agentEndpoint),
 	}
 
 	td.session.Store(&sessionState{
@@ -132,52 +139,10 @@ func New(ctx context.Context, logger logr.Logger, opts TunnelDriverOpts) (*Tunne
 	})
 	connOpts = append(connOpts,
 		ngrok.WithConnectHandler(func(ctx context.Context, sess ngrok.Session) {
-			td.session.Store(&sessionState{
-				session: sess,
-			})
+			td.connected(sess)
 		}),
 		ngrok.WithDisconnectHandler(func(ctx context.Context, sess ngrok.Session, err error) {
-			state := td.session.Load()
-
-			if state.session != nil {
-				// we have established session in the past, so record err only when it is going away
-				if err == nil {
-					td.session.Store(&sessionState{
-						healthErr: fmt.Errorf("session closed"),
-					})
-				}
-				return
-			}
-
-			if err == nil {
-				// session is disconnecting, do not override error
-				if state.healthErr == nil {
-					td.session.Store(&sessionState{
-						healthErr: fmt.Errorf("session closed"),
-					})
-				}
-				return
-			}
-
-			if state.healthErr != nil {
-				// we are already at a terminal error, just keep the first one
-				return
-			}
-
-			// we didn't have a session and we are seeing disconnect error
-			userErr := strings.HasPrefix(err.Error(), "authentication failed") && !strings.Contains(err.Error(), "internal server error")
-			if userErr {
-				// its a user error (e.g. authentication failure), so stop further
-				td.session.Store(&sessionState{
-					healthErr: err,
-				})
-				sess.Close()
-			} else {
-				// mark this as connecting error to return from readyz
-				td.session.Store(&sessionState{
-					readyErr: err,
-				})
-			}
+			td.disconnected(sess, err)
 		}),
 	)
 	//nolint:errcheck
@@ -186,6 +151,89 @@ func New(ctx context.Context, logger logr.Logger, opts TunnelDriverOpts) (*Tunne
 	return td, nil
 }
 
+// connected records the session once the agent connects, or reconnects, to the ngrok service
+func (td *TunnelDriver) connected(sess ngrok.Session) {
+	td.session.Store(&sessionState{
+		session: sess,
+	})
+	td.notifySessionChange()
+}
+
+// disconnected records why the session disconnected from the ngrok service
+func (td *TunnelDriver) disconnected(sess ngrok.Session, err error) {
+	defer td.notifySessionChange()
+
+	state := td.session.Load()
+
+	if state.session != nil {
+		// we have established session in the past, so record err only when it is going away
+		if err == nil {
+			td.session.Store(&sessionState{
+				healthErr: fmt.Errorf("session closed"),
+			})
+			return
+		}
+		// the session reconnects, keep it until then
+		td.session.Store(&sessionState{
+			session:       state.session,
+			disconnectErr: err,
+		})
+		return
+	}
+
+	if err == nil {
+		// session is disconnecting, do not override error
+		if state.healthErr == nil {
+			td.session.Store(&sessionState{
+				healthErr: fmt.Errorf("session closed"),
+			})
+		}
+		return
+	}
+
+	if state.healthErr != nil {
+		// we are already at a terminal error, just keep the first one
+		return
+	}
+
+	// we didn't have a session and we are seeing disconnect error
+	userErr := strings.HasPrefix(err.Error(), "authentication failed") && !strings.Contains(err.Error(), "internal server error")
+	if userErr {
+		// its a user error (e.g. authentication failure), so stop further
+		td.session.Store(&sessionState{
+			healthErr: err,
+		})
+		sess.Close()
+	} else {
+		// mark this as connecting error to return from readyz
+		td.session.Store(&sessionState{
+			readyErr: err,
+		})
+	}
+}
+
+// notifySessionChange signals SessionChanges without blocking, a pending signal already covers the new change
+func (td *TunnelDriver) notifySessionChange() {
+	select {
+	case td.sessionChanges <- struct{}{}:
+	default:
+	}
+}
+
+// SessionChanges receives a signal when the agent connects to, or disconnects from, the ngrok service
+func (td *TunnelDriver) SessionChanges() <-chan struct{} {
+	return td.sessionChanges
+}
+
+// SessionErr returns why the agent isn't connected to the ngrok service, nil while it is
+func (td *TunnelDriver) SessionErr() error {
+	if state := td.session.Load(); state.disconnectErr != nil {
+		return state.disconnectErr
+	}
+	_, err := td.getSession()
+	return err
+}
+
 // Ready implements the healthcheck.HealthChecker interface for when the TunnelDriver is ready to serve tunnels
 func (td *TunnelDriver) Ready(_ context.Context, _ *http.Request) error {
 	state := td.session.Load()
@@ -252,7 +300,7 @@ func caCerts(hostCA bool) (*x509.CertPool, error) {
 }
 
 // CreateTunnel creates and starts a new tunnel in a goroutine. If a tunnel with the same name already exists,
-// it will be stopped and replaced with a new tunnel unless the labels match.
+// it will be stopped and replaced with a new tunnel unless the labels, or the URL of internal agent endpoints, match.
 func (td *TunnelDriver) CreateTunnel(ctx context.Context, name string, spec ingressv1alpha1.TunnelSpec) error {
 	session, err := td.getSession()
 	if err != nil {
@@ -262,7 +310,11 @@ func (td *TunnelDriver) CreateTunnel(ctx context.Context, name string, spec ingr
 	log := log.FromContext(ctx)
 
 	if tun, ok := td.tunnels[name]; ok {
-		if maps.Equal(tun.Labels(), spec.Labels) {
+		if spec.URL != "" && tunnelURL(tun) == spec.URL {
+			log.Info("Tunnel URL matches existing tunnel, doing nothing")
+			return nil
+		}
+		if spec.URL == "" && tunnelURL(tun) == "" && maps.Equal(tun.Labels(), spec.Labels) {
 			log.Info("Tunnel labels match existing tunnel, doing nothing")
 			return nil
 		}
@@ -271,18 +323,29 @@ func (td *TunnelDriver) CreateTunnel(ctx context.Context, name string, spec ingr
 		defer td.stopTunnel(context.Background(), tun)
 	}
 
-	tun, err := session.Listen(ctx, td.buildTunnelConfig(spec.Labels, spec.ForwardsTo, spec.AppProtocol))
+	var tunnelConfig config.Tunnel
+	if spec.URL != "" {
+		tunnelConfig, err = td.buildEndpointConfig(spec.URL, spec.ForwardsTo, spec.AppProtocol)
+		if err != nil {
+			return err
+		}
+	} else {
+		tunnelConfig = td.buildTunnelConfig(spec.Labels, spec.ForwardsTo, spec.AppProtocol)
+	}
+
+	tun, err := session.Listen(ctx, tunnelConfig)
 	if err != nil {
 		return err
 	}
 	td.tunnels[name] = tun
+	tunnelsGauge.Set(float64(len(td.tunnels)))
 
 	protocol := ""
 	if spec.BackendConfig != nil {
 		protocol = spec.BackendConfig.Protocol
 	}
 
-	go handleConnections(ctx, &net.Dialer{}, tun, spec.ForwardsTo, protocol, spec.AppProtocol)
+	go handleConnections(ctx, &net.Dialer{}, tun, spec.ForwardsTo, protocol, spec.AppProtocol, nil)
 	return nil
 }
 
@@ -301,6 +364,7 @@ func (td *TunnelDriver) DeleteTunnel(ctx context.Context, name string) error {
 		return err
 	}
 	delete(td.tunnels, name)
+	tunnelsGauge.Set(float64(len(td.tunnels)))
 	log.Info("Tunnel deleted successfully")
 	return nil
 }
@@ -322,7 +386,34 @@ func (td *TunnelDriver) buildTunnelConfig(labels map[string]string, destination,
 	return config.LabeledTunnel(opts...)
 }
 
-func handleConnections(ctx context.Context, dialer Dialer, tun ngrok.Tunnel, dest string, protocol string, appProtocol string) {
+// buildEndpointConfig returns the config of an agent endpoint listening on the URL, like the internal agent endpoints
+// the CloudEndpoints forward to
+func (td *TunnelDriver) buildEndpointConfig(endpointURL, destination, appProtocol string) (config.Tunnel, error) {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tunnel URL %q: %w", endpointURL, err)
+	}
+	if u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid tunnel URL %q: must be an http or https URL", endpointURL)
+	}
+
+	return config.HTTPEndpoint(
+		config.WithDomain(u.Hostname()),
+		config.WithScheme(config.Scheme(u.Scheme)),
+		config.WithForwardsTo(destination),
+		config.WithAppProtocol(appProtocol),
+	), nil
+}
+
+// tunnelURL returns the URL of the tunnel, empty for the labeled tunnels
+func tunnelURL(tun ngrok.Tunnel) string {
+	if tun, ok := tun.(interface{ URL() string }); ok {
+		return tun.URL()
+	}
+	return ""
+}
+
+func handleConnections(ctx context.Context, dialer Dialer, tun ngrok.Tunnel, dest string, protocol string, appProtocol string, clientCerts []tls.Certificate) {
 	logger := log.FromContext(ctx).WithValues("id", tun.ID(), "protocol", protocol, "dest", dest)
 	for {
 		conn, err := tun.Accept()
@@ -338,21 +429,23 @@ func handleConnections(ctx context.Context, dialer Dialer, tun ngrok.Tunnel, des
 		}
 		connLogger := logger.WithValues("remoteAddr", conn.RemoteAddr())
 		connLogger.Info("Accepted connection")
+		connectionsAcceptedTotal.WithLabelValues(protocol).Inc()
 
 		go func() {
 			ctx := log.IntoContext(ctx, connLogger)
-			err := handleConn(ctx, dest, protocol, appProtocol, dialer, conn)
+			err := handleConn(ctx, dest, protocol, appProtocol, clientCerts, dialer, conn)
 			if err == nil || errors.Is(err, net.ErrClosed) {
 				connLogger.Info("Connection closed")
 				return
 			}
 
+			connectionErrorsTotal.WithLabelValues(protocol).Inc()
 			connLogger.Error(err, "Error handling connection")
 		}()
 	}
 }
 
-func handleConn(ctx context.Context, dest string, protocol string, appProtocol string, dialer Dialer, conn net.Conn) error {
+func handleConn(ctx context.Context, dest string, protocol string, appProtocol string, clientCerts []tls.Certificate, dialer Dialer, conn net.Conn) error {
 	log := log.FromContext(ctx)
 	next, err := dialer.DialContext(ctx, "tcp", dest)
 	if err != nil {
@@ -375,6 +468,7 @@ func handleConn(ctx context.Context, dest string, protocol string, appProtocol s
 			InsecureSkipVerify: true,
 			Renegotiation:      tls.RenegotiateFreelyAsClient,
 			NextProtos:         nextProtos,
+			Certificates:       clientCerts,
 		})
 	}
 
@@ -2,13 +2,20 @@ package tunneldriver
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net"
+	"reflect"
 	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	ingressv1alpha1 "github.com/ngrok/ngrok-operator/api/ingress/v1alpha1"
 	"github.com/ngrok/ngrok-operator/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.ngrok.com/ngrok"
+	"golang.ngrok.com/ngrok/config"
 )
 
 func TestConnectionIsClosed(t *testing.T) {
@@ -45,8 +52,224 @@ func TestConnectionIsClosed(t *testing.T) {
 		select {}
 	}).AnyTimes()
 
-	go handleConnections(ctx, mockDialer, mockTun, "target:port", "", "")
+	go handleConnections(ctx, mockDialer, mockTun, "target:port", "", "", nil)
 
 	bothClosed.Wait()
 	ctrl.Finish()
 }
+
+// tunnelConfig exposes the options of the ngrok-go tunnel configs, which are only implemented by unexported types
+type tunnelConfig interface {
+	Proto() string
+	ForwardsTo() string
+	ForwardsProto() string
+	Labels() map[string]string
+	Opts() any
+}
+
+func TestBuildEndpointConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantDomain string
+		wantProto  string
+		wantErr    bool
+	}{
+		{name: "https", url: "https://api.test-namespace.internal", wantDomain: "api.test-namespace.internal", wantProto: "https"},
+		{name: "http", url: "http://api.test-namespace.internal", wantDomain: "api.test-namespace.internal", wantProto: "http"},
+		{name: "tcp scheme", url: "tcp://api.test-namespace.internal:443", wantErr: true},
+		{name: "no host", url: "https://", wantErr: true},
+		{name: "invalid", url: "https://api test", wantErr: true},
+	}
+
+	td := &TunnelDriver{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tunnel, err := td.buildEndpointConfig(tt.url, "api.test-namespace.svc.cluster.local:80", "http2")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			cfg, ok := tunnel.(tunnelConfig)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantProto, cfg.Proto())
+			assert.Equal(t, "api.test-namespace.svc.cluster.local:80", cfg.ForwardsTo())
+			assert.Equal(t, "http2", cfg.ForwardsProto())
+			assert.Empty(t, cfg.Labels())
+			// the bind options are in an internal package of ngrok-go
+			assert.Equal(t, tt.wantDomain, reflect.ValueOf(cfg.Opts()).Elem().FieldByName("Domain").String())
+		})
+	}
+}
+
+// newTestTunnelDriver returns a tunnel driver whose session is the mock session
+func newTestTunnelDriver(session ngrok.Session) *TunnelDriver {
+	td := &TunnelDriver{
+		tunnels:        map[string]ngrok.Tunnel{},
+		agentEndpoints: map[string]*agentEndpoint{},
+	}
+	td.session.Store(&sessionState{session: session})
+	return td
+}
+
+// expectHandled expects the connections of the tunnel to be handled until it's closed, the returned channel is closed
+// once the tunnel isn't handled anymore
+func expectHandled(tun *mocks.MockTunnel) <-chan struct{} {
+	done := make(chan struct{})
+	tun.EXPECT().ID().Return("tunnel-id")
+	tun.EXPECT().Accept().DoAndReturn(func() (net.Conn, error) {
+		defer close(done)
+		return nil, errors.New("tunnel closed")
+	})
+	return done
+}
+
+func TestCreateTunnelKeepsTheTunnelOfTheSameURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSession := mocks.NewMockSession(ctrl)
+	existing := mocks.NewMockTunnel(ctrl)
+	existing.EXPECT().URL().Return("https://api.test-namespace.internal").AnyTimes()
+
+	td := newTestTunnelDriver(mockSession)
+	td.tunnels["api"] = existing
+
+	err := td.CreateTunnel(context.Background(), "api", ingressv1alpha1.TunnelSpec{
+		URL:        "https://api.test-namespace.internal",
+		ForwardsTo: "api.test-namespace.svc.cluster.local:80",
+	})
+	require.NoError(t, err)
+	assert.Same(t, existing, td.tunnels["api"])
+	ctrl.Finish()
+}
+
+func TestCreateTunnelReplacesTheTunnelOfAnotherURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSession := mocks.NewMockSession(ctrl)
+	existing := mocks.NewMockTunnel(ctrl)
+	existing.EXPECT().URL().Return("https://old.test-namespace.internal").AnyTimes()
+	replacement := mocks.NewMockTunnel(ctrl)
+
+	gomock.InOrder(
+		mockSession.EXPECT().Listen(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tunnel config.Tunnel) (ngrok.Tunnel, error) {
+			cfg, ok := tunnel.(tunnelConfig)
+			require.True(t, ok)
+			assert.Equal(t, "https", cfg.Proto())
+			assert.Equal(t, "api.test-namespace.svc.cluster.local:80", cfg.ForwardsTo())
+			return replacement, nil
+		}),
+		existing.EXPECT().CloseWithContext(gomock.Any()).Return(nil),
+	)
+	handled := expectHandled(replacement)
+
+	td := newTestTunnelDriver(mockSession)
+	td.tunnels["api"] = existing
+
+	err := td.CreateTunnel(context.Background(), "api", ingressv1alpha1.TunnelSpec{
+		URL:        "https://api.test-namespace.internal",
+		ForwardsTo: "api.test-namespace.svc.cluster.local:80",
+	})
+	require.NoError(t, err)
+	assert.Same(t, replacement, td.tunnels["api"])
+	<-handled
+	ctrl.Finish()
+}
+
+func TestCreateTunnelReplacesALabeledTunnelWithAnEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSession := mocks.NewMockSession(ctrl)
+	existing := mocks.NewMockTunnel(ctrl)
+	existing.EXPECT().URL().Return("").AnyTimes()
+	existing.EXPECT().Labels().Return(map[string]string{"k8s.ngrok.com/service": "api"}).AnyTimes()
+	replacement := mocks.NewMockTunnel(ctrl)
+
+	gomock.InOrder(
+		mockSession.EXPECT().Listen(gomock.Any(), gomock.Any()).Return(replacement, nil),
+		existing.EXPECT().CloseWithContext(gomock.Any()).Return(nil),
+	)
+	handled := expectHandled(replacement)
+
+	td := newTestTunnelDriver(mockSession)
+	td.tunnels["api"] = existing
+
+	err := td.CreateTunnel(context.Background(), "api", ingressv1alpha1.TunnelSpec{
+		URL:        "https://api.test-namespace.internal",
+		ForwardsTo: "api.test-namespace.svc.cluster.local:80",
+	})
+	require.NoError(t, err)
+	assert.Same(t, replacement, td.tunnels["api"])
+	<-handled
+	ctrl.Finish()
+}
+
+func TestCreateTunnelKeepsTheTunnelOfTheSameLabels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSession := mocks.NewMockSession(ctrl)
+	existing := mocks.NewMockTunnel(ctrl)
+	existing.EXPECT().URL().Return("").AnyTimes()
+	existing.EXPECT().Labels().Return(map[string]string{"k8s.ngrok.com/service": "api"}).AnyTimes()
+
+	td := newTestTunnelDriver(mockSession)
+	td.tunnels["api"] = existing
+
+	err := td.CreateTunnel(context.Background(), "api", ingressv1alpha1.TunnelSpec{
+		Labels:     map[string]string{"k8s.ngrok.com/service": "api"},
+		ForwardsTo: "api.test-namespace.svc.cluster.local:80",
+	})
+	require.NoError(t, err)
+	assert.Same(t, existing, td.tunnels["api"])
+	ctrl.Finish()
+}
+
+func TestCreateTunnelRejectsAnInvalidURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSession := mocks.NewMockSession(ctrl)
+
+	td := newTestTunnelDriver(mockSession)
+	err := td.CreateTunnel(context.Background(), "api", ingressv1alpha1.TunnelSpec{
+		URL:        "tcp://api.test-namespace.internal:443",
+		ForwardsTo: "api.test-namespace.svc.cluster.local:80",
+	})
+	assert.Error(t, err)
+	assert.Empty(t, td.tunnels)
+	ctrl.Finish()
+}
+
+func TestSessionChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSession := mocks.NewMockSession(ctrl)
+
+	td := &TunnelDriver{sessionChanges: make(chan struct{}, 1)}
+	td.session.Store(&sessionState{readyErr: errors.New("attempting to connect")})
+	assert.EqualError(t, td.SessionErr(), "attempting to connect")
+
+	assertChanged := func() {
+		t.Helper()
+		select {
+		case <-td.SessionChanges():
+		default:
+			t.Fatal("expected a session change")
+		}
+	}
+
+	td.connected(mockSession)
+	assertChanged()
+	assert.NoError(t, td.SessionErr())
+
+	// the established session is kept while it reconnects
+	td.disconnected(mockSession, errors.New("connection reset"))
+	assertChanged()
+	assert.EqualError(t, td.SessionErr(), "connection reset")
+	session, err := td.getSession()
+	require.NoError(t, err)
+	assert.Same(t, mockSession, session)
+
+	td.connected(mockSession)
+	assertChanged()
+	assert.NoError(t, td.SessionErr())
+
+	td.disconnected(mockSession, nil)
+	assertChanged()
+	assert.EqualError(t, td.SessionErr(), "session closed")
+	ctrl.Finish()
+}
@@ -0,0 +1,40 @@
+package tunneldriver
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	tunnelsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "tunnel_driver",
+		Name:      "tunnels",
+		Help:      "Number of tunnels the agent is running.",
+	})
+
+	agentEndpointsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "tunnel_driver",
+		Name:      "agent_endpoints",
+		Help:      "Number of agent endpoints the agent is running.",
+	})
+
+	connectionsAcceptedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "tunnel_driver",
+		Name:      "connections_accepted_total",
+		Help:      "Number of connections accepted by the tunnels of the agent, by backend protocol.",
+	}, []string{"protocol"})
+
+	connectionErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ngrok_operator",
+		Subsystem: "tunnel_driver",
+		Name:      "connection_errors_total",
+		Help:      "Number of accepted connections that failed to be forwarded to their backend, by backend protocol.",
+	}, []string{"protocol"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(tunnelsGauge, agentEndpointsGauge, connectionsAcceptedTotal, connectionErrorsTotal)
+}